@@ -3,12 +3,39 @@ package api
 import (
 	"errors"
 	"net/http"
+	"time"
 
 	"github.com/gin-gonic/gin"
 	db "github.com/tonisco/simple-bank-go/db/sqlc"
 	"github.com/tonisco/simple-bank-go/token"
+	"github.com/tonisco/simple-bank-go/util"
 )
 
+// accountResponse adds the balance as a decimal string in the account currency, such as "12.50",
+// and the available balance: the ledger balance less the funds reserved by active holds
+type accountResponse struct {
+	db.Account
+	BalanceDecimal          string `json:"balance_decimal"`
+	AvailableBalance        int64  `json:"available_balance"`
+	AvailableBalanceDecimal string `json:"available_balance_decimal"`
+}
+
+func newAccountResponse(account db.Account) accountResponse {
+	rsp := accountResponse{
+		Account:          account,
+		AvailableBalance: account.AvailableBalance(),
+	}
+
+	if balance, err := util.NewMoney(account.Balance, account.Currency); err == nil {
+		rsp.BalanceDecimal = balance.Decimal()
+	}
+	if available, err := util.NewMoney(rsp.AvailableBalance, account.Currency); err == nil {
+		rsp.AvailableBalanceDecimal = available.Decimal()
+	}
+
+	return rsp
+}
+
 type createAccountRequest struct {
 	Currency string `json:"currency" binding:"required,currency"`
 }
@@ -40,7 +67,7 @@ func (server *Server) createAccount(ctx *gin.Context) {
 		return
 	}
 
-	ctx.JSON(http.StatusCreated, account)
+	ctx.JSON(http.StatusCreated, newAccountResponse(account))
 }
 
 type listAccountRequest struct {
@@ -68,7 +95,12 @@ func (server *Server) listAccounts(ctx *gin.Context) {
 		return
 	}
 
-	ctx.JSON(http.StatusOK, accounts)
+	rsp := make([]accountResponse, len(accounts))
+	for i, account := range accounts {
+		rsp[i] = newAccountResponse(account)
+	}
+
+	ctx.JSON(http.StatusOK, rsp)
 }
 
 type getAccountRequest struct {
@@ -101,33 +133,29 @@ func (server *Server) getAccount(ctx *gin.Context) {
 		return
 	}
 
-	ctx.JSON(http.StatusOK, account)
+	ctx.JSON(http.StatusOK, newAccountResponse(account))
 }
 
-type updateAccountBalanceRequest struct {
-	ID int64 `uri:"id" binding:"required,min=1"`
-}
-
-type updateAccountBalanceAmount struct {
-	Amount int64 `json:"amount" binding:"required"`
+type accountStatementRequest struct {
+	From time.Time `form:"from" binding:"required"`
+	To   time.Time `form:"to" binding:"required,gtfield=From"`
 }
 
-func (server *Server) updateAccountBalance(ctx *gin.Context) {
-	var req updateAccountBalanceRequest
-	var amt updateAccountBalanceAmount
+func (server *Server) getAccountStatement(ctx *gin.Context) {
+	var uri getAccountRequest
+	var req accountStatementRequest
 
-	if err := ctx.ShouldBindUri(&req); err != nil {
-		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+	if err := ctx.ShouldBindUri(&uri); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
 		return
 	}
 
-	if err := ctx.ShouldBindJSON(&amt); err != nil {
-		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+	if err := ctx.ShouldBindQuery(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
 		return
 	}
 
-	// get account
-	account, err := server.store.GetAccount(ctx, req.ID)
+	account, err := server.store.GetAccount(ctx, uri.ID)
 
 	if err != nil {
 		if errors.Is(err, db.ErrRecordNotFound) {
@@ -138,27 +166,121 @@ func (server *Server) updateAccountBalance(ctx *gin.Context) {
 		return
 	}
 
-	// get payload from context and compare with account
 	authPayload := ctx.MustGet(authorizationPayloadKey).(*token.Payload)
-	if account.Owner != authPayload.Username {
+	if account.Owner != authPayload.Username && authPayload.Role != util.BankerRole {
 		err := errors.New("account does not belong to the authenticated user")
 		ctx.JSON(http.StatusUnauthorized, errorResponse(err))
 		return
 	}
 
-	args := db.AddAccountBalanceParams{
-		ID:     req.ID,
-		Amount: amt.Amount,
+	statement, err := server.store.GetAccountStatement(ctx, db.AccountStatementParams{
+		AccountID: account.ID,
+		From:      req.From,
+		To:        req.To,
+	})
+
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	ctx.JSON(http.StatusOK, statement)
+}
+
+type accountBalanceRequest struct {
+	ID int64 `uri:"id" binding:"required,min=1"`
+}
+
+type accountBalanceAmount struct {
+	Amount int64 `json:"amount" binding:"required,gt=0"`
+}
+
+// bindAccountBalanceRequest parses a teller deposit or withdrawal and checks that a banker is making it.
+// It writes the error response itself and returns false if the request cannot go ahead
+func (server *Server) bindAccountBalanceRequest(ctx *gin.Context) (accountBalanceRequest, accountBalanceAmount, *db.IdempotencyParams, bool) {
+	var req accountBalanceRequest
+	var amt accountBalanceAmount
+
+	if err := ctx.ShouldBindUri(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return req, amt, nil, false
+	}
+
+	if err := ctx.ShouldBindJSON(&amt); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return req, amt, nil, false
+	}
+
+	authPayload := ctx.MustGet(authorizationPayloadKey).(*token.Payload)
+	if authPayload.Role != util.BankerRole {
+		err := errors.New("only bankers can deposit or withdraw money")
+		ctx.JSON(http.StatusForbidden, errorResponse(err))
+		return req, amt, nil, false
+	}
+
+	idempotency, err := idempotencyParams(ctx, authPayload.Username, gin.H{"id": req.ID, "amount": amt.Amount})
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return req, amt, nil, false
+	}
+
+	return req, amt, idempotency, true
+}
+
+func (server *Server) deposit(ctx *gin.Context) {
+	req, amt, idempotency, ok := server.bindAccountBalanceRequest(ctx)
+	if !ok {
+		return
+	}
+
+	result, err := server.store.DepositTx(ctx, db.DepositTxParams{
+		AccountID:   req.ID,
+		Amount:      amt.Amount,
+		Idempotency: idempotency,
+	})
+
+	if err != nil {
+		if errors.Is(err, db.ErrRecordNotFound) {
+			ctx.JSON(http.StatusNotFound, errorResponse(err))
+			return
+		}
+		if db.IsLedgerRejection(err) || errors.Is(err, db.ErrIdempotencyKeyReused) {
+			ctx.JSON(http.StatusUnprocessableEntity, errorResponse(err))
+			return
+		}
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
 	}
 
-	account, err = server.store.AddAccountBalance(ctx, args)
+	ctx.JSON(http.StatusOK, result)
+}
+
+func (server *Server) withdraw(ctx *gin.Context) {
+	req, amt, idempotency, ok := server.bindAccountBalanceRequest(ctx)
+	if !ok {
+		return
+	}
+
+	result, err := server.store.WithdrawTx(ctx, db.WithdrawTxParams{
+		AccountID:   req.ID,
+		Amount:      amt.Amount,
+		Idempotency: idempotency,
+	})
 
 	if err != nil {
+		if errors.Is(err, db.ErrRecordNotFound) {
+			ctx.JSON(http.StatusNotFound, errorResponse(err))
+			return
+		}
+		if db.IsLedgerRejection(err) || errors.Is(err, db.ErrIdempotencyKeyReused) {
+			ctx.JSON(http.StatusUnprocessableEntity, errorResponse(err))
+			return
+		}
 		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
 		return
 	}
 
-	ctx.JSON(http.StatusOK, account)
+	ctx.JSON(http.StatusOK, result)
 }
 
 type deleteAccountRequest struct {
@@ -193,12 +315,22 @@ func (server *Server) deleteAccount(ctx *gin.Context) {
 		return
 	}
 
-	err = server.store.DeleteAccount(ctx, req.ID)
+	// accounts are closed rather than deleted so their history is kept.
+	// A frozen account stays frozen, or its owner could close it and have it reopened to lift the freeze
+	_, err = server.store.ChangeAccountStatusTx(ctx, db.ChangeAccountStatusTxParams{
+		ID:         req.ID,
+		FromStatus: db.AccountStatusActive,
+		Status:     db.AccountStatusClosed,
+	})
 
 	if err != nil {
+		if errors.Is(err, db.ErrAccountStatusChange) || errors.Is(err, db.ErrAccountNotEmpty) || errors.Is(err, db.ErrSystemAccount) {
+			ctx.JSON(http.StatusUnprocessableEntity, errorResponse(err))
+			return
+		}
 		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
 		return
 	}
 
-	ctx.JSON(http.StatusOK, gin.H{"message": "Account has been deleted successfully"})
+	ctx.JSON(http.StatusOK, gin.H{"message": "Account has been closed successfully"})
 }
@@ -2,6 +2,7 @@ package api
 
 import (
 	"bytes"
+	"context"
 	"database/sql"
 	"encoding/json"
 	"fmt"
@@ -127,7 +128,7 @@ func TestGetAccountAPI(t *testing.T) {
 			tc.buildStubs(store)
 
 			// start test server and send request
-			server := newTestServer(t, store)
+			server := newTestServer(t, store, nil)
 			recorder := httptest.NewRecorder()
 
 			url := fmt.Sprintf("/accounts/%d", tc.accountID)
@@ -196,7 +197,7 @@ func TestCreateAccountAPI(t *testing.T) {
 		{
 			name: "InvalidCurrency",
 			body: gin.H{
-				"currency": "GBP",
+				"currency": "XYZ",
 			},
 			setAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
 				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, user.Username, user.Role, time.Minute)
@@ -240,7 +241,7 @@ func TestCreateAccountAPI(t *testing.T) {
 			store := mockdb.NewMockStore(ctr)
 			tc.buildStubs(store)
 
-			server := newTestServer(t, store)
+			server := newTestServer(t, store, nil)
 			recorder := httptest.NewRecorder()
 
 			data, err := json.Marshal(tc.body)
@@ -442,7 +443,7 @@ func TestListAccountsAPI(t *testing.T) {
 				url = fmt.Sprintf("/accounts?page_id=%v&page_size=%v", tc.query.pageID, tc.query.pageSize)
 			}
 
-			server := newTestServer(t, store)
+			server := newTestServer(t, store, nil)
 			request, err := http.NewRequest(http.MethodGet, url, nil)
 			require.NoError(t, err)
 
@@ -454,37 +455,37 @@ func TestListAccountsAPI(t *testing.T) {
 	}
 }
 
-func TestUpdateAccountBalanceAPI(t *testing.T) {
+func TestGetAccountStatementAPI(t *testing.T) {
 	user, _ := randomUser(t)
 	user1, _ := randomUser(t)
 	account := randomAccount(user.Username)
-	amount := int64(5)
-	newAccount := db.Account{
-		ID:        account.ID,
-		Owner:     account.Owner,
-		Balance:   account.Balance + amount,
-		Currency:  account.Currency,
-		CreatedAt: account.CreatedAt,
-	}
 
-	type Params struct {
-		accountID int64
-		body      gin.H
+	to := time.Now().UTC().Truncate(time.Second)
+	from := to.Add(-24 * time.Hour)
+
+	statement := db.AccountStatement{
+		AccountID:      account.ID,
+		From:           from,
+		To:             to,
+		OpeningBalance: 100,
+		ClosingBalance: 150,
+		Entries: []db.ListStatementEntriesRow{
+			{ID: 1, AccountID: account.ID, Amount: 50, RunningBalance: 150, CreatedAt: from.Add(time.Hour)},
+		},
 	}
 
-	testCase := []struct {
+	testCases := []struct {
 		name          string
-		params        Params
+		accountID     int64
+		query         string
 		setAuth       func(t *testing.T, request *http.Request, tokenMaker token.Maker)
 		buildStubs    func(store *mockdb.MockStore)
 		checkResponse func(t *testing.T, recorder *httptest.ResponseRecorder)
 	}{
 		{
-			name: "Ok",
-			params: Params{
-				accountID: account.ID,
-				body:      gin.H{"amount": amount},
-			},
+			name:      "OK",
+			accountID: account.ID,
+			query:     fmt.Sprintf("from=%s&to=%s", from.Format(time.RFC3339), to.Format(time.RFC3339)),
 			setAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
 				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, user.Username, user.Role, time.Minute)
 			},
@@ -494,166 +495,425 @@ func TestUpdateAccountBalanceAPI(t *testing.T) {
 					Times(1).
 					Return(account, nil)
 
-				args := db.AddAccountBalanceParams{
-					ID:     account.ID,
-					Amount: amount,
-				}
-
 				store.EXPECT().
-					AddAccountBalance(gomock.Any(), gomock.Eq(args)).
+					GetAccountStatement(gomock.Any(), gomock.Any()).
 					Times(1).
-					Return(newAccount, nil)
+					DoAndReturn(func(_ context.Context, arg db.AccountStatementParams) (db.AccountStatement, error) {
+						require.Equal(t, account.ID, arg.AccountID)
+						require.True(t, from.Equal(arg.From))
+						require.True(t, to.Equal(arg.To))
+						return statement, nil
+					})
 			},
 			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
 				require.Equal(t, http.StatusOK, recorder.Code)
-				requireBodyMatchAccount(t, recorder.Body, newAccount)
+				requireBodyMatchAccount(t, recorder.Body, statement)
 			},
 		},
 		{
-			name: "notFound",
-			params: Params{
-				accountID: 4004,
-				body:      gin.H{"amount": amount},
+			name:      "InvalidRange",
+			accountID: account.ID,
+			query:     fmt.Sprintf("from=%s&to=%s", to.Format(time.RFC3339), from.Format(time.RFC3339)),
+			setAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, user.Username, user.Role, time.Minute)
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetAccount(gomock.Any(), gomock.Any()).
+					Times(0)
 			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
+		{
+			name:      "MissingRange",
+			accountID: account.ID,
 			setAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
 				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, user.Username, user.Role, time.Minute)
 			},
 			buildStubs: func(store *mockdb.MockStore) {
 				store.EXPECT().
-					GetAccount(gomock.Any(), gomock.Eq(int64(4004))).
+					GetAccount(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
+		{
+			name:      "UnauthorizedUser",
+			accountID: account.ID,
+			query:     fmt.Sprintf("from=%s&to=%s", from.Format(time.RFC3339), to.Format(time.RFC3339)),
+			setAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, user1.Username, user1.Role, time.Minute)
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetAccount(gomock.Any(), gomock.Eq(account.ID)).
 					Times(1).
-					Return(db.Account{}, db.ErrRecordNotFound)
+					Return(account, nil)
 
 				store.EXPECT().
-					AddAccountBalance(gomock.Any(), gomock.Any()).
+					GetAccountStatement(gomock.Any(), gomock.Any()).
 					Times(0)
 			},
 			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
-				require.Equal(t, http.StatusNotFound, recorder.Code)
+				require.Equal(t, http.StatusUnauthorized, recorder.Code)
 			},
 		},
 		{
-			name: "getAccountServerError",
-			params: Params{
-				accountID: account.ID,
-				body:      gin.H{"amount": amount},
+			name:      "NotFound",
+			accountID: account.ID,
+			query:     fmt.Sprintf("from=%s&to=%s", from.Format(time.RFC3339), to.Format(time.RFC3339)),
+			setAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, user.Username, user.Role, time.Minute)
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetAccount(gomock.Any(), gomock.Eq(account.ID)).
+					Times(1).
+					Return(db.Account{}, db.ErrRecordNotFound)
 			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusNotFound, recorder.Code)
+			},
+		},
+		{
+			name:      "InternalError",
+			accountID: account.ID,
+			query:     fmt.Sprintf("from=%s&to=%s", from.Format(time.RFC3339), to.Format(time.RFC3339)),
 			setAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
 				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, user.Username, user.Role, time.Minute)
 			},
 			buildStubs: func(store *mockdb.MockStore) {
 				store.EXPECT().
-					GetAccount(gomock.Any(), gomock.Eq(int64(account.ID))).
+					GetAccount(gomock.Any(), gomock.Eq(account.ID)).
 					Times(1).
-					Return(db.Account{}, sql.ErrConnDone)
+					Return(account, nil)
 
 				store.EXPECT().
-					AddAccountBalance(gomock.Any(), gomock.Any()).
-					Times(0)
+					GetAccountStatement(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(db.AccountStatement{}, sql.ErrConnDone)
 			},
 			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
 				require.Equal(t, http.StatusInternalServerError, recorder.Code)
 			},
 		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			ctr := gomock.NewController(t)
+			defer ctr.Finish()
+
+			store := mockdb.NewMockStore(ctr)
+			tc.buildStubs(store)
+
+			server := newTestServer(t, store, nil)
+			recorder := httptest.NewRecorder()
+
+			url := fmt.Sprintf("/accounts/%d/statement?%s", tc.accountID, tc.query)
+			request := httptest.NewRequest(http.MethodGet, url, nil)
+
+			tc.setAuth(t, request, server.tokenMaker)
+			server.router.ServeHTTP(recorder, request)
+			tc.checkResponse(t, recorder)
+		})
+	}
+}
+
+func TestDepositAPI(t *testing.T) {
+	banker, _ := randomUser(t)
+	banker.Role = util.BankerRole
+	user, _ := randomUser(t)
+	account := randomAccount(user.Username)
+	amount := int64(5)
+
+	result := db.DepositTxResult{
+		Account: db.Account{
+			ID:       account.ID,
+			Owner:    account.Owner,
+			Balance:  account.Balance + amount,
+			Currency: account.Currency,
+		},
+		Entry: db.Entry{
+			ID:        util.RandomInt(1, 1000),
+			AccountID: account.ID,
+			Amount:    amount,
+		},
+	}
+
+	testCases := []struct {
+		name          string
+		accountID     int64
+		body          gin.H
+		setAuth       func(t *testing.T, request *http.Request, tokenMaker token.Maker)
+		buildStubs    func(store *mockdb.MockStore)
+		checkResponse func(t *testing.T, recorder *httptest.ResponseRecorder)
+	}{
 		{
-			name: "invalidAmount",
-			params: Params{
-				accountID: account.ID,
-				body:      gin.H{},
+			name:      "OK",
+			accountID: account.ID,
+			body:      gin.H{"amount": amount},
+			setAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, banker.Username, banker.Role, time.Minute)
 			},
+			buildStubs: func(store *mockdb.MockStore) {
+				arg := db.DepositTxParams{
+					AccountID: account.ID,
+					Amount:    amount,
+				}
+
+				store.EXPECT().
+					DepositTx(gomock.Any(), gomock.Eq(arg)).
+					Times(1).
+					Return(result, nil)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+				requireBodyMatchAccount(t, recorder.Body, result)
+			},
+		},
+		{
+			name:      "DepositorForbidden",
+			accountID: account.ID,
+			body:      gin.H{"amount": amount},
 			setAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
 				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, user.Username, user.Role, time.Minute)
 			},
 			buildStubs: func(store *mockdb.MockStore) {
 				store.EXPECT().
-					GetAccount(gomock.Any(), gomock.Any()).
-					Times(0)
-
-				store.EXPECT().
-					AddAccountBalance(gomock.Any(), gomock.Any()).
+					DepositTx(gomock.Any(), gomock.Any()).
 					Times(0)
 			},
 			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
-				require.Equal(t, http.StatusInternalServerError, recorder.Code)
+				require.Equal(t, http.StatusForbidden, recorder.Code)
 			},
 		},
 		{
-			name: "invalidAccountId",
-			params: Params{
-				accountID: 0,
-				body:      gin.H{"amount": amount},
-			},
+			name:      "NegativeAmount",
+			accountID: account.ID,
+			body:      gin.H{"amount": -amount},
 			setAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
-				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, user.Username, user.Role, time.Minute)
+				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, banker.Username, banker.Role, time.Minute)
 			},
 			buildStubs: func(store *mockdb.MockStore) {
-
 				store.EXPECT().
-					GetAccount(gomock.Any(), gomock.Any()).
+					DepositTx(gomock.Any(), gomock.Any()).
 					Times(0)
-
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
+		{
+			name:      "InvalidID",
+			accountID: 0,
+			body:      gin.H{"amount": amount},
+			setAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, banker.Username, banker.Role, time.Minute)
+			},
+			buildStubs: func(store *mockdb.MockStore) {
 				store.EXPECT().
-					AddAccountBalance(gomock.Any(), gomock.Any()).
+					DepositTx(gomock.Any(), gomock.Any()).
 					Times(0)
 			},
 			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
-				require.Equal(t, http.StatusInternalServerError, recorder.Code)
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
 			},
 		},
 		{
-			name: "internalServerError",
-			params: Params{
-				accountID: account.ID,
-				body:      gin.H{"amount": amount},
+			name:      "NotFound",
+			accountID: account.ID,
+			body:      gin.H{"amount": amount},
+			setAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, banker.Username, banker.Role, time.Minute)
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					DepositTx(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(db.DepositTxResult{}, db.ErrRecordNotFound)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusNotFound, recorder.Code)
 			},
+		},
+		{
+			name:      "InternalError",
+			accountID: account.ID,
+			body:      gin.H{"amount": amount},
 			setAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
-				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, user.Username, user.Role, time.Minute)
+				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, banker.Username, banker.Role, time.Minute)
 			},
 			buildStubs: func(store *mockdb.MockStore) {
 				store.EXPECT().
-					GetAccount(gomock.Any(), gomock.Eq(account.ID)).
+					DepositTx(gomock.Any(), gomock.Any()).
 					Times(1).
-					Return(account, nil)
+					Return(db.DepositTxResult{}, sql.ErrConnDone)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusInternalServerError, recorder.Code)
+			},
+		},
+		{
+			name:      "NoAuthorization",
+			accountID: account.ID,
+			body:      gin.H{"amount": amount},
+			setAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					DepositTx(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusUnauthorized, recorder.Code)
+			},
+		},
+	}
 
-				args := db.AddAccountBalanceParams{
-					Amount: amount,
-					ID:     account.ID,
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			ctr := gomock.NewController(t)
+			defer ctr.Finish()
+
+			store := mockdb.NewMockStore(ctr)
+			tc.buildStubs(store)
+
+			server := newTestServer(t, store, nil)
+			recorder := httptest.NewRecorder()
+
+			data, err := json.Marshal(tc.body)
+			require.NoError(t, err)
+
+			url := fmt.Sprintf("/accounts/%v/deposit", tc.accountID)
+			request := httptest.NewRequest(http.MethodPost, url, bytes.NewReader(data))
+
+			tc.setAuth(t, request, server.tokenMaker)
+			server.router.ServeHTTP(recorder, request)
+			tc.checkResponse(t, recorder)
+		})
+	}
+}
+
+func TestWithdrawAPI(t *testing.T) {
+	banker, _ := randomUser(t)
+	banker.Role = util.BankerRole
+	user, _ := randomUser(t)
+	account := randomAccount(user.Username)
+	amount := int64(5)
+
+	result := db.WithdrawTxResult{
+		Account: db.Account{
+			ID:       account.ID,
+			Owner:    account.Owner,
+			Balance:  account.Balance - amount,
+			Currency: account.Currency,
+		},
+		Entry: db.Entry{
+			ID:        util.RandomInt(1, 1000),
+			AccountID: account.ID,
+			Amount:    -amount,
+		},
+	}
+
+	testCases := []struct {
+		name          string
+		body          gin.H
+		setAuth       func(t *testing.T, request *http.Request, tokenMaker token.Maker)
+		buildStubs    func(store *mockdb.MockStore)
+		checkResponse func(t *testing.T, recorder *httptest.ResponseRecorder)
+	}{
+		{
+			name: "OK",
+			body: gin.H{"amount": amount},
+			setAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, banker.Username, banker.Role, time.Minute)
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				arg := db.WithdrawTxParams{
+					AccountID: account.ID,
+					Amount:    amount,
 				}
+
 				store.EXPECT().
-					AddAccountBalance(gomock.Any(), gomock.Eq(args)).
+					WithdrawTx(gomock.Any(), gomock.Eq(arg)).
 					Times(1).
-					Return(db.Account{}, sql.ErrConnDone)
+					Return(result, nil)
 			},
 			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
-				require.Equal(t, http.StatusInternalServerError, recorder.Code)
+				require.Equal(t, http.StatusOK, recorder.Code)
+				requireBodyMatchAccount(t, recorder.Body, result)
 			},
 		},
 		{
-			name: "Unauthorized",
-			params: Params{
-				accountID: account.ID,
-				body:      gin.H{"amount": amount},
+			name: "DepositorForbidden",
+			body: gin.H{"amount": amount},
+			setAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, user.Username, user.Role, time.Minute)
 			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					WithdrawTx(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusForbidden, recorder.Code)
+			},
+		},
+		{
+			name: "InsufficientFunds",
+			body: gin.H{"amount": amount},
 			setAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
-				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, user1.Username, user.Role, time.Minute)
+				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, banker.Username, banker.Role, time.Minute)
 			},
 			buildStubs: func(store *mockdb.MockStore) {
 				store.EXPECT().
-					GetAccount(gomock.Any(), gomock.Eq(account.ID)).
+					WithdrawTx(gomock.Any(), gomock.Any()).
 					Times(1).
-					Return(account, nil)
-
+					Return(db.WithdrawTxResult{}, db.ErrInsufficientFunds)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusUnprocessableEntity, recorder.Code)
+			},
+		},
+		{
+			name: "ZeroAmount",
+			body: gin.H{"amount": 0},
+			setAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, banker.Username, banker.Role, time.Minute)
+			},
+			buildStubs: func(store *mockdb.MockStore) {
 				store.EXPECT().
-					AddAccountBalance(gomock.Any(), gomock.Any()).
+					WithdrawTx(gomock.Any(), gomock.Any()).
 					Times(0)
 			},
 			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
-				require.Equal(t, http.StatusUnauthorized, recorder.Code)
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
+		{
+			name: "InternalError",
+			body: gin.H{"amount": amount},
+			setAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, banker.Username, banker.Role, time.Minute)
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					WithdrawTx(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(db.WithdrawTxResult{}, sql.ErrConnDone)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusInternalServerError, recorder.Code)
 			},
 		},
 	}
 
-	for _, tc := range testCase {
+	for _, tc := range testCases {
 		t.Run(tc.name, func(t *testing.T) {
 			ctr := gomock.NewController(t)
 			defer ctr.Finish()
@@ -661,21 +921,20 @@ func TestUpdateAccountBalanceAPI(t *testing.T) {
 			store := mockdb.NewMockStore(ctr)
 			tc.buildStubs(store)
 
-			server := newTestServer(t, store)
+			server := newTestServer(t, store, nil)
 			recorder := httptest.NewRecorder()
 
-			data, err := json.Marshal(tc.params.body)
+			data, err := json.Marshal(tc.body)
 			require.NoError(t, err)
 
-			url := fmt.Sprintf("/accounts/%v", tc.params.accountID)
-			request := httptest.NewRequest(http.MethodPut, url, bytes.NewReader(data))
+			url := fmt.Sprintf("/accounts/%v/withdraw", account.ID)
+			request := httptest.NewRequest(http.MethodPost, url, bytes.NewReader(data))
 
 			tc.setAuth(t, request, server.tokenMaker)
 			server.router.ServeHTTP(recorder, request)
 			tc.checkResponse(t, recorder)
 		})
 	}
-
 }
 
 func TestDeleteAccountAPI(t *testing.T) {
@@ -702,10 +961,16 @@ func TestDeleteAccountAPI(t *testing.T) {
 					Times(1).
 					Return(account, nil)
 
+				arg := db.ChangeAccountStatusTxParams{
+					ID:         account.ID,
+					FromStatus: db.AccountStatusActive,
+					Status:     db.AccountStatusClosed,
+				}
+
 				store.EXPECT().
-					DeleteAccount(gomock.Any(), gomock.Eq(account.ID)).
+					ChangeAccountStatusTx(gomock.Any(), gomock.Eq(arg)).
 					Times(1).
-					Return(nil)
+					Return(account, nil)
 			},
 			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
 				require.Equal(t, http.StatusOK, recorder.Code)
@@ -723,7 +988,7 @@ func TestDeleteAccountAPI(t *testing.T) {
 					Times(0)
 
 				store.EXPECT().
-					DeleteAccount(gomock.Any(), gomock.Any()).
+					ChangeAccountStatusTx(gomock.Any(), gomock.Any()).
 					Times(0)
 			},
 			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
@@ -743,7 +1008,7 @@ func TestDeleteAccountAPI(t *testing.T) {
 					Return(db.Account{}, db.ErrRecordNotFound)
 
 				store.EXPECT().
-					DeleteAccount(gomock.Any(), gomock.Any()).
+					ChangeAccountStatusTx(gomock.Any(), gomock.Any()).
 					Times(0)
 			},
 			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
@@ -763,7 +1028,7 @@ func TestDeleteAccountAPI(t *testing.T) {
 					Return(db.Account{}, sql.ErrConnDone)
 
 				store.EXPECT().
-					DeleteAccount(gomock.Any(), gomock.Any()).
+					ChangeAccountStatusTx(gomock.Any(), gomock.Any()).
 					Times(0)
 			},
 			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
@@ -783,14 +1048,35 @@ func TestDeleteAccountAPI(t *testing.T) {
 					Return(account, nil)
 
 				store.EXPECT().
-					DeleteAccount(gomock.Any(), gomock.Eq(account.ID)).
+					ChangeAccountStatusTx(gomock.Any(), gomock.Any()).
 					Times(1).
-					Return(sql.ErrConnDone)
+					Return(db.Account{}, sql.ErrConnDone)
 			},
 			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
 				require.Equal(t, http.StatusInternalServerError, recorder.Code)
 			},
 		},
+		{
+			name:      "NotEmpty",
+			accountID: account.ID,
+			setAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, user.Username, user.Role, time.Minute)
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetAccount(gomock.Any(), gomock.Eq(account.ID)).
+					Times(1).
+					Return(account, nil)
+
+				store.EXPECT().
+					ChangeAccountStatusTx(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(db.Account{}, db.ErrAccountNotEmpty)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusUnprocessableEntity, recorder.Code)
+			},
+		},
 		{
 			name:      "UnauthorizedDeleteAccount",
 			accountID: account.ID,
@@ -804,7 +1090,7 @@ func TestDeleteAccountAPI(t *testing.T) {
 					Return(account, nil)
 
 				store.EXPECT().
-					DeleteAccount(gomock.Any(), gomock.Eq(account.ID)).
+					ChangeAccountStatusTx(gomock.Any(), gomock.Any()).
 					Times(0)
 			},
 			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
@@ -821,7 +1107,7 @@ func TestDeleteAccountAPI(t *testing.T) {
 			store := mockdb.NewMockStore(ctr)
 			tc.buildStubs(store)
 
-			server := newTestServer(t, store)
+			server := newTestServer(t, store, nil)
 			recorder := httptest.NewRecorder()
 
 			url := fmt.Sprintf("/accounts/%v", tc.accountID)
@@ -842,6 +1128,8 @@ func randomAccount(owner string) db.Account {
 		Owner:    owner,
 		Balance:  util.RandomMoney(),
 		Currency: util.RandomCurrency(),
+		Kind:     db.AccountKindCustomer,
+		Status:   db.AccountStatusActive,
 	}
 }
 
@@ -0,0 +1,42 @@
+package api
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+
+	"github.com/gin-gonic/gin"
+	db "github.com/tonisco/simple-bank-go/db/sqlc"
+	"github.com/tonisco/simple-bank-go/val"
+)
+
+const idempotencyKeyHeader = "Idempotency-Key"
+
+// idempotencyParams reads the Idempotency-Key header and fingerprints the request it is attached to.
+// It returns nil when the client did not send the header
+func idempotencyParams(ctx *gin.Context, username string, req interface{}) (*db.IdempotencyParams, error) {
+	key := ctx.GetHeader(idempotencyKeyHeader)
+	if key == "" {
+		return nil, nil
+	}
+
+	if err := val.ValidateIdempotencyKey(key); err != nil {
+		return nil, fmt.Errorf("invalid %s header: %w", idempotencyKeyHeader, err)
+	}
+
+	body, err := json.Marshal(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode request: %w", err)
+	}
+
+	hash := sha256.New()
+	hash.Write([]byte(ctx.Request.Method + " " + ctx.FullPath() + "\n"))
+	hash.Write(body)
+
+	return &db.IdempotencyParams{
+		Key:         key,
+		Username:    username,
+		RequestHash: hex.EncodeToString(hash.Sum(nil)),
+	}, nil
+}
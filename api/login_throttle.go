@@ -0,0 +1,117 @@
+package api
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/hibiken/asynq"
+	"github.com/rs/zerolog/log"
+	db "github.com/tonisco/simple-bank-go/db/sqlc"
+	"github.com/tonisco/simple-bank-go/worker"
+)
+
+// the same error is returned for an unknown username and a wrong password,
+// so that logins cannot be used to find out who has an account
+var errLoginFailed = errors.New("incorrect username or password")
+
+// checkLoginLock responds with 429 Too Many Requests while the username or the client ip is locked out
+func (server *Server) checkLoginLock(ctx *gin.Context, username string) bool {
+	locks, err := server.store.ListActiveLoginLocks(ctx, db.ListActiveLoginLocksParams{
+		Username: username,
+		ClientIp: ctx.ClientIP(),
+	})
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return false
+	}
+
+	if len(locks) == 0 {
+		return true
+	}
+
+	// the locks are sorted so the first one lasts the longest
+	lockedUntil := locks[0].LockedUntil.Time
+	retryAfter := int64(math.Ceil(time.Until(lockedUntil).Seconds()))
+
+	err = fmt.Errorf("too many failed login attempts, try again after %s", lockedUntil.UTC().Format(time.RFC3339))
+	ctx.Header("Retry-After", strconv.FormatInt(retryAfter, 10))
+	ctx.JSON(http.StatusTooManyRequests, errorResponse(err))
+	return false
+}
+
+// failLogin counts a failed login against the username and the client ip, emails the user if that locked
+// their account, and responds to the failed login. user is nil when no such user exists
+func (server *Server) failLogin(ctx *gin.Context, username string, user *db.User) {
+	if !server.recordFailedLogin(ctx, username, user) {
+		return
+	}
+
+	ctx.JSON(http.StatusUnauthorized, errorResponse(errLoginFailed))
+}
+
+// failCode counts a wrong TOTP code, recovery code or re-entered password against the user and the client ip,
+// so they run into the same lockout as failed logins, and responds with 401 Unauthorized and err
+func (server *Server) failCode(ctx *gin.Context, user *db.User, err error) {
+	if !server.recordFailedLogin(ctx, user.Username, user) {
+		return
+	}
+
+	ctx.JSON(http.StatusUnauthorized, errorResponse(err))
+}
+
+// resetLoginThrottle forgets the failed attempts of a user once they have proved who they are
+func (server *Server) resetLoginThrottle(ctx *gin.Context, username string) bool {
+	// the failed attempts of the client ip are kept, or an attacker could clear them by logging in to their own account
+	err := server.store.DeleteLoginThrottle(ctx, db.DeleteLoginThrottleParams{
+		Scope:   db.LoginThrottleScopeUser,
+		Subject: username,
+	})
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return false
+	}
+
+	return true
+}
+
+// recordFailedLogin counts a failed attempt against the username and the client ip and emails the user
+// if that locked their account
+func (server *Server) recordFailedLogin(ctx *gin.Context, username string, user *db.User) bool {
+	result, err := server.store.RecordFailedLoginTx(ctx, db.RecordFailedLoginTxParams{
+		Username:           username,
+		ClientIP:           ctx.ClientIP(),
+		MaxUserAttempts:    server.config.LoginMaxAttempts,
+		MaxIPAttempts:      server.config.LoginMaxAttemptsPerIP,
+		LockoutDuration:    server.config.LoginLockoutDuration,
+		MaxLockoutDuration: server.config.LoginMaxLockoutDuration,
+		AttemptWindow:      server.config.LoginAttemptWindow,
+	})
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return false
+	}
+
+	if result.UserLocked && user != nil {
+		payload := &worker.PayloadSendLockoutEmail{
+			Username:    user.Username,
+			LockedUntil: result.UserThrottle.LockedUntil.Time,
+		}
+		opts := []asynq.Option{
+			asynq.MaxRetry(10),
+			asynq.Queue(worker.QueueCritical),
+		}
+
+		// the attempt has failed either way, so a lost email is only logged
+		err = server.taskDistributor.DistributeTaskSendLockoutEmail(ctx, payload, opts...)
+		if err != nil {
+			log.Error().Err(err).Str("username", user.Username).Msg("failed to send lockout email")
+		}
+	}
+
+	return true
+}
@@ -7,17 +7,40 @@ import (
 
 	"github.com/gin-gonic/gin"
 	"github.com/stretchr/testify/require"
+	mockdb "github.com/tonisco/simple-bank-go/db/mock"
 	db "github.com/tonisco/simple-bank-go/db/sqlc"
+	"github.com/tonisco/simple-bank-go/fx"
+	"github.com/tonisco/simple-bank-go/token"
 	"github.com/tonisco/simple-bank-go/util"
+	"github.com/tonisco/simple-bank-go/worker"
+	"go.uber.org/mock/gomock"
 )
 
-func newTestServer(t *testing.T, store db.Store) *Server {
+func newTestServer(t *testing.T, store db.Store, taskDistributor worker.TaskDistributor) *Server {
 	config := util.Config{
 		TokenSymmetricKey:   util.RandomString(32),
 		AccessTokenDuration: time.Minute,
+		FXQuoteDuration:     30 * time.Second,
 	}
 
-	server, err := NewServer(config, store)
+	rates, err := fx.NewStaticRateProvider(map[string]map[string]string{
+		util.USD: {util.EUR: "0.92"},
+	})
+	require.NoError(t, err)
+
+	if store == nil {
+		store = mockdb.NewMockStore(gomock.NewController(t))
+	}
+
+	// every authorized request checks that the token was issued after the last password change
+	if mockStore, ok := store.(*mockdb.MockStore); ok {
+		mockStore.EXPECT().
+			GetUserPasswordChangedAt(gomock.Any(), gomock.Any()).
+			AnyTimes().
+			Return(time.Time{}, nil)
+	}
+
+	server, err := NewServer(config, store, taskDistributor, rates, token.NewMemoryDenylist(time.Hour))
 	require.NoError(t, err)
 
 	return server
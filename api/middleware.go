@@ -1,13 +1,16 @@
 package api
 
 import (
+	"context"
 	"errors"
 	"fmt"
 	"net/http"
 	"strings"
 
 	"github.com/gin-gonic/gin"
+	db "github.com/tonisco/simple-bank-go/db/sqlc"
 	"github.com/tonisco/simple-bank-go/token"
+	"github.com/tonisco/simple-bank-go/util"
 )
 
 const (
@@ -16,7 +19,7 @@ const (
 	authorizationPayloadKey = "authorization_payload"
 )
 
-func authMiddleware(tokenMaker token.Maker) gin.HandlerFunc {
+func authMiddleware(tokenMaker token.Maker, denylist token.Denylist, store db.Store) gin.HandlerFunc {
 	return func(ctx *gin.Context) {
 		authorizationHeader := ctx.GetHeader(authorizationHeaderKey)
 		if len(authorizationHeader) == 0 {
@@ -47,7 +50,56 @@ func authMiddleware(tokenMaker token.Maker) gin.HandlerFunc {
 			return
 		}
 
+		// a challenge token only proves the password, the login is not finished until the TOTP code is checked
+		if payload.Role == util.TotpChallengeRole {
+			err := errors.New("two-factor authentication is not complete")
+			ctx.AbortWithStatusJSON(http.StatusUnauthorized, errorResponse(err))
+			return
+		}
+
+		// refresh tokens live longer than access tokens and are not revoked with them
+		if payload.Type != token.TokenTypeAccess {
+			err := errors.New("not an access token")
+			ctx.AbortWithStatusJSON(http.StatusUnauthorized, errorResponse(err))
+			return
+		}
+
+		if err := denylist.Check(ctx, payload); err != nil {
+			ctx.AbortWithStatusJSON(http.StatusUnauthorized, errorResponse(err))
+			return
+		}
+
+		if err := checkPasswordChange(ctx, store, payload); err != nil {
+			ctx.AbortWithStatusJSON(passwordChangeStatus(err), errorResponse(err))
+			return
+		}
+
 		ctx.Set(authorizationPayloadKey, payload)
 		ctx.Next()
 	}
 }
+
+var errPasswordChanged = errors.New("token was issued before the password was changed")
+
+// checkPasswordChange rejects tokens issued before the user last changed their password.
+// The database rather than the denylist remembers password changes, so they survive restarts and Redis outages
+func checkPasswordChange(ctx context.Context, store db.Store, payload *token.Payload) error {
+	passwordChangedAt, err := store.GetUserPasswordChangedAt(ctx, payload.Username)
+	if err != nil {
+		return err
+	}
+
+	if payload.IssuedAt.Before(passwordChangedAt) {
+		return errPasswordChanged
+	}
+
+	return nil
+}
+
+// passwordChangeStatus is the response status for an error from checkPasswordChange
+func passwordChangeStatus(err error) int {
+	if errors.Is(err, errPasswordChanged) || errors.Is(err, db.ErrRecordNotFound) {
+		return http.StatusUnauthorized
+	}
+	return http.StatusInternalServerError
+}
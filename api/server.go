@@ -7,59 +7,81 @@ import (
 	"github.com/gin-gonic/gin/binding"
 	"github.com/go-playground/validator/v10"
 	db "github.com/tonisco/simple-bank-go/db/sqlc"
+	"github.com/tonisco/simple-bank-go/fx"
 	"github.com/tonisco/simple-bank-go/token"
 	"github.com/tonisco/simple-bank-go/util"
+	"github.com/tonisco/simple-bank-go/worker"
 )
 
 // Server serves HTTP requests for our banking service
 type Server struct {
-	config     util.Config
-	store      db.Store
-	tokenMaker token.Maker
-	router     *gin.Engine
+	config          util.Config
+	store           db.Store
+	tokenMaker      token.Maker
+	taskDistributor worker.TaskDistributor
+	rates           fx.RateProvider
+	denylist        token.Denylist
+	router          *gin.Engine
 }
 
 // New server creates a HTTP server and set up routing
-func NewServer(config util.Config, store db.Store) (*Server, error) {
-	tokenMaker, err := token.NewPasetoMaker(config.TokenSymmetricKey)
+func NewServer(config util.Config, store db.Store, taskDistributor worker.TaskDistributor, rates fx.RateProvider, denylist token.Denylist) (*Server, error) {
+	tokenMaker, err := token.LoadKeyring(config.TokenMakerType, config.TokenSymmetricKey, config.TokenPrivateKeyFile, config.TokenKeyringFile)
 	if err != nil {
 		return nil, fmt.Errorf("cannot create token maker: %v", err)
 	}
 
 	server := &Server{
-		config:     config,
-		store:      store,
-		tokenMaker: tokenMaker,
+		config:          config,
+		store:           store,
+		tokenMaker:      tokenMaker,
+		taskDistributor: taskDistributor,
+		rates:           rates,
+		denylist:        denylist,
 	}
 
 	if v, ok := binding.Validator.Engine().(*validator.Validate); ok {
 		v.RegisterValidation("currency", validCurrency)
 	}
 
-	server.setupRouter()
+	err = server.setupRouter()
+	if err != nil {
+		return nil, fmt.Errorf("cannot set up router: %v", err)
+	}
 	return server, nil
 }
 
-func (server *Server) setupRouter() {
+func (server *Server) setupRouter() error {
 	router := gin.Default()
-	router.SetTrustedProxies([]string{"*"})
+
+	// ClientIP is the address the login throttle counts against, so only the configured proxies may set it
+	err := router.SetTrustedProxies(server.config.TrustedProxies)
+	if err != nil {
+		return err
+	}
 
 	router.POST("/users", server.createUser)
 	router.POST("/users/login", server.loginUser)
+	router.POST("/users/login/totp", server.verifyLoginTotp)
 	router.POST("/tokens/renew_access", server.renewAccessToken)
 
-	authRoutes := router.Group("/").Use(authMiddleware(server.tokenMaker))
+	authRoutes := router.Group("/").Use(authMiddleware(server.tokenMaker, server.denylist, server.store))
 
 	authRoutes.GET("/users/:username", server.getUser)
+	authRoutes.POST("/users/reauthenticate", server.reauthenticateUser)
 	authRoutes.POST("/accounts", server.createAccount)
 	authRoutes.GET("/accounts", server.listAccounts)
 	authRoutes.GET("/accounts/:id", server.getAccount)
-	authRoutes.PUT("/accounts/:id", server.updateAccountBalance)
+	authRoutes.GET("/accounts/:id/statement", server.getAccountStatement)
+	authRoutes.POST("/accounts/:id/deposit", server.deposit)
+	authRoutes.POST("/accounts/:id/withdraw", server.withdraw)
 	authRoutes.DELETE("/accounts/:id", server.deleteAccount)
 
 	authRoutes.POST("/transfers", server.createTransfer)
+	authRoutes.POST("/fx_quotes", server.createFxQuote)
 
 	server.router = router
+	return nil
 }
 
 func (server *Server) Start(address string) error {
@@ -2,12 +2,13 @@ package api
 
 import (
 	"errors"
-	"fmt"
 	"net/http"
 	"time"
 
 	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
 	db "github.com/tonisco/simple-bank-go/db/sqlc"
+	"github.com/tonisco/simple-bank-go/token"
 )
 
 type renewAccessTokenRequest struct {
@@ -15,10 +16,16 @@ type renewAccessTokenRequest struct {
 }
 
 type renewAccessTokenResponse struct {
-	AccessToken          string    `json:"access_token"`
-	AccessTokenExpiresAt time.Time `json:"access_token_expires_at"`
+	SessionID             uuid.UUID `json:"session_id"`
+	AccessToken           string    `json:"access_token"`
+	AccessTokenExpiresAt  time.Time `json:"access_token_expires_at"`
+	RefreshToken          string    `json:"refresh_token"`
+	RefreshTokenExpiresAt time.Time `json:"refresh_token_expires_at"`
 }
 
+// renewAccessToken exchanges a refresh token for a new access token and a new refresh token.
+// The old refresh token cannot be used again, presenting it a second time revokes the whole login.
+// The new tokens carry the user's current role and the login still ends when its first refresh token would have expired
 func (server *Server) renewAccessToken(ctx *gin.Context) {
 	var req renewAccessTokenRequest
 
@@ -33,44 +40,82 @@ func (server *Server) renewAccessToken(ctx *gin.Context) {
 		return
 	}
 
-	session, err := server.store.GetSession(ctx, refreshPayload.ID)
-	if err != nil {
-		if errors.Is(err, db.ErrRecordNotFound) {
-			ctx.JSON(http.StatusNotFound, errorResponse(err))
-			return
-		}
-		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+	if refreshPayload.Type != token.TokenTypeRefresh {
+		ctx.JSON(http.StatusUnauthorized, errorResponse(errors.New("not a refresh token")))
 		return
 	}
 
-	if session.IsBlocked {
-		err = fmt.Errorf("blocked session")
+	if err := server.denylist.Check(ctx, refreshPayload); err != nil {
 		ctx.JSON(http.StatusUnauthorized, errorResponse(err))
 		return
 	}
 
-	if session.Username != refreshPayload.Username {
-		err = fmt.Errorf("incorrect session user")
-		ctx.JSON(http.StatusUnauthorized, errorResponse(err))
+	if err := checkPasswordChange(ctx, server.store, refreshPayload); err != nil {
+		ctx.JSON(passwordChangeStatus(err), errorResponse(err))
 		return
 	}
 
-	if session.RefreshToken != req.RefreshToken {
-		err = fmt.Errorf("mismatch session token")
-		ctx.JSON(http.StatusUnauthorized, errorResponse(err))
+	// the role may have changed since the login, so the new tokens carry the one the user has now
+	user, err := server.store.GetUser(ctx, refreshPayload.Username)
+	if err != nil {
+		if errors.Is(err, db.ErrRecordNotFound) {
+			ctx.JSON(http.StatusUnauthorized, errorResponse(err))
+			return
+		}
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
 		return
 	}
 
-	if time.Now().After(session.ExpiresAt) {
-		err = fmt.Errorf("expired session")
-		ctx.JSON(http.StatusUnauthorized, errorResponse(err))
+	// the new refresh token expires with the one it replaces, so renewing never makes a login last longer
+	refreshToken, newRefreshPayload, err := server.tokenMaker.CreateRefreshToken(
+		user.Username,
+		user.Role,
+		refreshPayload.SessionID,
+		time.Until(refreshPayload.ExpiredAt),
+	)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
 		return
+	}
 
+	session, err := server.store.RotateSessionTx(ctx, db.RotateSessionTxParams{
+		ID:           refreshPayload.ID,
+		Username:     refreshPayload.Username,
+		RefreshToken: req.RefreshToken,
+		NewSession: db.CreateSessionParams{
+			ID:           newRefreshPayload.ID,
+			Username:     refreshPayload.Username,
+			RefreshToken: refreshToken,
+			UserAgent:    ctx.Request.UserAgent(),
+			ClientIp:     ctx.ClientIP(),
+			IsBlocked:    false,
+			ExpiresAt:    newRefreshPayload.ExpiredAt,
+		},
+	})
+	if err != nil {
+		if errors.Is(err, db.ErrRecordNotFound) {
+			ctx.JSON(http.StatusNotFound, errorResponse(err))
+			return
+		}
+		if errors.Is(err, db.ErrRefreshTokenReused) {
+			// the access tokens of the family are as compromised as its refresh tokens
+			if err := server.denylist.RevokeID(ctx, session.FamilyID); err != nil {
+				ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+				return
+			}
+		}
+		if isSessionRejection(err) {
+			ctx.JSON(http.StatusUnauthorized, errorResponse(err))
+			return
+		}
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
 	}
 
-	accessToken, accessPayload, err := server.tokenMaker.CreateToken(
-		refreshPayload.Username,
-		refreshPayload.Role,
+	accessToken, accessPayload, err := server.tokenMaker.CreateSessionToken(
+		user.Username,
+		user.Role,
+		session.FamilyID,
 		server.config.AccessTokenDuration,
 	)
 	if err != nil {
@@ -79,9 +124,19 @@ func (server *Server) renewAccessToken(ctx *gin.Context) {
 	}
 
 	rsp := renewAccessTokenResponse{
-		AccessToken:          accessToken,
-		AccessTokenExpiresAt: accessPayload.ExpiredAt,
+		SessionID:             session.ID,
+		AccessToken:           accessToken,
+		AccessTokenExpiresAt:  accessPayload.ExpiredAt,
+		RefreshToken:          refreshToken,
+		RefreshTokenExpiresAt: newRefreshPayload.ExpiredAt,
 	}
 
 	ctx.JSON(http.StatusOK, rsp)
 }
+
+func isSessionRejection(err error) bool {
+	return errors.Is(err, db.ErrSessionBlocked) ||
+		errors.Is(err, db.ErrSessionExpired) ||
+		errors.Is(err, db.ErrSessionMismatch) ||
+		errors.Is(err, db.ErrRefreshTokenReused)
+}
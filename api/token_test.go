@@ -2,6 +2,7 @@ package api
 
 import (
 	"bytes"
+	"context"
 	"database/sql"
 	"encoding/json"
 	"fmt"
@@ -11,6 +12,7 @@ import (
 	"time"
 
 	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
 	"github.com/stretchr/testify/require"
 	mockdb "github.com/tonisco/simple-bank-go/db/mock"
 	db "github.com/tonisco/simple-bank-go/db/sqlc"
@@ -21,7 +23,6 @@ import (
 
 func TestRenewAccessToken(t *testing.T) {
 	user1, _ := randomUser(t)
-	user2, _ := randomUser(t)
 
 	type Params struct {
 		accessUsername   string
@@ -61,12 +62,25 @@ func TestRenewAccessToken(t *testing.T) {
 			},
 			buildStubs: func(store *mockdb.MockStore, session db.Session) {
 				store.EXPECT().
-					GetSession(gomock.Any(), gomock.Eq(session.ID)).
+					RotateSessionTx(gomock.Any(), gomock.Any()).
 					Times(1).
-					Return(session, nil)
+					DoAndReturn(func(_ context.Context, arg db.RotateSessionTxParams) (db.Session, error) {
+						require.Equal(t, session.ID, arg.ID)
+						require.Equal(t, session.Username, arg.Username)
+						require.Equal(t, session.RefreshToken, arg.RefreshToken)
+						require.NotEqual(t, session.ID, arg.NewSession.ID)
+						require.NotEqual(t, session.RefreshToken, arg.NewSession.RefreshToken)
+						return db.Session{ID: arg.NewSession.ID}, nil
+					})
 			},
 			checkResponse: func(recorder *httptest.ResponseRecorder) {
 				require.Equal(t, http.StatusOK, recorder.Code)
+
+				var rsp renewAccessTokenResponse
+				err := json.Unmarshal(recorder.Body.Bytes(), &rsp)
+				require.NoError(t, err)
+				require.NotEmpty(t, rsp.AccessToken)
+				require.NotEmpty(t, rsp.RefreshToken)
 			},
 		},
 		{
@@ -88,7 +102,7 @@ func TestRenewAccessToken(t *testing.T) {
 			},
 			buildStubs: func(store *mockdb.MockStore, session db.Session) {
 				store.EXPECT().
-					GetSession(gomock.Any(), gomock.Any()).
+					RotateSessionTx(gomock.Any(), gomock.Any()).
 					Times(0)
 			},
 			checkResponse: func(recorder *httptest.ResponseRecorder) {
@@ -116,7 +130,7 @@ func TestRenewAccessToken(t *testing.T) {
 			},
 			buildStubs: func(store *mockdb.MockStore, session db.Session) {
 				store.EXPECT().
-					GetSession(gomock.Any(), gomock.Any()).
+					RotateSessionTx(gomock.Any(), gomock.Any()).
 					Times(0)
 			},
 			checkResponse: func(recorder *httptest.ResponseRecorder) {
@@ -144,7 +158,7 @@ func TestRenewAccessToken(t *testing.T) {
 			},
 			buildStubs: func(store *mockdb.MockStore, session db.Session) {
 				store.EXPECT().
-					GetSession(gomock.Any(), gomock.Eq(session.ID)).
+					RotateSessionTx(gomock.Any(), gomock.Any()).
 					Times(1).
 					Return(db.Session{}, db.ErrRecordNotFound)
 			},
@@ -173,7 +187,7 @@ func TestRenewAccessToken(t *testing.T) {
 			},
 			buildStubs: func(store *mockdb.MockStore, session db.Session) {
 				store.EXPECT().
-					GetSession(gomock.Any(), gomock.Eq(session.ID)).
+					RotateSessionTx(gomock.Any(), gomock.Any()).
 					Times(1).
 					Return(db.Session{}, sql.ErrConnDone)
 			},
@@ -202,16 +216,16 @@ func TestRenewAccessToken(t *testing.T) {
 			},
 			buildStubs: func(store *mockdb.MockStore, session db.Session) {
 				store.EXPECT().
-					GetSession(gomock.Any(), gomock.Eq(session.ID)).
+					RotateSessionTx(gomock.Any(), gomock.Any()).
 					Times(1).
-					Return(session, nil)
+					Return(db.Session{}, db.ErrSessionBlocked)
 			},
 			checkResponse: func(recorder *httptest.ResponseRecorder) {
 				require.Equal(t, http.StatusUnauthorized, recorder.Code)
 			},
 		},
 		{
-			name: "MismatchUsername",
+			name: "MismatchToken",
 			getBody: func(refreshToken string) gin.H {
 				return gin.H{
 					"refresh_token": refreshToken,
@@ -230,19 +244,17 @@ func TestRenewAccessToken(t *testing.T) {
 				request.Header.Set(authorizationHeaderKey, authorizationHeader)
 			},
 			buildStubs: func(store *mockdb.MockStore, session db.Session) {
-				newSession := session
-				newSession.Username = user2.Username
 				store.EXPECT().
-					GetSession(gomock.Any(), gomock.Eq(session.ID)).
+					RotateSessionTx(gomock.Any(), gomock.Any()).
 					Times(1).
-					Return(newSession, nil)
+					Return(db.Session{}, db.ErrSessionMismatch)
 			},
 			checkResponse: func(recorder *httptest.ResponseRecorder) {
 				require.Equal(t, http.StatusUnauthorized, recorder.Code)
 			},
 		},
 		{
-			name: "MismatchToken",
+			name: "ExpiredSession",
 			getBody: func(refreshToken string) gin.H {
 				return gin.H{
 					"refresh_token": refreshToken,
@@ -261,19 +273,17 @@ func TestRenewAccessToken(t *testing.T) {
 				request.Header.Set(authorizationHeaderKey, authorizationHeader)
 			},
 			buildStubs: func(store *mockdb.MockStore, session db.Session) {
-				newSession := session
-				newSession.RefreshToken = user2.Username
 				store.EXPECT().
-					GetSession(gomock.Any(), gomock.Eq(session.ID)).
+					RotateSessionTx(gomock.Any(), gomock.Any()).
 					Times(1).
-					Return(newSession, nil)
+					Return(db.Session{}, db.ErrSessionExpired)
 			},
 			checkResponse: func(recorder *httptest.ResponseRecorder) {
 				require.Equal(t, http.StatusUnauthorized, recorder.Code)
 			},
 		},
 		{
-			name: "ExpiredSession",
+			name: "ReusedToken",
 			getBody: func(refreshToken string) gin.H {
 				return gin.H{
 					"refresh_token": refreshToken,
@@ -292,12 +302,10 @@ func TestRenewAccessToken(t *testing.T) {
 				request.Header.Set(authorizationHeaderKey, authorizationHeader)
 			},
 			buildStubs: func(store *mockdb.MockStore, session db.Session) {
-				newSession := session
-				newSession.ExpiresAt = time.Now().Add(-time.Minute)
 				store.EXPECT().
-					GetSession(gomock.Any(), gomock.Eq(session.ID)).
+					RotateSessionTx(gomock.Any(), gomock.Any()).
 					Times(1).
-					Return(newSession, nil)
+					Return(db.Session{}, db.ErrRefreshTokenReused)
 			},
 			checkResponse: func(recorder *httptest.ResponseRecorder) {
 				require.Equal(t, http.StatusUnauthorized, recorder.Code)
@@ -311,7 +319,7 @@ func TestRenewAccessToken(t *testing.T) {
 			defer ctr.Finish()
 
 			store := mockdb.NewMockStore(ctr)
-			server := newTestServer(t, store)
+			server := newTestServer(t, store, nil)
 
 			accessToken, _ := randomAccessToken(
 				t,
@@ -332,6 +340,10 @@ func TestRenewAccessToken(t *testing.T) {
 			)
 
 			tc.buildStubs(store, session)
+			store.EXPECT().
+				GetUser(gomock.Any(), gomock.Eq(user1.Username)).
+				AnyTimes().
+				Return(user1, nil)
 
 			data, err := json.Marshal(tc.getBody(refreshToken))
 			require.NoError(t, err)
@@ -347,8 +359,55 @@ func TestRenewAccessToken(t *testing.T) {
 	}
 }
 
+func TestRenewAccessTokenCurrentRole(t *testing.T) {
+	user, _ := randomUser(t)
+
+	ctrl := gomock.NewController(t)
+	store := mockdb.NewMockStore(ctrl)
+	server := newTestServer(t, store, nil)
+	server.config.RefreshTokenDuration = 24 * time.Hour
+
+	refreshToken, refreshPayload, err := server.tokenMaker.CreateRefreshToken(user.Username, util.BankerRole, uuid.Nil, time.Hour)
+	require.NoError(t, err)
+
+	// a banker took the user's role away after the login
+	user.Role = util.DepositorRole
+	store.EXPECT().
+		GetUser(gomock.Any(), gomock.Eq(user.Username)).
+		Times(1).
+		Return(user, nil)
+
+	store.EXPECT().
+		RotateSessionTx(gomock.Any(), gomock.Any()).
+		Times(1).
+		DoAndReturn(func(_ context.Context, arg db.RotateSessionTxParams) (db.Session, error) {
+			return db.Session{ID: arg.NewSession.ID, FamilyID: refreshPayload.SessionID}, nil
+		})
+
+	data, err := json.Marshal(gin.H{"refresh_token": refreshToken})
+	require.NoError(t, err)
+
+	recorder := httptest.NewRecorder()
+	request, err := http.NewRequest(http.MethodPost, "/tokens/renew_access", bytes.NewReader(data))
+	require.NoError(t, err)
+
+	server.router.ServeHTTP(recorder, request)
+	require.Equal(t, http.StatusOK, recorder.Code)
+
+	var rsp renewAccessTokenResponse
+	err = json.Unmarshal(recorder.Body.Bytes(), &rsp)
+	require.NoError(t, err)
+
+	accessPayload, err := server.tokenMaker.VerifyToken(rsp.AccessToken)
+	require.NoError(t, err)
+	require.Equal(t, util.DepositorRole, accessPayload.Role)
+
+	// renewing does not make the login last longer than its first refresh token
+	require.WithinDuration(t, refreshPayload.ExpiredAt, rsp.RefreshTokenExpiresAt, time.Second)
+}
+
 func randomAccessToken(t *testing.T, tokenMaker token.Maker, authorizationType, username string, role string, duration time.Duration) (string, *token.Payload) {
-	accessToken, payload, err := tokenMaker.CreateToken(username, role, duration)
+	accessToken, payload, err := tokenMaker.CreateSessionToken(username, role, uuid.New(), duration)
 	require.NoError(t, err)
 	return accessToken, payload
 }
@@ -362,7 +421,7 @@ func randomRefreshToken(
 	duration time.Duration,
 	isBlocked bool,
 ) (string, *token.Payload, db.Session) {
-	RefreshToken, payload, err := tokenMaker.CreateToken(username, role, duration)
+	RefreshToken, payload, err := tokenMaker.CreateRefreshToken(username, role, uuid.Nil, duration)
 	require.NoError(t, err)
 
 	session := db.Session{
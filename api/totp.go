@@ -0,0 +1,167 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	db "github.com/tonisco/simple-bank-go/db/sqlc"
+	"github.com/tonisco/simple-bank-go/totp"
+	"github.com/tonisco/simple-bank-go/util"
+)
+
+type verifyLoginTotpRequest struct {
+	ChallengeToken string `json:"challenge_token" binding:"required"`
+	Code           string `json:"code" binding:"omitempty,len=6,numeric"`
+	RecoveryCode   string `json:"recovery_code" binding:"omitempty,min=10,max=11"`
+}
+
+// verifyLoginTotp finishes a login that loginUser answered with a challenge,
+// using either a TOTP code or one of the user's unused recovery codes. Each challenge completes one login
+func (server *Server) verifyLoginTotp(ctx *gin.Context) {
+	var req verifyLoginTotpRequest
+
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	if (req.Code == "") == (req.RecoveryCode == "") {
+		err := errors.New("exactly one of code and recovery_code must be set")
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	challengePayload, err := server.tokenMaker.VerifyToken(req.ChallengeToken)
+	if err != nil {
+		ctx.JSON(http.StatusUnauthorized, errorResponse(err))
+		return
+	}
+
+	if challengePayload.Role != util.TotpChallengeRole {
+		err := errors.New("invalid challenge token")
+		ctx.JSON(http.StatusUnauthorized, errorResponse(err))
+		return
+	}
+
+	// a challenge that has already completed a login is revoked
+	if err := server.denylist.Check(ctx, challengePayload); err != nil {
+		ctx.JSON(http.StatusUnauthorized, errorResponse(err))
+		return
+	}
+
+	// a password reset after the challenge was issued also cancels it
+	if err := checkPasswordChange(ctx, server.store, challengePayload); err != nil {
+		ctx.JSON(passwordChangeStatus(err), errorResponse(err))
+		return
+	}
+
+	// wrong codes count as failed logins, so guessing codes locks the user out like guessing passwords
+	if !server.checkLoginLock(ctx, challengePayload.Username) {
+		return
+	}
+
+	user, err := server.store.GetUser(ctx, challengePayload.Username)
+	if err != nil {
+		if errors.Is(err, db.ErrRecordNotFound) {
+			ctx.JSON(http.StatusNotFound, errorResponse(err))
+			return
+		}
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	var ok bool
+	if req.Code != "" {
+		ok = server.useTotpCode(ctx, &user, req.Code)
+	} else {
+		ok = server.useRecoveryCode(ctx, &user, req.RecoveryCode)
+	}
+	if !ok {
+		return
+	}
+
+	// the challenge cannot be used again with a later code to open a second session
+	if err := server.denylist.RevokeID(ctx, challengePayload.ID); err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	if !server.resetLoginThrottle(ctx, user.Username) {
+		return
+	}
+
+	server.createLoginSession(ctx, user)
+}
+
+// useTotpCode checks a TOTP code and records its time step so it cannot be used a second time.
+// A wrong or reused code counts as a failed login
+func (server *Server) useTotpCode(ctx *gin.Context, user *db.User, code string) bool {
+	userTotp, err := server.store.GetUserTotp(ctx, user.Username)
+	if err != nil {
+		if errors.Is(err, db.ErrRecordNotFound) {
+			err := errors.New("two-factor authentication is not on")
+			ctx.JSON(http.StatusUnprocessableEntity, errorResponse(err))
+			return false
+		}
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return false
+	}
+
+	step, ok := totp.Validate(userTotp.Secret, code, time.Now())
+	if !ok {
+		err := errors.New("incorrect code")
+		server.failCode(ctx, user, err)
+		return false
+	}
+
+	_, err = server.store.UseTotpStep(ctx, db.UseTotpStepParams{
+		Step:     step,
+		Username: user.Username,
+	})
+	if err != nil {
+		if errors.Is(err, db.ErrRecordNotFound) {
+			err := errors.New("code has already been used")
+			server.failCode(ctx, user, err)
+			return false
+		}
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return false
+	}
+
+	return true
+}
+
+// useRecoveryCode finds the unused recovery code matching code and marks it used.
+// A wrong or reused code counts as a failed login
+func (server *Server) useRecoveryCode(ctx *gin.Context, user *db.User, code string) bool {
+	recoveryCodes, err := server.store.ListUnusedTotpRecoveryCodes(ctx, user.Username)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return false
+	}
+
+	code = totp.NormalizeRecoveryCode(code)
+	for _, recoveryCode := range recoveryCodes {
+		if util.CheckPassword(code, recoveryCode.HashedCode) != nil {
+			continue
+		}
+
+		_, err = server.store.UseTotpRecoveryCode(ctx, recoveryCode.ID)
+		if err != nil {
+			if errors.Is(err, db.ErrRecordNotFound) {
+				err := errors.New("recovery code has already been used")
+				server.failCode(ctx, user, err)
+				return false
+			}
+			ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+			return false
+		}
+		return true
+	}
+
+	err = errors.New("incorrect recovery code")
+	server.failCode(ctx, user, err)
+	return false
+}
@@ -0,0 +1,346 @@
+package api
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/stretchr/testify/require"
+	mockdb "github.com/tonisco/simple-bank-go/db/mock"
+	db "github.com/tonisco/simple-bank-go/db/sqlc"
+	"github.com/tonisco/simple-bank-go/totp"
+	"github.com/tonisco/simple-bank-go/util"
+	"go.uber.org/mock/gomock"
+)
+
+func TestVerifyLoginTotp(t *testing.T) {
+	user, _ := randomUser(t)
+
+	secret, err := totp.GenerateSecret()
+	require.NoError(t, err)
+
+	userTotp := db.UserTotp{
+		Username:    user.Username,
+		Secret:      secret,
+		ConfirmedAt: pgtype.Timestamptz{Time: time.Now(), Valid: true},
+	}
+
+	code, err := totp.Code(secret, totp.Step(time.Now()))
+	require.NoError(t, err)
+
+	recoveryCode := "abcde-fghij"
+	hashedRecoveryCode, err := util.HashPassword(totp.NormalizeRecoveryCode(recoveryCode))
+	require.NoError(t, err)
+
+	testCases := []struct {
+		name          string
+		role          string
+		body          gin.H
+		buildStubs    func(store *mockdb.MockStore)
+		checkResponse func(recorder *httptest.ResponseRecorder)
+	}{
+		{
+			name: "OK",
+			role: util.TotpChallengeRole,
+			body: gin.H{"code": code},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					ListActiveLoginLocks(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return([]db.LoginThrottle{}, nil)
+
+				store.EXPECT().
+					GetUser(gomock.Any(), gomock.Eq(user.Username)).
+					Times(1).
+					Return(user, nil)
+
+				store.EXPECT().
+					GetUserTotp(gomock.Any(), gomock.Eq(user.Username)).
+					Times(1).
+					Return(userTotp, nil)
+
+				store.EXPECT().
+					UseTotpStep(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(userTotp, nil)
+
+				store.EXPECT().
+					CreateSession(gomock.Any(), gomock.Any()).
+					Times(1)
+
+				store.EXPECT().
+					DeleteLoginThrottle(gomock.Any(), gomock.Eq(db.DeleteLoginThrottleParams{
+						Scope:   db.LoginThrottleScopeUser,
+						Subject: user.Username,
+					})).
+					Times(1).
+					Return(nil)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+
+				var rsp loginUserResponse
+				err := json.Unmarshal(recorder.Body.Bytes(), &rsp)
+				require.NoError(t, err)
+				require.NotEmpty(t, rsp.AccessToken)
+				require.NotEmpty(t, rsp.RefreshToken)
+			},
+		},
+		{
+			name: "RecoveryCode",
+			role: util.TotpChallengeRole,
+			body: gin.H{"recovery_code": "ABCDEFGHIJ"},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					ListActiveLoginLocks(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return([]db.LoginThrottle{}, nil)
+
+				store.EXPECT().
+					GetUser(gomock.Any(), gomock.Eq(user.Username)).
+					Times(1).
+					Return(user, nil)
+
+				store.EXPECT().
+					ListUnusedTotpRecoveryCodes(gomock.Any(), gomock.Eq(user.Username)).
+					Times(1).
+					Return([]db.TotpRecoveryCode{{ID: 7, Username: user.Username, HashedCode: hashedRecoveryCode}}, nil)
+
+				store.EXPECT().
+					UseTotpRecoveryCode(gomock.Any(), gomock.Eq(int64(7))).
+					Times(1)
+
+				store.EXPECT().
+					CreateSession(gomock.Any(), gomock.Any()).
+					Times(1)
+
+				store.EXPECT().
+					DeleteLoginThrottle(gomock.Any(), gomock.Eq(db.DeleteLoginThrottleParams{
+						Scope:   db.LoginThrottleScopeUser,
+						Subject: user.Username,
+					})).
+					Times(1).
+					Return(nil)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+			},
+		},
+		{
+			name: "CodeAlreadyUsed",
+			role: util.TotpChallengeRole,
+			body: gin.H{"code": code},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					ListActiveLoginLocks(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return([]db.LoginThrottle{}, nil)
+
+				store.EXPECT().
+					GetUser(gomock.Any(), gomock.Eq(user.Username)).
+					Times(1).
+					Return(user, nil)
+
+				store.EXPECT().
+					GetUserTotp(gomock.Any(), gomock.Eq(user.Username)).
+					Times(1).
+					Return(userTotp, nil)
+
+				store.EXPECT().
+					UseTotpStep(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(db.UserTotp{}, db.ErrRecordNotFound)
+
+				store.EXPECT().
+					CreateSession(gomock.Any(), gomock.Any()).
+					Times(0)
+
+				store.EXPECT().
+					RecordFailedLoginTx(gomock.Any(), gomock.Any()).
+					Times(1).
+					DoAndReturn(func(_ context.Context, arg db.RecordFailedLoginTxParams) (db.RecordFailedLoginTxResult, error) {
+						require.Equal(t, user.Username, arg.Username)
+						return db.RecordFailedLoginTxResult{}, nil
+					})
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusUnauthorized, recorder.Code)
+			},
+		},
+		{
+			name: "Locked",
+			role: util.TotpChallengeRole,
+			body: gin.H{"code": code},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					ListActiveLoginLocks(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return([]db.LoginThrottle{{
+						Scope:       db.LoginThrottleScopeUser,
+						Subject:     user.Username,
+						LockedUntil: pgtype.Timestamptz{Time: time.Now().Add(time.Minute), Valid: true},
+					}}, nil)
+
+				store.EXPECT().
+					GetUser(gomock.Any(), gomock.Any()).
+					Times(0)
+
+				store.EXPECT().
+					UseTotpStep(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusTooManyRequests, recorder.Code)
+			},
+		},
+		{
+			name: "PasswordChangedAfterChallenge",
+			role: util.TotpChallengeRole,
+			body: gin.H{"code": code},
+			buildStubs: func(store *mockdb.MockStore) {
+				// the password was reset after the challenge was issued
+				store.EXPECT().
+					GetUserPasswordChangedAt(gomock.Any(), gomock.Eq(user.Username)).
+					Times(1).
+					Return(time.Now().Add(time.Minute), nil)
+
+				store.EXPECT().
+					GetUser(gomock.Any(), gomock.Any()).
+					Times(0)
+
+				store.EXPECT().
+					CreateSession(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusUnauthorized, recorder.Code)
+			},
+		},
+		{
+			name: "NotChallengeToken",
+			role: util.DepositorRole,
+			body: gin.H{"code": code},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetUser(gomock.Any(), gomock.Any()).
+					Times(0)
+
+				store.EXPECT().
+					CreateSession(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusUnauthorized, recorder.Code)
+			},
+		},
+		{
+			name: "CodeAndRecoveryCode",
+			role: util.TotpChallengeRole,
+			body: gin.H{"code": code, "recovery_code": recoveryCode},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetUser(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			ctr := gomock.NewController(t)
+			defer ctr.Finish()
+
+			store := mockdb.NewMockStore(ctr)
+			tc.buildStubs(store)
+			server := newTestServer(t, store, nil)
+
+			challengeToken, _, err := server.tokenMaker.CreateToken(user.Username, tc.role, time.Minute)
+			require.NoError(t, err)
+			tc.body["challenge_token"] = challengeToken
+
+			recorder := httptest.NewRecorder()
+
+			data, err := json.Marshal(tc.body)
+			require.NoError(t, err)
+
+			url := "/users/login/totp"
+			request, err := http.NewRequest(http.MethodPost, url, bytes.NewReader(data))
+			require.NoError(t, err)
+
+			server.router.ServeHTTP(recorder, request)
+			tc.checkResponse(recorder)
+		})
+	}
+}
+
+func TestVerifyLoginTotpChallengeUsedOnce(t *testing.T) {
+	user, _ := randomUser(t)
+
+	recoveryCode := "abcde-fghij"
+	hashedRecoveryCode, err := util.HashPassword(totp.NormalizeRecoveryCode(recoveryCode))
+	require.NoError(t, err)
+
+	ctrl := gomock.NewController(t)
+	store := mockdb.NewMockStore(ctrl)
+
+	// only the first login gets past the challenge
+	store.EXPECT().
+		ListActiveLoginLocks(gomock.Any(), gomock.Any()).
+		Times(1).
+		Return([]db.LoginThrottle{}, nil)
+
+	store.EXPECT().
+		GetUser(gomock.Any(), gomock.Eq(user.Username)).
+		Times(1).
+		Return(user, nil)
+
+	store.EXPECT().
+		ListUnusedTotpRecoveryCodes(gomock.Any(), gomock.Eq(user.Username)).
+		Times(1).
+		Return([]db.TotpRecoveryCode{{ID: 7, Username: user.Username, HashedCode: hashedRecoveryCode}}, nil)
+
+	store.EXPECT().
+		UseTotpRecoveryCode(gomock.Any(), gomock.Eq(int64(7))).
+		Times(1)
+
+	store.EXPECT().
+		DeleteLoginThrottle(gomock.Any(), gomock.Any()).
+		Times(1).
+		Return(nil)
+
+	store.EXPECT().
+		CreateSession(gomock.Any(), gomock.Any()).
+		Times(1)
+
+	server := newTestServer(t, store, nil)
+
+	challengeToken, _, err := server.tokenMaker.CreateToken(user.Username, util.TotpChallengeRole, time.Minute)
+	require.NoError(t, err)
+
+	for _, tc := range []struct {
+		recoveryCode string
+		status       int
+	}{
+		{recoveryCode: recoveryCode, status: http.StatusOK},
+		{recoveryCode: "klmno-pqrst", status: http.StatusUnauthorized},
+	} {
+		data, err := json.Marshal(gin.H{"challenge_token": challengeToken, "recovery_code": tc.recoveryCode})
+		require.NoError(t, err)
+
+		request, err := http.NewRequest(http.MethodPost, "/users/login/totp", bytes.NewReader(data))
+		require.NoError(t, err)
+
+		recorder := httptest.NewRecorder()
+		server.router.ServeHTTP(recorder, request)
+		require.Equal(t, tc.status, recorder.Code)
+	}
+}
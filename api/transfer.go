@@ -6,15 +6,39 @@ import (
 	"net/http"
 
 	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
 	db "github.com/tonisco/simple-bank-go/db/sqlc"
 	"github.com/tonisco/simple-bank-go/token"
+	"github.com/tonisco/simple-bank-go/util"
 )
 
+// transferRequest moves Amount in Currency, the currency of the from account.
+// The amount is given either in minor units as Amount or as a decimal string such as "12.50" as AmountDecimal.
+// With an fx quote the to account may hold another currency and is credited at the quoted rate
 type transferRequest struct {
 	FromAccountID int64  `json:"from_account_id" binding:"required,min=1"`
 	ToAccountID   int64  `json:"to_account_id" binding:"required,min=1"`
-	Amount        int64  `json:"amount" binding:"required,gt=0"`
+	Amount        int64  `json:"amount" binding:"required_without=AmountDecimal,excluded_with=AmountDecimal,gte=0"`
+	AmountDecimal string `json:"amount_decimal"`
 	Currency      string `json:"currency" binding:"required,currency"`
+	FxQuoteID     string `json:"fx_quote_id" binding:"omitempty,uuid"`
+}
+
+// amount returns the requested amount in minor units of the currency
+func (req transferRequest) amount() (int64, error) {
+	if req.AmountDecimal == "" {
+		return req.Amount, nil
+	}
+
+	money, err := util.ParseMoney(req.AmountDecimal, req.Currency)
+	if err != nil {
+		return 0, err
+	}
+	if money.Amount <= 0 {
+		return 0, errors.New("amount_decimal must be greater than 0")
+	}
+
+	return money.Amount, nil
 }
 
 func (server *Server) createTransfer(ctx *gin.Context) {
@@ -25,7 +49,24 @@ func (server *Server) createTransfer(ctx *gin.Context) {
 		return
 	}
 
+	amount, err := req.amount()
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
 	authPayload := ctx.MustGet(authorizationPayloadKey).(*token.Payload)
+
+	if !server.requireStepUp(ctx, authPayload, amount, req.Currency) {
+		return
+	}
+
+	idempotency, err := idempotencyParams(ctx, authPayload.Username, req)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
 	account, valid := server.validAccount(ctx, req.FromAccountID, req.Currency)
 
 	if !valid {
@@ -37,21 +78,50 @@ func (server *Server) createTransfer(ctx *gin.Context) {
 		return
 	}
 
-	_, valid = server.validAccount(ctx, req.ToAccountID, req.Currency)
+	var transfer db.TransferTxResult
+	if req.FxQuoteID == "" {
+		_, valid = server.validAccount(ctx, req.ToAccountID, req.Currency)
 
-	if !valid {
-		return
-	}
+		if !valid {
+			return
+		}
 
-	args := db.TransferTxParams{
-		FromAccountID: req.FromAccountID,
-		ToAccountID:   req.ToAccountID,
-		Amount:        int64(req.Amount),
-	}
+		args := db.TransferTxParams{
+			FromAccountID: req.FromAccountID,
+			ToAccountID:   req.ToAccountID,
+			Amount:        amount,
+			Idempotency:   idempotency,
+		}
+
+		transfer, err = server.store.TransferTx(ctx, args)
+	} else {
+		// the quote fixes the to currency, the transaction checks it against the to account
+		args := db.CrossCurrencyTransferTxParams{
+			FromAccountID: req.FromAccountID,
+			ToAccountID:   req.ToAccountID,
+			Amount:        amount,
+			QuoteID:       uuid.MustParse(req.FxQuoteID),
+			Username:      authPayload.Username,
+			Idempotency:   idempotency,
+		}
 
-	transfer, err := server.store.TransferTx(ctx, args)
+		transfer, err = server.store.CrossCurrencyTransferTx(ctx, args)
+	}
 
 	if err != nil {
+		if errors.Is(err, db.ErrRecordNotFound) {
+			ctx.JSON(http.StatusNotFound, errorResponse(err))
+			return
+		}
+		var limitErr *db.TransferLimitError
+		if errors.As(err, &limitErr) {
+			ctx.JSON(http.StatusTooManyRequests, gin.H{"error": err.Error(), "limit": limitErr})
+			return
+		}
+		if db.IsLedgerRejection(err) || errors.Is(err, db.ErrIdempotencyKeyReused) {
+			ctx.JSON(http.StatusUnprocessableEntity, errorResponse(err))
+			return
+		}
 		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
 		return
 	}
@@ -59,6 +129,35 @@ func (server *Server) createTransfer(ctx *gin.Context) {
 	ctx.JSON(http.StatusCreated, transfer)
 }
 
+// requireStepUp checks that a transfer of amount, in minor units of currency, is authorized by a recent
+// elevated token when it is above the step-up threshold configured for currency
+func (server *Server) requireStepUp(ctx *gin.Context, payload *token.Payload, amount int64, currency string) bool {
+	thresholds := server.config.StepUpTransferThresholds
+	if len(thresholds) == 0 {
+		return true
+	}
+
+	money, err := util.NewMoney(amount, currency)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return false
+	}
+
+	// a currency without a threshold has a zero one, so any amount of it needs step-up
+	threshold := thresholds[money.Currency.Code]
+	if !money.ExceedsMajorUnits(threshold) {
+		return true
+	}
+
+	if !payload.IsElevated(server.config.ElevatedTokenDuration) {
+		err := fmt.Errorf("transfers above %d %s need a recent re-authentication, use the token from /users/reauthenticate", threshold, currency)
+		ctx.JSON(http.StatusForbidden, errorResponse(err))
+		return false
+	}
+
+	return true
+}
+
 func (server *Server) validAccount(ctx *gin.Context, accountID int64, currency string) (db.Account, bool) {
 	account, err := server.store.GetAccount(ctx, accountID)
 
@@ -8,6 +8,7 @@ import (
 	"github.com/gin-gonic/gin"
 	"github.com/google/uuid"
 	db "github.com/tonisco/simple-bank-go/db/sqlc"
+	"github.com/tonisco/simple-bank-go/token"
 	"github.com/tonisco/simple-bank-go/util"
 )
 
@@ -89,6 +90,9 @@ func (server *Server) getUser(ctx *gin.Context) {
 	}
 
 	user, err := server.store.GetUser(ctx, req.Username)
+	if err == nil && user.Role == util.SystemRole {
+		err = db.ErrRecordNotFound
+	}
 
 	if err != nil {
 		if errors.Is(err, db.ErrRecordNotFound) {
@@ -118,6 +122,13 @@ type loginUserResponse struct {
 	User                  userResponse `json:"user"`
 }
 
+// loginChallengeResponse is sent instead of the tokens when the user has two-factor authentication on
+type loginChallengeResponse struct {
+	TwoFactorRequired       bool      `json:"two_factor_required"`
+	ChallengeToken          string    `json:"challenge_token"`
+	ChallengeTokenExpiresAt time.Time `json:"challenge_token_expires_at"`
+}
+
 func (server *Server) loginUser(ctx *gin.Context) {
 	var req loginUserRequest
 
@@ -126,11 +137,21 @@ func (server *Server) loginUser(ctx *gin.Context) {
 		return
 	}
 
+	if !server.checkLoginLock(ctx, req.Username) {
+		return
+	}
+
 	user, err := server.store.GetUser(ctx, req.Username)
+	if err == nil && user.Role == util.SystemRole {
+		// the bank's own user cannot log in, so it is treated the same as an unknown user
+		err = db.ErrRecordNotFound
+	}
 
 	if err != nil {
 		if errors.Is(err, db.ErrRecordNotFound) {
-			ctx.JSON(http.StatusNotFound, errorResponse(err))
+			// spend as long as a wrong password does, so the time taken does not give away who has an account
+			_ = util.CheckDummyPassword(req.Password)
+			server.failLogin(ctx, req.Username, nil)
 			return
 		}
 		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
@@ -139,24 +160,64 @@ func (server *Server) loginUser(ctx *gin.Context) {
 
 	err = util.CheckPassword(req.Password, user.HashedPassword)
 	if err != nil {
-		ctx.JSON(http.StatusUnauthorized, errorResponse(err))
+		server.failLogin(ctx, user.Username, &user)
+		return
+	}
+
+	userTotp, err := server.store.GetUserTotp(ctx, user.Username)
+	if err != nil && !errors.Is(err, db.ErrRecordNotFound) {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	// with two-factor authentication on, the password only earns a challenge to exchange with a TOTP code.
+	// The failed attempts are kept until the code is right, so that the password does not buy fresh guesses at it
+	if err == nil && userTotp.ConfirmedAt.Valid {
+		challengeToken, challengePayload, err := server.tokenMaker.CreateToken(
+			user.Username,
+			util.TotpChallengeRole,
+			server.config.TotpChallengeDuration,
+		)
+		if err != nil {
+			ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+			return
+		}
+
+		rsp := loginChallengeResponse{
+			TwoFactorRequired:       true,
+			ChallengeToken:          challengeToken,
+			ChallengeTokenExpiresAt: challengePayload.ExpiredAt,
+		}
+		ctx.JSON(http.StatusOK, rsp)
+		return
+	}
+
+	if !server.resetLoginThrottle(ctx, user.Username) {
 		return
 	}
 
-	accessToken, accessPayload, err := server.tokenMaker.CreateToken(
+	server.createLoginSession(ctx, user)
+}
+
+// createLoginSession responds with the access and refresh tokens of a new session once a user has proved who they are
+func (server *Server) createLoginSession(ctx *gin.Context, user db.User) {
+	refreshToken, refreshPayload, err := server.tokenMaker.CreateRefreshToken(
 		user.Username,
 		user.Role,
-		server.config.AccessTokenDuration,
+		uuid.Nil,
+		server.config.RefreshTokenDuration,
 	)
 	if err != nil {
 		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
 		return
 	}
 
-	refreshToken, refreshPayload, err := server.tokenMaker.CreateToken(
-		req.Username,
+	// the refresh token id is the id of the session and of its family, which the access token is tied to
+	accessToken, accessPayload, err := server.tokenMaker.CreateSessionToken(
+		user.Username,
 		user.Role,
-		server.config.RefreshTokenDuration,
+		refreshPayload.ID,
+		server.config.AccessTokenDuration,
 	)
 	if err != nil {
 		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
@@ -165,12 +226,13 @@ func (server *Server) loginUser(ctx *gin.Context) {
 
 	session, err := server.store.CreateSession(ctx, db.CreateSessionParams{
 		ID:           refreshPayload.ID,
-		Username:     req.Username,
+		Username:     user.Username,
 		RefreshToken: refreshToken,
 		UserAgent:    ctx.Request.UserAgent(),
 		ClientIp:     ctx.ClientIP(),
 		IsBlocked:    false,
 		ExpiresAt:    refreshPayload.ExpiredAt,
+		FamilyID:     refreshPayload.ID,
 	})
 
 	if err != nil {
@@ -189,3 +251,87 @@ func (server *Server) loginUser(ctx *gin.Context) {
 
 	ctx.JSON(http.StatusOK, rsp)
 }
+
+type reauthenticateUserRequest struct {
+	Password string `json:"password" binding:"required,min=6"`
+	// required when the user has two-factor authentication on
+	TotpCode string `json:"totp_code" binding:"omitempty,len=6,numeric"`
+}
+
+type reauthenticateUserResponse struct {
+	ElevatedToken          string    `json:"elevated_token"`
+	ElevatedTokenExpiresAt time.Time `json:"elevated_token_expires_at"`
+}
+
+// reauthenticateUser checks the password of the logged in user again, and their TOTP code when two-factor
+// authentication is on, and issues a short-lived elevated token for operations that need a recent login
+func (server *Server) reauthenticateUser(ctx *gin.Context) {
+	var req reauthenticateUserRequest
+
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	authPayload := ctx.MustGet(authorizationPayloadKey).(*token.Payload)
+
+	// wrong passwords and codes count as failed logins, so a stolen access token cannot be used to guess them
+	if !server.checkLoginLock(ctx, authPayload.Username) {
+		return
+	}
+
+	user, err := server.store.GetUser(ctx, authPayload.Username)
+	if err != nil {
+		if errors.Is(err, db.ErrRecordNotFound) {
+			ctx.JSON(http.StatusNotFound, errorResponse(err))
+			return
+		}
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	err = util.CheckPassword(req.Password, user.HashedPassword)
+	if err != nil {
+		server.failCode(ctx, &user, err)
+		return
+	}
+
+	userTotp, err := server.store.GetUserTotp(ctx, user.Username)
+	if err != nil && !errors.Is(err, db.ErrRecordNotFound) {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	if err == nil && userTotp.ConfirmedAt.Valid {
+		if req.TotpCode == "" {
+			err := errors.New("totp_code is required when two-factor authentication is on")
+			ctx.JSON(http.StatusUnauthorized, errorResponse(err))
+			return
+		}
+
+		if !server.useTotpCode(ctx, &user, req.TotpCode) {
+			return
+		}
+	}
+
+	if !server.resetLoginThrottle(ctx, user.Username) {
+		return
+	}
+
+	elevatedToken, elevatedPayload, err := server.tokenMaker.CreateElevatedToken(
+		user.Username,
+		user.Role,
+		authPayload.SessionID,
+		server.config.ElevatedTokenDuration,
+	)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	rsp := reauthenticateUserResponse{
+		ElevatedToken:          elevatedToken,
+		ElevatedTokenExpiresAt: elevatedPayload.ExpiredAt,
+	}
+	ctx.JSON(http.StatusOK, rsp)
+}
@@ -2,6 +2,7 @@ package api
 
 import (
 	"bytes"
+	"context"
 	"database/sql"
 	"encoding/json"
 	"fmt"
@@ -13,11 +14,14 @@ import (
 	"time"
 
 	"github.com/gin-gonic/gin"
+	"github.com/jackc/pgx/v5/pgtype"
 	"github.com/stretchr/testify/require"
 	mockdb "github.com/tonisco/simple-bank-go/db/mock"
 	db "github.com/tonisco/simple-bank-go/db/sqlc"
 	"github.com/tonisco/simple-bank-go/token"
 	"github.com/tonisco/simple-bank-go/util"
+	"github.com/tonisco/simple-bank-go/worker"
+	mockwk "github.com/tonisco/simple-bank-go/worker/mock"
 	"go.uber.org/mock/gomock"
 )
 
@@ -179,7 +183,7 @@ func TestCreateUser(t *testing.T) {
 
 			store := mockdb.NewMockStore(ctr)
 			tc.buildStubs(store)
-			server := newTestServer(t, store)
+			server := newTestServer(t, store, nil)
 
 			data, err := json.Marshal(tc.body)
 			require.NoError(t, err)
@@ -255,6 +259,22 @@ func TestGetUser(t *testing.T) {
 				require.Equal(t, http.StatusNotFound, recorder.Code)
 			},
 		},
+		{
+			name:     "SystemUser",
+			username: util.SystemUsername,
+			setAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, user1.Username, user1.Role, time.Minute)
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetUser(gomock.Any(), gomock.Eq(util.SystemUsername)).
+					Times(1).
+					Return(db.User{Username: util.SystemUsername, Role: util.SystemRole}, nil)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusNotFound, recorder.Code)
+			},
+		},
 		{
 			name:     "InternalError",
 			username: user1.Username,
@@ -296,7 +316,7 @@ func TestGetUser(t *testing.T) {
 
 			store := mockdb.NewMockStore(ctr)
 			tc.buildStubs(store)
-			server := newTestServer(t, store)
+			server := newTestServer(t, store, nil)
 
 			recorder := httptest.NewRecorder()
 
@@ -313,11 +333,12 @@ func TestGetUser(t *testing.T) {
 
 func TestLoginUser(t *testing.T) {
 	user, password := randomUser(t)
+	clientIP := "203.0.113.7"
 
 	testCases := []struct {
 		name          string
 		body          gin.H
-		buildStubs    func(store *mockdb.MockStore)
+		buildStubs    func(store *mockdb.MockStore, taskDistributor *mockwk.MockTaskDistributor)
 		checkResponse func(recorder *httptest.ResponseRecorder)
 	}{
 		{
@@ -326,12 +347,33 @@ func TestLoginUser(t *testing.T) {
 				"username": user.Username,
 				"password": password,
 			},
-			buildStubs: func(store *mockdb.MockStore) {
+			buildStubs: func(store *mockdb.MockStore, taskDistributor *mockwk.MockTaskDistributor) {
+				store.EXPECT().
+					ListActiveLoginLocks(gomock.Any(), gomock.Eq(db.ListActiveLoginLocksParams{
+						Username: user.Username,
+						ClientIp: clientIP,
+					})).
+					Times(1).
+					Return([]db.LoginThrottle{}, nil)
+
 				store.EXPECT().
 					GetUser(gomock.Any(), gomock.Eq(user.Username)).
 					Times(1).
 					Return(user, nil)
 
+				store.EXPECT().
+					DeleteLoginThrottle(gomock.Any(), gomock.Eq(db.DeleteLoginThrottleParams{
+						Scope:   db.LoginThrottleScopeUser,
+						Subject: user.Username,
+					})).
+					Times(1).
+					Return(nil)
+
+				store.EXPECT().
+					GetUserTotp(gomock.Any(), gomock.Eq(user.Username)).
+					Times(1).
+					Return(db.UserTotp{}, db.ErrRecordNotFound)
+
 				store.EXPECT().
 					CreateSession(gomock.Any(), gomock.Any()).
 					Times(1)
@@ -340,20 +382,82 @@ func TestLoginUser(t *testing.T) {
 				require.Equal(t, http.StatusOK, recorder.Code)
 			},
 		},
+		{
+			name: "TwoFactorRequired",
+			body: gin.H{
+				"username": user.Username,
+				"password": password,
+			},
+			buildStubs: func(store *mockdb.MockStore, taskDistributor *mockwk.MockTaskDistributor) {
+				store.EXPECT().
+					ListActiveLoginLocks(gomock.Any(), gomock.Eq(db.ListActiveLoginLocksParams{
+						Username: user.Username,
+						ClientIp: clientIP,
+					})).
+					Times(1).
+					Return([]db.LoginThrottle{}, nil)
+
+				store.EXPECT().
+					GetUser(gomock.Any(), gomock.Eq(user.Username)).
+					Times(1).
+					Return(user, nil)
+
+				store.EXPECT().
+					DeleteLoginThrottle(gomock.Any(), gomock.Any()).
+					Times(0)
+
+				store.EXPECT().
+					GetUserTotp(gomock.Any(), gomock.Eq(user.Username)).
+					Times(1).
+					Return(db.UserTotp{
+						Username:    user.Username,
+						ConfirmedAt: pgtype.Timestamptz{Time: time.Now(), Valid: true},
+					}, nil)
+
+				store.EXPECT().
+					CreateSession(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+
+				var rsp loginChallengeResponse
+				err := json.Unmarshal(recorder.Body.Bytes(), &rsp)
+				require.NoError(t, err)
+				require.True(t, rsp.TwoFactorRequired)
+				require.NotEmpty(t, rsp.ChallengeToken)
+			},
+		},
 		{
 			name: "UserNotFound",
 			body: gin.H{
 				"username": "NotFound",
 				"password": password,
 			},
-			buildStubs: func(store *mockdb.MockStore) {
+			buildStubs: func(store *mockdb.MockStore, taskDistributor *mockwk.MockTaskDistributor) {
+				store.EXPECT().
+					ListActiveLoginLocks(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return([]db.LoginThrottle{}, nil)
+
 				store.EXPECT().
 					GetUser(gomock.Any(), gomock.Any()).
 					Times(1).
 					Return(db.User{}, db.ErrRecordNotFound)
+
+				store.EXPECT().
+					RecordFailedLoginTx(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(db.RecordFailedLoginTxResult{UserLocked: true}, nil)
+
+				taskDistributor.EXPECT().
+					DistributeTaskSendLockoutEmail(gomock.Any(), gomock.Any(), gomock.Any()).
+					Times(0)
 			},
 			checkResponse: func(recorder *httptest.ResponseRecorder) {
-				require.Equal(t, http.StatusNotFound, recorder.Code)
+				// the same response as a wrong password
+				require.Equal(t, http.StatusUnauthorized, recorder.Code)
+				require.Contains(t, recorder.Body.String(), errLoginFailed.Error())
 			},
 		},
 		{
@@ -362,23 +466,125 @@ func TestLoginUser(t *testing.T) {
 				"username": user.Username,
 				"password": "incorrect",
 			},
-			buildStubs: func(store *mockdb.MockStore) {
+			buildStubs: func(store *mockdb.MockStore, taskDistributor *mockwk.MockTaskDistributor) {
+				store.EXPECT().
+					ListActiveLoginLocks(gomock.Any(), gomock.Eq(db.ListActiveLoginLocksParams{
+						Username: user.Username,
+						ClientIp: clientIP,
+					})).
+					Times(1).
+					Return([]db.LoginThrottle{}, nil)
+
+				store.EXPECT().
+					GetUser(gomock.Any(), gomock.Eq(user.Username)).
+					Times(1).
+					Return(user, nil)
+
+				store.EXPECT().
+					RecordFailedLoginTx(gomock.Any(), gomock.Any()).
+					Times(1).
+					DoAndReturn(func(_ context.Context, arg db.RecordFailedLoginTxParams) (db.RecordFailedLoginTxResult, error) {
+						require.Equal(t, user.Username, arg.Username)
+						require.Equal(t, clientIP, arg.ClientIP)
+						return db.RecordFailedLoginTxResult{}, nil
+					})
+
+				store.EXPECT().
+					DeleteLoginThrottle(gomock.Any(), gomock.Any()).
+					Times(0)
+
+				taskDistributor.EXPECT().
+					DistributeTaskSendLockoutEmail(gomock.Any(), gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusUnauthorized, recorder.Code)
+				require.Contains(t, recorder.Body.String(), errLoginFailed.Error())
+			},
+		},
+		{
+			name: "LockoutEmail",
+			body: gin.H{
+				"username": user.Username,
+				"password": "incorrect",
+			},
+			buildStubs: func(store *mockdb.MockStore, taskDistributor *mockwk.MockTaskDistributor) {
+				lockedUntil := time.Now().Add(time.Minute)
+
+				store.EXPECT().
+					ListActiveLoginLocks(gomock.Any(), gomock.Eq(db.ListActiveLoginLocksParams{
+						Username: user.Username,
+						ClientIp: clientIP,
+					})).
+					Times(1).
+					Return([]db.LoginThrottle{}, nil)
+
 				store.EXPECT().
 					GetUser(gomock.Any(), gomock.Eq(user.Username)).
 					Times(1).
 					Return(user, nil)
+
+				store.EXPECT().
+					RecordFailedLoginTx(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(db.RecordFailedLoginTxResult{
+						UserThrottle: db.LoginThrottle{
+							Scope:       db.LoginThrottleScopeUser,
+							Subject:     user.Username,
+							LockedUntil: pgtype.Timestamptz{Time: lockedUntil, Valid: true},
+						},
+						UserLocked: true,
+					}, nil)
+
+				taskDistributor.EXPECT().
+					DistributeTaskSendLockoutEmail(gomock.Any(), gomock.Eq(&worker.PayloadSendLockoutEmail{
+						Username:    user.Username,
+						LockedUntil: lockedUntil,
+					}), gomock.Any()).
+					Times(1).
+					Return(nil)
 			},
 			checkResponse: func(recorder *httptest.ResponseRecorder) {
 				require.Equal(t, http.StatusUnauthorized, recorder.Code)
 			},
 		},
+		{
+			name: "Locked",
+			body: gin.H{
+				"username": user.Username,
+				"password": password,
+			},
+			buildStubs: func(store *mockdb.MockStore, taskDistributor *mockwk.MockTaskDistributor) {
+				store.EXPECT().
+					ListActiveLoginLocks(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return([]db.LoginThrottle{{
+						Scope:       db.LoginThrottleScopeUser,
+						Subject:     user.Username,
+						LockedUntil: pgtype.Timestamptz{Time: time.Now().Add(time.Minute), Valid: true},
+					}}, nil)
+
+				store.EXPECT().
+					GetUser(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusTooManyRequests, recorder.Code)
+				require.NotEmpty(t, recorder.Header().Get("Retry-After"))
+			},
+		},
 		{
 			name: "InternalError",
 			body: gin.H{
 				"username": user.Username,
 				"password": password,
 			},
-			buildStubs: func(store *mockdb.MockStore) {
+			buildStubs: func(store *mockdb.MockStore, taskDistributor *mockwk.MockTaskDistributor) {
+				store.EXPECT().
+					ListActiveLoginLocks(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return([]db.LoginThrottle{}, nil)
+
 				store.EXPECT().
 					GetUser(gomock.Any(), gomock.Any()).
 					Times(1).
@@ -396,7 +602,7 @@ func TestLoginUser(t *testing.T) {
 				"full_name": user.FullName,
 				"email":     user.Email,
 			},
-			buildStubs: func(store *mockdb.MockStore) {
+			buildStubs: func(store *mockdb.MockStore, taskDistributor *mockwk.MockTaskDistributor) {
 				store.EXPECT().
 					GetUser(gomock.Any(), gomock.Any()).
 					Times(0)
@@ -413,8 +619,9 @@ func TestLoginUser(t *testing.T) {
 			defer ctr.Finish()
 
 			store := mockdb.NewMockStore(ctr)
-			tc.buildStubs(store)
-			server := newTestServer(t, store)
+			taskDistributor := mockwk.NewMockTaskDistributor(ctr)
+			tc.buildStubs(store, taskDistributor)
+			server := newTestServer(t, store, taskDistributor)
 
 			recorder := httptest.NewRecorder()
 
@@ -424,6 +631,9 @@ func TestLoginUser(t *testing.T) {
 			url := "/users/login"
 			request, err := http.NewRequest(http.MethodPost, url, bytes.NewReader(data))
 			require.NoError(t, err)
+			request.RemoteAddr = clientIP + ":54321"
+			// no proxy is trusted, so the throttle counts against the remote address and not the header
+			request.Header.Set("X-Forwarded-For", "198.51.100.1")
 
 			server.router.ServeHTTP(recorder, request)
 			tc.checkResponse(recorder)
@@ -431,6 +641,175 @@ func TestLoginUser(t *testing.T) {
 	}
 }
 
+func TestReauthenticateUser(t *testing.T) {
+	user, password := randomUser(t)
+
+	testCases := []struct {
+		name          string
+		body          gin.H
+		setupAuth     func(t *testing.T, request *http.Request, tokenMaker token.Maker)
+		buildStubs    func(store *mockdb.MockStore)
+		checkResponse func(recorder *httptest.ResponseRecorder, tokenMaker token.Maker)
+	}{
+		{
+			name: "OK",
+			body: gin.H{
+				"password": password,
+			},
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, user.Username, user.Role, time.Minute)
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					ListActiveLoginLocks(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return([]db.LoginThrottle{}, nil)
+
+				store.EXPECT().
+					GetUser(gomock.Any(), gomock.Eq(user.Username)).
+					Times(1).
+					Return(user, nil)
+
+				store.EXPECT().
+					GetUserTotp(gomock.Any(), gomock.Eq(user.Username)).
+					Times(1).
+					Return(db.UserTotp{}, db.ErrRecordNotFound)
+
+				store.EXPECT().
+					DeleteLoginThrottle(gomock.Any(), gomock.Eq(db.DeleteLoginThrottleParams{
+						Scope:   db.LoginThrottleScopeUser,
+						Subject: user.Username,
+					})).
+					Times(1).
+					Return(nil)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder, tokenMaker token.Maker) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+
+				var rsp reauthenticateUserResponse
+				err := json.NewDecoder(recorder.Body).Decode(&rsp)
+				require.NoError(t, err)
+
+				payload, err := tokenMaker.VerifyToken(rsp.ElevatedToken)
+				require.NoError(t, err)
+				require.Equal(t, user.Username, payload.Username)
+				require.True(t, payload.IsElevated(time.Minute))
+			},
+		},
+		{
+			name: "IncorrectPassword",
+			body: gin.H{
+				"password": "incorrect",
+			},
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, user.Username, user.Role, time.Minute)
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					ListActiveLoginLocks(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return([]db.LoginThrottle{}, nil)
+
+				store.EXPECT().
+					GetUser(gomock.Any(), gomock.Eq(user.Username)).
+					Times(1).
+					Return(user, nil)
+
+				store.EXPECT().
+					GetUserTotp(gomock.Any(), gomock.Any()).
+					Times(0)
+
+				store.EXPECT().
+					RecordFailedLoginTx(gomock.Any(), gomock.Any()).
+					Times(1).
+					DoAndReturn(func(_ context.Context, arg db.RecordFailedLoginTxParams) (db.RecordFailedLoginTxResult, error) {
+						require.Equal(t, user.Username, arg.Username)
+						return db.RecordFailedLoginTxResult{}, nil
+					})
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder, tokenMaker token.Maker) {
+				require.Equal(t, http.StatusUnauthorized, recorder.Code)
+			},
+		},
+		{
+			name: "TotpCodeRequired",
+			body: gin.H{
+				"password": password,
+			},
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, user.Username, user.Role, time.Minute)
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					ListActiveLoginLocks(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return([]db.LoginThrottle{}, nil)
+
+				store.EXPECT().
+					GetUser(gomock.Any(), gomock.Eq(user.Username)).
+					Times(1).
+					Return(user, nil)
+
+				store.EXPECT().
+					GetUserTotp(gomock.Any(), gomock.Eq(user.Username)).
+					Times(1).
+					Return(db.UserTotp{
+						Username:    user.Username,
+						ConfirmedAt: pgtype.Timestamptz{Time: time.Now(), Valid: true},
+					}, nil)
+
+				store.EXPECT().
+					RecordFailedLoginTx(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder, tokenMaker token.Maker) {
+				require.Equal(t, http.StatusUnauthorized, recorder.Code)
+			},
+		},
+		{
+			name: "NoAuthorization",
+			body: gin.H{
+				"password": password,
+			},
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetUser(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder, tokenMaker token.Maker) {
+				require.Equal(t, http.StatusUnauthorized, recorder.Code)
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			ctr := gomock.NewController(t)
+			defer ctr.Finish()
+
+			store := mockdb.NewMockStore(ctr)
+			tc.buildStubs(store)
+			server := newTestServer(t, store, nil)
+			server.config.ElevatedTokenDuration = time.Minute
+
+			recorder := httptest.NewRecorder()
+
+			data, err := json.Marshal(tc.body)
+			require.NoError(t, err)
+
+			url := "/users/reauthenticate"
+			request, err := http.NewRequest(http.MethodPost, url, bytes.NewReader(data))
+			require.NoError(t, err)
+
+			tc.setupAuth(t, request, server.tokenMaker)
+			server.router.ServeHTTP(recorder, request)
+			tc.checkResponse(recorder, server.tokenMaker)
+		})
+	}
+}
+
 func randomUser(t *testing.T) (user db.User, password string) {
 	password = util.RandomString(8)
 	hashedPassword, err := util.HashPassword(password)
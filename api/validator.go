@@ -7,7 +7,8 @@ import (
 
 var validCurrency validator.Func = func(fieldLevel validator.FieldLevel) bool {
 	if currency, ok := fieldLevel.Field().Interface().(string); ok {
-		return util.IsSupported(currency)
+		_, ok := util.LookupCurrency(currency)
+		return ok
 	}
 	return false
 }
@@ -0,0 +1,21 @@
+DROP TRIGGER IF EXISTS "entries_journal_balanced" ON "entries";
+
+DROP FUNCTION IF EXISTS check_journal_balanced();
+
+DELETE FROM "entries" WHERE "account_id" IN (SELECT "id" FROM "accounts" WHERE "kind" = 'system');
+
+DELETE FROM "accounts" WHERE "kind" = 'system';
+
+DELETE FROM "users" WHERE "username" = 'simplebank';
+
+DROP INDEX IF EXISTS "system_currency_key";
+
+DROP INDEX IF EXISTS "owner_currency_key";
+
+ALTER TABLE "accounts" ADD CONSTRAINT "owner_currency_key" UNIQUE ("owner", "currency");
+
+ALTER TABLE "accounts" DROP COLUMN "kind";
+
+ALTER TABLE "entries" DROP COLUMN "journal_id";
+
+DROP TABLE IF EXISTS "journals";
@@ -0,0 +1,61 @@
+CREATE TABLE "journals" (
+  "id" bigserial PRIMARY KEY,
+  "kind" varchar NOT NULL,
+  "created_at" timestamptz NOT NULL DEFAULT (now())
+);
+
+COMMENT ON COLUMN "journals"."kind" IS 'transfer, deposit or withdrawal';
+
+ALTER TABLE "entries" ADD COLUMN "journal_id" bigint;
+
+ALTER TABLE "entries" ADD FOREIGN KEY ("journal_id") REFERENCES "journals" ("id");
+
+CREATE INDEX ON "entries" ("journal_id");
+
+COMMENT ON COLUMN "entries"."journal_id" IS 'the balanced journal this entry is a posting of, null for entries made before journals existed';
+
+ALTER TABLE "accounts" ADD COLUMN "kind" varchar NOT NULL DEFAULT 'customer';
+
+ALTER TABLE "accounts" ADD CONSTRAINT "accounts_kind_check" CHECK ("kind" IN ('customer', 'system'));
+
+COMMENT ON COLUMN "accounts"."kind" IS 'customer accounts belong to users, system accounts are the bank''s own books';
+
+-- customers keep one account per currency, the bank keeps one system account per currency
+ALTER TABLE "accounts" DROP CONSTRAINT "owner_currency_key";
+
+CREATE UNIQUE INDEX "owner_currency_key" ON "accounts" ("owner", "currency") WHERE "kind" = 'customer';
+
+CREATE UNIQUE INDEX "system_currency_key" ON "accounts" ("currency") WHERE "kind" = 'system';
+
+-- the bank owns its system accounts through a user that cannot log in
+INSERT INTO "users" ("username", "hashed_password", "full_name", "email", "role")
+VALUES ('simplebank', '', 'Simple Bank', 'ledger@simplebank.internal', 'depositor');
+
+INSERT INTO "accounts" ("owner", "balance", "currency", "kind")
+VALUES ('simplebank', 0, 'USD', 'system'),
+       ('simplebank', 0, 'EUR', 'system'),
+       ('simplebank', 0, 'CAD', 'system');
+
+-- every journal must sum to zero in each currency once its transaction commits
+CREATE FUNCTION check_journal_balanced() RETURNS trigger AS $$
+BEGIN
+  IF EXISTS (
+    SELECT 1
+    FROM "entries" e
+    JOIN "accounts" a ON a."id" = e."account_id"
+    WHERE e."journal_id" = NEW."journal_id"
+    GROUP BY a."currency"
+    HAVING SUM(e."amount") <> 0
+  ) THEN
+    RAISE EXCEPTION 'journal % is not balanced', NEW."journal_id" USING ERRCODE = 'check_violation';
+  END IF;
+  RETURN NULL;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE CONSTRAINT TRIGGER "entries_journal_balanced"
+AFTER INSERT OR UPDATE ON "entries"
+DEFERRABLE INITIALLY DEFERRED
+FOR EACH ROW
+WHEN (NEW."journal_id" IS NOT NULL)
+EXECUTE FUNCTION check_journal_balanced();
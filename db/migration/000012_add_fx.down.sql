@@ -0,0 +1,17 @@
+DROP TABLE IF EXISTS "fx_quotes";
+
+ALTER TABLE "transfers" DROP COLUMN "exchange_rate";
+
+ALTER TABLE "transfers" DROP COLUMN "to_amount";
+
+DELETE FROM "entries" WHERE "account_id" IN (SELECT "id" FROM "accounts" WHERE "kind" = 'fx');
+
+DELETE FROM "accounts" WHERE "kind" = 'fx';
+
+DROP INDEX IF EXISTS "bank_account_key";
+
+CREATE UNIQUE INDEX "system_currency_key" ON "accounts" ("currency") WHERE "kind" = 'system';
+
+ALTER TABLE "accounts" DROP CONSTRAINT "accounts_kind_check";
+
+ALTER TABLE "accounts" ADD CONSTRAINT "accounts_kind_check" CHECK ("kind" IN ('customer', 'system'));
@@ -0,0 +1,41 @@
+ALTER TABLE "accounts" DROP CONSTRAINT "accounts_kind_check";
+
+ALTER TABLE "accounts" ADD CONSTRAINT "accounts_kind_check" CHECK ("kind" IN ('customer', 'system', 'fx'));
+
+COMMENT ON COLUMN "accounts"."kind" IS 'customer accounts belong to users, system and fx accounts are the bank''s own books';
+
+-- the bank keeps one account of each kind per currency
+DROP INDEX "system_currency_key";
+
+CREATE UNIQUE INDEX "bank_account_key" ON "accounts" ("kind", "currency") WHERE "kind" <> 'customer';
+
+-- fx accounts hold the bank's position in each currency from exchanging money between customers
+INSERT INTO "accounts" ("owner", "balance", "currency", "kind")
+VALUES ('simplebank', 0, 'USD', 'fx'),
+       ('simplebank', 0, 'EUR', 'fx'),
+       ('simplebank', 0, 'CAD', 'fx');
+
+ALTER TABLE "transfers" ADD COLUMN "to_amount" bigint;
+
+ALTER TABLE "transfers" ADD COLUMN "exchange_rate" bigint;
+
+COMMENT ON COLUMN "transfers"."to_amount" IS 'amount credited in the to account currency, null when both accounts share a currency';
+
+COMMENT ON COLUMN "transfers"."exchange_rate" IS 'units of the to currency bought by one unit of the from currency, scaled by 10^8';
+
+CREATE TABLE "fx_quotes" (
+  "id" uuid PRIMARY KEY,
+  "username" varchar NOT NULL,
+  "from_currency" varchar NOT NULL,
+  "to_currency" varchar NOT NULL,
+  "rate" bigint NOT NULL,
+  "expires_at" timestamptz NOT NULL,
+  "used_at" timestamptz,
+  "created_at" timestamptz NOT NULL DEFAULT (now())
+);
+
+ALTER TABLE "fx_quotes" ADD FOREIGN KEY ("username") REFERENCES "users" ("username");
+
+COMMENT ON COLUMN "fx_quotes"."rate" IS 'units of to_currency bought by one unit of from_currency, scaled by 10^8';
+
+COMMENT ON COLUMN "fx_quotes"."used_at" IS 'a quote can be used for a single transfer';
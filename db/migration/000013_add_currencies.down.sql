@@ -0,0 +1,7 @@
+COMMENT ON COLUMN "accounts"."balance" IS NULL;
+
+DELETE FROM "entries" WHERE "account_id" IN (
+  SELECT "id" FROM "accounts" WHERE "kind" <> 'customer' AND "currency" IN ('GBP', 'JPY', 'KWD')
+);
+
+DELETE FROM "accounts" WHERE "kind" <> 'customer' AND "currency" IN ('GBP', 'JPY', 'KWD');
@@ -0,0 +1,10 @@
+-- the bank's system and fx accounts for the currencies added to the util currency registry
+INSERT INTO "accounts" ("owner", "balance", "currency", "kind")
+VALUES ('simplebank', 0, 'GBP', 'system'),
+       ('simplebank', 0, 'JPY', 'system'),
+       ('simplebank', 0, 'KWD', 'system'),
+       ('simplebank', 0, 'GBP', 'fx'),
+       ('simplebank', 0, 'JPY', 'fx'),
+       ('simplebank', 0, 'KWD', 'fx');
+
+COMMENT ON COLUMN "accounts"."balance" IS 'in the minor unit of the currency, e.g. cents for USD, yen for JPY, fils for KWD';
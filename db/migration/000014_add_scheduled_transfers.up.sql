@@ -0,0 +1,46 @@
+CREATE TABLE "scheduled_transfers" (
+  "id" bigserial PRIMARY KEY,
+  "owner" varchar NOT NULL,
+  "from_account_id" bigint NOT NULL,
+  "to_account_id" bigint NOT NULL,
+  "amount" bigint NOT NULL CHECK ("amount" > 0),
+  "schedule" varchar NOT NULL DEFAULT '',
+  "status" varchar NOT NULL DEFAULT 'active' CHECK ("status" IN ('active', 'paused', 'completed')),
+  "next_run_at" timestamptz NOT NULL,
+  "created_at" timestamptz NOT NULL DEFAULT (now())
+);
+
+CREATE TABLE "scheduled_transfer_runs" (
+  "id" bigserial PRIMARY KEY,
+  "scheduled_transfer_id" bigint NOT NULL,
+  "run_at" timestamptz NOT NULL,
+  "status" varchar NOT NULL CHECK ("status" IN ('succeeded', 'failed')),
+  "transfer_id" bigint,
+  "error" varchar NOT NULL DEFAULT '',
+  "created_at" timestamptz NOT NULL DEFAULT (now())
+);
+
+ALTER TABLE "scheduled_transfers" ADD FOREIGN KEY ("owner") REFERENCES "users" ("username");
+
+ALTER TABLE "scheduled_transfers" ADD FOREIGN KEY ("from_account_id") REFERENCES "accounts" ("id");
+
+ALTER TABLE "scheduled_transfers" ADD FOREIGN KEY ("to_account_id") REFERENCES "accounts" ("id");
+
+ALTER TABLE "scheduled_transfer_runs" ADD FOREIGN KEY ("scheduled_transfer_id") REFERENCES "scheduled_transfers" ("id") ON DELETE CASCADE;
+
+ALTER TABLE "scheduled_transfer_runs" ADD FOREIGN KEY ("transfer_id") REFERENCES "transfers" ("id");
+
+CREATE INDEX ON "scheduled_transfers" ("owner");
+
+CREATE INDEX ON "scheduled_transfers" ("next_run_at") WHERE "status" = 'active';
+
+-- a scheduled time is executed at most once
+CREATE UNIQUE INDEX ON "scheduled_transfer_runs" ("scheduled_transfer_id", "run_at");
+
+COMMENT ON COLUMN "scheduled_transfers"."schedule" IS 'cron expression or @every interval in UTC, empty for a one-off transfer';
+
+COMMENT ON COLUMN "scheduled_transfers"."next_run_at" IS 'when the transfer is next due, kept after completion as the time of the last run';
+
+COMMENT ON COLUMN "scheduled_transfer_runs"."transfer_id" IS 'the transfer made by a successful run';
+
+COMMENT ON COLUMN "scheduled_transfer_runs"."error" IS 'why a failed run did not move the money';
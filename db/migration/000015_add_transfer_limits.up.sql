@@ -0,0 +1,43 @@
+CREATE TABLE "transfer_limits" (
+  "role" varchar NOT NULL,
+  "currency" varchar NOT NULL,
+  "per_transfer" bigint NOT NULL CHECK ("per_transfer" >= 0),
+  "daily" bigint NOT NULL CHECK ("daily" >= 0),
+  "monthly" bigint NOT NULL CHECK ("monthly" >= 0),
+  PRIMARY KEY ("role", "currency")
+);
+
+CREATE TABLE "account_transfer_limits" (
+  "account_id" bigint PRIMARY KEY,
+  "per_transfer" bigint CHECK ("per_transfer" >= 0),
+  "daily" bigint CHECK ("daily" >= 0),
+  "monthly" bigint CHECK ("monthly" >= 0),
+  "updated_at" timestamptz NOT NULL DEFAULT (now())
+);
+
+ALTER TABLE "account_transfer_limits" ADD FOREIGN KEY ("account_id") REFERENCES "accounts" ("id") ON DELETE CASCADE;
+
+-- the daily and monthly limits sum an account's outgoing transfers since the start of the period
+CREATE INDEX ON "transfers" ("from_account_id", "created_at");
+
+COMMENT ON TABLE "transfer_limits" IS 'limits for the accounts of users with the role, roles without limits for a currency are not limited';
+
+COMMENT ON COLUMN "transfer_limits"."daily" IS 'total of transfers out of an account per UTC calendar day';
+
+COMMENT ON COLUMN "transfer_limits"."monthly" IS 'total of transfers out of an account per UTC calendar month';
+
+COMMENT ON TABLE "account_transfer_limits" IS 'overrides of the role limits for a single account, null keeps the role limit';
+
+INSERT INTO "transfer_limits" ("role", "currency", "per_transfer", "daily", "monthly")
+VALUES ('depositor', 'USD', 1000000, 2500000, 10000000),
+       ('depositor', 'EUR', 1000000, 2500000, 10000000),
+       ('depositor', 'CAD', 1000000, 2500000, 10000000),
+       ('depositor', 'GBP', 1000000, 2500000, 10000000),
+       ('depositor', 'JPY', 1500000, 3750000, 15000000),
+       ('depositor', 'KWD', 3000000, 7500000, 30000000),
+       ('banker', 'USD', 10000000, 25000000, 100000000),
+       ('banker', 'EUR', 10000000, 25000000, 100000000),
+       ('banker', 'CAD', 10000000, 25000000, 100000000),
+       ('banker', 'GBP', 10000000, 25000000, 100000000),
+       ('banker', 'JPY', 15000000, 37500000, 150000000),
+       ('banker', 'KWD', 30000000, 75000000, 300000000);
@@ -0,0 +1,33 @@
+ALTER TABLE "accounts" ADD COLUMN "held_amount" bigint NOT NULL DEFAULT 0;
+
+ALTER TABLE "accounts" ADD CONSTRAINT "held_amount_non_negative" CHECK ("held_amount" >= 0);
+
+COMMENT ON COLUMN "accounts"."held_amount" IS 'total of the active holds on the account, the available balance is balance minus held_amount';
+
+CREATE TABLE "holds" (
+  "id" bigserial PRIMARY KEY,
+  "account_id" bigint NOT NULL,
+  "to_account_id" bigint NOT NULL,
+  "amount" bigint NOT NULL CHECK ("amount" > 0),
+  "status" varchar NOT NULL DEFAULT 'active' CHECK ("status" IN ('active', 'captured', 'released', 'expired')),
+  "transfer_id" bigint,
+  "expires_at" timestamptz NOT NULL,
+  "created_at" timestamptz NOT NULL DEFAULT (now()),
+  "updated_at" timestamptz NOT NULL DEFAULT (now())
+);
+
+ALTER TABLE "holds" ADD FOREIGN KEY ("account_id") REFERENCES "accounts" ("id");
+
+ALTER TABLE "holds" ADD FOREIGN KEY ("to_account_id") REFERENCES "accounts" ("id");
+
+ALTER TABLE "holds" ADD FOREIGN KEY ("transfer_id") REFERENCES "transfers" ("id");
+
+CREATE INDEX ON "holds" ("account_id");
+
+CREATE INDEX ON "holds" ("expires_at") WHERE "status" = 'active';
+
+COMMENT ON COLUMN "holds"."account_id" IS 'the account the funds are reserved on';
+
+COMMENT ON COLUMN "holds"."to_account_id" IS 'the account the funds are paid to when the hold is captured';
+
+COMMENT ON COLUMN "holds"."transfer_id" IS 'the transfer made when the hold was captured';
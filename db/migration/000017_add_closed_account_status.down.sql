@@ -0,0 +1,7 @@
+UPDATE "accounts" SET "status" = 'frozen' WHERE "status" = 'closed';
+
+ALTER TABLE "accounts" DROP CONSTRAINT "accounts_status_check";
+
+ALTER TABLE "accounts" ADD CONSTRAINT "accounts_status_check" CHECK ("status" IN ('active', 'frozen'));
+
+COMMENT ON COLUMN "accounts"."status" IS 'frozen accounts cannot send or receive money';
@@ -0,0 +1,5 @@
+ALTER TABLE "accounts" DROP CONSTRAINT "accounts_status_check";
+
+ALTER TABLE "accounts" ADD CONSTRAINT "accounts_status_check" CHECK ("status" IN ('active', 'frozen', 'closed'));
+
+COMMENT ON COLUMN "accounts"."status" IS 'frozen and closed accounts cannot send or receive money, closed accounts are kept for their history';
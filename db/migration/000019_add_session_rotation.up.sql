@@ -0,0 +1,17 @@
+ALTER TABLE "sessions" ADD COLUMN "family_id" uuid;
+
+UPDATE "sessions" SET "family_id" = "id";
+
+ALTER TABLE "sessions" ALTER COLUMN "family_id" SET NOT NULL;
+
+ALTER TABLE "sessions" ADD COLUMN "previous_session_id" uuid;
+
+ALTER TABLE "sessions" ADD COLUMN "rotated_at" timestamptz;
+
+ALTER TABLE "sessions" ADD FOREIGN KEY ("previous_session_id") REFERENCES "sessions" ("id");
+
+CREATE INDEX ON "sessions" ("family_id");
+
+COMMENT ON COLUMN "sessions"."family_id" IS 'id of the session created at login, shared by every session rotated from it';
+
+COMMENT ON COLUMN "sessions"."rotated_at" IS 'set once the refresh token has been exchanged, presenting it again blocks the whole family';
@@ -0,0 +1,25 @@
+CREATE TABLE "user_totps" (
+  "username" varchar PRIMARY KEY,
+  "secret" varchar NOT NULL,
+  "confirmed_at" timestamptz,
+  "last_used_step" bigint NOT NULL DEFAULT 0,
+  "created_at" timestamptz NOT NULL DEFAULT (now())
+);
+
+CREATE TABLE "totp_recovery_codes" (
+  "id" bigserial PRIMARY KEY,
+  "username" varchar NOT NULL,
+  "hashed_code" varchar NOT NULL,
+  "used_at" timestamptz,
+  "created_at" timestamptz NOT NULL DEFAULT (now())
+);
+
+ALTER TABLE "user_totps" ADD FOREIGN KEY ("username") REFERENCES "users" ("username");
+
+ALTER TABLE "totp_recovery_codes" ADD FOREIGN KEY ("username") REFERENCES "users" ("username");
+
+CREATE INDEX ON "totp_recovery_codes" ("username");
+
+COMMENT ON COLUMN "user_totps"."confirmed_at" IS 'two-factor login is only required once the user has confirmed the authenticator with a first code';
+
+COMMENT ON COLUMN "user_totps"."last_used_step" IS 'time step of the last accepted code, codes from it or earlier steps are refused so they cannot be replayed';
@@ -0,0 +1,14 @@
+CREATE TABLE "login_throttles" (
+  "scope" varchar NOT NULL,
+  "subject" varchar NOT NULL,
+  "failed_attempts" int NOT NULL DEFAULT 0,
+  "locked_until" timestamptz,
+  "last_failed_at" timestamptz NOT NULL DEFAULT (now()),
+  PRIMARY KEY ("scope", "subject")
+);
+
+COMMENT ON COLUMN "login_throttles"."scope" IS 'user or ip';
+
+COMMENT ON COLUMN "login_throttles"."subject" IS 'username or client ip the failed logins came from, usernames are tracked even when no such user exists';
+
+COMMENT ON COLUMN "login_throttles"."locked_until" IS 'logins for the subject are refused until then';
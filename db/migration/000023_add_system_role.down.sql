@@ -0,0 +1 @@
+UPDATE "users" SET "role" = 'depositor' WHERE "username" = 'simplebank';
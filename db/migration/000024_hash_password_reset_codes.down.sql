@@ -0,0 +1 @@
+COMMENT ON COLUMN "password_resets"."secret_code" IS NULL;
@@ -0,0 +1,4 @@
+-- codes issued before now were stored in plain text and can no longer be matched
+UPDATE "password_resets" SET "is_used" = true WHERE "is_used" = false;
+
+COMMENT ON COLUMN "password_resets"."secret_code" IS 'SHA-256 hash of the code sent by email, the code itself is not stored';
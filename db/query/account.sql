@@ -17,6 +17,11 @@ WHERE id = $1
 LIMIT 1
 FOR NO KEY UPDATE;
 
+-- name: GetBankAccount :one
+SELECT * FROM accounts
+WHERE kind = $1 AND currency = $2
+LIMIT 1;
+
 -- name: ListAccounts :many
 SELECT * FROM accounts
 WHERE owner= $1
@@ -24,6 +29,21 @@ ORDER BY id
 LIMIT $2
 OFFSET $3;
 
+-- name: ListAccountIDs :many
+SELECT id FROM accounts
+WHERE kind = 'customer' AND status <> 'closed' AND id > sqlc.arg(after_id)
+ORDER BY id
+LIMIT sqlc.arg('limit');
+
+-- name: ListAccountLedgerBalances :many
+SELECT a.id, a.balance, COALESCE(SUM(e.amount), 0)::bigint AS entries_balance
+FROM accounts a
+LEFT JOIN entries e ON e.account_id = a.id
+WHERE a.id > sqlc.arg(after_id)
+GROUP BY a.id
+ORDER BY a.id
+LIMIT sqlc.arg('limit');
+
 -- name: UpdateAccount :one
 UPDATE accounts
 SET balance=$2
@@ -36,6 +56,18 @@ SET balance= balance + sqlc.arg(amount)
 WHERE id=sqlc.arg(id)
 RETURNING *;
 
+-- name: AddAccountHeldAmount :one
+UPDATE accounts
+SET held_amount = held_amount + sqlc.arg(amount)
+WHERE id = sqlc.arg(id)
+RETURNING *;
+
+-- name: UpdateAccountStatus :one
+UPDATE accounts
+SET status = $2
+WHERE id = $1
+RETURNING *;
+
 -- name: DeleteAccount :exec
 DELETE FROM accounts
 WHERE id=$1;
@@ -1,8 +1,10 @@
 -- name: CreateEntry :one
 INSERT INTO entries (
     account_id,
-    amount
-) VALUES ($1, $2)
+    amount,
+    transfer_id,
+    journal_id
+) VALUES ($1, $2, $3, $4)
 RETURNING *;
 
 -- name: GetEntry :one 
@@ -12,5 +14,26 @@ LIMIT 1;
 
 -- name: ListEntries :many
 SELECT * FROM entries
-LIMIT $1
-OFFSET $2;
\ No newline at end of file
+WHERE account_id = $1
+ORDER BY id
+LIMIT $2
+OFFSET $3;
+
+-- name: GetAccountBalanceAt :one
+SELECT (balance - COALESCE((
+    SELECT SUM(amount) FROM entries
+    WHERE account_id = accounts.id AND created_at >= sqlc.arg(at)
+), 0))::bigint AS balance
+FROM accounts
+WHERE id = sqlc.arg(account_id);
+
+-- name: ListStatementEntries :many
+SELECT e.id, e.account_id, e.amount, e.created_at, e.transfer_id,
+    COALESCE(CASE WHEN t.from_account_id = e.account_id THEN t.to_account_id ELSE t.from_account_id END, 0)::bigint AS counterparty_account_id,
+    (sqlc.arg(opening_balance)::bigint + SUM(e.amount) OVER (ORDER BY e.created_at, e.id))::bigint AS running_balance
+FROM entries e
+LEFT JOIN transfers t ON t.id = e.transfer_id
+WHERE e.account_id = sqlc.arg(account_id)
+    AND e.created_at >= sqlc.arg(from_time)
+    AND e.created_at < sqlc.arg(to_time)
+ORDER BY e.created_at, e.id;
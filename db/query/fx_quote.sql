@@ -0,0 +1,24 @@
+-- name: CreateFxQuote :one
+INSERT INTO fx_quotes (
+    id,
+    username,
+    from_currency,
+    to_currency,
+    rate,
+    expires_at
+) VALUES ($1, $2, $3, $4, $5, $6)
+RETURNING *;
+
+-- name: GetFxQuote :one
+SELECT * FROM fx_quotes
+WHERE id = $1
+LIMIT 1;
+
+-- name: UseFxQuote :one
+UPDATE fx_quotes
+SET used_at = now()
+WHERE id = sqlc.arg(id)
+    AND username = sqlc.arg(username)
+    AND used_at IS NULL
+    AND expires_at > now()
+RETURNING *;
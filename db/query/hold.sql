@@ -0,0 +1,40 @@
+-- name: CreateHold :one
+INSERT INTO holds (
+    account_id,
+    to_account_id,
+    amount,
+    expires_at
+) VALUES ($1, $2, $3, $4)
+RETURNING *;
+
+-- name: GetHold :one
+SELECT * FROM holds
+WHERE id = $1
+LIMIT 1;
+
+-- name: GetHoldForUpdate :one
+SELECT * FROM holds
+WHERE id = $1
+LIMIT 1
+FOR NO KEY UPDATE;
+
+-- name: UpdateHoldStatus :one
+UPDATE holds
+SET status = sqlc.arg(status),
+    transfer_id = sqlc.narg(transfer_id),
+    updated_at = now()
+WHERE id = sqlc.arg(id)
+RETURNING *;
+
+-- name: ExpireHolds :many
+UPDATE holds
+SET status = 'expired',
+    updated_at = now()
+WHERE id IN (
+    SELECT id FROM holds
+    WHERE status = 'active' AND expires_at <= sqlc.arg(now)
+    ORDER BY id
+    LIMIT sqlc.arg('limit')
+    FOR NO KEY UPDATE SKIP LOCKED
+)
+RETURNING *;
@@ -0,0 +1,43 @@
+-- name: GetLoginThrottle :one
+SELECT * FROM login_throttles
+WHERE scope = @scope AND subject = @subject LIMIT 1;
+
+-- name: ListActiveLoginLocks :many
+SELECT * FROM login_throttles
+WHERE
+    locked_until > now()
+    AND (
+        (scope = 'user' AND subject = @username)
+        OR (scope = 'ip' AND subject = @client_ip)
+    )
+ORDER BY locked_until DESC;
+
+-- name: IncrementLoginThrottle :one
+INSERT INTO login_throttles (
+    scope,
+    subject,
+    failed_attempts
+) VALUES (
+    @scope, @subject, 1
+)
+ON CONFLICT (scope, subject) DO UPDATE
+SET
+    failed_attempts = CASE
+        WHEN login_throttles.last_failed_at < @reset_before THEN 1
+        ELSE login_throttles.failed_attempts + 1
+    END,
+    last_failed_at = now()
+RETURNING *;
+
+-- name: LockLoginThrottle :one
+UPDATE login_throttles
+SET
+    locked_until = @locked_until
+WHERE
+    scope = @scope
+    AND subject = @subject
+RETURNING *;
+
+-- name: DeleteLoginThrottle :exec
+DELETE FROM login_throttles
+WHERE scope = @scope AND subject = @subject;
@@ -0,0 +1,19 @@
+-- name: CreatePasswordReset :one
+INSERT INTO password_resets (
+    username,
+    email,
+    secret_code
+) VALUES (
+    $1, $2, $3
+) RETURNING *;
+
+-- name: UsePasswordReset :one
+UPDATE password_resets
+SET
+    is_used = TRUE
+WHERE
+    id = @id
+    AND secret_code = @secret_code
+    AND is_used = FALSE
+    AND expired_at > now()
+RETURNING *;
@@ -0,0 +1,72 @@
+-- name: CreateScheduledTransfer :one
+INSERT INTO scheduled_transfers (
+    owner,
+    from_account_id,
+    to_account_id,
+    amount,
+    schedule,
+    next_run_at
+) VALUES ($1, $2, $3, $4, $5, $6)
+RETURNING *;
+
+-- name: GetScheduledTransfer :one
+SELECT * FROM scheduled_transfers
+WHERE id = $1
+LIMIT 1;
+
+-- name: GetScheduledTransferForUpdate :one
+SELECT * FROM scheduled_transfers
+WHERE id = $1
+LIMIT 1
+FOR NO KEY UPDATE;
+
+-- name: ListScheduledTransfers :many
+SELECT * FROM scheduled_transfers
+WHERE owner = $1
+ORDER BY id
+LIMIT $2
+OFFSET $3;
+
+-- name: ListDueScheduledTransfers :many
+SELECT * FROM scheduled_transfers
+WHERE status = 'active'
+    AND next_run_at <= sqlc.arg(now)
+    AND id > sqlc.arg(after_id)
+ORDER BY id
+LIMIT sqlc.arg('limit');
+
+-- name: UpdateScheduledTransfer :one
+UPDATE scheduled_transfers
+SET
+    amount = coalesce(sqlc.narg(amount), amount),
+    schedule = coalesce(sqlc.narg(schedule), schedule),
+    status = coalesce(sqlc.narg(status), status),
+    next_run_at = coalesce(sqlc.narg(next_run_at), next_run_at)
+WHERE id = sqlc.arg(id)
+RETURNING *;
+
+-- name: DeleteScheduledTransfer :exec
+DELETE FROM scheduled_transfers
+WHERE id = $1;
+
+-- name: CreateScheduledTransferRun :one
+INSERT INTO scheduled_transfer_runs (
+    scheduled_transfer_id,
+    run_at,
+    status,
+    transfer_id,
+    error
+) VALUES ($1, $2, $3, $4, $5)
+RETURNING *;
+
+-- name: GetScheduledTransferRun :one
+SELECT * FROM scheduled_transfer_runs
+WHERE id = $1
+LIMIT 1;
+
+-- name: ListScheduledTransferRuns :many
+SELECT * FROM scheduled_transfer_runs
+WHERE scheduled_transfer_id = $1
+ORDER BY run_at DESC
+LIMIT $2
+OFFSET $3;
@@ -6,11 +6,52 @@ INSERT INTO sessions (
     user_agent,
     client_ip,
     is_blocked,
-    expires_at
-) VALUES( $1, $2, $3, $4, $5, $6, $7)
+    expires_at,
+    family_id,
+    previous_session_id
+) VALUES( $1, $2, $3, $4, $5, $6, $7, $8, $9)
 RETURNING *;
 
 -- name: GetSession :one 
 SELECT * FROM sessions 
 WHERE id = $1
 LIMIT 1;
+
+-- name: GetSessionForUpdate :one
+SELECT * FROM sessions
+WHERE id = $1
+LIMIT 1
+FOR NO KEY UPDATE;
+
+-- name: ListSessions :many
+SELECT * FROM sessions
+WHERE username = $1 AND is_blocked = false AND rotated_at IS NULL AND expires_at > now()
+ORDER BY created_at DESC
+LIMIT $2
+OFFSET $3;
+
+-- name: ListUserSessionFamilies :many
+SELECT DISTINCT family_id FROM sessions
+WHERE username = $1 AND expires_at > now();
+
+-- name: BlockSession :one
+UPDATE sessions
+SET is_blocked = true
+WHERE id = $1
+RETURNING *;
+
+-- name: BlockSessionFamily :execrows
+UPDATE sessions
+SET is_blocked = true
+WHERE family_id = $1 AND is_blocked = false;
+
+-- name: BlockUserSessions :execrows
+UPDATE sessions
+SET is_blocked = true
+WHERE username = $1 AND is_blocked = false AND expires_at > now();
+
+-- name: RotateSession :one
+UPDATE sessions
+SET rotated_at = now()
+WHERE id = $1
+RETURNING *;
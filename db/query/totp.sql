@@ -0,0 +1,66 @@
+-- name: CreateUserTotp :one
+INSERT INTO user_totps (
+    username,
+    secret
+) VALUES (
+    $1, $2
+) ON CONFLICT (username) DO UPDATE
+SET
+    secret = EXCLUDED.secret,
+    last_used_step = 0,
+    created_at = now()
+WHERE user_totps.confirmed_at IS NULL
+RETURNING *;
+
+-- name: GetUserTotp :one
+SELECT * FROM user_totps
+WHERE username = $1
+LIMIT 1;
+
+-- name: ConfirmUserTotp :one
+UPDATE user_totps
+SET
+    confirmed_at = now(),
+    last_used_step = @last_used_step
+WHERE
+    username = @username
+    AND confirmed_at IS NULL
+RETURNING *;
+
+-- name: UseTotpStep :one
+UPDATE user_totps
+SET
+    last_used_step = @step
+WHERE
+    username = @username
+    AND confirmed_at IS NOT NULL
+    AND last_used_step < @step
+RETURNING *;
+
+-- name: CreateTotpRecoveryCode :one
+INSERT INTO totp_recovery_codes (
+    username,
+    hashed_code
+) VALUES (
+    $1, $2
+) RETURNING *;
+
+-- name: ListUnusedTotpRecoveryCodes :many
+SELECT * FROM totp_recovery_codes
+WHERE
+    username = $1
+    AND used_at IS NULL
+ORDER BY id;
+
+-- name: UseTotpRecoveryCode :one
+UPDATE totp_recovery_codes
+SET
+    used_at = now()
+WHERE
+    id = $1
+    AND used_at IS NULL
+RETURNING *;
+
+-- name: DeleteTotpRecoveryCodes :exec
+DELETE FROM totp_recovery_codes
+WHERE username = $1;
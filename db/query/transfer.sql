@@ -3,8 +3,10 @@ INSERT INTO transfers
 (
     from_account_id,
     to_account_id,
-    amount
-) VALUES ($1, $2, $3)
+    amount,
+    to_amount,
+    exchange_rate
+) VALUES ($1, $2, $3, $4, $5)
 RETURNING *;
 
 -- name: GetTransfer :one
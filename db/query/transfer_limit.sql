@@ -0,0 +1,28 @@
+-- name: GetTransferLimit :one
+SELECT * FROM transfer_limits
+WHERE role = $1 AND currency = $2
+LIMIT 1;
+
+-- name: GetAccountTransferLimit :one
+SELECT * FROM account_transfer_limits
+WHERE account_id = $1
+LIMIT 1;
+
+-- name: SetAccountTransferLimit :one
+INSERT INTO account_transfer_limits (
+    account_id,
+    per_transfer,
+    daily,
+    monthly
+) VALUES ($1, $2, $3, $4)
+ON CONFLICT (account_id) DO UPDATE
+SET per_transfer = EXCLUDED.per_transfer,
+    daily = EXCLUDED.daily,
+    monthly = EXCLUDED.monthly,
+    updated_at = now()
+RETURNING *;
+
+-- name: SumTransfersFromAccount :one
+SELECT COALESCE(SUM(amount), 0)::bigint FROM transfers
+WHERE from_account_id = sqlc.arg(from_account_id)
+    AND created_at >= sqlc.arg(since);
@@ -14,6 +14,11 @@ SELECT * FROM users
 WHERE username =$1
 LIMIT 1;
 
+-- name: GetUserPasswordChangedAt :one
+SELECT password_changed_at FROM users
+WHERE username = $1
+LIMIT 1;
+
 -- name: UpdateUser :one
 Update users 
 SET 
@@ -22,11 +27,15 @@ SET
     full_name= coalesce(sqlc.narg('full_name'),full_name),
     email= coalesce(sqlc.narg('email'),email),
     is_email_verified = COALESCE(sqlc.narg(is_email_verified), is_email_verified)
-WHERE username = @username
+WHERE username = @username AND role <> 'system'
 RETURNING *;
 
 -- name: UpdateUserRole :one 
 Update users
 SET role = $2
-WHERE username = $1
-RETURNING *;
\ No newline at end of file
+WHERE username = $1 AND role <> 'system'
+RETURNING *;
+-- name: GetUserByEmail :one
+SELECT * FROM users
+WHERE email = $1 AND role <> 'system'
+LIMIT 1;
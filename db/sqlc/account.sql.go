@@ -13,7 +13,7 @@ const addAccountBalance = `-- name: AddAccountBalance :one
 UPDATE accounts
 SET balance= balance + $1
 WHERE id=$2
-RETURNING id, owner, balance, currency, created_at
+RETURNING id, owner, balance, currency, created_at, overdraft_limit, kind, status, held_amount
 `
 
 type AddAccountBalanceParams struct {
@@ -30,6 +30,39 @@ func (q *Queries) AddAccountBalance(ctx context.Context, arg AddAccountBalancePa
 		&i.Balance,
 		&i.Currency,
 		&i.CreatedAt,
+		&i.OverdraftLimit,
+		&i.Kind,
+		&i.Status,
+		&i.HeldAmount,
+	)
+	return i, err
+}
+
+const addAccountHeldAmount = `-- name: AddAccountHeldAmount :one
+UPDATE accounts
+SET held_amount = held_amount + $1
+WHERE id = $2
+RETURNING id, owner, balance, currency, created_at, overdraft_limit, kind, status, held_amount
+`
+
+type AddAccountHeldAmountParams struct {
+	Amount int64 `json:"amount"`
+	ID     int64 `json:"id"`
+}
+
+func (q *Queries) AddAccountHeldAmount(ctx context.Context, arg AddAccountHeldAmountParams) (Account, error) {
+	row := q.db.QueryRow(ctx, addAccountHeldAmount, arg.Amount, arg.ID)
+	var i Account
+	err := row.Scan(
+		&i.ID,
+		&i.Owner,
+		&i.Balance,
+		&i.Currency,
+		&i.CreatedAt,
+		&i.OverdraftLimit,
+		&i.Kind,
+		&i.Status,
+		&i.HeldAmount,
 	)
 	return i, err
 }
@@ -40,7 +73,7 @@ INSERT INTO accounts (
     balance,
     currency
 ) VALUES ($1, $2, $3)
-RETURNING id, owner, balance, currency, created_at
+RETURNING id, owner, balance, currency, created_at, overdraft_limit, kind, status, held_amount
 `
 
 type CreateAccountParams struct {
@@ -58,6 +91,10 @@ func (q *Queries) CreateAccount(ctx context.Context, arg CreateAccountParams) (A
 		&i.Balance,
 		&i.Currency,
 		&i.CreatedAt,
+		&i.OverdraftLimit,
+		&i.Kind,
+		&i.Status,
+		&i.HeldAmount,
 	)
 	return i, err
 }
@@ -73,7 +110,7 @@ func (q *Queries) DeleteAccount(ctx context.Context, id int64) error {
 }
 
 const getAccount = `-- name: GetAccount :one
-SELECT id, owner, balance, currency, created_at FROM accounts
+SELECT id, owner, balance, currency, created_at, overdraft_limit, kind, status, held_amount FROM accounts
 WHERE id = $1 
 LIMIT 1
 `
@@ -87,12 +124,16 @@ func (q *Queries) GetAccount(ctx context.Context, id int64) (Account, error) {
 		&i.Balance,
 		&i.Currency,
 		&i.CreatedAt,
+		&i.OverdraftLimit,
+		&i.Kind,
+		&i.Status,
+		&i.HeldAmount,
 	)
 	return i, err
 }
 
 const getAccountForUpdate = `-- name: GetAccountForUpdate :one
-SELECT id, owner, balance, currency, created_at FROM accounts
+SELECT id, owner, balance, currency, created_at, overdraft_limit, kind, status, held_amount FROM accounts
 WHERE id = $1 
 LIMIT 1
 FOR NO KEY UPDATE
@@ -107,12 +148,121 @@ func (q *Queries) GetAccountForUpdate(ctx context.Context, id int64) (Account, e
 		&i.Balance,
 		&i.Currency,
 		&i.CreatedAt,
+		&i.OverdraftLimit,
+		&i.Kind,
+		&i.Status,
+		&i.HeldAmount,
 	)
 	return i, err
 }
 
+const getBankAccount = `-- name: GetBankAccount :one
+SELECT id, owner, balance, currency, created_at, overdraft_limit, kind, status, held_amount FROM accounts
+WHERE kind = $1 AND currency = $2
+LIMIT 1
+`
+
+type GetBankAccountParams struct {
+	Kind     string `json:"kind"`
+	Currency string `json:"currency"`
+}
+
+func (q *Queries) GetBankAccount(ctx context.Context, arg GetBankAccountParams) (Account, error) {
+	row := q.db.QueryRow(ctx, getBankAccount, arg.Kind, arg.Currency)
+	var i Account
+	err := row.Scan(
+		&i.ID,
+		&i.Owner,
+		&i.Balance,
+		&i.Currency,
+		&i.CreatedAt,
+		&i.OverdraftLimit,
+		&i.Kind,
+		&i.Status,
+		&i.HeldAmount,
+	)
+	return i, err
+}
+
+const listAccountIDs = `-- name: ListAccountIDs :many
+SELECT id FROM accounts
+WHERE kind = 'customer' AND status <> 'closed' AND id > $1
+ORDER BY id
+LIMIT $2
+`
+
+type ListAccountIDsParams struct {
+	AfterID int64 `json:"after_id"`
+	Limit   int32 `json:"limit"`
+}
+
+func (q *Queries) ListAccountIDs(ctx context.Context, arg ListAccountIDsParams) ([]int64, error) {
+	rows, err := q.db.Query(ctx, listAccountIDs, arg.AfterID, arg.Limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []int64{}
+	for rows.Next() {
+		var id int64
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		items = append(items, id)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listAccountLedgerBalances = `-- name: ListAccountLedgerBalances :many
+SELECT a.id, a.balance, COALESCE(SUM(e.amount), 0)::bigint AS entries_balance
+FROM accounts a
+LEFT JOIN entries e ON e.account_id = a.id
+WHERE a.id > $1
+GROUP BY a.id
+ORDER BY a.id
+LIMIT $2
+`
+
+type ListAccountLedgerBalancesParams struct {
+	AfterID int64 `json:"after_id"`
+	Limit   int32 `json:"limit"`
+}
+
+type ListAccountLedgerBalancesRow struct {
+	ID             int64 `json:"id"`
+	Balance        int64 `json:"balance"`
+	EntriesBalance int64 `json:"entries_balance"`
+}
+
+func (q *Queries) ListAccountLedgerBalances(ctx context.Context, arg ListAccountLedgerBalancesParams) ([]ListAccountLedgerBalancesRow, error) {
+	rows, err := q.db.Query(ctx, listAccountLedgerBalances, arg.AfterID, arg.Limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []ListAccountLedgerBalancesRow{}
+	for rows.Next() {
+		var i ListAccountLedgerBalancesRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.Balance,
+			&i.EntriesBalance,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
 const listAccounts = `-- name: ListAccounts :many
-SELECT id, owner, balance, currency, created_at FROM accounts
+SELECT id, owner, balance, currency, created_at, overdraft_limit, kind, status, held_amount FROM accounts
 WHERE owner= $1
 ORDER BY id
 LIMIT $2
@@ -140,6 +290,10 @@ func (q *Queries) ListAccounts(ctx context.Context, arg ListAccountsParams) ([]A
 			&i.Balance,
 			&i.Currency,
 			&i.CreatedAt,
+			&i.OverdraftLimit,
+			&i.Kind,
+			&i.Status,
+			&i.HeldAmount,
 		); err != nil {
 			return nil, err
 		}
@@ -155,7 +309,7 @@ const updateAccount = `-- name: UpdateAccount :one
 UPDATE accounts
 SET balance=$2
 WHERE id=$1
-RETURNING id, owner, balance, currency, created_at
+RETURNING id, owner, balance, currency, created_at, overdraft_limit, kind, status, held_amount
 `
 
 type UpdateAccountParams struct {
@@ -172,6 +326,39 @@ func (q *Queries) UpdateAccount(ctx context.Context, arg UpdateAccountParams) (A
 		&i.Balance,
 		&i.Currency,
 		&i.CreatedAt,
+		&i.OverdraftLimit,
+		&i.Kind,
+		&i.Status,
+		&i.HeldAmount,
+	)
+	return i, err
+}
+
+const updateAccountStatus = `-- name: UpdateAccountStatus :one
+UPDATE accounts
+SET status = $2
+WHERE id = $1
+RETURNING id, owner, balance, currency, created_at, overdraft_limit, kind, status, held_amount
+`
+
+type UpdateAccountStatusParams struct {
+	ID     int64  `json:"id"`
+	Status string `json:"status"`
+}
+
+func (q *Queries) UpdateAccountStatus(ctx context.Context, arg UpdateAccountStatusParams) (Account, error) {
+	row := q.db.QueryRow(ctx, updateAccountStatus, arg.ID, arg.Status)
+	var i Account
+	err := row.Scan(
+		&i.ID,
+		&i.Owner,
+		&i.Balance,
+		&i.Currency,
+		&i.CreatedAt,
+		&i.OverdraftLimit,
+		&i.Kind,
+		&i.Status,
+		&i.HeldAmount,
 	)
 	return i, err
 }
@@ -10,11 +10,16 @@ import (
 )
 
 func createRandomAccount(t *testing.T) Account {
+	return createRandomAccountWithCurrency(t, util.RandomCurrency())
+}
+
+// createRandomAccountWithCurrency creates an account that can be posted to in the same journal as other accounts of the currency
+func createRandomAccountWithCurrency(t *testing.T, currency string) Account {
 	user := createRandomUser(t)
 	args := CreateAccountParams{
 		Owner:    user.Username,
 		Balance:  util.RandomMoney(),
-		Currency: util.RandomCurrency(),
+		Currency: currency,
 	}
 
 	account, err := testStore.CreateAccount(context.Background(), args)
@@ -25,6 +30,8 @@ func createRandomAccount(t *testing.T) Account {
 	require.Equal(t, account.Owner, args.Owner)
 	require.Equal(t, account.Balance, args.Balance)
 	require.Equal(t, account.Currency, args.Currency)
+	require.Equal(t, AccountKindCustomer, account.Kind)
+	require.Equal(t, AccountStatusActive, account.Status)
 
 	require.NotZero(t, account.ID)
 	require.NotZero(t, account.CreatedAt)
@@ -104,3 +111,41 @@ func TestListAccounts(t *testing.T) {
 		require.Equal(t, lastAccount.Owner, acc.Owner)
 	}
 }
+
+func TestListAccountIDs(t *testing.T) {
+	account1 := createRandomAccount(t)
+	account2 := createRandomAccount(t)
+
+	ids, err := testStore.ListAccountIDs(context.Background(), ListAccountIDsParams{
+		AfterID: account1.ID - 1,
+		Limit:   2,
+	})
+	require.NoError(t, err)
+	require.Equal(t, []int64{account1.ID, account2.ID}, ids)
+
+	ids, err = testStore.ListAccountIDs(context.Background(), ListAccountIDsParams{
+		AfterID: account2.ID,
+		Limit:   5,
+	})
+	require.NoError(t, err)
+	for _, id := range ids {
+		require.Greater(t, id, account2.ID)
+	}
+}
+
+func TestListAccountIDsSkipsClosedAccounts(t *testing.T) {
+	closed := createRandomAccount(t)
+
+	_, err := testStore.UpdateAccountStatus(context.Background(), UpdateAccountStatusParams{
+		ID:     closed.ID,
+		Status: AccountStatusClosed,
+	})
+	require.NoError(t, err)
+
+	ids, err := testStore.ListAccountIDs(context.Background(), ListAccountIDsParams{
+		AfterID: closed.ID - 1,
+		Limit:   5,
+	})
+	require.NoError(t, err)
+	require.NotContains(t, ids, closed.ID)
+}
@@ -7,35 +7,70 @@ package db
 
 import (
 	"context"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgtype"
 )
 
 const createEntry = `-- name: CreateEntry :one
 INSERT INTO entries (
     account_id,
-    amount
-) VALUES ($1, $2)
-RETURNING id, account_id, amount, created_at
+    amount,
+    transfer_id,
+    journal_id
+) VALUES ($1, $2, $3, $4)
+RETURNING id, account_id, amount, created_at, transfer_id, journal_id
 `
 
 type CreateEntryParams struct {
-	AccountID int64 `json:"account_id"`
-	Amount    int64 `json:"amount"`
+	AccountID  int64       `json:"account_id"`
+	Amount     int64       `json:"amount"`
+	TransferID pgtype.Int8 `json:"transfer_id"`
+	JournalID  pgtype.Int8 `json:"journal_id"`
 }
 
 func (q *Queries) CreateEntry(ctx context.Context, arg CreateEntryParams) (Entry, error) {
-	row := q.db.QueryRow(ctx, createEntry, arg.AccountID, arg.Amount)
+	row := q.db.QueryRow(ctx, createEntry,
+		arg.AccountID,
+		arg.Amount,
+		arg.TransferID,
+		arg.JournalID,
+	)
 	var i Entry
 	err := row.Scan(
 		&i.ID,
 		&i.AccountID,
 		&i.Amount,
 		&i.CreatedAt,
+		&i.TransferID,
+		&i.JournalID,
 	)
 	return i, err
 }
 
-const getEntry = `-- name: GetEntry :one
-SELECT id, account_id, amount, created_at FROM entries
+const getAccountBalanceAt = `-- name: GetAccountBalanceAt :one
+SELECT (balance - COALESCE((
+    SELECT SUM(amount) FROM entries
+    WHERE account_id = accounts.id AND created_at >= $1
+), 0))::bigint AS balance
+FROM accounts
+WHERE id = $2
+`
+
+type GetAccountBalanceAtParams struct {
+	At        time.Time `json:"at"`
+	AccountID int64     `json:"account_id"`
+}
+
+func (q *Queries) GetAccountBalanceAt(ctx context.Context, arg GetAccountBalanceAtParams) (int64, error) {
+	row := q.db.QueryRow(ctx, getAccountBalanceAt, arg.At, arg.AccountID)
+	var balance int64
+	err := row.Scan(&balance)
+	return balance, err
+}
+
+const getEntry = `-- name: GetEntry :one 
+SELECT id, account_id, amount, created_at, transfer_id, journal_id FROM entries
 WHERE id = $1
 LIMIT 1
 `
@@ -48,23 +83,28 @@ func (q *Queries) GetEntry(ctx context.Context, id int64) (Entry, error) {
 		&i.AccountID,
 		&i.Amount,
 		&i.CreatedAt,
+		&i.TransferID,
+		&i.JournalID,
 	)
 	return i, err
 }
 
 const listEntries = `-- name: ListEntries :many
-SELECT id, account_id, amount, created_at FROM entries
-LIMIT $1
-OFFSET $2
+SELECT id, account_id, amount, created_at, transfer_id, journal_id FROM entries
+WHERE account_id = $1
+ORDER BY id
+LIMIT $2
+OFFSET $3
 `
 
 type ListEntriesParams struct {
-	Limit  int32 `json:"limit"`
-	Offset int32 `json:"offset"`
+	AccountID int64 `json:"account_id"`
+	Limit     int32 `json:"limit"`
+	Offset    int32 `json:"offset"`
 }
 
 func (q *Queries) ListEntries(ctx context.Context, arg ListEntriesParams) ([]Entry, error) {
-	rows, err := q.db.Query(ctx, listEntries, arg.Limit, arg.Offset)
+	rows, err := q.db.Query(ctx, listEntries, arg.AccountID, arg.Limit, arg.Offset)
 	if err != nil {
 		return nil, err
 	}
@@ -77,6 +117,70 @@ func (q *Queries) ListEntries(ctx context.Context, arg ListEntriesParams) ([]Ent
 			&i.AccountID,
 			&i.Amount,
 			&i.CreatedAt,
+			&i.TransferID,
+			&i.JournalID,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listStatementEntries = `-- name: ListStatementEntries :many
+SELECT e.id, e.account_id, e.amount, e.created_at, e.transfer_id,
+    COALESCE(CASE WHEN t.from_account_id = e.account_id THEN t.to_account_id ELSE t.from_account_id END, 0)::bigint AS counterparty_account_id,
+    ($1::bigint + SUM(e.amount) OVER (ORDER BY e.created_at, e.id))::bigint AS running_balance
+FROM entries e
+LEFT JOIN transfers t ON t.id = e.transfer_id
+WHERE e.account_id = $2
+    AND e.created_at >= $3
+    AND e.created_at < $4
+ORDER BY e.created_at, e.id
+`
+
+type ListStatementEntriesParams struct {
+	OpeningBalance int64     `json:"opening_balance"`
+	AccountID      int64     `json:"account_id"`
+	FromTime       time.Time `json:"from_time"`
+	ToTime         time.Time `json:"to_time"`
+}
+
+type ListStatementEntriesRow struct {
+	ID                    int64       `json:"id"`
+	AccountID             int64       `json:"account_id"`
+	Amount                int64       `json:"amount"`
+	CreatedAt             time.Time   `json:"created_at"`
+	TransferID            pgtype.Int8 `json:"transfer_id"`
+	CounterpartyAccountID int64       `json:"counterparty_account_id"`
+	RunningBalance        int64       `json:"running_balance"`
+}
+
+func (q *Queries) ListStatementEntries(ctx context.Context, arg ListStatementEntriesParams) ([]ListStatementEntriesRow, error) {
+	rows, err := q.db.Query(ctx, listStatementEntries,
+		arg.OpeningBalance,
+		arg.AccountID,
+		arg.FromTime,
+		arg.ToTime,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []ListStatementEntriesRow{}
+	for rows.Next() {
+		var i ListStatementEntriesRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.AccountID,
+			&i.Amount,
+			&i.CreatedAt,
+			&i.TransferID,
+			&i.CounterpartyAccountID,
+			&i.RunningBalance,
 		); err != nil {
 			return nil, err
 		}
@@ -56,9 +56,13 @@ func TestListEntries(t *testing.T) {
 		createRandomEntry(t, account)
 	}
 
+	// entries of other accounts must not show up
+	createRandomEntry(t, createRandomAccount(t))
+
 	args := ListEntriesParams{
-		Limit:  5,
-		Offset: 5,
+		AccountID: account.ID,
+		Limit:     5,
+		Offset:    5,
 	}
 
 	entries, err := testStore.ListEntries(context.Background(), args)
@@ -68,5 +72,6 @@ func TestListEntries(t *testing.T) {
 
 	for _, entry := range entries {
 		require.NotEmpty(t, entry)
+		require.Equal(t, account.ID, entry.AccountID)
 	}
 }
@@ -10,14 +10,63 @@ import (
 const (
 	ForeignKeyViolation = "23503"
 	UniqueViolation     = "23505"
+	CheckViolation      = "23514"
 )
 
 var ErrRecordNotFound = pgx.ErrNoRows
 
+var ErrInsufficientFunds = errors.New("insufficient funds")
+
+var ErrSystemAccount = errors.New("system accounts cannot be used directly")
+
+var ErrAccountNotActive = errors.New("account is not active")
+
+var ErrAccountStatusChange = errors.New("account cannot change to that status from its current one")
+
+var ErrAccountNotEmpty = errors.New("account must have a zero balance and no active holds to be closed")
+
+var ErrInvalidFxQuote = errors.New("fx quote is unknown, expired, already used or for other currencies")
+
+var ErrAmountTooSmall = errors.New("amount is too small to convert")
+
+var ErrUnbalancedJournal = errors.New("journal postings do not sum to zero")
+
+// ErrTransferLimitExceeded is wrapped by TransferLimitError, which says how much of the limit is left
+var ErrTransferLimitExceeded = errors.New("transfer limit exceeded")
+
+var ErrHoldNotActive = errors.New("hold has already been captured, released or has expired")
+
+var ErrHoldAmountExceeded = errors.New("capture amount is more than the hold")
+
+var ErrScheduledTransferNotDue = errors.New("scheduled transfer is not due")
+
+var ErrSessionBlocked = errors.New("session has been blocked")
+
+var ErrSessionExpired = errors.New("session has expired")
+
+var ErrSessionMismatch = errors.New("refresh token does not match its session")
+
+// ErrRefreshTokenReused is returned when an already rotated refresh token is presented again.
+// Only a stolen copy of the token can do that, so every session of its family has been blocked
+var ErrRefreshTokenReused = errors.New("refresh token has already been used")
+
 var ErrUniqueViolation = &pgconn.PgError{
 	Code: UniqueViolation,
 }
 
+// IsLedgerRejection reports whether err is the ledger refusing to move money,
+// which the client can act on, rather than a failure of the database
+func IsLedgerRejection(err error) bool {
+	return errors.Is(err, ErrInsufficientFunds) ||
+		errors.Is(err, ErrSystemAccount) ||
+		errors.Is(err, ErrAccountNotActive) ||
+		errors.Is(err, ErrInvalidFxQuote) ||
+		errors.Is(err, ErrAmountTooSmall) ||
+		errors.Is(err, ErrTransferLimitExceeded) ||
+		errors.Is(err, ErrHoldNotActive) ||
+		errors.Is(err, ErrHoldAmountExceeded)
+}
+
 func ErrorCode(err error) string {
 	var pgErr *pgconn.PgError
 	if errors.As(err, &pgErr) {
@@ -3,10 +3,17 @@ package db
 import (
 	"context"
 	"fmt"
+
+	"github.com/jackc/pgx/v5"
 )
 
 func (store *SQLStore) execTx(ctx context.Context, fn func(*Queries) error) error {
-	tx, err := store.connPool.Begin(ctx)
+	return store.execTxWithOptions(ctx, pgx.TxOptions{}, fn)
+}
+
+// execTxWithOptions is execTx with a transaction other than the default read committed, read write one
+func (store *SQLStore) execTxWithOptions(ctx context.Context, txOptions pgx.TxOptions, fn func(*Queries) error) error {
+	tx, err := store.connPool.BeginTx(ctx, txOptions)
 
 	if err != nil {
 		return err
@@ -0,0 +1,78 @@
+package db
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/require"
+	"github.com/tonisco/simple-bank-go/util"
+)
+
+func createRandomFxQuote(t *testing.T, username string, from string, to string, expiresIn time.Duration) FxQuote {
+	args := CreateFxQuoteParams{
+		ID:           uuid.New(),
+		Username:     username,
+		FromCurrency: from,
+		ToCurrency:   to,
+		Rate:         util.RandomInt(50_000_000, 150_000_000),
+		ExpiresAt:    time.Now().Add(expiresIn),
+	}
+
+	quote, err := testStore.CreateFxQuote(context.Background(), args)
+	require.NoError(t, err)
+
+	require.Equal(t, args.ID, quote.ID)
+	require.Equal(t, args.Username, quote.Username)
+	require.Equal(t, args.FromCurrency, quote.FromCurrency)
+	require.Equal(t, args.ToCurrency, quote.ToCurrency)
+	require.Equal(t, args.Rate, quote.Rate)
+	require.WithinDuration(t, args.ExpiresAt, quote.ExpiresAt, time.Second)
+	require.False(t, quote.UsedAt.Valid)
+
+	return quote
+}
+
+func TestGetFxQuote(t *testing.T) {
+	user := createRandomUser(t)
+	quote1 := createRandomFxQuote(t, user.Username, util.USD, util.EUR, time.Minute)
+
+	quote2, err := testStore.GetFxQuote(context.Background(), quote1.ID)
+	require.NoError(t, err)
+	require.Equal(t, quote1.ID, quote2.ID)
+	require.Equal(t, quote1.Rate, quote2.Rate)
+}
+
+func TestUseFxQuote(t *testing.T) {
+	user := createRandomUser(t)
+	quote := createRandomFxQuote(t, user.Username, util.USD, util.EUR, time.Minute)
+
+	// only the user the quote was made for can use it
+	_, err := testStore.UseFxQuote(context.Background(), UseFxQuoteParams{
+		ID:       quote.ID,
+		Username: createRandomUser(t).Username,
+	})
+	require.ErrorIs(t, err, ErrRecordNotFound)
+
+	used, err := testStore.UseFxQuote(context.Background(), UseFxQuoteParams{
+		ID:       quote.ID,
+		Username: user.Username,
+	})
+	require.NoError(t, err)
+	require.True(t, used.UsedAt.Valid)
+
+	// a quote is good for a single transfer
+	_, err = testStore.UseFxQuote(context.Background(), UseFxQuoteParams{
+		ID:       quote.ID,
+		Username: user.Username,
+	})
+	require.ErrorIs(t, err, ErrRecordNotFound)
+
+	expired := createRandomFxQuote(t, user.Username, util.USD, util.EUR, -time.Second)
+	_, err = testStore.UseFxQuote(context.Background(), UseFxQuoteParams{
+		ID:       expired.ID,
+		Username: user.Username,
+	})
+	require.ErrorIs(t, err, ErrRecordNotFound)
+}
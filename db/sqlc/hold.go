@@ -0,0 +1,13 @@
+package db
+
+const (
+	HoldStatusActive   = "active"
+	HoldStatusCaptured = "captured"
+	HoldStatusReleased = "released"
+	HoldStatusExpired  = "expired"
+)
+
+// AvailableBalance is the balance the account can spend: its ledger balance less the funds reserved by active holds
+func (account Account) AvailableBalance() int64 {
+	return account.Balance - account.HeldAmount
+}
@@ -0,0 +1,71 @@
+package db
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+)
+
+var ErrIdempotencyKeyReused = errors.New("idempotency key has already been used for a different request")
+
+// IdempotencyParams identifies a client request that must be applied at most once
+type IdempotencyParams struct {
+	Key         string
+	Username    string
+	RequestHash string
+}
+
+// withIdempotency runs fn and saves result under the idempotency key in the same transaction.
+// If the key was used before, fn is skipped and the saved result is decoded into result instead.
+// A nil arg means the client did not ask for idempotency and fn is simply run.
+func withIdempotency(ctx context.Context, q *Queries, arg *IdempotencyParams, result interface{}, fn func() error) error {
+	if arg == nil {
+		return fn()
+	}
+
+	_, err := q.CreateIdempotencyKey(ctx, CreateIdempotencyKeyParams{
+		Key:         arg.Key,
+		Username:    arg.Username,
+		RequestHash: arg.RequestHash,
+	})
+	if err != nil {
+		if !errors.Is(err, ErrRecordNotFound) {
+			return err
+		}
+
+		// the key already exists, the insert waits for any concurrent request holding it to finish
+		saved, err := q.GetIdempotencyKey(ctx, GetIdempotencyKeyParams{
+			Username: arg.Username,
+			Key:      arg.Key,
+		})
+		if err != nil {
+			return err
+		}
+
+		if saved.RequestHash != arg.RequestHash {
+			return ErrIdempotencyKeyReused
+		}
+
+		if err := json.Unmarshal(saved.Response, result); err != nil {
+			return fmt.Errorf("failed to decode saved response: %w", err)
+		}
+		return nil
+	}
+
+	if err := fn(); err != nil {
+		return err
+	}
+
+	response, err := json.Marshal(result)
+	if err != nil {
+		return fmt.Errorf("failed to encode response: %w", err)
+	}
+
+	_, err = q.UpdateIdempotencyKeyResponse(ctx, UpdateIdempotencyKeyResponseParams{
+		Username: arg.Username,
+		Key:      arg.Key,
+		Response: response,
+	})
+	return err
+}
@@ -0,0 +1,107 @@
+package db
+
+import (
+	"context"
+
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+const (
+	AccountKindCustomer = "customer"
+	AccountKindSystem   = "system"
+	AccountKindFx       = "fx"
+)
+
+const (
+	AccountStatusActive = "active"
+	AccountStatusFrozen = "frozen"
+	AccountStatusClosed = "closed"
+)
+
+const (
+	JournalKindTransfer   = "transfer"
+	JournalKindDeposit    = "deposit"
+	JournalKindWithdrawal = "withdrawal"
+)
+
+// posting is one side of a journal: an amount booked against an account
+type posting struct {
+	accountID int64
+	amount    int64
+}
+
+// postJournal records a journal with one entry per posting and applies each posting to its account balance,
+// in the order given so callers control the lock order. The postings must sum to zero;
+// the database checks the same per currency when the transaction commits
+func postJournal(
+	ctx context.Context,
+	q *Queries,
+	kind string,
+	transferID pgtype.Int8,
+	postings ...posting,
+) (journal Journal, entries []Entry, accounts []Account, err error) {
+	var sum int64
+	for _, p := range postings {
+		sum += p.amount
+	}
+	if sum != 0 {
+		err = ErrUnbalancedJournal
+		return
+	}
+
+	journal, err = q.CreateJournal(ctx, kind)
+	if err != nil {
+		return
+	}
+
+	journalID := pgtype.Int8{Int64: journal.ID, Valid: true}
+
+	entries = make([]Entry, len(postings))
+	accounts = make([]Account, len(postings))
+	for i, p := range postings {
+		entries[i], err = q.CreateEntry(ctx, CreateEntryParams{
+			AccountID:  p.accountID,
+			Amount:     p.amount,
+			TransferID: transferID,
+			JournalID:  journalID,
+		})
+		if err != nil {
+			return
+		}
+
+		accounts[i], err = q.AddAccountBalance(ctx, AddAccountBalanceParams{
+			ID:     p.accountID,
+			Amount: p.amount,
+		})
+		if err != nil {
+			return
+		}
+	}
+
+	return
+}
+
+// lockCustomerAccount locks an active account that money is paid in to or out of
+// and looks up the system account of its currency that takes the other side of the journal
+func lockCustomerAccount(ctx context.Context, q *Queries, accountID int64) (account Account, system Account, err error) {
+	account, err = q.GetAccountForUpdate(ctx, accountID)
+	if err != nil {
+		return
+	}
+
+	if account.Kind != AccountKindCustomer {
+		err = ErrSystemAccount
+		return
+	}
+
+	if account.Status != AccountStatusActive {
+		err = ErrAccountNotActive
+		return
+	}
+
+	system, err = q.GetBankAccount(ctx, GetBankAccountParams{
+		Kind:     AccountKindSystem,
+		Currency: account.Currency,
+	})
+	return
+}
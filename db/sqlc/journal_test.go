@@ -0,0 +1,129 @@
+package db
+
+import (
+	"context"
+	"testing"
+
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/stretchr/testify/require"
+	"github.com/tonisco/simple-bank-go/util"
+)
+
+// requireBalancedJournal checks the ledger invariant: every journal's entries sum to zero
+func requireBalancedJournal(t *testing.T, journal Journal) {
+	entries, err := testStore.ListJournalEntries(context.Background(), journal.ID)
+	require.NoError(t, err)
+	require.GreaterOrEqual(t, len(entries), 2)
+
+	var sum int64
+	for _, entry := range entries {
+		require.Equal(t, journal.ID, entry.JournalID.Int64)
+		sum += entry.Amount
+	}
+	require.Zero(t, sum)
+}
+
+func requireBankAccount(t *testing.T, kind string, currency string) Account {
+	account, err := testStore.GetBankAccount(context.Background(), GetBankAccountParams{
+		Kind:     kind,
+		Currency: currency,
+	})
+	require.NoError(t, err)
+	require.Equal(t, kind, account.Kind)
+	require.Equal(t, currency, account.Currency)
+
+	return account
+}
+
+func TestGetBankAccount(t *testing.T) {
+	for _, kind := range []string{AccountKindSystem, AccountKindFx} {
+		for _, currency := range []string{util.USD, util.EUR, util.CAD} {
+			requireBankAccount(t, kind, currency)
+		}
+	}
+}
+
+func TestJournalsBalance(t *testing.T) {
+	account1 := fundAccount(t, createRandomAccount(t), 100)
+	account2 := createRandomAccountWithCurrency(t, account1.Currency)
+
+	system := requireBankAccount(t, AccountKindSystem, account1.Currency)
+
+	deposit, err := testStore.DepositTx(context.Background(), DepositTxParams{
+		AccountID: account1.ID,
+		Amount:    50,
+	})
+	require.NoError(t, err)
+	require.Equal(t, JournalKindDeposit, deposit.Journal.Kind)
+	requireBalancedJournal(t, deposit.Journal)
+
+	withdrawal, err := testStore.WithdrawTx(context.Background(), WithdrawTxParams{
+		AccountID: account1.ID,
+		Amount:    20,
+	})
+	require.NoError(t, err)
+	require.Equal(t, JournalKindWithdrawal, withdrawal.Journal.Kind)
+	requireBalancedJournal(t, withdrawal.Journal)
+
+	transfer, err := testStore.TransferTx(context.Background(), TransferTxParams{
+		FromAccountID: account1.ID,
+		ToAccountID:   account2.ID,
+		Amount:        10,
+	})
+	require.NoError(t, err)
+	require.Equal(t, JournalKindTransfer, transfer.Journal.Kind)
+	require.Equal(t, transfer.Journal.ID, transfer.FromEntry.JournalID.Int64)
+	requireBalancedJournal(t, transfer.Journal)
+
+	// money paid in and out is offset on the bank's system account
+	updatedSystem := requireBankAccount(t, AccountKindSystem, account1.Currency)
+	require.Equal(t, system.Balance-50+20, updatedSystem.Balance)
+}
+
+func TestSystemAccountCannotTransfer(t *testing.T) {
+	account := createRandomAccount(t)
+
+	system := requireBankAccount(t, AccountKindSystem, account.Currency)
+
+	_, err := testStore.TransferTx(context.Background(), TransferTxParams{
+		FromAccountID: system.ID,
+		ToAccountID:   account.ID,
+		Amount:        1,
+	})
+	require.ErrorIs(t, err, ErrSystemAccount)
+
+	_, err = testStore.DepositTx(context.Background(), DepositTxParams{
+		AccountID: system.ID,
+		Amount:    1,
+	})
+	require.ErrorIs(t, err, ErrSystemAccount)
+}
+
+func TestUnbalancedJournalRejected(t *testing.T) {
+	account := createRandomAccount(t)
+
+	err := testStore.(*SQLStore).execTx(context.Background(), func(q *Queries) error {
+		_, _, _, err := postJournal(context.Background(), q, JournalKindDeposit, pgtype.Int8{},
+			posting{accountID: account.ID, amount: 10},
+		)
+		return err
+	})
+	require.ErrorIs(t, err, ErrUnbalancedJournal)
+
+	// bypassing postJournal still fails when the transaction commits
+	err = testStore.(*SQLStore).execTx(context.Background(), func(q *Queries) error {
+		journal, err := q.CreateJournal(context.Background(), JournalKindDeposit)
+		if err != nil {
+			return err
+		}
+
+		_, err = q.CreateEntry(context.Background(), CreateEntryParams{
+			AccountID: account.ID,
+			Amount:    10,
+			JournalID: pgtype.Int8{Int64: journal.ID, Valid: true},
+		})
+		return err
+	})
+	require.Error(t, err)
+	require.Equal(t, CheckViolation, ErrorCode(err))
+}
@@ -0,0 +1,79 @@
+package db
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"github.com/tonisco/simple-bank-go/util"
+)
+
+func TestRecordFailedLoginTx(t *testing.T) {
+	arg := RecordFailedLoginTxParams{
+		Username:           util.RandomOwner(),
+		ClientIP:           util.RandomString(12),
+		MaxUserAttempts:    3,
+		MaxIPAttempts:      10,
+		LockoutDuration:    time.Minute,
+		MaxLockoutDuration: time.Hour,
+		AttemptWindow:      time.Hour,
+	}
+
+	for i := int32(1); i < arg.MaxUserAttempts; i++ {
+		result, err := testStore.RecordFailedLoginTx(context.Background(), arg)
+		require.NoError(t, err)
+		require.False(t, result.UserLocked)
+		require.Equal(t, i, result.UserThrottle.FailedAttempts)
+		require.Equal(t, i, result.IPThrottle.FailedAttempts)
+		require.False(t, result.UserThrottle.LockedUntil.Valid)
+	}
+
+	result, err := testStore.RecordFailedLoginTx(context.Background(), arg)
+	require.NoError(t, err)
+	require.True(t, result.UserLocked)
+	require.WithinDuration(t, time.Now().Add(time.Minute), result.UserThrottle.LockedUntil.Time, time.Second)
+	require.False(t, result.IPThrottle.LockedUntil.Valid)
+
+	locks, err := testStore.ListActiveLoginLocks(context.Background(), ListActiveLoginLocksParams{
+		Username: arg.Username,
+		ClientIp: arg.ClientIP,
+	})
+	require.NoError(t, err)
+	require.Len(t, locks, 1)
+	require.Equal(t, LoginThrottleScopeUser, locks[0].Scope)
+
+	// every further failure doubles the lockout
+	result, err = testStore.RecordFailedLoginTx(context.Background(), arg)
+	require.NoError(t, err)
+	require.True(t, result.UserLocked)
+	require.WithinDuration(t, time.Now().Add(2*time.Minute), result.UserThrottle.LockedUntil.Time, time.Second)
+
+	err = testStore.DeleteLoginThrottle(context.Background(), DeleteLoginThrottleParams{
+		Scope:   LoginThrottleScopeUser,
+		Subject: arg.Username,
+	})
+	require.NoError(t, err)
+
+	_, err = testStore.GetLoginThrottle(context.Background(), GetLoginThrottleParams{
+		Scope:   LoginThrottleScopeUser,
+		Subject: arg.Username,
+	})
+	require.ErrorIs(t, err, ErrRecordNotFound)
+
+	// the client ip keeps its failed attempts
+	ipThrottle, err := testStore.GetLoginThrottle(context.Background(), GetLoginThrottleParams{
+		Scope:   LoginThrottleScopeIP,
+		Subject: arg.ClientIP,
+	})
+	require.NoError(t, err)
+	require.Equal(t, arg.MaxUserAttempts+1, ipThrottle.FailedAttempts)
+}
+
+func TestLoginLockoutDuration(t *testing.T) {
+	require.Equal(t, time.Minute, LoginLockoutDuration(0, time.Minute, time.Hour))
+	require.Equal(t, 2*time.Minute, LoginLockoutDuration(1, time.Minute, time.Hour))
+	require.Equal(t, 32*time.Minute, LoginLockoutDuration(5, time.Minute, time.Hour))
+	require.Equal(t, time.Hour, LoginLockoutDuration(6, time.Minute, time.Hour))
+	require.Equal(t, time.Hour, LoginLockoutDuration(1000, time.Minute, time.Hour))
+}
@@ -8,14 +8,33 @@ import (
 	"time"
 
 	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgtype"
 )
 
 type Account struct {
-	ID        int64     `json:"id"`
-	Owner     string    `json:"owner"`
+	ID    int64  `json:"id"`
+	Owner string `json:"owner"`
+	// in the minor unit of the currency, e.g. cents for USD, yen for JPY, fils for KWD
 	Balance   int64     `json:"balance"`
 	Currency  string    `json:"currency"`
 	CreatedAt time.Time `json:"created_at"`
+	// how far below zero the balance may go
+	OverdraftLimit int64 `json:"overdraft_limit"`
+	// customer accounts belong to users, system and fx accounts are the bank's own books
+	Kind string `json:"kind"`
+	// frozen and closed accounts cannot send or receive money, closed accounts are kept for their history
+	Status string `json:"status"`
+	// total of the active holds on the account, the available balance is balance minus held_amount
+	HeldAmount int64 `json:"held_amount"`
+}
+
+// overrides of the role limits for a single account, null keeps the role limit
+type AccountTransferLimit struct {
+	AccountID   int64       `json:"account_id"`
+	PerTransfer pgtype.Int8 `json:"per_transfer"`
+	Daily       pgtype.Int8 `json:"daily"`
+	Monthly     pgtype.Int8 `json:"monthly"`
+	UpdatedAt   time.Time   `json:"updated_at"`
 }
 
 type Entry struct {
@@ -24,6 +43,101 @@ type Entry struct {
 	// can be negative or positive
 	Amount    int64     `json:"amount"`
 	CreatedAt time.Time `json:"created_at"`
+	// set when the entry is one side of a transfer
+	TransferID pgtype.Int8 `json:"transfer_id"`
+	// the balanced journal this entry is a posting of, null for entries made before journals existed
+	JournalID pgtype.Int8 `json:"journal_id"`
+}
+
+type FxQuote struct {
+	ID           uuid.UUID `json:"id"`
+	Username     string    `json:"username"`
+	FromCurrency string    `json:"from_currency"`
+	ToCurrency   string    `json:"to_currency"`
+	// units of to_currency bought by one unit of from_currency, scaled by 10^8
+	Rate      int64     `json:"rate"`
+	ExpiresAt time.Time `json:"expires_at"`
+	// a quote can be used for a single transfer
+	UsedAt    pgtype.Timestamptz `json:"used_at"`
+	CreatedAt time.Time          `json:"created_at"`
+}
+
+type Hold struct {
+	ID int64 `json:"id"`
+	// the account the funds are reserved on
+	AccountID int64 `json:"account_id"`
+	// the account the funds are paid to when the hold is captured
+	ToAccountID int64  `json:"to_account_id"`
+	Amount      int64  `json:"amount"`
+	Status      string `json:"status"`
+	// the transfer made when the hold was captured
+	TransferID pgtype.Int8 `json:"transfer_id"`
+	ExpiresAt  time.Time   `json:"expires_at"`
+	CreatedAt  time.Time   `json:"created_at"`
+	UpdatedAt  time.Time   `json:"updated_at"`
+}
+
+type IdempotencyKey struct {
+	Key         string `json:"key"`
+	Username    string `json:"username"`
+	RequestHash string `json:"request_hash"`
+	// result returned to the first request, replayed on retries
+	Response  []byte    `json:"response"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+type Journal struct {
+	ID int64 `json:"id"`
+	// transfer, deposit or withdrawal
+	Kind      string    `json:"kind"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+type LoginThrottle struct {
+	// user or ip
+	Scope string `json:"scope"`
+	// username or client ip the failed logins came from, usernames are tracked even when no such user exists
+	Subject        string `json:"subject"`
+	FailedAttempts int32  `json:"failed_attempts"`
+	// logins for the subject are refused until then
+	LockedUntil  pgtype.Timestamptz `json:"locked_until"`
+	LastFailedAt time.Time          `json:"last_failed_at"`
+}
+
+type PasswordReset struct {
+	ID         int64     `json:"id"`
+	Username   string    `json:"username"`
+	Email      string    `json:"email"`
+	SecretCode string    `json:"secret_code"`
+	IsUsed     bool      `json:"is_used"`
+	CreatedAt  time.Time `json:"created_at"`
+	ExpiredAt  time.Time `json:"expired_at"`
+}
+
+type ScheduledTransfer struct {
+	ID            int64  `json:"id"`
+	Owner         string `json:"owner"`
+	FromAccountID int64  `json:"from_account_id"`
+	ToAccountID   int64  `json:"to_account_id"`
+	Amount        int64  `json:"amount"`
+	// cron expression or @every interval in UTC, empty for a one-off transfer
+	Schedule string `json:"schedule"`
+	Status   string `json:"status"`
+	// when the transfer is next due, kept after completion as the time of the last run
+	NextRunAt time.Time `json:"next_run_at"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+type ScheduledTransferRun struct {
+	ID                  int64     `json:"id"`
+	ScheduledTransferID int64     `json:"scheduled_transfer_id"`
+	RunAt               time.Time `json:"run_at"`
+	Status              string    `json:"status"`
+	// the transfer made by a successful run
+	TransferID pgtype.Int8 `json:"transfer_id"`
+	// why a failed run did not move the money
+	Error     string    `json:"error"`
+	CreatedAt time.Time `json:"created_at"`
 }
 
 type Session struct {
@@ -35,6 +149,19 @@ type Session struct {
 	IsBlocked    bool      `json:"is_blocked"`
 	ExpiresAt    time.Time `json:"expires_at"`
 	CreatedAt    time.Time `json:"created_at"`
+	// id of the session created at login, shared by every session rotated from it
+	FamilyID          uuid.UUID   `json:"family_id"`
+	PreviousSessionID pgtype.UUID `json:"previous_session_id"`
+	// set once the refresh token has been exchanged, presenting it again blocks the whole family
+	RotatedAt pgtype.Timestamptz `json:"rotated_at"`
+}
+
+type TotpRecoveryCode struct {
+	ID         int64              `json:"id"`
+	Username   string             `json:"username"`
+	HashedCode string             `json:"hashed_code"`
+	UsedAt     pgtype.Timestamptz `json:"used_at"`
+	CreatedAt  time.Time          `json:"created_at"`
 }
 
 type Transfer struct {
@@ -44,6 +171,21 @@ type Transfer struct {
 	// must be positive
 	Amount    int64     `json:"amount"`
 	CreatedAt time.Time `json:"created_at"`
+	// amount credited in the to account currency, null when both accounts share a currency
+	ToAmount pgtype.Int8 `json:"to_amount"`
+	// units of the to currency bought by one unit of the from currency, scaled by 10^8
+	ExchangeRate pgtype.Int8 `json:"exchange_rate"`
+}
+
+// limits for the accounts of users with the role, roles without limits for a currency are not limited
+type TransferLimit struct {
+	Role        string `json:"role"`
+	Currency    string `json:"currency"`
+	PerTransfer int64  `json:"per_transfer"`
+	// total of transfers out of an account per UTC calendar day
+	Daily int64 `json:"daily"`
+	// total of transfers out of an account per UTC calendar month
+	Monthly int64 `json:"monthly"`
 }
 
 type User struct {
@@ -57,6 +199,16 @@ type User struct {
 	Role              string    `json:"role"`
 }
 
+type UserTotp struct {
+	Username string `json:"username"`
+	Secret   string `json:"secret"`
+	// two-factor login is only required once the user has confirmed the authenticator with a first code
+	ConfirmedAt pgtype.Timestamptz `json:"confirmed_at"`
+	// time step of the last accepted code, codes from it or earlier steps are refused so they cannot be replayed
+	LastUsedStep int64     `json:"last_used_step"`
+	CreatedAt    time.Time `json:"created_at"`
+}
+
 type VerifyEmail struct {
 	ID         int64     `json:"id"`
 	Username   string    `json:"username"`
@@ -0,0 +1,44 @@
+package db
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"github.com/tonisco/simple-bank-go/util"
+)
+
+func TestResetPasswordTx(t *testing.T) {
+	user := createRandomUser(t)
+	for i := 0; i < 2; i++ {
+		createRandomSession(t, user)
+	}
+
+	passwordReset, err := testStore.CreatePasswordReset(context.Background(), CreatePasswordResetParams{
+		Username:   user.Username,
+		Email:      user.Email,
+		SecretCode: util.RandomString(32),
+	})
+	require.NoError(t, err)
+	require.False(t, passwordReset.IsUsed)
+
+	hashedPassword, err := util.HashPassword(util.RandomString(8))
+	require.NoError(t, err)
+
+	arg := ResetPasswordTxParams{
+		ResetID:        passwordReset.ID,
+		SecretCode:     passwordReset.SecretCode,
+		HashedPassword: hashedPassword,
+	}
+
+	result, err := testStore.ResetPasswordTx(context.Background(), arg)
+	require.NoError(t, err)
+	require.True(t, result.PasswordReset.IsUsed)
+	require.Equal(t, hashedPassword, result.User.HashedPassword)
+	require.True(t, result.User.PasswordChangedAt.After(user.PasswordChangedAt))
+	require.Equal(t, int64(2), result.BlockedSessions)
+
+	// the code can only be used once
+	_, err = testStore.ResetPasswordTx(context.Background(), arg)
+	require.ErrorIs(t, err, ErrRecordNotFound)
+}
@@ -0,0 +1,89 @@
+package db
+
+import (
+	"context"
+	"errors"
+	"fmt"
+)
+
+const reconcilePageSize = 500
+
+// ReconcileAccountsParams contains the input parameters of the ledger reconciliation
+type ReconcileAccountsParams struct {
+	// FreezeMismatched freezes every active customer account whose balance has drifted from its entries.
+	// System and fx accounts, and accounts that are already frozen or closed, are reported but left alone
+	FreezeMismatched bool
+}
+
+// AccountDrift is an account whose balance does not equal the sum of its entries
+type AccountDrift struct {
+	AccountID      int64 `json:"account_id"`
+	Balance        int64 `json:"balance"`
+	EntriesBalance int64 `json:"entries_balance"`
+	// Delta is how much the balance is above the sum of its entries
+	Delta  int64 `json:"delta"`
+	Frozen bool  `json:"frozen"`
+}
+
+// ReconcileAccountsResult is the result of the ledger reconciliation
+type ReconcileAccountsResult struct {
+	AccountsChecked int            `json:"accounts_checked"`
+	Drifts          []AccountDrift `json:"drifts"`
+}
+
+// ReconcileAccounts scans every account and checks that its balance equals the sum of its entries.
+// Each page of accounts is compared in a single statement so the balance and entries come from the same snapshot
+func (store *SQLStore) ReconcileAccounts(ctx context.Context, arg ReconcileAccountsParams) (ReconcileAccountsResult, error) {
+	result := ReconcileAccountsResult{
+		Drifts: []AccountDrift{},
+	}
+
+	var afterID int64
+	for {
+		rows, err := store.ListAccountLedgerBalances(ctx, ListAccountLedgerBalancesParams{
+			AfterID: afterID,
+			Limit:   reconcilePageSize,
+		})
+		if err != nil {
+			return result, fmt.Errorf("failed to list account balances: %w", err)
+		}
+
+		for _, row := range rows {
+			result.AccountsChecked++
+			if row.Balance == row.EntriesBalance {
+				continue
+			}
+
+			drift := AccountDrift{
+				AccountID:      row.ID,
+				Balance:        row.Balance,
+				EntriesBalance: row.EntriesBalance,
+				Delta:          row.Balance - row.EntriesBalance,
+			}
+
+			if arg.FreezeMismatched {
+				_, err = store.ChangeAccountStatusTx(ctx, ChangeAccountStatusTxParams{
+					ID:         row.ID,
+					FromStatus: AccountStatusActive,
+					Status:     AccountStatusFrozen,
+				})
+				switch {
+				case err == nil:
+					drift.Frozen = true
+				case errors.Is(err, ErrSystemAccount) || errors.Is(err, ErrAccountStatusChange):
+				default:
+					return result, fmt.Errorf("failed to freeze account %d: %w", row.ID, err)
+				}
+			}
+
+			result.Drifts = append(result.Drifts, drift)
+		}
+
+		if len(rows) < reconcilePageSize {
+			break
+		}
+		afterID = rows[len(rows)-1].ID
+	}
+
+	return result, nil
+}
@@ -0,0 +1,88 @@
+package db
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestReconcileAccounts(t *testing.T) {
+	// the opening balance of a random account has no entries behind it
+	drifted := createRandomAccount(t)
+
+	balanced, err := testStore.CreateAccount(context.Background(), CreateAccountParams{
+		Owner:    createRandomUser(t).Username,
+		Balance:  0,
+		Currency: drifted.Currency,
+	})
+	require.NoError(t, err)
+
+	_, err = testStore.DepositTx(context.Background(), DepositTxParams{
+		AccountID: balanced.ID,
+		Amount:    10,
+	})
+	require.NoError(t, err)
+
+	result, err := testStore.ReconcileAccounts(context.Background(), ReconcileAccountsParams{})
+	require.NoError(t, err)
+	require.GreaterOrEqual(t, result.AccountsChecked, 2)
+
+	drifts := make(map[int64]AccountDrift)
+	for _, drift := range result.Drifts {
+		drifts[drift.AccountID] = drift
+	}
+
+	require.NotContains(t, drifts, balanced.ID)
+	require.Contains(t, drifts, drifted.ID)
+	require.Equal(t, drifted.Balance, drifts[drifted.ID].Delta)
+	require.False(t, drifts[drifted.ID].Frozen)
+
+	_, err = testStore.ReconcileAccounts(context.Background(), ReconcileAccountsParams{
+		FreezeMismatched: true,
+	})
+	require.NoError(t, err)
+
+	account, err := testStore.GetAccount(context.Background(), drifted.ID)
+	require.NoError(t, err)
+	require.Equal(t, AccountStatusFrozen, account.Status)
+
+	// a frozen account can no longer move money
+	_, err = testStore.TransferTx(context.Background(), TransferTxParams{
+		FromAccountID: drifted.ID,
+		ToAccountID:   balanced.ID,
+		Amount:        1,
+	})
+	require.ErrorIs(t, err, ErrAccountNotActive)
+
+	account, err = testStore.GetAccount(context.Background(), balanced.ID)
+	require.NoError(t, err)
+	require.Equal(t, AccountStatusActive, account.Status)
+}
+
+func TestReconcileAccountsSkipsClosedAccounts(t *testing.T) {
+	closed := createRandomAccount(t)
+
+	_, err := testStore.UpdateAccountStatus(context.Background(), UpdateAccountStatusParams{
+		ID:     closed.ID,
+		Status: AccountStatusClosed,
+	})
+	require.NoError(t, err)
+
+	// a closed account cannot move to frozen, so its drift is only reported
+	result, err := testStore.ReconcileAccounts(context.Background(), ReconcileAccountsParams{
+		FreezeMismatched: true,
+	})
+	require.NoError(t, err)
+
+	drifts := make(map[int64]AccountDrift)
+	for _, drift := range result.Drifts {
+		drifts[drift.AccountID] = drift
+	}
+	require.Contains(t, drifts, closed.ID)
+	require.False(t, drifts[closed.ID].Frozen)
+
+	account, err := testStore.GetAccount(context.Background(), closed.ID)
+	require.NoError(t, err)
+	require.Equal(t, AccountStatusClosed, account.Status)
+}
@@ -0,0 +1,125 @@
+package db
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/stretchr/testify/require"
+	"github.com/tonisco/simple-bank-go/util"
+)
+
+func createRandomScheduledTransfer(t *testing.T, from Account, to Account, schedule string, nextRunAt time.Time) ScheduledTransfer {
+	args := CreateScheduledTransferParams{
+		Owner:         from.Owner,
+		FromAccountID: from.ID,
+		ToAccountID:   to.ID,
+		Amount:        util.RandomInt(1, 100),
+		Schedule:      schedule,
+		NextRunAt:     nextRunAt,
+	}
+
+	scheduled, err := testStore.CreateScheduledTransfer(context.Background(), args)
+	require.NoError(t, err)
+
+	require.NotZero(t, scheduled.ID)
+	require.Equal(t, args.Owner, scheduled.Owner)
+	require.Equal(t, args.FromAccountID, scheduled.FromAccountID)
+	require.Equal(t, args.ToAccountID, scheduled.ToAccountID)
+	require.Equal(t, args.Amount, scheduled.Amount)
+	require.Equal(t, args.Schedule, scheduled.Schedule)
+	require.Equal(t, ScheduledTransferStatusActive, scheduled.Status)
+	require.WithinDuration(t, args.NextRunAt, scheduled.NextRunAt, time.Second)
+	require.NotZero(t, scheduled.CreatedAt)
+
+	return scheduled
+}
+
+func TestGetScheduledTransfer(t *testing.T) {
+	account1 := createRandomAccountWithCurrency(t, util.USD)
+	account2 := createRandomAccountWithCurrency(t, util.USD)
+	scheduled1 := createRandomScheduledTransfer(t, account1, account2, "@monthly", time.Now().Add(time.Hour))
+
+	scheduled2, err := testStore.GetScheduledTransfer(context.Background(), scheduled1.ID)
+	require.NoError(t, err)
+	require.Equal(t, scheduled1, scheduled2)
+}
+
+func TestListScheduledTransfers(t *testing.T) {
+	account1 := createRandomAccountWithCurrency(t, util.USD)
+	account2 := createRandomAccountWithCurrency(t, util.USD)
+	for i := 0; i < 3; i++ {
+		createRandomScheduledTransfer(t, account1, account2, "@weekly", time.Now().Add(time.Hour))
+	}
+
+	scheduled, err := testStore.ListScheduledTransfers(context.Background(), ListScheduledTransfersParams{
+		Owner:  account1.Owner,
+		Limit:  5,
+		Offset: 0,
+	})
+	require.NoError(t, err)
+	require.Len(t, scheduled, 3)
+	for _, s := range scheduled {
+		require.Equal(t, account1.Owner, s.Owner)
+	}
+}
+
+func TestListDueScheduledTransfers(t *testing.T) {
+	account1 := createRandomAccountWithCurrency(t, util.USD)
+	account2 := createRandomAccountWithCurrency(t, util.USD)
+	due := createRandomScheduledTransfer(t, account1, account2, "@daily", time.Now().Add(-time.Minute))
+	notDue := createRandomScheduledTransfer(t, account1, account2, "@daily", time.Now().Add(time.Hour))
+	paused := createRandomScheduledTransfer(t, account1, account2, "@daily", time.Now().Add(-time.Minute))
+
+	_, err := testStore.UpdateScheduledTransfer(context.Background(), UpdateScheduledTransferParams{
+		ID:     paused.ID,
+		Status: pgtype.Text{String: ScheduledTransferStatusPaused, Valid: true},
+	})
+	require.NoError(t, err)
+
+	scheduled, err := testStore.ListDueScheduledTransfers(context.Background(), ListDueScheduledTransfersParams{
+		Now:     time.Now(),
+		AfterID: due.ID - 1,
+		Limit:   100,
+	})
+	require.NoError(t, err)
+
+	ids := make(map[int64]bool, len(scheduled))
+	for _, s := range scheduled {
+		require.Equal(t, ScheduledTransferStatusActive, s.Status)
+		require.False(t, s.NextRunAt.After(time.Now()))
+		ids[s.ID] = true
+	}
+	require.True(t, ids[due.ID])
+	require.False(t, ids[notDue.ID])
+	require.False(t, ids[paused.ID])
+}
+
+func TestUpdateScheduledTransferOnlyAmount(t *testing.T) {
+	account1 := createRandomAccountWithCurrency(t, util.USD)
+	account2 := createRandomAccountWithCurrency(t, util.USD)
+	scheduled := createRandomScheduledTransfer(t, account1, account2, "@daily", time.Now().Add(time.Hour))
+
+	updated, err := testStore.UpdateScheduledTransfer(context.Background(), UpdateScheduledTransferParams{
+		ID:     scheduled.ID,
+		Amount: pgtype.Int8{Int64: scheduled.Amount + 1, Valid: true},
+	})
+	require.NoError(t, err)
+	require.Equal(t, scheduled.Amount+1, updated.Amount)
+	require.Equal(t, scheduled.Schedule, updated.Schedule)
+	require.Equal(t, scheduled.Status, updated.Status)
+	require.Equal(t, scheduled.NextRunAt, updated.NextRunAt)
+}
+
+func TestDeleteScheduledTransfer(t *testing.T) {
+	account1 := createRandomAccountWithCurrency(t, util.USD)
+	account2 := createRandomAccountWithCurrency(t, util.USD)
+	scheduled := createRandomScheduledTransfer(t, account1, account2, "", time.Now().Add(time.Hour))
+
+	err := testStore.DeleteScheduledTransfer(context.Background(), scheduled.ID)
+	require.NoError(t, err)
+
+	_, err = testStore.GetScheduledTransfer(context.Background(), scheduled.ID)
+	require.ErrorIs(t, err, ErrRecordNotFound)
+}
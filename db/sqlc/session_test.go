@@ -0,0 +1,111 @@
+package db
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/require"
+	"github.com/tonisco/simple-bank-go/util"
+)
+
+func createRandomSession(t *testing.T, user User) Session {
+	id := uuid.New()
+	arg := CreateSessionParams{
+		ID:           id,
+		Username:     user.Username,
+		RefreshToken: util.RandomString(32),
+		UserAgent:    util.RandomString(10),
+		ClientIp:     "127.0.0.1",
+		ExpiresAt:    time.Now().Add(time.Hour),
+		FamilyID:     id,
+	}
+
+	session, err := testStore.CreateSession(context.Background(), arg)
+	require.NoError(t, err)
+	require.Equal(t, arg.ID, session.ID)
+	require.Equal(t, arg.Username, session.Username)
+	require.False(t, session.IsBlocked)
+
+	return session
+}
+
+func TestBlockSession(t *testing.T) {
+	session := createRandomSession(t, createRandomUser(t))
+
+	blocked, err := testStore.BlockSession(context.Background(), session.ID)
+	require.NoError(t, err)
+	require.True(t, blocked.IsBlocked)
+
+	sessions, err := testStore.ListSessions(context.Background(), ListSessionsParams{
+		Username: session.Username,
+		Limit:    10,
+	})
+	require.NoError(t, err)
+	require.Empty(t, sessions)
+}
+
+func TestBlockUserSessions(t *testing.T) {
+	user := createRandomUser(t)
+	for i := 0; i < 3; i++ {
+		createRandomSession(t, user)
+	}
+
+	sessions, err := testStore.ListSessions(context.Background(), ListSessionsParams{
+		Username: user.Username,
+		Limit:    10,
+	})
+	require.NoError(t, err)
+	require.Len(t, sessions, 3)
+
+	familyIDs, err := testStore.ListUserSessionFamilies(context.Background(), user.Username)
+	require.NoError(t, err)
+	require.Len(t, familyIDs, 3)
+
+	revoked, err := testStore.BlockUserSessions(context.Background(), user.Username)
+	require.NoError(t, err)
+	require.Equal(t, int64(3), revoked)
+
+	sessions, err = testStore.ListSessions(context.Background(), ListSessionsParams{
+		Username: user.Username,
+		Limit:    10,
+	})
+	require.NoError(t, err)
+	require.Empty(t, sessions)
+}
+
+func TestRotateSessionTx(t *testing.T) {
+	session := createRandomSession(t, createRandomUser(t))
+
+	rotate := func(from Session) (Session, error) {
+		return testStore.RotateSessionTx(context.Background(), RotateSessionTxParams{
+			ID:           from.ID,
+			Username:     from.Username,
+			RefreshToken: from.RefreshToken,
+			NewSession: CreateSessionParams{
+				ID:           uuid.New(),
+				Username:     from.Username,
+				RefreshToken: util.RandomString(32),
+				ExpiresAt:    time.Now().Add(time.Hour),
+			},
+		})
+	}
+
+	rotated, err := rotate(session)
+	require.NoError(t, err)
+	require.Equal(t, session.FamilyID, rotated.FamilyID)
+	require.True(t, rotated.PreviousSessionID.Valid)
+	require.Equal(t, session.ID, uuid.UUID(rotated.PreviousSessionID.Bytes))
+
+	// presenting the rotated token again blocks the whole family
+	_, err = rotate(session)
+	require.ErrorIs(t, err, ErrRefreshTokenReused)
+
+	current, err := testStore.GetSession(context.Background(), rotated.ID)
+	require.NoError(t, err)
+	require.True(t, current.IsBlocked)
+
+	_, err = rotate(current)
+	require.ErrorIs(t, err, ErrSessionBlocked)
+}
@@ -0,0 +1,70 @@
+package db
+
+import (
+	"context"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+)
+
+// AccountStatementParams contains the input parameters of an account statement
+type AccountStatementParams struct {
+	AccountID int64     `json:"account_id"`
+	From      time.Time `json:"from"`
+	To        time.Time `json:"to"`
+}
+
+// AccountStatement lists the entries of an account over [From, To) with the balance after each one
+type AccountStatement struct {
+	AccountID      int64                     `json:"account_id"`
+	From           time.Time                 `json:"from"`
+	To             time.Time                 `json:"to"`
+	OpeningBalance int64                     `json:"opening_balance"`
+	ClosingBalance int64                     `json:"closing_balance"`
+	Entries        []ListStatementEntriesRow `json:"entries"`
+}
+
+// GetAccountStatement builds the statement of an account for a date range.
+// The opening balance is worked back from the current balance, and both queries run in one
+// read only repeatable read transaction, so they see the same entries even if new ones are added meanwhile
+func (store *SQLStore) GetAccountStatement(ctx context.Context, arg AccountStatementParams) (AccountStatement, error) {
+	statement := AccountStatement{
+		AccountID: arg.AccountID,
+		From:      arg.From,
+		To:        arg.To,
+	}
+
+	txOptions := pgx.TxOptions{
+		IsoLevel:   pgx.RepeatableRead,
+		AccessMode: pgx.ReadOnly,
+	}
+
+	err := store.execTxWithOptions(ctx, txOptions, func(q *Queries) error {
+		var err error
+		statement.OpeningBalance, err = q.GetAccountBalanceAt(ctx, GetAccountBalanceAtParams{
+			At:        arg.From,
+			AccountID: arg.AccountID,
+		})
+		if err != nil {
+			return err
+		}
+
+		statement.Entries, err = q.ListStatementEntries(ctx, ListStatementEntriesParams{
+			OpeningBalance: statement.OpeningBalance,
+			AccountID:      arg.AccountID,
+			FromTime:       arg.From,
+			ToTime:         arg.To,
+		})
+		return err
+	})
+	if err != nil {
+		return statement, err
+	}
+
+	statement.ClosingBalance = statement.OpeningBalance
+	if n := len(statement.Entries); n > 0 {
+		statement.ClosingBalance = statement.Entries[n-1].RunningBalance
+	}
+
+	return statement, nil
+}
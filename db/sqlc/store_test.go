@@ -3,18 +3,22 @@ package db
 import (
 	"context"
 	"testing"
+	"time"
 
+	"github.com/jackc/pgx/v5/pgtype"
 	"github.com/stretchr/testify/require"
+	"github.com/tonisco/simple-bank-go/fx"
+	"github.com/tonisco/simple-bank-go/util"
 )
 
 func TestTransferTx(t *testing.T) {
-	account1 := createRandomAccount(t)
-	account2 := createRandomAccount(t)
-
 	// run n concurrent transactions
 	n := 5
 	amount := int64(10)
 
+	account1 := fundAccount(t, createRandomAccount(t), int64(n)*amount)
+	account2 := createRandomAccountWithCurrency(t, account1.Currency)
+
 	errs := make(chan error)
 	results := make(chan TransferTxResult)
 
@@ -58,6 +62,7 @@ func TestTransferTx(t *testing.T) {
 		require.NotEmpty(t, fromEntry)
 		require.Equal(t, fromEntry.AccountID, account1.ID)
 		require.Equal(t, fromEntry.Amount, -amount)
+		require.Equal(t, fromEntry.TransferID.Int64, transfer.ID)
 		require.NotZero(t, fromEntry.ID)
 		require.NotZero(t, fromEntry.CreatedAt)
 
@@ -69,6 +74,7 @@ func TestTransferTx(t *testing.T) {
 		require.NotEmpty(t, toEntry)
 		require.Equal(t, toEntry.AccountID, account2.ID)
 		require.Equal(t, toEntry.Amount, amount)
+		require.Equal(t, toEntry.TransferID.Int64, transfer.ID)
 		require.NotZero(t, toEntry.ID)
 		require.NotZero(t, toEntry.CreatedAt)
 
@@ -110,13 +116,13 @@ func TestTransferTx(t *testing.T) {
 }
 
 func TestTransferTxDeadlock(t *testing.T) {
-	account1 := createRandomAccount(t)
-	account2 := createRandomAccount(t)
-
 	// run n concurrent transactions
 	n := 10
 	amount := int64(10)
 
+	account1 := fundAccount(t, createRandomAccount(t), int64(n)*amount)
+	account2 := fundAccount(t, createRandomAccountWithCurrency(t, account1.Currency), int64(n)*amount)
+
 	errs := make(chan error)
 
 	for i := 0; i < n; i++ {
@@ -157,3 +163,541 @@ func TestTransferTxDeadlock(t *testing.T) {
 	require.Equal(t, account2.Balance, updateAccount2.Balance)
 
 }
+
+func TestTransferTxInsufficientFunds(t *testing.T) {
+	account1 := createRandomAccount(t)
+	account2 := createRandomAccountWithCurrency(t, account1.Currency)
+
+	_, err := testStore.TransferTx(context.Background(), TransferTxParams{
+		FromAccountID: account1.ID,
+		ToAccountID:   account2.ID,
+		Amount:        account1.Balance + 1,
+	})
+	require.ErrorIs(t, err, ErrInsufficientFunds)
+
+	// nothing should have moved
+	updateAccount1, err := testStore.GetAccount(context.Background(), account1.ID)
+	require.NoError(t, err)
+	require.Equal(t, account1.Balance, updateAccount1.Balance)
+
+	updateAccount2, err := testStore.GetAccount(context.Background(), account2.ID)
+	require.NoError(t, err)
+	require.Equal(t, account2.Balance, updateAccount2.Balance)
+}
+
+func TestTransferTxLimits(t *testing.T) {
+	account1 := fundAccount(t, createRandomAccount(t), 1000)
+	account2 := createRandomAccountWithCurrency(t, account1.Currency)
+
+	_, err := testStore.SetAccountTransferLimit(context.Background(), SetAccountTransferLimitParams{
+		AccountID:   account1.ID,
+		PerTransfer: pgtype.Int8{Int64: 100, Valid: true},
+		Daily:       pgtype.Int8{Int64: 150, Valid: true},
+	})
+	require.NoError(t, err)
+
+	transfer := func(amount int64) error {
+		_, err := testStore.TransferTx(context.Background(), TransferTxParams{
+			FromAccountID: account1.ID,
+			ToAccountID:   account2.ID,
+			Amount:        amount,
+		})
+		return err
+	}
+
+	err = transfer(101)
+	require.ErrorIs(t, err, ErrTransferLimitExceeded)
+	var limitErr *TransferLimitError
+	require.ErrorAs(t, err, &limitErr)
+	require.Equal(t, TransferLimitPerTransfer, limitErr.Period)
+	require.Equal(t, int64(100), limitErr.Remaining)
+
+	require.NoError(t, transfer(100))
+
+	err = transfer(60)
+	require.ErrorAs(t, err, &limitErr)
+	require.Equal(t, TransferLimitDaily, limitErr.Period)
+	require.Equal(t, int64(150), limitErr.Limit)
+	require.Equal(t, int64(50), limitErr.Remaining)
+	require.True(t, IsLedgerRejection(err))
+
+	require.NoError(t, transfer(50))
+
+	updatedAccount1, err := testStore.GetAccount(context.Background(), account1.ID)
+	require.NoError(t, err)
+	require.Equal(t, account1.Balance-150, updatedAccount1.Balance)
+}
+
+func TestTransferTxIdempotency(t *testing.T) {
+	account1 := fundAccount(t, createRandomAccount(t), 100)
+	account2 := createRandomAccountWithCurrency(t, account1.Currency)
+
+	arg := TransferTxParams{
+		FromAccountID: account1.ID,
+		ToAccountID:   account2.ID,
+		Amount:        10,
+		Idempotency: &IdempotencyParams{
+			Key:         util.RandomString(16),
+			Username:    account1.Owner,
+			RequestHash: util.RandomString(32),
+		},
+	}
+
+	result1, err := testStore.TransferTx(context.Background(), arg)
+	require.NoError(t, err)
+
+	// a retry replays the original response without moving money again
+	result2, err := testStore.TransferTx(context.Background(), arg)
+	require.NoError(t, err)
+	require.Equal(t, result1.Transfer.ID, result2.Transfer.ID)
+	require.Equal(t, result1.FromEntry.ID, result2.FromEntry.ID)
+	require.Equal(t, result1.ToEntry.ID, result2.ToEntry.ID)
+	require.Equal(t, result1.FromAccount.Balance, result2.FromAccount.Balance)
+
+	updateAccount1, err := testStore.GetAccount(context.Background(), account1.ID)
+	require.NoError(t, err)
+	require.Equal(t, account1.Balance-arg.Amount, updateAccount1.Balance)
+
+	// the same key with a different payload is rejected
+	arg.Amount = 20
+	arg.Idempotency.RequestHash = util.RandomString(32)
+	_, err = testStore.TransferTx(context.Background(), arg)
+	require.ErrorIs(t, err, ErrIdempotencyKeyReused)
+}
+
+func TestCrossCurrencyTransferTx(t *testing.T) {
+	account1 := fundAccount(t, createRandomAccountWithCurrency(t, util.USD), 1000)
+	account2 := createRandomAccountWithCurrency(t, util.EUR)
+	quote := createRandomFxQuote(t, account1.Owner, util.USD, util.EUR, time.Minute)
+
+	fromFx := requireBankAccount(t, AccountKindFx, util.USD)
+	toFx := requireBankAccount(t, AccountKindFx, util.EUR)
+
+	arg := CrossCurrencyTransferTxParams{
+		FromAccountID: account1.ID,
+		ToAccountID:   account2.ID,
+		Amount:        100,
+		QuoteID:       quote.ID,
+		Username:      account1.Owner,
+	}
+	fromMoney, err := util.NewMoney(arg.Amount, util.USD)
+	require.NoError(t, err)
+	eur, _ := util.LookupCurrency(util.EUR)
+	toAmount := fx.Convert(fromMoney, eur, quote.Rate).Amount
+
+	result, err := testStore.CrossCurrencyTransferTx(context.Background(), arg)
+	require.NoError(t, err)
+
+	require.Equal(t, arg.Amount, result.Transfer.Amount)
+	require.Equal(t, toAmount, result.Transfer.ToAmount.Int64)
+	require.Equal(t, quote.Rate, result.Transfer.ExchangeRate.Int64)
+
+	require.Equal(t, -arg.Amount, result.FromEntry.Amount)
+	require.Equal(t, toAmount, result.ToEntry.Amount)
+	require.Equal(t, account1.Balance-arg.Amount, result.FromAccount.Balance)
+	require.Equal(t, account2.Balance+toAmount, result.ToAccount.Balance)
+	requireBalancedJournal(t, result.Journal)
+
+	// the bank's fx accounts take the other side in each currency
+	require.Equal(t, fromFx.Balance+arg.Amount, requireBankAccount(t, AccountKindFx, util.USD).Balance)
+	require.Equal(t, toFx.Balance-toAmount, requireBankAccount(t, AccountKindFx, util.EUR).Balance)
+
+	// the quote cannot be used again
+	_, err = testStore.CrossCurrencyTransferTx(context.Background(), arg)
+	require.ErrorIs(t, err, ErrInvalidFxQuote)
+
+	// nor can a quote for other currencies
+	arg.QuoteID = createRandomFxQuote(t, account1.Owner, util.USD, util.CAD, time.Minute).ID
+	_, err = testStore.CrossCurrencyTransferTx(context.Background(), arg)
+	require.ErrorIs(t, err, ErrInvalidFxQuote)
+}
+
+func TestDepositTx(t *testing.T) {
+	account := createRandomAccount(t)
+	amount := util.RandomMoney()
+
+	result, err := testStore.DepositTx(context.Background(), DepositTxParams{
+		AccountID: account.ID,
+		Amount:    amount,
+	})
+	require.NoError(t, err)
+
+	require.Equal(t, account.ID, result.Entry.AccountID)
+	require.Equal(t, amount, result.Entry.Amount)
+	require.NotZero(t, result.Entry.ID)
+
+	_, err = testStore.GetEntry(context.Background(), result.Entry.ID)
+	require.NoError(t, err)
+
+	require.Equal(t, account.ID, result.Account.ID)
+	require.Equal(t, account.Balance+amount, result.Account.Balance)
+}
+
+func TestWithdrawTx(t *testing.T) {
+	account := createRandomAccount(t)
+	amount := account.Balance
+
+	result, err := testStore.WithdrawTx(context.Background(), WithdrawTxParams{
+		AccountID: account.ID,
+		Amount:    amount,
+	})
+	require.NoError(t, err)
+
+	require.Equal(t, account.ID, result.Entry.AccountID)
+	require.Equal(t, -amount, result.Entry.Amount)
+	require.Zero(t, result.Account.Balance)
+
+	// the balance is now empty so any further withdrawal must fail
+	_, err = testStore.WithdrawTx(context.Background(), WithdrawTxParams{
+		AccountID: account.ID,
+		Amount:    1,
+	})
+	require.ErrorIs(t, err, ErrInsufficientFunds)
+
+	updatedAccount, err := testStore.GetAccount(context.Background(), account.ID)
+	require.NoError(t, err)
+	require.Zero(t, updatedAccount.Balance)
+}
+
+func TestGetAccountStatement(t *testing.T) {
+	account := createRandomAccount(t)
+	from := time.Now()
+
+	amounts := []int64{10, -4, 7}
+	for _, amount := range amounts {
+		var err error
+		if amount > 0 {
+			_, err = testStore.DepositTx(context.Background(), DepositTxParams{
+				AccountID: account.ID,
+				Amount:    amount,
+			})
+		} else {
+			_, err = testStore.WithdrawTx(context.Background(), WithdrawTxParams{
+				AccountID: account.ID,
+				Amount:    -amount,
+			})
+		}
+		require.NoError(t, err)
+	}
+
+	statement, err := testStore.GetAccountStatement(context.Background(), AccountStatementParams{
+		AccountID: account.ID,
+		From:      from,
+		To:        time.Now().Add(time.Minute),
+	})
+	require.NoError(t, err)
+
+	require.Equal(t, account.Balance, statement.OpeningBalance)
+	require.Len(t, statement.Entries, len(amounts))
+
+	balance := statement.OpeningBalance
+	for i, entry := range statement.Entries {
+		balance += amounts[i]
+		require.Equal(t, amounts[i], entry.Amount)
+		require.Equal(t, balance, entry.RunningBalance)
+	}
+	require.Equal(t, balance, statement.ClosingBalance)
+
+	// a range before any activity has no entries and a flat balance
+	statement, err = testStore.GetAccountStatement(context.Background(), AccountStatementParams{
+		AccountID: account.ID,
+		From:      from.Add(-time.Hour),
+		To:        from.Add(-time.Minute),
+	})
+	require.NoError(t, err)
+	require.Empty(t, statement.Entries)
+	require.Equal(t, account.Balance, statement.OpeningBalance)
+	require.Equal(t, account.Balance, statement.ClosingBalance)
+}
+
+// fundAccount tops up the account so that transfers from it cannot overdraw it
+func fundAccount(t *testing.T, account Account, amount int64) Account {
+	account, err := testStore.AddAccountBalance(context.Background(), AddAccountBalanceParams{
+		ID:     account.ID,
+		Amount: amount,
+	})
+	require.NoError(t, err)
+
+	return account
+}
+
+func TestExecuteScheduledTransferTx(t *testing.T) {
+	account1 := fundAccount(t, createRandomAccountWithCurrency(t, util.USD), 1000)
+	account2 := createRandomAccountWithCurrency(t, util.USD)
+	runAt := time.Now().Add(-time.Minute).Truncate(time.Microsecond)
+	scheduled := createRandomScheduledTransfer(t, account1, account2, "@daily", runAt)
+
+	// only the occurrence the execution was enqueued for can run
+	_, err := testStore.ExecuteScheduledTransferTx(context.Background(), ExecuteScheduledTransferTxParams{
+		ID:    scheduled.ID,
+		RunAt: runAt.Add(-time.Hour),
+	})
+	require.ErrorIs(t, err, ErrScheduledTransferNotDue)
+
+	arg := ExecuteScheduledTransferTxParams{
+		ID:    scheduled.ID,
+		RunAt: scheduled.NextRunAt,
+	}
+	result, err := testStore.ExecuteScheduledTransferTx(context.Background(), arg)
+	require.NoError(t, err)
+
+	require.Equal(t, ScheduledTransferRunSucceeded, result.Run.Status)
+	require.Equal(t, scheduled.ID, result.Run.ScheduledTransferID)
+	require.True(t, result.Run.RunAt.Equal(scheduled.NextRunAt))
+	require.True(t, result.Run.TransferID.Valid)
+	require.Equal(t, result.Transfer.Transfer.ID, result.Run.TransferID.Int64)
+	require.Equal(t, scheduled.Amount, result.Transfer.Transfer.Amount)
+	require.Equal(t, account1.Balance-scheduled.Amount, result.Transfer.FromAccount.Balance)
+	require.Equal(t, account2.Balance+scheduled.Amount, result.Transfer.ToAccount.Balance)
+	requireBalancedJournal(t, result.Transfer.Journal)
+
+	// the schedule moves on to its next occurrence, so executing the same run again does nothing
+	require.Equal(t, ScheduledTransferStatusActive, result.ScheduledTransfer.Status)
+	require.True(t, result.ScheduledTransfer.NextRunAt.After(time.Now()))
+
+	_, err = testStore.ExecuteScheduledTransferTx(context.Background(), arg)
+	require.ErrorIs(t, err, ErrScheduledTransferNotDue)
+}
+
+func TestExecuteScheduledTransferTxFailedRun(t *testing.T) {
+	account1 := createRandomAccountWithCurrency(t, util.USD)
+	account2 := createRandomAccountWithCurrency(t, util.USD)
+	scheduled := createRandomScheduledTransfer(t, account1, account2, "", time.Now().Add(-time.Minute))
+
+	_, err := testStore.UpdateScheduledTransfer(context.Background(), UpdateScheduledTransferParams{
+		ID:     scheduled.ID,
+		Amount: pgtype.Int8{Int64: account1.Balance + 1, Valid: true},
+	})
+	require.NoError(t, err)
+
+	result, err := testStore.ExecuteScheduledTransferTx(context.Background(), ExecuteScheduledTransferTxParams{
+		ID:    scheduled.ID,
+		RunAt: scheduled.NextRunAt,
+	})
+	require.NoError(t, err)
+
+	require.Equal(t, ScheduledTransferRunFailed, result.Run.Status)
+	require.Equal(t, ErrInsufficientFunds.Error(), result.Run.Error)
+	require.False(t, result.Run.TransferID.Valid)
+	require.Zero(t, result.Transfer.Transfer.ID)
+
+	// a one-off transfer is done after its only run, whatever the outcome
+	require.Equal(t, ScheduledTransferStatusCompleted, result.ScheduledTransfer.Status)
+
+	unchanged, err := testStore.GetAccount(context.Background(), account1.ID)
+	require.NoError(t, err)
+	require.Equal(t, account1.Balance, unchanged.Balance)
+
+	runs, err := testStore.ListScheduledTransferRuns(context.Background(), ListScheduledTransferRunsParams{
+		ScheduledTransferID: scheduled.ID,
+		Limit:               5,
+	})
+	require.NoError(t, err)
+	require.Len(t, runs, 1)
+	require.Equal(t, result.Run, runs[0])
+}
+
+func placeHold(t *testing.T, account Account, toAccount Account, amount int64, expiresAt time.Time) PlaceHoldTxResult {
+	result, err := testStore.PlaceHoldTx(context.Background(), PlaceHoldTxParams{
+		AccountID:   account.ID,
+		ToAccountID: toAccount.ID,
+		Amount:      amount,
+		ExpiresAt:   expiresAt,
+	})
+	require.NoError(t, err)
+
+	require.Equal(t, HoldStatusActive, result.Hold.Status)
+	require.Equal(t, account.ID, result.Hold.AccountID)
+	require.Equal(t, toAccount.ID, result.Hold.ToAccountID)
+	require.Equal(t, amount, result.Hold.Amount)
+	require.WithinDuration(t, expiresAt, result.Hold.ExpiresAt, time.Second)
+
+	return result
+}
+
+func TestPlaceAndCaptureHoldTx(t *testing.T) {
+	account1 := fundAccount(t, createRandomAccount(t), 100)
+	account2 := createRandomAccountWithCurrency(t, account1.Currency)
+
+	placed := placeHold(t, account1, account2, 80, time.Now().Add(time.Hour))
+	require.Equal(t, account1.Balance, placed.Account.Balance)
+	require.Equal(t, int64(80), placed.Account.HeldAmount)
+	require.Equal(t, account1.Balance-80, placed.Account.AvailableBalance())
+
+	// the held funds cannot be spent elsewhere
+	_, err := testStore.TransferTx(context.Background(), TransferTxParams{
+		FromAccountID: account1.ID,
+		ToAccountID:   account2.ID,
+		Amount:        account1.Balance - 79,
+	})
+	require.ErrorIs(t, err, ErrInsufficientFunds)
+
+	_, err = testStore.CaptureHoldTx(context.Background(), CaptureHoldTxParams{ID: placed.Hold.ID, Amount: 81})
+	require.ErrorIs(t, err, ErrHoldAmountExceeded)
+
+	captured, err := testStore.CaptureHoldTx(context.Background(), CaptureHoldTxParams{ID: placed.Hold.ID, Amount: 50})
+	require.NoError(t, err)
+	require.Equal(t, HoldStatusCaptured, captured.Hold.Status)
+	require.True(t, captured.Hold.TransferID.Valid)
+	require.Equal(t, captured.Transfer.Transfer.ID, captured.Hold.TransferID.Int64)
+	require.Equal(t, int64(50), captured.Transfer.Transfer.Amount)
+
+	// the part of the hold that was not captured is available again
+	require.Equal(t, account1.Balance-50, captured.Transfer.FromAccount.Balance)
+	require.Zero(t, captured.Transfer.FromAccount.HeldAmount)
+	require.Equal(t, account2.Balance+50, captured.Transfer.ToAccount.Balance)
+
+	_, err = testStore.CaptureHoldTx(context.Background(), CaptureHoldTxParams{ID: placed.Hold.ID, Amount: 10})
+	require.ErrorIs(t, err, ErrHoldNotActive)
+}
+
+func TestPlaceHoldTxInsufficientFunds(t *testing.T) {
+	account1 := createRandomAccount(t)
+	account2 := createRandomAccountWithCurrency(t, account1.Currency)
+
+	_, err := testStore.PlaceHoldTx(context.Background(), PlaceHoldTxParams{
+		AccountID:   account1.ID,
+		ToAccountID: account2.ID,
+		Amount:      account1.Balance + 1,
+		ExpiresAt:   time.Now().Add(time.Hour),
+	})
+	require.ErrorIs(t, err, ErrInsufficientFunds)
+}
+
+func TestPlaceHoldTxLimits(t *testing.T) {
+	account1 := fundAccount(t, createRandomAccount(t), 1000)
+	account2 := createRandomAccountWithCurrency(t, account1.Currency)
+
+	_, err := testStore.SetAccountTransferLimit(context.Background(), SetAccountTransferLimitParams{
+		AccountID:   account1.ID,
+		PerTransfer: pgtype.Int8{Int64: 100, Valid: true},
+		Daily:       pgtype.Int8{Int64: 150, Valid: true},
+	})
+	require.NoError(t, err)
+
+	placeHold(t, account1, account2, 100, time.Now().Add(time.Hour))
+
+	// the second hold fits the per transfer limit, but both together would go over the daily one
+	_, err = testStore.PlaceHoldTx(context.Background(), PlaceHoldTxParams{
+		AccountID:   account1.ID,
+		ToAccountID: account2.ID,
+		Amount:      60,
+		ExpiresAt:   time.Now().Add(time.Hour),
+	})
+	var limitErr *TransferLimitError
+	require.ErrorAs(t, err, &limitErr)
+	require.Equal(t, TransferLimitDaily, limitErr.Period)
+	require.Equal(t, int64(50), limitErr.Remaining)
+
+	placeHold(t, account1, account2, 50, time.Now().Add(time.Hour))
+}
+
+func TestReleaseHoldTx(t *testing.T) {
+	account1 := fundAccount(t, createRandomAccount(t), 100)
+	account2 := createRandomAccountWithCurrency(t, account1.Currency)
+
+	placed := placeHold(t, account1, account2, 100, time.Now().Add(time.Hour))
+
+	released, err := testStore.ReleaseHoldTx(context.Background(), placed.Hold.ID)
+	require.NoError(t, err)
+	require.Equal(t, HoldStatusReleased, released.Hold.Status)
+	require.False(t, released.Hold.TransferID.Valid)
+	require.Equal(t, account1.Balance, released.Account.Balance)
+	require.Zero(t, released.Account.HeldAmount)
+
+	_, err = testStore.ReleaseHoldTx(context.Background(), placed.Hold.ID)
+	require.ErrorIs(t, err, ErrHoldNotActive)
+}
+
+func TestExpireHoldsTx(t *testing.T) {
+	account1 := fundAccount(t, createRandomAccount(t), 100)
+	account2 := createRandomAccountWithCurrency(t, account1.Currency)
+
+	expiring := placeHold(t, account1, account2, 30, time.Now().Add(time.Second))
+	active := placeHold(t, account1, account2, 20, time.Now().Add(time.Hour))
+
+	var expired []Hold
+	now := time.Now().Add(2 * time.Second)
+	for {
+		holds, err := testStore.ExpireHoldsTx(context.Background(), ExpireHoldsTxParams{Now: now, Limit: 100})
+		require.NoError(t, err)
+		expired = append(expired, holds...)
+		if len(holds) < 100 {
+			break
+		}
+	}
+
+	var found bool
+	for _, hold := range expired {
+		require.Equal(t, HoldStatusExpired, hold.Status)
+		require.NotEqual(t, active.Hold.ID, hold.ID)
+		if hold.ID == expiring.Hold.ID {
+			found = true
+		}
+	}
+	require.True(t, found)
+
+	account, err := testStore.GetAccount(context.Background(), account1.ID)
+	require.NoError(t, err)
+	require.Equal(t, int64(20), account.HeldAmount)
+
+	_, err = testStore.CaptureHoldTx(context.Background(), CaptureHoldTxParams{ID: expiring.Hold.ID, Amount: 30})
+	require.ErrorIs(t, err, ErrHoldNotActive)
+}
+
+func TestChangeAccountStatusTx(t *testing.T) {
+	user := createRandomUser(t)
+	account, err := testStore.CreateAccount(context.Background(), CreateAccountParams{
+		Owner:    user.Username,
+		Balance:  0,
+		Currency: util.RandomCurrency(),
+	})
+	require.NoError(t, err)
+
+	frozen, err := testStore.ChangeAccountStatusTx(context.Background(), ChangeAccountStatusTxParams{
+		ID:         account.ID,
+		FromStatus: AccountStatusActive,
+		Status:     AccountStatusFrozen,
+	})
+	require.NoError(t, err)
+	require.Equal(t, AccountStatusFrozen, frozen.Status)
+
+	// a frozen account cannot be frozen again
+	_, err = testStore.ChangeAccountStatusTx(context.Background(), ChangeAccountStatusTxParams{
+		ID:         account.ID,
+		FromStatus: AccountStatusActive,
+		Status:     AccountStatusFrozen,
+	})
+	require.ErrorIs(t, err, ErrAccountStatusChange)
+
+	closed, err := testStore.ChangeAccountStatusTx(context.Background(), ChangeAccountStatusTxParams{
+		ID:     account.ID,
+		Status: AccountStatusClosed,
+	})
+	require.NoError(t, err)
+	require.Equal(t, AccountStatusClosed, closed.Status)
+
+	reopened, err := testStore.ChangeAccountStatusTx(context.Background(), ChangeAccountStatusTxParams{
+		ID:         account.ID,
+		FromStatus: AccountStatusClosed,
+		Status:     AccountStatusActive,
+	})
+	require.NoError(t, err)
+	require.Equal(t, AccountStatusActive, reopened.Status)
+
+	// only closed accounts are reopened
+	_, err = testStore.ChangeAccountStatusTx(context.Background(), ChangeAccountStatusTxParams{
+		ID:         account.ID,
+		FromStatus: AccountStatusClosed,
+		Status:     AccountStatusActive,
+	})
+	require.ErrorIs(t, err, ErrAccountStatusChange)
+
+	// accounts holding money cannot be closed
+	funded := fundAccount(t, createRandomAccount(t), 100)
+	_, err = testStore.ChangeAccountStatusTx(context.Background(), ChangeAccountStatusTxParams{
+		ID:     funded.ID,
+		Status: AccountStatusClosed,
+	})
+	require.ErrorIs(t, err, ErrAccountNotEmpty)
+}
@@ -0,0 +1,98 @@
+package db
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"github.com/tonisco/simple-bank-go/util"
+)
+
+func createRandomUserTotp(t *testing.T, user User) UserTotp {
+	userTotp, err := testStore.CreateUserTotp(context.Background(), CreateUserTotpParams{
+		Username: user.Username,
+		Secret:   util.RandomString(32),
+	})
+	require.NoError(t, err)
+	require.Equal(t, user.Username, userTotp.Username)
+	require.False(t, userTotp.ConfirmedAt.Valid)
+
+	return userTotp
+}
+
+func TestConfirmTotpTx(t *testing.T) {
+	user := createRandomUser(t)
+	createRandomUserTotp(t, user)
+
+	// enrolling again before confirming replaces the secret
+	userTotp := createRandomUserTotp(t, user)
+
+	result, err := testStore.ConfirmTotpTx(context.Background(), ConfirmTotpTxParams{
+		Username:            user.Username,
+		Step:                100,
+		HashedRecoveryCodes: []string{util.RandomString(32), util.RandomString(32)},
+	})
+	require.NoError(t, err)
+	require.True(t, result.UserTotp.ConfirmedAt.Valid)
+	require.Equal(t, userTotp.Secret, result.UserTotp.Secret)
+	require.Equal(t, int64(100), result.UserTotp.LastUsedStep)
+	require.Len(t, result.RecoveryCodes, 2)
+
+	// a confirmed secret cannot be replaced
+	_, err = testStore.CreateUserTotp(context.Background(), CreateUserTotpParams{
+		Username: user.Username,
+		Secret:   util.RandomString(32),
+	})
+	require.ErrorIs(t, err, ErrRecordNotFound)
+}
+
+func TestUseTotpStep(t *testing.T) {
+	user := createRandomUser(t)
+	createRandomUserTotp(t, user)
+
+	_, err := testStore.ConfirmTotpTx(context.Background(), ConfirmTotpTxParams{
+		Username: user.Username,
+		Step:     100,
+	})
+	require.NoError(t, err)
+
+	userTotp, err := testStore.UseTotpStep(context.Background(), UseTotpStepParams{
+		Step:     101,
+		Username: user.Username,
+	})
+	require.NoError(t, err)
+	require.Equal(t, int64(101), userTotp.LastUsedStep)
+
+	// the same step, or an earlier one, cannot be used again
+	for _, step := range []int64{101, 100} {
+		_, err = testStore.UseTotpStep(context.Background(), UseTotpStepParams{
+			Step:     step,
+			Username: user.Username,
+		})
+		require.ErrorIs(t, err, ErrRecordNotFound)
+	}
+}
+
+func TestUseTotpRecoveryCode(t *testing.T) {
+	user := createRandomUser(t)
+	createRandomUserTotp(t, user)
+
+	result, err := testStore.ConfirmTotpTx(context.Background(), ConfirmTotpTxParams{
+		Username:            user.Username,
+		Step:                100,
+		HashedRecoveryCodes: []string{util.RandomString(32), util.RandomString(32)},
+	})
+	require.NoError(t, err)
+
+	used, err := testStore.UseTotpRecoveryCode(context.Background(), result.RecoveryCodes[0].ID)
+	require.NoError(t, err)
+	require.True(t, used.UsedAt.Valid)
+
+	_, err = testStore.UseTotpRecoveryCode(context.Background(), result.RecoveryCodes[0].ID)
+	require.ErrorIs(t, err, ErrRecordNotFound)
+
+	unused, err := testStore.ListUnusedTotpRecoveryCodes(context.Background(), user.Username)
+	require.NoError(t, err)
+	require.Len(t, unused, 1)
+	require.Equal(t, result.RecoveryCodes[1].ID, unused[0].ID)
+}
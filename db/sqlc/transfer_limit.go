@@ -0,0 +1,148 @@
+package db
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/tonisco/simple-bank-go/util"
+)
+
+const (
+	TransferLimitPerTransfer = "per_transfer"
+	TransferLimitDaily       = "daily"
+	TransferLimitMonthly     = "monthly"
+)
+
+// TransferLimitError reports which limit a transfer would break and how much of it the account has left
+type TransferLimitError struct {
+	Period    string `json:"period"`
+	Currency  string `json:"currency"`
+	Limit     int64  `json:"limit"`
+	Remaining int64  `json:"remaining"`
+}
+
+func (e *TransferLimitError) Error() string {
+	return fmt.Sprintf("%s transfer limit of %s exceeded, %s remaining",
+		e.Period, formatLimit(e.Limit, e.Currency), formatLimit(e.Remaining, e.Currency))
+}
+
+func (e *TransferLimitError) Unwrap() error {
+	return ErrTransferLimitExceeded
+}
+
+func formatLimit(amount int64, currency string) string {
+	money, err := util.NewMoney(amount, currency)
+	if err != nil {
+		return fmt.Sprintf("%d %s", amount, currency)
+	}
+	return money.String()
+}
+
+// TransferLimits are the limits that apply to transfers out of one account
+type TransferLimits struct {
+	PerTransfer pgtype.Int8
+	Daily       pgtype.Int8
+	Monthly     pgtype.Int8
+}
+
+// effectiveTransferLimits combines the limits of the account owner's role with the account's own overrides.
+// A limit that is neither set for the role nor for the account is not valid and does not apply
+func effectiveTransferLimits(ctx context.Context, q *Queries, account Account) (TransferLimits, error) {
+	var limits TransferLimits
+
+	owner, err := q.GetUser(ctx, account.Owner)
+	if err != nil {
+		return limits, err
+	}
+
+	roleLimit, err := q.GetTransferLimit(ctx, GetTransferLimitParams{
+		Role:     owner.Role,
+		Currency: account.Currency,
+	})
+	if err == nil {
+		limits.PerTransfer = pgtype.Int8{Int64: roleLimit.PerTransfer, Valid: true}
+		limits.Daily = pgtype.Int8{Int64: roleLimit.Daily, Valid: true}
+		limits.Monthly = pgtype.Int8{Int64: roleLimit.Monthly, Valid: true}
+	} else if !errors.Is(err, ErrRecordNotFound) {
+		return limits, err
+	}
+
+	accountLimit, err := q.GetAccountTransferLimit(ctx, account.ID)
+	if err != nil {
+		if errors.Is(err, ErrRecordNotFound) {
+			return limits, nil
+		}
+		return limits, err
+	}
+
+	if accountLimit.PerTransfer.Valid {
+		limits.PerTransfer = accountLimit.PerTransfer
+	}
+	if accountLimit.Daily.Valid {
+		limits.Daily = accountLimit.Daily
+	}
+	if accountLimit.Monthly.Valid {
+		limits.Monthly = accountLimit.Monthly
+	}
+
+	return limits, nil
+}
+
+// checkTransferLimits returns a TransferLimitError if sending amount from the account would break
+// its per transfer limit or take its outgoing transfers for the UTC day or month over the limit.
+// Reserved is counted as already sent in both periods, it is how much of the account's held funds are still to be sent.
+// The account must already be locked so that concurrent transfers cannot both fit in the same allowance
+func checkTransferLimits(ctx context.Context, q *Queries, account Account, amount int64, reserved int64) error {
+	limits, err := effectiveTransferLimits(ctx, q, account)
+	if err != nil {
+		return err
+	}
+
+	if limits.PerTransfer.Valid && amount > limits.PerTransfer.Int64 {
+		return &TransferLimitError{
+			Period:    TransferLimitPerTransfer,
+			Currency:  account.Currency,
+			Limit:     limits.PerTransfer.Int64,
+			Remaining: limits.PerTransfer.Int64,
+		}
+	}
+
+	now := time.Now().UTC()
+	periods := []struct {
+		name  string
+		limit pgtype.Int8
+		since time.Time
+	}{
+		{TransferLimitDaily, limits.Daily, time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC)},
+		{TransferLimitMonthly, limits.Monthly, time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, time.UTC)},
+	}
+
+	for _, period := range periods {
+		if !period.limit.Valid {
+			continue
+		}
+
+		sent, err := q.SumTransfersFromAccount(ctx, SumTransfersFromAccountParams{
+			FromAccountID: account.ID,
+			Since:         period.since,
+		})
+		if err != nil {
+			return err
+		}
+
+		sent += reserved
+		if sent+amount > period.limit.Int64 {
+			return &TransferLimitError{
+				Period:    period.name,
+				Currency:  account.Currency,
+				Limit:     period.limit.Int64,
+				Remaining: max(period.limit.Int64-sent, 0),
+			}
+		}
+	}
+
+	return nil
+}
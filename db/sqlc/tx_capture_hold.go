@@ -0,0 +1,81 @@
+package db
+
+import (
+	"context"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+// CaptureHoldTxParams contains the input parameters of the capture hold transaction.
+// Amount may be less than the hold, the rest of the hold is released
+type CaptureHoldTxParams struct {
+	ID     int64 `json:"id"`
+	Amount int64 `json:"amount"`
+}
+
+// CaptureHoldTxResult is the result of the capture hold transaction
+type CaptureHoldTxResult struct {
+	Hold     Hold             `json:"hold"`
+	Transfer TransferTxResult `json:"transfer"`
+}
+
+// CaptureHoldTx pays the held funds to the hold's to account.
+// It releases the hold and makes the transfer in one database transaction, so if the transfer is rejected the hold stays in place.
+// It returns ErrHoldNotActive if the hold was captured or released before or has expired,
+// ErrHoldAmountExceeded if Amount is more than the hold, and a TransferLimitError if the transfer would break
+// the account's limits at the time of the capture
+func (store *SQLStore) CaptureHoldTx(ctx context.Context, arg CaptureHoldTxParams) (CaptureHoldTxResult, error) {
+	var result CaptureHoldTxResult
+
+	err := store.execTx(ctx, func(q *Queries) error {
+		hold, err := q.GetHoldForUpdate(ctx, arg.ID)
+		if err != nil {
+			return err
+		}
+
+		if hold.Status != HoldStatusActive || !hold.ExpiresAt.After(time.Now()) {
+			return ErrHoldNotActive
+		}
+
+		if arg.Amount > hold.Amount {
+			return ErrHoldAmountExceeded
+		}
+
+		// lock both accounts in ID order before the held amount is given back, as the transfer would
+		if hold.AccountID < hold.ToAccountID {
+			_, _, err = lockAccounts(ctx, q, hold.AccountID, hold.ToAccountID)
+		} else {
+			_, _, err = lockAccounts(ctx, q, hold.ToAccountID, hold.AccountID)
+		}
+		if err != nil {
+			return err
+		}
+
+		_, err = q.AddAccountHeldAmount(ctx, AddAccountHeldAmountParams{
+			ID:     hold.AccountID,
+			Amount: -hold.Amount,
+		})
+		if err != nil {
+			return err
+		}
+
+		err = transfer(ctx, q, TransferTxParams{
+			FromAccountID: hold.AccountID,
+			ToAccountID:   hold.ToAccountID,
+			Amount:        arg.Amount,
+		}, &result.Transfer)
+		if err != nil {
+			return err
+		}
+
+		result.Hold, err = q.UpdateHoldStatus(ctx, UpdateHoldStatusParams{
+			ID:         hold.ID,
+			Status:     HoldStatusCaptured,
+			TransferID: pgtype.Int8{Int64: result.Transfer.Transfer.ID, Valid: true},
+		})
+		return err
+	})
+
+	return result, err
+}
@@ -0,0 +1,58 @@
+package db
+
+import (
+	"context"
+	"fmt"
+	"slices"
+)
+
+// accountStatusChanges lists the statuses an account can move to from each status
+var accountStatusChanges = map[string][]string{
+	AccountStatusActive: {AccountStatusFrozen, AccountStatusClosed},
+	AccountStatusFrozen: {AccountStatusActive, AccountStatusClosed},
+	AccountStatusClosed: {AccountStatusActive},
+}
+
+// ChangeAccountStatusTxParams contains the input parameters of the change account status transaction.
+// When FromStatus is set the account must currently have that status, otherwise any status that can move to Status will do
+type ChangeAccountStatusTxParams struct {
+	ID         int64  `json:"id"`
+	FromStatus string `json:"from_status"`
+	Status     string `json:"status"`
+}
+
+// ChangeAccountStatusTx freezes, unfreezes, closes or reopens a customer account.
+// Accounts are closed rather than deleted so that their entries and transfers are kept, and only when they hold no money.
+// It returns ErrAccountStatusChange if the account cannot move from its current status to the new one,
+// and ErrAccountNotEmpty when closing an account with a balance or active holds
+func (store *SQLStore) ChangeAccountStatusTx(ctx context.Context, arg ChangeAccountStatusTxParams) (Account, error) {
+	var result Account
+
+	err := store.execTx(ctx, func(q *Queries) error {
+		account, err := q.GetAccountForUpdate(ctx, arg.ID)
+		if err != nil {
+			return err
+		}
+
+		if account.Kind != AccountKindCustomer {
+			return ErrSystemAccount
+		}
+
+		if (arg.FromStatus != "" && account.Status != arg.FromStatus) ||
+			!slices.Contains(accountStatusChanges[account.Status], arg.Status) {
+			return fmt.Errorf("%w: %s to %s", ErrAccountStatusChange, account.Status, arg.Status)
+		}
+
+		if arg.Status == AccountStatusClosed && (account.Balance != 0 || account.HeldAmount != 0) {
+			return ErrAccountNotEmpty
+		}
+
+		result, err = q.UpdateAccountStatus(ctx, UpdateAccountStatusParams{
+			ID:     account.ID,
+			Status: arg.Status,
+		})
+		return err
+	})
+
+	return result, err
+}
@@ -0,0 +1,53 @@
+package db
+
+import "context"
+
+type ConfirmTotpTxParams struct {
+	Username string
+	// Step is the time step of the first code, which cannot be used again to log in
+	Step                int64
+	HashedRecoveryCodes []string
+}
+
+type ConfirmTotpTxResult struct {
+	UserTotp      UserTotp
+	RecoveryCodes []TotpRecoveryCode
+}
+
+// ConfirmTotpTx turns on two-factor login for a user and replaces their recovery codes
+func (store *SQLStore) ConfirmTotpTx(ctx context.Context, arg ConfirmTotpTxParams) (ConfirmTotpTxResult, error) {
+	var result ConfirmTotpTxResult
+
+	err := store.execTx(ctx, func(q *Queries) error {
+		var err error
+
+		result.UserTotp, err = q.ConfirmUserTotp(ctx, ConfirmUserTotpParams{
+			LastUsedStep: arg.Step,
+			Username:     arg.Username,
+		})
+		if err != nil {
+			return err
+		}
+
+		err = q.DeleteTotpRecoveryCodes(ctx, arg.Username)
+		if err != nil {
+			return err
+		}
+
+		for _, hashedCode := range arg.HashedRecoveryCodes {
+			code, err := q.CreateTotpRecoveryCode(ctx, CreateTotpRecoveryCodeParams{
+				Username:   arg.Username,
+				HashedCode: hashedCode,
+			})
+			if err != nil {
+				return err
+			}
+
+			result.RecoveryCodes = append(result.RecoveryCodes, code)
+		}
+
+		return nil
+	})
+
+	return result, err
+}
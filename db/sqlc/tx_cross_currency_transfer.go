@@ -0,0 +1,153 @@
+package db
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/tonisco/simple-bank-go/fx"
+	"github.com/tonisco/simple-bank-go/util"
+)
+
+// CrossCurrencyTransferTxParams contains the input parameters of the cross-currency transfer transaction.
+// Amount is debited in the from account currency and converted at the rate locked by the quote
+type CrossCurrencyTransferTxParams struct {
+	FromAccountID int64              `json:"from_account_id"`
+	ToAccountID   int64              `json:"to_account_id"`
+	Amount        int64              `json:"amount"`
+	QuoteID       uuid.UUID          `json:"quote_id"`
+	Username      string             `json:"username"`
+	Idempotency   *IdempotencyParams `json:"-"`
+}
+
+// CrossCurrencyTransferTx moves money between accounts of different currencies.
+// It uses up the user's fx quote, then posts a journal that debits the from account, credits the bank's fx account
+// in that currency, debits the bank's fx account in the to currency and credits the to account, so each currency balances.
+// It returns ErrInvalidFxQuote if the quote has expired, was used before or does not match the accounts' currencies
+func (store *SQLStore) CrossCurrencyTransferTx(ctx context.Context, arg CrossCurrencyTransferTxParams) (TransferTxResult, error) {
+	var result TransferTxResult
+
+	err := store.execTx(ctx, func(q *Queries) error {
+		return withIdempotency(ctx, q, arg.Idempotency, &result, func() error {
+			return crossCurrencyTransfer(ctx, q, arg, &result)
+		})
+	})
+
+	return result, err
+}
+
+func crossCurrencyTransfer(ctx context.Context, q *Queries, arg CrossCurrencyTransferTxParams, result *TransferTxResult) error {
+	var err error
+
+	var fromAccount, toAccount Account
+	if arg.FromAccountID < arg.ToAccountID {
+		fromAccount, toAccount, err = lockAccounts(ctx, q, arg.FromAccountID, arg.ToAccountID)
+	} else {
+		toAccount, fromAccount, err = lockAccounts(ctx, q, arg.ToAccountID, arg.FromAccountID)
+	}
+	if err != nil {
+		return err
+	}
+
+	if fromAccount.Kind != AccountKindCustomer || toAccount.Kind != AccountKindCustomer {
+		return ErrSystemAccount
+	}
+
+	if fromAccount.Status != AccountStatusActive || toAccount.Status != AccountStatusActive {
+		return ErrAccountNotActive
+	}
+
+	if fromAccount.AvailableBalance()+fromAccount.OverdraftLimit < arg.Amount {
+		return ErrInsufficientFunds
+	}
+
+	err = checkTransferLimits(ctx, q, fromAccount, arg.Amount, 0)
+	if err != nil {
+		return err
+	}
+
+	quote, err := q.UseFxQuote(ctx, UseFxQuoteParams{
+		ID:       arg.QuoteID,
+		Username: arg.Username,
+	})
+	if err != nil {
+		if errors.Is(err, ErrRecordNotFound) {
+			return ErrInvalidFxQuote
+		}
+		return err
+	}
+
+	if quote.FromCurrency != fromAccount.Currency || quote.ToCurrency != toAccount.Currency {
+		return ErrInvalidFxQuote
+	}
+
+	fromMoney, err := util.NewMoney(arg.Amount, fromAccount.Currency)
+	if err != nil {
+		return err
+	}
+	toCurrency, ok := util.LookupCurrency(toAccount.Currency)
+	if !ok {
+		return fmt.Errorf("currency %q is not supported", toAccount.Currency)
+	}
+
+	toAmount := fx.Convert(fromMoney, toCurrency, quote.Rate).Amount
+	if toAmount <= 0 {
+		return ErrAmountTooSmall
+	}
+
+	fromFx, err := q.GetBankAccount(ctx, GetBankAccountParams{
+		Kind:     AccountKindFx,
+		Currency: fromAccount.Currency,
+	})
+	if err != nil {
+		return err
+	}
+
+	toFx, err := q.GetBankAccount(ctx, GetBankAccountParams{
+		Kind:     AccountKindFx,
+		Currency: toAccount.Currency,
+	})
+	if err != nil {
+		return err
+	}
+
+	result.Transfer, err = q.CreateTransfer(ctx, CreateTransferParams{
+		FromAccountID: arg.FromAccountID,
+		ToAccountID:   arg.ToAccountID,
+		Amount:        arg.Amount,
+		ToAmount:      pgtype.Int8{Int64: toAmount, Valid: true},
+		ExchangeRate:  pgtype.Int8{Int64: quote.Rate, Valid: true},
+	})
+	if err != nil {
+		return err
+	}
+
+	// the customer accounts are already locked, the fx accounts are updated in ID order
+	// so that transfers in opposite directions cannot deadlock on them
+	fromFxPosting := posting{accountID: fromFx.ID, amount: arg.Amount}
+	toFxPosting := posting{accountID: toFx.ID, amount: -toAmount}
+	fxPostings := []posting{fromFxPosting, toFxPosting}
+	if toFx.ID < fromFx.ID {
+		fxPostings = []posting{toFxPosting, fromFxPosting}
+	}
+
+	var entries []Entry
+	var accounts []Account
+	result.Journal, entries, accounts, err = postJournal(ctx, q, JournalKindTransfer,
+		pgtype.Int8{Int64: result.Transfer.ID, Valid: true},
+		posting{accountID: arg.FromAccountID, amount: -arg.Amount},
+		posting{accountID: arg.ToAccountID, amount: toAmount},
+		fxPostings[0],
+		fxPostings[1],
+	)
+	if err != nil {
+		return err
+	}
+
+	result.FromEntry, result.ToEntry = entries[0], entries[1]
+	result.FromAccount, result.ToAccount = accounts[0], accounts[1]
+
+	return nil
+}
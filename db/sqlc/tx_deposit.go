@@ -0,0 +1,53 @@
+package db
+
+import (
+	"context"
+
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+// DepositTxParams contains the input parameters of the deposit transaction
+type DepositTxParams struct {
+	AccountID   int64              `json:"account_id"`
+	Amount      int64              `json:"amount"`
+	Idempotency *IdempotencyParams `json:"-"`
+}
+
+// DepositTxResult is the result of the deposit transaction
+type DepositTxResult struct {
+	Account Account `json:"account"`
+	Entry   Entry   `json:"entry"`
+	Journal Journal `json:"journal"`
+}
+
+// DepositTx credits money paid in at the bank to an account.
+// It posts a journal crediting the account and debiting the bank's system account of the same currency,
+// and updates both balances within a database transaction.
+// When Idempotency is set, retries with the same key replay the first result instead of depositing again
+func (store *SQLStore) DepositTx(ctx context.Context, arg DepositTxParams) (DepositTxResult, error) {
+	var result DepositTxResult
+
+	err := store.execTx(ctx, func(q *Queries) error {
+		return withIdempotency(ctx, q, arg.Idempotency, &result, func() error {
+			_, system, err := lockCustomerAccount(ctx, q, arg.AccountID)
+			if err != nil {
+				return err
+			}
+
+			var entries []Entry
+			var accounts []Account
+			result.Journal, entries, accounts, err = postJournal(ctx, q, JournalKindDeposit, pgtype.Int8{},
+				posting{accountID: arg.AccountID, amount: arg.Amount},
+				posting{accountID: system.ID, amount: -arg.Amount},
+			)
+			if err != nil {
+				return err
+			}
+
+			result.Entry, result.Account = entries[0], accounts[0]
+			return nil
+		})
+	})
+
+	return result, err
+}
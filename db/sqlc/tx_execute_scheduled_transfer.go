@@ -0,0 +1,113 @@
+package db
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/tonisco/simple-bank-go/util"
+)
+
+const (
+	ScheduledTransferStatusActive    = "active"
+	ScheduledTransferStatusPaused    = "paused"
+	ScheduledTransferStatusCompleted = "completed"
+)
+
+const (
+	ScheduledTransferRunSucceeded = "succeeded"
+	ScheduledTransferRunFailed    = "failed"
+)
+
+// ExecuteScheduledTransferTxParams contains the input parameters of the scheduled transfer execution.
+// RunAt is the next_run_at the execution was enqueued for
+type ExecuteScheduledTransferTxParams struct {
+	ID    int64     `json:"id"`
+	RunAt time.Time `json:"run_at"`
+}
+
+// ExecuteScheduledTransferTxResult is the result of the scheduled transfer execution.
+// Transfer is only set when the run succeeded
+type ExecuteScheduledTransferTxResult struct {
+	ScheduledTransfer ScheduledTransfer    `json:"scheduled_transfer"`
+	Run               ScheduledTransferRun `json:"run"`
+	Transfer          TransferTxResult     `json:"transfer"`
+}
+
+// ExecuteScheduledTransferTx makes the transfer of a due scheduled transfer, records the outcome as a run
+// and moves next_run_at on to the following occurrence of the schedule, or completes a one-off transfer or a schedule with no run left.
+// A run the ledger rejects, for example for insufficient funds, is recorded as failed and the schedule still moves on.
+// It returns ErrScheduledTransferNotDue if the schedule is not active or has already run at RunAt
+func (store *SQLStore) ExecuteScheduledTransferTx(ctx context.Context, arg ExecuteScheduledTransferTxParams) (ExecuteScheduledTransferTxResult, error) {
+	var result ExecuteScheduledTransferTxResult
+
+	err := store.execTx(ctx, func(q *Queries) error {
+		scheduled, err := q.GetScheduledTransferForUpdate(ctx, arg.ID)
+		if err != nil {
+			return err
+		}
+
+		now := time.Now()
+		if scheduled.Status != ScheduledTransferStatusActive ||
+			!scheduled.NextRunAt.Equal(arg.RunAt) ||
+			scheduled.NextRunAt.After(now) {
+			return ErrScheduledTransferNotDue
+		}
+
+		run := CreateScheduledTransferRunParams{
+			ScheduledTransferID: scheduled.ID,
+			RunAt:               scheduled.NextRunAt,
+			Status:              ScheduledTransferRunSucceeded,
+		}
+
+		err = transfer(ctx, q, TransferTxParams{
+			FromAccountID: scheduled.FromAccountID,
+			ToAccountID:   scheduled.ToAccountID,
+			Amount:        scheduled.Amount,
+		}, &result.Transfer)
+		if err != nil {
+			// the ledger checks the accounts before it writes anything,
+			// so a rejected transfer leaves the transaction usable to record the failure
+			if !IsLedgerRejection(err) {
+				return err
+			}
+			result.Transfer = TransferTxResult{}
+			run.Status = ScheduledTransferRunFailed
+			run.Error = err.Error()
+		} else {
+			run.TransferID = pgtype.Int8{Int64: result.Transfer.Transfer.ID, Valid: true}
+		}
+
+		result.Run, err = q.CreateScheduledTransferRun(ctx, run)
+		if err != nil {
+			return err
+		}
+
+		update := UpdateScheduledTransferParams{ID: scheduled.ID}
+		if scheduled.Schedule == "" {
+			update.Status = pgtype.Text{String: ScheduledTransferStatusCompleted, Valid: true}
+		} else {
+			// runs missed while the worker was down are skipped rather than made one after the other
+			after := scheduled.NextRunAt
+			if now.After(after) {
+				after = now
+			}
+			next, err := util.NextRun(scheduled.Schedule, after)
+			switch {
+			case errors.Is(err, util.ErrScheduleNeverRuns):
+				// a schedule with no run left is done, keeping it active would make it due on every check
+				update.Status = pgtype.Text{String: ScheduledTransferStatusCompleted, Valid: true}
+			case err != nil:
+				return err
+			default:
+				update.NextRunAt = pgtype.Timestamptz{Time: next, Valid: true}
+			}
+		}
+
+		result.ScheduledTransfer, err = q.UpdateScheduledTransfer(ctx, update)
+		return err
+	})
+
+	return result, err
+}
@@ -0,0 +1,58 @@
+package db
+
+import (
+	"context"
+	"slices"
+	"time"
+)
+
+// ExpireHoldsTxParams contains the input parameters of the expire holds transaction
+type ExpireHoldsTxParams struct {
+	Now   time.Time `json:"now"`
+	Limit int32     `json:"limit"`
+}
+
+// ExpireHoldsTx expires up to Limit active holds whose expiry is at or before Now and makes their funds available again.
+// Holds locked by a concurrent capture or release are skipped. It returns the holds it expired,
+// fewer than Limit means there are none left to expire
+func (store *SQLStore) ExpireHoldsTx(ctx context.Context, arg ExpireHoldsTxParams) ([]Hold, error) {
+	var holds []Hold
+
+	err := store.execTx(ctx, func(q *Queries) error {
+		var err error
+
+		holds, err = q.ExpireHolds(ctx, ExpireHoldsParams{
+			Now:   arg.Now,
+			Limit: arg.Limit,
+		})
+		if err != nil {
+			return err
+		}
+
+		held := make(map[int64]int64)
+		for _, hold := range holds {
+			held[hold.AccountID] += hold.Amount
+		}
+
+		// update the accounts in ID order, the same order transfers lock them in
+		accountIDs := make([]int64, 0, len(held))
+		for accountID := range held {
+			accountIDs = append(accountIDs, accountID)
+		}
+		slices.Sort(accountIDs)
+
+		for _, accountID := range accountIDs {
+			_, err = q.AddAccountHeldAmount(ctx, AddAccountHeldAmountParams{
+				ID:     accountID,
+				Amount: -held[accountID],
+			})
+			if err != nil {
+				return err
+			}
+		}
+
+		return nil
+	})
+
+	return holds, err
+}
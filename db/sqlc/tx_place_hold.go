@@ -0,0 +1,79 @@
+package db
+
+import (
+	"context"
+	"time"
+)
+
+// PlaceHoldTxParams contains the input parameters of the place hold transaction
+type PlaceHoldTxParams struct {
+	AccountID   int64     `json:"account_id"`
+	ToAccountID int64     `json:"to_account_id"`
+	Amount      int64     `json:"amount"`
+	ExpiresAt   time.Time `json:"expires_at"`
+}
+
+// PlaceHoldTxResult is the result of the place hold transaction
+type PlaceHoldTxResult struct {
+	Hold    Hold    `json:"hold"`
+	Account Account `json:"account"`
+}
+
+// PlaceHoldTx reserves funds on an account for a later payment to the to account.
+// The held amount no longer counts towards the account's available balance until the hold is captured, released or expires.
+// It applies the same checks as TransferTx, counting the account's other active holds as already sent
+// against the daily and monthly limits. The limits are checked again on capture against the day and month of the capture,
+// so a hold captured in a later period, or after other transfers, can still fail with a TransferLimitError
+func (store *SQLStore) PlaceHoldTx(ctx context.Context, arg PlaceHoldTxParams) (PlaceHoldTxResult, error) {
+	var result PlaceHoldTxResult
+
+	err := store.execTx(ctx, func(q *Queries) error {
+		var err error
+
+		var account, toAccount Account
+		if arg.AccountID < arg.ToAccountID {
+			account, toAccount, err = lockAccounts(ctx, q, arg.AccountID, arg.ToAccountID)
+		} else {
+			toAccount, account, err = lockAccounts(ctx, q, arg.ToAccountID, arg.AccountID)
+		}
+		if err != nil {
+			return err
+		}
+
+		if account.Kind != AccountKindCustomer || toAccount.Kind != AccountKindCustomer {
+			return ErrSystemAccount
+		}
+
+		if account.Status != AccountStatusActive || toAccount.Status != AccountStatusActive {
+			return ErrAccountNotActive
+		}
+
+		if account.AvailableBalance()+account.OverdraftLimit < arg.Amount {
+			return ErrInsufficientFunds
+		}
+
+		// the account's other active holds will be sent too, so they use up the same allowance
+		err = checkTransferLimits(ctx, q, account, arg.Amount, account.HeldAmount)
+		if err != nil {
+			return err
+		}
+
+		result.Account, err = q.AddAccountHeldAmount(ctx, AddAccountHeldAmountParams{
+			ID:     arg.AccountID,
+			Amount: arg.Amount,
+		})
+		if err != nil {
+			return err
+		}
+
+		result.Hold, err = q.CreateHold(ctx, CreateHoldParams{
+			AccountID:   arg.AccountID,
+			ToAccountID: arg.ToAccountID,
+			Amount:      arg.Amount,
+			ExpiresAt:   arg.ExpiresAt,
+		})
+		return err
+	})
+
+	return result, err
+}
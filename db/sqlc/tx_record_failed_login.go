@@ -0,0 +1,108 @@
+package db
+
+import (
+	"context"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+const (
+	LoginThrottleScopeUser = "user"
+	LoginThrottleScopeIP   = "ip"
+)
+
+type RecordFailedLoginTxParams struct {
+	Username string
+	// the client ip is not tracked when it is empty
+	ClientIP string
+	// failed attempts allowed before the username or the client ip is locked, zero never locks
+	MaxUserAttempts int32
+	MaxIPAttempts   int32
+	// LockoutDuration is the first lock, each failed attempt after it doubles the lock up to MaxLockoutDuration
+	LockoutDuration    time.Duration
+	MaxLockoutDuration time.Duration
+	// failed attempts older than AttemptWindow are forgotten
+	AttemptWindow time.Duration
+}
+
+type RecordFailedLoginTxResult struct {
+	UserThrottle LoginThrottle
+	IPThrottle   LoginThrottle
+	// UserLocked is true when this attempt locked the username
+	UserLocked bool
+}
+
+// RecordFailedLoginTx counts a failed login against the username and the client ip and locks them
+// once they run out of attempts
+func (store *SQLStore) RecordFailedLoginTx(ctx context.Context, arg RecordFailedLoginTxParams) (RecordFailedLoginTxResult, error) {
+	var result RecordFailedLoginTxResult
+
+	err := store.execTx(ctx, func(q *Queries) error {
+		var err error
+
+		result.UserThrottle, result.UserLocked, err = recordFailedLogin(ctx, q, LoginThrottleScopeUser, arg.Username, arg.MaxUserAttempts, arg)
+		if err != nil {
+			return err
+		}
+
+		if arg.ClientIP == "" {
+			return nil
+		}
+
+		result.IPThrottle, _, err = recordFailedLogin(ctx, q, LoginThrottleScopeIP, arg.ClientIP, arg.MaxIPAttempts, arg)
+		return err
+	})
+
+	return result, err
+}
+
+func recordFailedLogin(
+	ctx context.Context,
+	q *Queries,
+	scope string,
+	subject string,
+	maxAttempts int32,
+	arg RecordFailedLoginTxParams,
+) (LoginThrottle, bool, error) {
+	throttle, err := q.IncrementLoginThrottle(ctx, IncrementLoginThrottleParams{
+		Scope:       scope,
+		Subject:     subject,
+		ResetBefore: time.Now().Add(-arg.AttemptWindow),
+	})
+	if err != nil {
+		return throttle, false, err
+	}
+
+	if maxAttempts <= 0 || throttle.FailedAttempts < maxAttempts {
+		return throttle, false, nil
+	}
+
+	lockout := LoginLockoutDuration(throttle.FailedAttempts-maxAttempts, arg.LockoutDuration, arg.MaxLockoutDuration)
+
+	throttle, err = q.LockLoginThrottle(ctx, LockLoginThrottleParams{
+		LockedUntil: pgtype.Timestamptz{
+			Time:  time.Now().Add(lockout),
+			Valid: true,
+		},
+		Scope:   scope,
+		Subject: subject,
+	})
+	return throttle, err == nil, err
+}
+
+// LoginLockoutDuration doubles the lockout for every attempt failed past the limit, up to maxLockout.
+// A zero maxLockout leaves the lockout uncapped
+func LoginLockoutDuration(attemptsOverLimit int32, lockout time.Duration, maxLockout time.Duration) time.Duration {
+	for i := int32(0); i < attemptsOverLimit; i++ {
+		if maxLockout > 0 && lockout >= maxLockout {
+			break
+		}
+		lockout *= 2
+	}
+
+	if maxLockout > 0 && lockout > maxLockout {
+		return maxLockout
+	}
+	return lockout
+}
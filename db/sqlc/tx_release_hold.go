@@ -0,0 +1,44 @@
+package db
+
+import (
+	"context"
+)
+
+// ReleaseHoldTxResult is the result of the release hold transaction
+type ReleaseHoldTxResult struct {
+	Hold    Hold    `json:"hold"`
+	Account Account `json:"account"`
+}
+
+// ReleaseHoldTx cancels an active hold and makes its funds available again.
+// It returns ErrHoldNotActive if the hold was captured or released before or has already been expired
+func (store *SQLStore) ReleaseHoldTx(ctx context.Context, id int64) (ReleaseHoldTxResult, error) {
+	var result ReleaseHoldTxResult
+
+	err := store.execTx(ctx, func(q *Queries) error {
+		hold, err := q.GetHoldForUpdate(ctx, id)
+		if err != nil {
+			return err
+		}
+
+		if hold.Status != HoldStatusActive {
+			return ErrHoldNotActive
+		}
+
+		result.Account, err = q.AddAccountHeldAmount(ctx, AddAccountHeldAmountParams{
+			ID:     hold.AccountID,
+			Amount: -hold.Amount,
+		})
+		if err != nil {
+			return err
+		}
+
+		result.Hold, err = q.UpdateHoldStatus(ctx, UpdateHoldStatusParams{
+			ID:     hold.ID,
+			Status: HoldStatusReleased,
+		})
+		return err
+	})
+
+	return result, err
+}
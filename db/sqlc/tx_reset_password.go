@@ -0,0 +1,60 @@
+package db
+
+import (
+	"context"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+// ResetPasswordTxParams contains the input parameters of the reset password transaction.
+// SecretCode is the hash of the code from the email, as made by util.HashSecretCode
+type ResetPasswordTxParams struct {
+	ResetID        int64
+	SecretCode     string
+	HashedPassword string
+}
+
+type ResetPasswordTxResult struct {
+	User          User
+	PasswordReset PasswordReset
+	// number of sessions blocked because they were opened with the old password
+	BlockedSessions int64
+}
+
+// ResetPasswordTx uses up a password reset code, sets the new password and blocks every session of the user
+func (store *SQLStore) ResetPasswordTx(ctx context.Context, arg ResetPasswordTxParams) (ResetPasswordTxResult, error) {
+	var result ResetPasswordTxResult
+
+	err := store.execTx(ctx, func(q *Queries) error {
+		var err error
+
+		result.PasswordReset, err = q.UsePasswordReset(ctx, UsePasswordResetParams{
+			ID:         arg.ResetID,
+			SecretCode: arg.SecretCode,
+		})
+		if err != nil {
+			return err
+		}
+
+		result.User, err = q.UpdateUser(ctx, UpdateUserParams{
+			Username: result.PasswordReset.Username,
+			HashedPassword: pgtype.Text{
+				String: arg.HashedPassword,
+				Valid:  true,
+			},
+			PasswordChangedAt: pgtype.Timestamptz{
+				Time:  time.Now(),
+				Valid: true,
+			},
+		})
+		if err != nil {
+			return err
+		}
+
+		result.BlockedSessions, err = q.BlockUserSessions(ctx, result.User.Username)
+		return err
+	})
+
+	return result, err
+}
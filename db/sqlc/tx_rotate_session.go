@@ -0,0 +1,71 @@
+package db
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+// RotateSessionTxParams contains the input parameters of the rotate session transaction.
+// NewSession is the session of the refresh token issued in exchange, its family and previous session are set by the transaction
+type RotateSessionTxParams struct {
+	ID           uuid.UUID           `json:"id"`
+	Username     string              `json:"username"`
+	RefreshToken string              `json:"refresh_token"`
+	NewSession   CreateSessionParams `json:"new_session"`
+}
+
+// RotateSessionTx exchanges the refresh token of a session for a new one, recording the new session in the same family.
+// Presenting a refresh token that was already rotated blocks every session of the family and returns ErrRefreshTokenReused,
+// along with the presented session so the caller can revoke the access tokens of its family
+func (store *SQLStore) RotateSessionTx(ctx context.Context, arg RotateSessionTxParams) (Session, error) {
+	var result Session
+	var reused bool
+
+	err := store.execTx(ctx, func(q *Queries) error {
+		session, err := q.GetSessionForUpdate(ctx, arg.ID)
+		if err != nil {
+			return err
+		}
+
+		if session.Username != arg.Username || session.RefreshToken != arg.RefreshToken {
+			return ErrSessionMismatch
+		}
+
+		if session.IsBlocked {
+			return ErrSessionBlocked
+		}
+
+		// the family is blocked in this transaction, so the reuse is reported once it has committed
+		if session.RotatedAt.Valid {
+			reused = true
+			result = session
+			_, err = q.BlockSessionFamily(ctx, session.FamilyID)
+			return err
+		}
+
+		if time.Now().After(session.ExpiresAt) {
+			return ErrSessionExpired
+		}
+
+		_, err = q.RotateSession(ctx, session.ID)
+		if err != nil {
+			return err
+		}
+
+		newSession := arg.NewSession
+		newSession.FamilyID = session.FamilyID
+		newSession.PreviousSessionID = pgtype.UUID{Bytes: session.ID, Valid: true}
+
+		result, err = q.CreateSession(ctx, newSession)
+		return err
+	})
+
+	if err == nil && reused {
+		err = ErrRefreshTokenReused
+	}
+
+	return result, err
+}
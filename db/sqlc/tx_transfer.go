@@ -1,12 +1,17 @@
 package db
 
-import "context"
+import (
+	"context"
+
+	"github.com/jackc/pgx/v5/pgtype"
+)
 
 // TransferTxParams contains the input parameters of the transfer transaction
 type TransferTxParams struct {
-	FromAccountID int64 `json:"from_account_id"`
-	ToAccountID   int64 `json:"to_account_id"`
-	Amount        int64 `json:"amount"`
+	FromAccountID int64              `json:"from_account_id"`
+	ToAccountID   int64              `json:"to_account_id"`
+	Amount        int64              `json:"amount"`
+	Idempotency   *IdempotencyParams `json:"-"`
 }
 
 // TransferTxResult is the result of the transfer transaction
@@ -16,72 +21,97 @@ type TransferTxResult struct {
 	ToAccount   Account  `json:"to_account"`
 	FromEntry   Entry    `json:"from_entry"`
 	ToEntry     Entry    `json:"to_entry"`
+	Journal     Journal  `json:"journal"`
 }
 
 // TransferTx performs a money transfer from one account to the other.
-// It creates the transfer, add account entries, and update accounts' balance within a database transaction
+// It creates the transfer, posts it as a balanced journal of two account entries, and update accounts' balance within a database transaction.
+// Only active customer accounts can take part in a transfer, otherwise it returns ErrSystemAccount or ErrAccountNotActive.
+// It returns ErrInsufficientFunds if the transfer would take the from account's available balance below its overdraft limit,
+// and a TransferLimitError if it would go over the from account's per transfer, daily or monthly limit.
+// When Idempotency is set, retries with the same key replay the first result instead of moving money again
 func (store *SQLStore) TransferTx(ctx context.Context, arg TransferTxParams) (TransferTxResult, error) {
 	var result TransferTxResult
 
 	err := store.execTx(ctx, func(q *Queries) error {
-		var err error
-
-		result.Transfer, err = q.CreateTransfer(ctx, CreateTransferParams{
-			FromAccountID: arg.FromAccountID,
-			ToAccountID:   arg.ToAccountID,
-			Amount:        arg.Amount,
+		return withIdempotency(ctx, q, arg.Idempotency, &result, func() error {
+			return transfer(ctx, q, arg, &result)
 		})
-		if err != nil {
-			return err
-		}
+	})
 
-		result.FromEntry, err = q.CreateEntry(ctx, CreateEntryParams{
-			AccountID: arg.FromAccountID,
-			Amount:    -arg.Amount,
-		})
-		if err != nil {
-			return err
-		}
+	return result, err
+}
 
-		result.ToEntry, err = q.CreateEntry(ctx, CreateEntryParams{
-			AccountID: arg.ToAccountID,
-			Amount:    arg.Amount,
-		})
-		if err != nil {
-			return err
-		}
+func transfer(ctx context.Context, q *Queries, arg TransferTxParams, result *TransferTxResult) error {
+	var err error
+
+	var fromAccount, toAccount Account
+	if arg.FromAccountID < arg.ToAccountID {
+		fromAccount, toAccount, err = lockAccounts(ctx, q, arg.FromAccountID, arg.ToAccountID)
+	} else {
+		toAccount, fromAccount, err = lockAccounts(ctx, q, arg.ToAccountID, arg.FromAccountID)
+	}
+	if err != nil {
+		return err
+	}
+
+	if fromAccount.Kind != AccountKindCustomer || toAccount.Kind != AccountKindCustomer {
+		return ErrSystemAccount
+	}
+
+	if fromAccount.Status != AccountStatusActive || toAccount.Status != AccountStatusActive {
+		return ErrAccountNotActive
+	}
 
-		if arg.FromAccountID < arg.ToAccountID {
-			result.FromAccount, result.ToAccount, err = addMoney(ctx, q, arg.FromAccountID, -arg.Amount, arg.ToAccountID, arg.Amount)
-		} else {
-			result.ToAccount, result.FromAccount, err = addMoney(ctx, q, arg.ToAccountID, arg.Amount, arg.FromAccountID, -arg.Amount)
-		}
+	if fromAccount.AvailableBalance()+fromAccount.OverdraftLimit < arg.Amount {
+		return ErrInsufficientFunds
+	}
 
+	err = checkTransferLimits(ctx, q, fromAccount, arg.Amount, 0)
+	if err != nil {
 		return err
+	}
+
+	result.Transfer, err = q.CreateTransfer(ctx, CreateTransferParams{
+		FromAccountID: arg.FromAccountID,
+		ToAccountID:   arg.ToAccountID,
+		Amount:        arg.Amount,
 	})
+	if err != nil {
+		return err
+	}
 
-	return result, err
+	transferID := pgtype.Int8{Int64: result.Transfer.ID, Valid: true}
+
+	var entries []Entry
+	var accounts []Account
+	result.Journal, entries, accounts, err = postJournal(ctx, q, JournalKindTransfer, transferID,
+		posting{accountID: arg.FromAccountID, amount: -arg.Amount},
+		posting{accountID: arg.ToAccountID, amount: arg.Amount},
+	)
+	if err != nil {
+		return err
+	}
+
+	result.FromEntry, result.ToEntry = entries[0], entries[1]
+	result.FromAccount, result.ToAccount = accounts[0], accounts[1]
+
+	return nil
 }
 
-func addMoney(
+// lockAccounts locks both accounts for update. Callers must pass the smaller ID first
+// so that concurrent transactions always acquire the locks in the same order
+func lockAccounts(
 	ctx context.Context,
 	q *Queries,
 	accountID1 int64,
-	amount1 int64,
 	accountID2 int64,
-	amount2 int64,
 ) (account1 Account, account2 Account, err error) {
-	account1, err = q.AddAccountBalance(ctx, AddAccountBalanceParams{
-		ID:     accountID1,
-		Amount: amount1,
-	})
+	account1, err = q.GetAccountForUpdate(ctx, accountID1)
 	if err != nil {
 		return
 	}
 
-	account2, err = q.AddAccountBalance(ctx, AddAccountBalanceParams{
-		ID:     accountID2,
-		Amount: amount2,
-	})
+	account2, err = q.GetAccountForUpdate(ctx, accountID2)
 	return
 }
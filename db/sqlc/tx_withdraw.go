@@ -0,0 +1,58 @@
+package db
+
+import (
+	"context"
+
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+// WithdrawTxParams contains the input parameters of the withdraw transaction
+type WithdrawTxParams struct {
+	AccountID   int64              `json:"account_id"`
+	Amount      int64              `json:"amount"`
+	Idempotency *IdempotencyParams `json:"-"`
+}
+
+// WithdrawTxResult is the result of the withdraw transaction
+type WithdrawTxResult struct {
+	Account Account `json:"account"`
+	Entry   Entry   `json:"entry"`
+	Journal Journal `json:"journal"`
+}
+
+// WithdrawTx pays money out of an account.
+// It posts a journal debiting the account and crediting the bank's system account of the same currency,
+// and updates both balances within a database transaction.
+// Withdrawals never use the overdraft limit: it returns ErrInsufficientFunds if the available balance would go negative.
+// When Idempotency is set, retries with the same key replay the first result instead of withdrawing again
+func (store *SQLStore) WithdrawTx(ctx context.Context, arg WithdrawTxParams) (WithdrawTxResult, error) {
+	var result WithdrawTxResult
+
+	err := store.execTx(ctx, func(q *Queries) error {
+		return withIdempotency(ctx, q, arg.Idempotency, &result, func() error {
+			account, system, err := lockCustomerAccount(ctx, q, arg.AccountID)
+			if err != nil {
+				return err
+			}
+
+			if account.AvailableBalance() < arg.Amount {
+				return ErrInsufficientFunds
+			}
+
+			var entries []Entry
+			var accounts []Account
+			result.Journal, entries, accounts, err = postJournal(ctx, q, JournalKindWithdrawal, pgtype.Int8{},
+				posting{accountID: arg.AccountID, amount: -arg.Amount},
+				posting{accountID: system.ID, amount: arg.Amount},
+			)
+			if err != nil {
+				return err
+			}
+
+			result.Entry, result.Account = entries[0], accounts[0]
+			return nil
+		})
+	})
+
+	return result, err
+}
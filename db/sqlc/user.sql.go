@@ -7,6 +7,7 @@ package db
 
 import (
 	"context"
+	"time"
 
 	"github.com/jackc/pgx/v5/pgtype"
 )
@@ -75,6 +76,41 @@ func (q *Queries) GetUser(ctx context.Context, username string) (User, error) {
 	return i, err
 }
 
+const getUserByEmail = `-- name: GetUserByEmail :one
+SELECT username, hashed_password, full_name, email, password_changed_at, created_at, is_email_verified, role FROM users
+WHERE email = $1 AND role <> 'system'
+LIMIT 1
+`
+
+func (q *Queries) GetUserByEmail(ctx context.Context, email string) (User, error) {
+	row := q.db.QueryRow(ctx, getUserByEmail, email)
+	var i User
+	err := row.Scan(
+		&i.Username,
+		&i.HashedPassword,
+		&i.FullName,
+		&i.Email,
+		&i.PasswordChangedAt,
+		&i.CreatedAt,
+		&i.IsEmailVerified,
+		&i.Role,
+	)
+	return i, err
+}
+
+const getUserPasswordChangedAt = `-- name: GetUserPasswordChangedAt :one
+SELECT password_changed_at FROM users
+WHERE username = $1
+LIMIT 1
+`
+
+func (q *Queries) GetUserPasswordChangedAt(ctx context.Context, username string) (time.Time, error) {
+	row := q.db.QueryRow(ctx, getUserPasswordChangedAt, username)
+	var password_changed_at time.Time
+	err := row.Scan(&password_changed_at)
+	return password_changed_at, err
+}
+
 const updateUser = `-- name: UpdateUser :one
 Update users 
 SET 
@@ -83,7 +119,7 @@ SET
     full_name= coalesce($3,full_name),
     email= coalesce($4,email),
     is_email_verified = COALESCE($5, is_email_verified)
-WHERE username = $6
+WHERE username = $6 AND role <> 'system'
 RETURNING username, hashed_password, full_name, email, password_changed_at, created_at, is_email_verified, role
 `
 
@@ -122,7 +158,7 @@ func (q *Queries) UpdateUser(ctx context.Context, arg UpdateUserParams) (User, e
 const updateUserRole = `-- name: UpdateUserRole :one
 Update users
 SET role = $2
-WHERE username = $1
+WHERE username = $1 AND role <> 'system'
 RETURNING username, hashed_password, full_name, email, password_changed_at, created_at, is_email_verified, role
 `
 
@@ -117,6 +117,31 @@ func TestUpdateUserOnlyPassword(t *testing.T) {
 	require.Equal(t, oldUser.Email, updatedUser.Email)
 }
 
+func TestGetUserPasswordChangedAt(t *testing.T) {
+	user := createRandomUser(t)
+
+	passwordChangedAt, err := testStore.GetUserPasswordChangedAt(context.Background(), user.Username)
+	require.NoError(t, err)
+	require.WithinDuration(t, user.PasswordChangedAt, passwordChangedAt, time.Second)
+
+	changedAt := time.Now()
+	_, err = testStore.UpdateUser(context.Background(), UpdateUserParams{
+		Username: user.Username,
+		PasswordChangedAt: pgtype.Timestamptz{
+			Time:  changedAt,
+			Valid: true,
+		},
+	})
+	require.NoError(t, err)
+
+	passwordChangedAt, err = testStore.GetUserPasswordChangedAt(context.Background(), user.Username)
+	require.NoError(t, err)
+	require.WithinDuration(t, changedAt, passwordChangedAt, time.Second)
+
+	_, err = testStore.GetUserPasswordChangedAt(context.Background(), util.RandomOwner())
+	require.ErrorIs(t, err, ErrRecordNotFound)
+}
+
 func TestUpdateUserAllFields(t *testing.T) {
 	oldUser := createRandomUser(t)
 
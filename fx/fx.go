@@ -0,0 +1,66 @@
+package fx
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/big"
+	"strconv"
+	"strings"
+
+	"github.com/tonisco/simple-bank-go/util"
+)
+
+// RateScale is the fixed-point scale of exchange rates: a rate of 1.5 is stored as 150_000_000
+const RateScale = 100_000_000
+
+// rateDecimals is the number of decimal places a rate can hold at RateScale
+const rateDecimals = 8
+
+var ErrRateNotFound = errors.New("exchange rate not found")
+
+// RateProvider looks up the current rate to exchange one currency for another
+type RateProvider interface {
+	// Rate returns how many units of to one unit of from buys, scaled by RateScale
+	Rate(ctx context.Context, from string, to string) (int64, error)
+}
+
+// Convert exchanges amount for the to currency at rate, adjusting for the currencies' minor units.
+// The result is rounded down so the bank never pays out more than the rate allows
+func Convert(amount util.Money, to util.Currency, rate int64) util.Money {
+	result := new(big.Int).Mul(big.NewInt(amount.Amount), big.NewInt(rate))
+	result.Mul(result, pow10(to.Exponent))
+
+	divisor := new(big.Int).Mul(big.NewInt(RateScale), pow10(amount.Currency.Exponent))
+	result.Quo(result, divisor)
+
+	return util.Money{Amount: result.Int64(), Currency: to}
+}
+
+func pow10(exponent int) *big.Int {
+	return new(big.Int).Exp(big.NewInt(10), big.NewInt(int64(exponent)), nil)
+}
+
+// ParseRate parses a positive decimal rate such as "0.9215" into its fixed-point value
+func ParseRate(s string) (int64, error) {
+	whole, frac, _ := strings.Cut(s, ".")
+	if len(frac) > rateDecimals {
+		return 0, fmt.Errorf("rate %q has more than %d decimal places", s, rateDecimals)
+	}
+	frac += strings.Repeat("0", rateDecimals-len(frac))
+
+	rate, err := strconv.ParseInt(whole+frac, 10, 64)
+	if err != nil || strings.HasPrefix(s, "-") || strings.HasPrefix(s, "+") {
+		return 0, fmt.Errorf("invalid rate %q", s)
+	}
+	if rate <= 0 {
+		return 0, fmt.Errorf("rate %q must be positive", s)
+	}
+
+	return rate, nil
+}
+
+// FormatRate formats a fixed-point rate as a decimal string with all its decimal places
+func FormatRate(rate int64) string {
+	return fmt.Sprintf("%d.%08d", rate/RateScale, rate%RateScale)
+}
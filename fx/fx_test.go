@@ -0,0 +1,90 @@
+package fx
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"github.com/tonisco/simple-bank-go/util"
+)
+
+func TestParseRate(t *testing.T) {
+	testCases := []struct {
+		rate    string
+		want    int64
+		wantErr bool
+	}{
+		{rate: "1", want: RateScale},
+		{rate: "0.92", want: 92_000_000},
+		{rate: "1.08701234", want: 108_701_234},
+		{rate: "1.087012345", wantErr: true},
+		{rate: "0", wantErr: true},
+		{rate: "-1.5", wantErr: true},
+		{rate: "abc", wantErr: true},
+		{rate: "1.2.3", wantErr: true},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.rate, func(t *testing.T) {
+			rate, err := ParseRate(tc.rate)
+			if tc.wantErr {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, tc.want, rate)
+			require.Equal(t, rate, mustParseRate(t, FormatRate(rate)))
+		})
+	}
+}
+
+func TestConvert(t *testing.T) {
+	require.Equal(t, int64(92), convert(t, 100, util.USD, util.EUR, 92_000_000))
+	// fractions of a minor unit are rounded down
+	require.Equal(t, int64(1), convert(t, 1, util.USD, util.EUR, 199_999_999))
+	require.Equal(t, int64(0), convert(t, 1, util.USD, util.EUR, 92_000_000))
+	// large amounts do not overflow while multiplying
+	require.Equal(t, int64(9_200_000_000_000), convert(t, 10_000_000_000_000, util.USD, util.EUR, 92_000_000))
+	// 1.00 USD at 151.20 JPY per dollar is 151 yen, yen have no minor unit
+	require.Equal(t, int64(151), convert(t, 100, util.USD, util.JPY, 15_120_000_000))
+	// 1000 JPY at 0.0066 USD per yen is 6.60 USD
+	require.Equal(t, int64(660), convert(t, 1000, util.JPY, util.USD, 660_000))
+	// 10.00 USD at 0.3075 KWD per dollar is 3.075 KWD, dinars have three decimal places
+	require.Equal(t, int64(3075), convert(t, 1000, util.USD, util.KWD, 30_750_000))
+}
+
+func TestStaticRateProvider(t *testing.T) {
+	provider, err := LoadStaticRateProvider("testdata/rates.json")
+	require.NoError(t, err)
+
+	rate, err := provider.Rate(context.Background(), "USD", "EUR")
+	require.NoError(t, err)
+	require.Equal(t, int64(92_000_000), rate)
+
+	rate, err = provider.Rate(context.Background(), "EUR", "EUR")
+	require.NoError(t, err)
+	require.Equal(t, int64(RateScale), rate)
+
+	_, err = provider.Rate(context.Background(), "USD", "JPY")
+	require.ErrorIs(t, err, ErrRateNotFound)
+
+	_, err = NewStaticRateProvider(map[string]map[string]string{"USD": {"EUR": "-1"}})
+	require.Error(t, err)
+}
+
+func convert(t *testing.T, amount int64, from string, to string, rate int64) int64 {
+	money, err := util.NewMoney(amount, from)
+	require.NoError(t, err)
+	toCurrency, ok := util.LookupCurrency(to)
+	require.True(t, ok)
+
+	result := Convert(money, toCurrency, rate)
+	require.Equal(t, to, result.Currency.Code)
+	return result.Amount
+}
+
+func mustParseRate(t *testing.T, s string) int64 {
+	rate, err := ParseRate(s)
+	require.NoError(t, err)
+	return rate
+}
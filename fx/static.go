@@ -0,0 +1,62 @@
+package fx
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+)
+
+// StaticRateProvider serves fixed rates, for tests and development.
+// Exchanging a currency for itself is always at a rate of 1
+type StaticRateProvider struct {
+	rates map[string]map[string]int64
+}
+
+// NewStaticRateProvider creates a provider from decimal rates keyed by the currency sold, then the currency bought
+func NewStaticRateProvider(rates map[string]map[string]string) (*StaticRateProvider, error) {
+	provider := &StaticRateProvider{
+		rates: make(map[string]map[string]int64, len(rates)),
+	}
+
+	for from, toRates := range rates {
+		provider.rates[from] = make(map[string]int64, len(toRates))
+		for to, value := range toRates {
+			rate, err := ParseRate(value)
+			if err != nil {
+				return nil, fmt.Errorf("%s/%s: %w", from, to, err)
+			}
+			provider.rates[from][to] = rate
+		}
+	}
+
+	return provider, nil
+}
+
+// LoadStaticRateProvider reads the rates from a JSON file such as {"USD": {"EUR": "0.92"}}
+func LoadStaticRateProvider(path string) (*StaticRateProvider, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("cannot read rates file: %w", err)
+	}
+
+	var rates map[string]map[string]string
+	if err := json.Unmarshal(data, &rates); err != nil {
+		return nil, fmt.Errorf("cannot parse rates file: %w", err)
+	}
+
+	return NewStaticRateProvider(rates)
+}
+
+func (provider *StaticRateProvider) Rate(ctx context.Context, from string, to string) (int64, error) {
+	if from == to {
+		return RateScale, nil
+	}
+
+	rate, ok := provider.rates[from][to]
+	if !ok {
+		return 0, fmt.Errorf("%w: %s/%s", ErrRateNotFound, from, to)
+	}
+
+	return rate, nil
+}
@@ -0,0 +1,42 @@
+package gapi
+
+import (
+	"context"
+	"errors"
+
+	db "github.com/tonisco/simple-bank-go/db/sqlc"
+	"github.com/tonisco/simple-bank-go/val"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// changeAccountStatus moves an account to a new status and maps the lifecycle errors to gRPC status errors.
+// An empty fromStatus accepts any status the account can move to the new status from
+func (server *Server) changeAccountStatus(ctx context.Context, id int64, fromStatus string, toStatus string) (db.Account, error) {
+	account, err := server.store.ChangeAccountStatusTx(ctx, db.ChangeAccountStatusTxParams{
+		ID:         id,
+		FromStatus: fromStatus,
+		Status:     toStatus,
+	})
+
+	if err != nil {
+		if errors.Is(err, db.ErrRecordNotFound) {
+			return db.Account{}, status.Errorf(codes.NotFound, "account not found")
+		}
+		if errors.Is(err, db.ErrAccountStatusChange) || errors.Is(err, db.ErrAccountNotEmpty) || errors.Is(err, db.ErrSystemAccount) {
+			return db.Account{}, status.Errorf(codes.FailedPrecondition, "%s", err)
+		}
+		return db.Account{}, status.Errorf(codes.Internal, "failed to change account status: %s", err)
+	}
+
+	return account, nil
+}
+
+func validateAccountID(id int64) (violations []*errdetails.BadRequest_FieldViolation) {
+	if err := val.ValidateID(id); err != nil {
+		violations = append(violations, fieldViolation("id", err))
+	}
+
+	return violations
+}
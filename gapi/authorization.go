@@ -6,7 +6,10 @@ import (
 	"strings"
 
 	"github.com/tonisco/simple-bank-go/token"
+	"github.com/tonisco/simple-bank-go/util"
+	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
 )
 
 const (
@@ -24,7 +27,12 @@ func (server *Server) authorizeUser(ctx context.Context, accessibleRoles []strin
 		return nil, fmt.Errorf("missing authorization header")
 	}
 
-	authHeader := values[0]
+	return server.authorizeHeader(ctx, values[0], accessibleRoles)
+}
+
+// authorizeHeader verifies the bearer token of an authorization header value.
+// It lets plain HTTP handlers on the gateway share the checks done for gRPC calls
+func (server *Server) authorizeHeader(ctx context.Context, authHeader string, accessibleRoles []string) (*token.Payload, error) {
 	fields := strings.Fields(authHeader)
 
 	if len(fields) < 2 {
@@ -42,7 +50,19 @@ func (server *Server) authorizeUser(ctx context.Context, accessibleRoles []strin
 	if err != nil {
 		return nil, fmt.Errorf("invalid access token: %s", err)
 	}
-	fmt.Println(payload)
+
+	// refresh and challenge tokens live longer than access tokens and are not revoked with them
+	if payload.Type != token.TokenTypeAccess {
+		return nil, fmt.Errorf("invalid access token: not an access token")
+	}
+
+	if err := server.denylist.Check(ctx, payload); err != nil {
+		return nil, fmt.Errorf("invalid access token: %s", err)
+	}
+
+	if err := server.checkPasswordChange(ctx, payload); err != nil {
+		return nil, fmt.Errorf("invalid access token: %s", err)
+	}
 
 	if !hasPermission(payload.Role, accessibleRoles) {
 		return nil, fmt.Errorf("permission denied")
@@ -51,6 +71,21 @@ func (server *Server) authorizeUser(ctx context.Context, accessibleRoles []strin
 	return payload, nil
 }
 
+// checkPasswordChange rejects tokens issued before the user last changed their password.
+// The database rather than the denylist remembers password changes, so they survive restarts and Redis outages
+func (server *Server) checkPasswordChange(ctx context.Context, payload *token.Payload) error {
+	passwordChangedAt, err := server.store.GetUserPasswordChangedAt(ctx, payload.Username)
+	if err != nil {
+		return fmt.Errorf("cannot check user: %s", err)
+	}
+
+	if payload.IssuedAt.Before(passwordChangedAt) {
+		return fmt.Errorf("token was issued before the password was changed")
+	}
+
+	return nil
+}
+
 func hasPermission(userRole string, accessibleRoles []string) bool {
 	for _, role := range accessibleRoles {
 		if userRole == role {
@@ -59,3 +94,35 @@ func hasPermission(userRole string, accessibleRoles []string) bool {
 	}
 	return false
 }
+
+// isAccountOwnerOrBanker reports whether the authenticated user may act on the account
+func isAccountOwnerOrBanker(payload *token.Payload, owner string) bool {
+	return payload.Role == util.BankerRole || payload.Username == owner
+}
+
+// requireStepUp checks that moving amount, in minor units of currency, is authorized by a recent elevated token
+// when it is above the step-up threshold configured for currency
+func (server *Server) requireStepUp(payload *token.Payload, amount int64, currency string) error {
+	thresholds := server.config.StepUpTransferThresholds
+	if len(thresholds) == 0 {
+		return nil
+	}
+
+	money, err := util.NewMoney(amount, currency)
+	if err != nil {
+		return status.Errorf(codes.InvalidArgument, "%s", err)
+	}
+
+	// a currency without a threshold has a zero one, so any amount of it needs step-up
+	threshold := thresholds[money.Currency.Code]
+	if !money.ExceedsMajorUnits(threshold) {
+		return nil
+	}
+
+	if !payload.IsElevated(server.config.ElevatedTokenDuration) {
+		return status.Errorf(codes.PermissionDenied,
+			"amounts above %d %s need a recent re-authentication, use the token from ReauthenticateUser", threshold, currency)
+	}
+
+	return nil
+}
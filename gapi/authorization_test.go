@@ -0,0 +1,149 @@
+package gapi
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/require"
+	mockdb "github.com/tonisco/simple-bank-go/db/mock"
+	"github.com/tonisco/simple-bank-go/util"
+	"go.uber.org/mock/gomock"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+func TestAuthorizeUserRevokedToken(t *testing.T) {
+	user, _ := randomUser(t)
+	server := newTestServer(t, nil, nil)
+
+	newContext := func(sessionID uuid.UUID) context.Context {
+		accessToken, _, err := server.tokenMaker.CreateSessionToken(user.Username, user.Role, sessionID, time.Minute)
+		require.NoError(t, err)
+
+		md := metadata.MD{
+			authorizationHeader: []string{fmt.Sprintf("%s %s", authorizationBearer, accessToken)},
+		}
+		return metadata.NewIncomingContext(context.Background(), md)
+	}
+
+	roles := []string{util.DepositorRole}
+
+	sessionID := uuid.New()
+	ctx := newContext(sessionID)
+	_, err := server.authorizeUser(ctx, roles)
+	require.NoError(t, err)
+
+	// revoking the session revokes its access tokens but not those of other sessions
+	err = server.denylist.RevokeID(context.Background(), sessionID)
+	require.NoError(t, err)
+	_, err = server.authorizeUser(ctx, roles)
+	require.Error(t, err)
+
+	otherCtx := newContext(uuid.New())
+	_, err = server.authorizeUser(otherCtx, roles)
+	require.NoError(t, err)
+
+}
+
+func TestAuthorizeUserPasswordChanged(t *testing.T) {
+	user, _ := randomUser(t)
+	server := newTestServer(t, nil, nil)
+
+	accessToken, _, err := server.tokenMaker.CreateSessionToken(user.Username, user.Role, uuid.New(), time.Minute)
+	require.NoError(t, err)
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	store := mockdb.NewMockStore(ctrl)
+	server.store = store
+
+	passwordChangedAt := time.Now()
+	store.EXPECT().
+		GetUserPasswordChangedAt(gomock.Any(), gomock.Eq(user.Username)).
+		Times(2).
+		Return(passwordChangedAt, nil)
+
+	// changing the password rejects every token issued before the change, whatever the denylist remembers
+	_, err = server.authorizeUser(newContextWithToken(accessToken), []string{util.DepositorRole})
+	require.Error(t, err)
+
+	newAccessToken, _, err := server.tokenMaker.CreateSessionToken(user.Username, user.Role, uuid.New(), time.Minute)
+	require.NoError(t, err)
+
+	_, err = server.authorizeUser(newContextWithToken(newAccessToken), []string{util.DepositorRole})
+	require.NoError(t, err)
+}
+
+func TestAuthorizeUserRefreshToken(t *testing.T) {
+	user, _ := randomUser(t)
+	server := newTestServer(t, nil, nil)
+
+	familyID := uuid.New()
+	refreshToken, _, err := server.tokenMaker.CreateRefreshToken(user.Username, user.Role, familyID, time.Minute)
+	require.NoError(t, err)
+
+	// a refresh token is not an access token, even while its session is still live
+	_, err = server.authorizeUser(newContextWithToken(refreshToken), []string{util.DepositorRole})
+	require.Error(t, err)
+
+	challengeToken, _, err := server.tokenMaker.CreateToken(user.Username, util.TotpChallengeRole, time.Minute)
+	require.NoError(t, err)
+
+	_, err = server.authorizeUser(newContextWithToken(challengeToken), []string{util.DepositorRole, util.TotpChallengeRole})
+	require.Error(t, err)
+}
+
+func TestRequireStepUp(t *testing.T) {
+	user, _ := randomUser(t)
+	server := newTestServer(t, nil, nil)
+	server.config.StepUpTransferThresholds = util.CurrencyAmounts{
+		util.USD: 1000,
+		util.JPY: 150000,
+		util.KWD: 300,
+	}
+	server.config.ElevatedTokenDuration = time.Minute
+
+	accessToken, _, err := server.tokenMaker.CreateSessionToken(user.Username, user.Role, uuid.New(), time.Minute)
+	require.NoError(t, err)
+	payload, err := server.tokenMaker.VerifyToken(accessToken)
+	require.NoError(t, err)
+
+	testCases := []struct {
+		name       string
+		amount     int64
+		currency   string
+		needStepUp bool
+	}{
+		{name: "USDAtThreshold", amount: 1000_00, currency: util.USD, needStepUp: false},
+		{name: "USDAboveThreshold", amount: 1000_01, currency: util.USD, needStepUp: true},
+		// the same 1000 whole units that would need step-up in USD are only a few dollars in JPY
+		{name: "JPYBelowThreshold", amount: 100000, currency: util.JPY, needStepUp: false},
+		{name: "JPYAboveThreshold", amount: 150001, currency: util.JPY, needStepUp: true},
+		{name: "KWDAboveThreshold", amount: 300_001, currency: util.KWD, needStepUp: true},
+		{name: "CurrencyWithoutThreshold", amount: 1, currency: util.EUR, needStepUp: true},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			err := server.requireStepUp(payload, tc.amount, tc.currency)
+			if tc.needStepUp {
+				require.Error(t, err)
+				st, ok := status.FromError(err)
+				require.True(t, ok)
+				require.Equal(t, codes.PermissionDenied, st.Code())
+			} else {
+				require.NoError(t, err)
+			}
+		})
+	}
+
+	// no thresholds turn step-up off
+	server.config.StepUpTransferThresholds = nil
+	require.NoError(t, server.requireStepUp(payload, 1000_000_00, util.USD))
+}
@@ -2,7 +2,9 @@ package gapi
 
 import (
 	db "github.com/tonisco/simple-bank-go/db/sqlc"
+	"github.com/tonisco/simple-bank-go/fx"
 	"github.com/tonisco/simple-bank-go/pb"
+	"github.com/tonisco/simple-bank-go/util"
 	"google.golang.org/protobuf/types/known/timestamppb"
 )
 
@@ -16,3 +18,142 @@ func convertUser(user db.User) *pb.User {
 		CreatedAt:         timestamppb.New(user.CreatedAt),
 	}
 }
+
+func convertAccount(account db.Account) *pb.Account {
+	rsp := &pb.Account{
+		Id:               account.ID,
+		Owner:            account.Owner,
+		Balance:          account.Balance,
+		Currency:         account.Currency,
+		CreatedAt:        timestamppb.New(account.CreatedAt),
+		OverdraftLimit:   account.OverdraftLimit,
+		AvailableBalance: account.AvailableBalance(),
+		Status:           account.Status,
+	}
+
+	if balance, err := util.NewMoney(account.Balance, account.Currency); err == nil {
+		rsp.BalanceDecimal = balance.Decimal()
+	}
+	if available, err := util.NewMoney(account.AvailableBalance(), account.Currency); err == nil {
+		rsp.AvailableBalanceDecimal = available.Decimal()
+	}
+
+	return rsp
+}
+
+// convertTransfer fills in to_amount for every transfer; exchange_rate is only set between currencies
+func convertTransfer(transfer db.Transfer) *pb.Transfer {
+	rsp := &pb.Transfer{
+		Id:            transfer.ID,
+		FromAccountId: transfer.FromAccountID,
+		ToAccountId:   transfer.ToAccountID,
+		Amount:        transfer.Amount,
+		CreatedAt:     timestamppb.New(transfer.CreatedAt),
+		ToAmount:      transfer.Amount,
+	}
+
+	if transfer.ToAmount.Valid {
+		rsp.ToAmount = transfer.ToAmount.Int64
+	}
+	if transfer.ExchangeRate.Valid {
+		rsp.ExchangeRate = fx.FormatRate(transfer.ExchangeRate.Int64)
+	}
+
+	return rsp
+}
+
+func convertEntry(entry db.Entry) *pb.Entry {
+	return &pb.Entry{
+		Id:        entry.ID,
+		AccountId: entry.AccountID,
+		Amount:    entry.Amount,
+		CreatedAt: timestamppb.New(entry.CreatedAt),
+	}
+}
+
+func convertStatementEntry(entry db.ListStatementEntriesRow) *pb.StatementEntry {
+	return &pb.StatementEntry{
+		Id:             entry.ID,
+		Amount:         entry.Amount,
+		RunningBalance: entry.RunningBalance,
+		CreatedAt:      timestamppb.New(entry.CreatedAt),
+	}
+}
+
+func convertFxQuote(quote db.FxQuote) *pb.FxQuote {
+	return &pb.FxQuote{
+		Id:           quote.ID.String(),
+		FromCurrency: quote.FromCurrency,
+		ToCurrency:   quote.ToCurrency,
+		Rate:         fx.FormatRate(quote.Rate),
+		ExpiresAt:    timestamppb.New(quote.ExpiresAt),
+	}
+}
+
+func convertScheduledTransfer(scheduled db.ScheduledTransfer) *pb.ScheduledTransfer {
+	return &pb.ScheduledTransfer{
+		Id:            scheduled.ID,
+		Owner:         scheduled.Owner,
+		FromAccountId: scheduled.FromAccountID,
+		ToAccountId:   scheduled.ToAccountID,
+		Amount:        scheduled.Amount,
+		Schedule:      scheduled.Schedule,
+		Status:        scheduled.Status,
+		NextRunAt:     timestamppb.New(scheduled.NextRunAt),
+		CreatedAt:     timestamppb.New(scheduled.CreatedAt),
+	}
+}
+
+func convertScheduledTransferRun(run db.ScheduledTransferRun) *pb.ScheduledTransferRun {
+	return &pb.ScheduledTransferRun{
+		Id:                  run.ID,
+		ScheduledTransferId: run.ScheduledTransferID,
+		RunAt:               timestamppb.New(run.RunAt),
+		Status:              run.Status,
+		TransferId:          run.TransferID.Int64,
+		Error:               run.Error,
+	}
+}
+
+func convertAccountTransferLimits(limit db.AccountTransferLimit) *pb.AccountTransferLimits {
+	limits := &pb.AccountTransferLimits{
+		AccountId: limit.AccountID,
+		UpdatedAt: timestamppb.New(limit.UpdatedAt),
+	}
+	if limit.PerTransfer.Valid {
+		limits.PerTransfer = &limit.PerTransfer.Int64
+	}
+	if limit.Daily.Valid {
+		limits.Daily = &limit.Daily.Int64
+	}
+	if limit.Monthly.Valid {
+		limits.Monthly = &limit.Monthly.Int64
+	}
+	return limits
+}
+
+func convertHold(hold db.Hold) *pb.Hold {
+	return &pb.Hold{
+		Id:          hold.ID,
+		AccountId:   hold.AccountID,
+		ToAccountId: hold.ToAccountID,
+		Amount:      hold.Amount,
+		Status:      hold.Status,
+		TransferId:  hold.TransferID.Int64,
+		ExpiresAt:   timestamppb.New(hold.ExpiresAt),
+		CreatedAt:   timestamppb.New(hold.CreatedAt),
+	}
+}
+
+// convertSession leaves out the refresh token, which must never be handed back out
+func convertSession(session db.Session) *pb.Session {
+	return &pb.Session{
+		Id:        session.ID.String(),
+		Username:  session.Username,
+		UserAgent: session.UserAgent,
+		ClientIp:  session.ClientIp,
+		IsBlocked: session.IsBlocked,
+		ExpiresAt: timestamppb.New(session.ExpiresAt),
+		CreatedAt: timestamppb.New(session.CreatedAt),
+	}
+}
@@ -1,9 +1,15 @@
 package gapi
 
 import (
+	"net/http"
+	"strconv"
+
+	"github.com/grpc-ecosystem/grpc-gateway/v2/runtime"
+	db "github.com/tonisco/simple-bank-go/db/sqlc"
 	"google.golang.org/genproto/googleapis/rpc/errdetails"
 	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/encoding/protojson"
 )
 
 func fieldViolation(field string, err error) *errdetails.BadRequest_FieldViolation {
@@ -28,3 +34,47 @@ func invalidArgumentError(violations []*errdetails.BadRequest_FieldViolation) er
 func unauthenticatedError(err error) error {
 	return status.Errorf(codes.Unauthenticated, "unauthorized: %s", err)
 }
+
+// transferLimitError reports a broken transfer limit as ResourceExhausted,
+// with the limit and the remaining allowance in minor units as error details
+func transferLimitError(limitErr *db.TransferLimitError) error {
+	statusExhausted := status.New(codes.ResourceExhausted, limitErr.Error())
+
+	statusDetails, err := statusExhausted.WithDetails(
+		&errdetails.QuotaFailure{
+			Violations: []*errdetails.QuotaFailure_Violation{{
+				Subject:     limitErr.Period,
+				Description: limitErr.Error(),
+			}},
+		},
+		&errdetails.ErrorInfo{
+			Reason: "TRANSFER_LIMIT_EXCEEDED",
+			Metadata: map[string]string{
+				"period":    limitErr.Period,
+				"currency":  limitErr.Currency,
+				"limit":     strconv.FormatInt(limitErr.Limit, 10),
+				"remaining": strconv.FormatInt(limitErr.Remaining, 10),
+			},
+		},
+	)
+	if err != nil {
+		return statusExhausted.Err()
+	}
+
+	return statusDetails.Err()
+}
+
+// writeHTTPError writes a gRPC status error the same way the gateway does, for handlers served on the mux directly
+func writeHTTPError(w http.ResponseWriter, err error) {
+	st := status.Convert(err)
+
+	body, marshalErr := protojson.Marshal(st.Proto())
+	if marshalErr != nil {
+		http.Error(w, st.Message(), runtime.HTTPStatusFromCode(st.Code()))
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(runtime.HTTPStatusFromCode(st.Code()))
+	w.Write(body)
+}
@@ -0,0 +1,124 @@
+package gapi
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/rs/zerolog/log"
+	db "github.com/tonisco/simple-bank-go/db/sqlc"
+	"github.com/tonisco/simple-bank-go/statement"
+	"github.com/tonisco/simple-bank-go/util"
+	"github.com/tonisco/simple-bank-go/val"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// ExportAccountStatementPath is where ExportAccountStatement is served on the gateway mux
+const ExportAccountStatementPath = "/v1/accounts/{id}/statement/export"
+
+// ExportAccountStatement streams the statement of an account as a CSV, OFX or camt.053 file download.
+// It is a plain HTTP handler for the gateway mux since file downloads do not fit a gRPC response.
+// The range is given by the from and to query parameters in RFC 3339 and the file type by format
+func (server *Server) ExportAccountStatement(w http.ResponseWriter, r *http.Request, pathParams map[string]string) {
+	authPayload, err := server.authorizeHeader(r.Context(), r.Header.Get(authorizationHeader), []string{util.BankerRole, util.DepositorRole})
+
+	if err != nil {
+		writeHTTPError(w, unauthenticatedError(err))
+		return
+	}
+
+	accountID, from, to, exporter, violations := parseExportAccountStatementRequest(r, pathParams)
+
+	if violations != nil {
+		writeHTTPError(w, invalidArgumentError(violations))
+		return
+	}
+
+	account, err := server.store.GetAccount(r.Context(), accountID)
+
+	if err != nil {
+		if errors.Is(err, db.ErrRecordNotFound) {
+			writeHTTPError(w, status.Errorf(codes.NotFound, "account not found"))
+			return
+		}
+		writeHTTPError(w, status.Errorf(codes.Internal, "failed to get account: %s", err))
+		return
+	}
+
+	if !isAccountOwnerOrBanker(authPayload, account.Owner) {
+		writeHTTPError(w, status.Errorf(codes.PermissionDenied, "account does not belong to the authenticated user"))
+		return
+	}
+
+	period, err := server.store.GetAccountStatement(r.Context(), db.AccountStatementParams{
+		AccountID: account.ID,
+		From:      from,
+		To:        to,
+	})
+
+	if err != nil {
+		writeHTTPError(w, status.Errorf(codes.Internal, "failed to get account statement: %s", err))
+		return
+	}
+
+	stmt := statement.Statement{
+		Account:     account,
+		Period:      period,
+		GeneratedAt: time.Now(),
+	}
+
+	w.Header().Set("Content-Type", exporter.ContentType())
+	w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%q", statement.FileName(exporter, stmt)))
+	w.WriteHeader(http.StatusOK)
+
+	// the headers are already sent so a failure can only be logged
+	if err := exporter.Export(w, stmt); err != nil {
+		log.Error().Err(err).Int64("account_id", account.ID).Msg("failed to export account statement")
+	}
+}
+
+func parseExportAccountStatementRequest(r *http.Request, pathParams map[string]string) (
+	accountID int64,
+	from time.Time,
+	to time.Time,
+	exporter statement.Exporter,
+	violations []*errdetails.BadRequest_FieldViolation,
+) {
+	query := r.URL.Query()
+
+	accountID, err := strconv.ParseInt(pathParams["id"], 10, 64)
+	if err == nil {
+		err = val.ValidateID(accountID)
+	}
+	if err != nil {
+		violations = append(violations, fieldViolation("id", err))
+	}
+
+	from, err = time.Parse(time.RFC3339, query.Get("from"))
+	if err != nil {
+		violations = append(violations, fieldViolation("from", err))
+	}
+
+	to, err = time.Parse(time.RFC3339, query.Get("to"))
+	if err == nil {
+		err = val.ValidateTimeRange(from, to)
+	}
+	if err != nil {
+		violations = append(violations, fieldViolation("to", err))
+	}
+
+	format := query.Get("format")
+	if format == "" {
+		format = statement.FormatCSV
+	}
+	exporter, err = statement.NewExporter(format)
+	if err != nil {
+		violations = append(violations, fieldViolation("format", err))
+	}
+
+	return
+}
@@ -0,0 +1,162 @@
+package gapi
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/require"
+	mockdb "github.com/tonisco/simple-bank-go/db/mock"
+	db "github.com/tonisco/simple-bank-go/db/sqlc"
+	"github.com/tonisco/simple-bank-go/token"
+	"github.com/tonisco/simple-bank-go/util"
+	"go.uber.org/mock/gomock"
+)
+
+func TestExportAccountStatementAPI(t *testing.T) {
+	user, _ := randomUser(t)
+	other, _ := randomUser(t)
+
+	account := randomAccount(user.Username)
+	account.Currency = util.USD
+	to := time.Now().UTC().Truncate(time.Second)
+	from := to.Add(-24 * time.Hour)
+
+	statement := db.AccountStatement{
+		AccountID:      account.ID,
+		From:           from,
+		To:             to,
+		OpeningBalance: 100,
+		ClosingBalance: 150,
+		Entries: []db.ListStatementEntriesRow{
+			{ID: 1, AccountID: account.ID, Amount: 50, RunningBalance: 150, CreatedAt: from.Add(time.Hour)},
+		},
+	}
+
+	validQuery := fmt.Sprintf("from=%s&to=%s", from.Format(time.RFC3339), to.Format(time.RFC3339))
+
+	testCases := []struct {
+		name          string
+		query         string
+		setAuth       func(t *testing.T, request *http.Request, tokenMaker token.Maker)
+		buildStubs    func(store *mockdb.MockStore)
+		checkResponse func(t *testing.T, recorder *httptest.ResponseRecorder)
+	}{
+		{
+			name:  "CSV",
+			query: validQuery,
+			setAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				addBearerToken(t, request, tokenMaker, user.Username, user.Role)
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account.ID)).Times(1).Return(account, nil)
+				store.EXPECT().GetAccountStatement(gomock.Any(), gomock.Any()).Times(1).Return(statement, nil)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+				require.Equal(t, "text/csv; charset=utf-8", recorder.Header().Get("Content-Type"))
+				require.Contains(t, recorder.Header().Get("Content-Disposition"), "attachment")
+				require.Contains(t, recorder.Body.String(), "Deposit,0.50,1.50,USD")
+			},
+		},
+		{
+			name:  "Camt053",
+			query: validQuery + "&format=camt053",
+			setAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				addBearerToken(t, request, tokenMaker, user.Username, user.Role)
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account.ID)).Times(1).Return(account, nil)
+				store.EXPECT().GetAccountStatement(gomock.Any(), gomock.Any()).Times(1).Return(statement, nil)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+				require.Equal(t, "application/xml", recorder.Header().Get("Content-Type"))
+				require.Contains(t, recorder.Body.String(), "camt.053.001.02")
+			},
+		},
+		{
+			name:  "UnsupportedFormat",
+			query: validQuery + "&format=pdf",
+			setAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				addBearerToken(t, request, tokenMaker, user.Username, user.Role)
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
+		{
+			name:  "MissingRange",
+			query: "",
+			setAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				addBearerToken(t, request, tokenMaker, user.Username, user.Role)
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
+		{
+			name:  "PermissionDenied",
+			query: validQuery,
+			setAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				addBearerToken(t, request, tokenMaker, other.Username, other.Role)
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account.ID)).Times(1).Return(account, nil)
+				store.EXPECT().GetAccountStatement(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusForbidden, recorder.Code)
+			},
+		},
+		{
+			name:  "NoAuthorization",
+			query: validQuery,
+			setAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusUnauthorized, recorder.Code)
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			storeCtrl := gomock.NewController(t)
+			defer storeCtrl.Finish()
+			store := mockdb.NewMockStore(storeCtrl)
+
+			tc.buildStubs(store)
+			server := newTestServer(t, store, nil)
+
+			url := fmt.Sprintf("/v1/accounts/%d/statement/export?%s", account.ID, tc.query)
+			request := httptest.NewRequest(http.MethodGet, url, nil)
+			tc.setAuth(t, request, server.tokenMaker)
+
+			recorder := httptest.NewRecorder()
+			server.ExportAccountStatement(recorder, request, map[string]string{"id": fmt.Sprint(account.ID)})
+			tc.checkResponse(t, recorder)
+		})
+	}
+}
+
+func addBearerToken(t *testing.T, request *http.Request, tokenMaker token.Maker, username string, role string) {
+	accessToken, _, err := tokenMaker.CreateSessionToken(username, role, uuid.New(), time.Minute)
+	require.NoError(t, err)
+
+	request.Header.Set(authorizationHeader, fmt.Sprintf("%s %s", authorizationBearer, accessToken))
+}
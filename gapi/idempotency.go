@@ -0,0 +1,60 @@
+package gapi
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"strings"
+
+	"github.com/grpc-ecosystem/grpc-gateway/v2/runtime"
+	db "github.com/tonisco/simple-bank-go/db/sqlc"
+	"github.com/tonisco/simple-bank-go/val"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/protobuf/proto"
+)
+
+const idempotencyKeyHeader = "idempotency-key"
+
+// HeaderMatcher forwards the Idempotency-Key HTTP header to the gRPC handlers
+// on top of the headers the gateway forwards by default
+func HeaderMatcher(key string) (string, bool) {
+	if strings.EqualFold(key, idempotencyKeyHeader) {
+		return idempotencyKeyHeader, true
+	}
+	return runtime.DefaultHeaderMatcher(key)
+}
+
+// extractIdempotency reads the idempotency key from the metadata and fingerprints the request it is attached to.
+// It returns nil when the client did not send a key
+func (server *Server) extractIdempotency(ctx context.Context, username string, method string, req proto.Message) (*db.IdempotencyParams, error) {
+	md, ok := metadata.FromIncomingContext(ctx)
+	if !ok {
+		return nil, nil
+	}
+
+	values := md.Get(idempotencyKeyHeader)
+	if len(values) == 0 {
+		return nil, nil
+	}
+
+	key := values[0]
+	if err := val.ValidateIdempotencyKey(key); err != nil {
+		return nil, err
+	}
+
+	body, err := proto.MarshalOptions{Deterministic: true}.Marshal(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode request: %w", err)
+	}
+
+	hash := sha256.New()
+	hash.Write([]byte(method + "\n"))
+	hash.Write(body)
+
+	return &db.IdempotencyParams{
+		Key:         key,
+		Username:    username,
+		RequestHash: hex.EncodeToString(hash.Sum(nil)),
+	}, nil
+}
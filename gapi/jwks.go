@@ -0,0 +1,37 @@
+package gapi
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/rs/zerolog/log"
+	"github.com/tonisco/simple-bank-go/token"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// JWKSPath is where JWKS is served on the gateway mux
+const JWKSPath = "/.well-known/jwks.json"
+
+// JWKSMaxAge is how long verifiers may cache the key set. A new key must be added to the keyring as a non-active key
+// and served for at least this long before it is made active, or tokens signed with it fail until the caches expire
+const JWKSMaxAge = 5 * time.Minute
+
+// JWKS publishes the public keys tokens are signed with, so other services can verify them.
+// Symmetric keys cannot be published, so it is not found unless the token maker uses public keys
+func (server *Server) JWKS(w http.ResponseWriter, r *http.Request, pathParams map[string]string) {
+	if server.keyring == nil || !token.IsPublicKeyMaker(server.config.TokenMakerType) {
+		writeHTTPError(w, status.Errorf(codes.NotFound, "token signing keys are not public"))
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", fmt.Sprintf("public, max-age=%d", int(JWKSMaxAge.Seconds())))
+	w.WriteHeader(http.StatusOK)
+
+	if err := json.NewEncoder(w).Encode(server.keyring.JWKS()); err != nil {
+		log.Error().Err(err).Msg("failed to write jwks")
+	}
+}
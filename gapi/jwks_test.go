@@ -0,0 +1,80 @@
+package gapi
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/x509"
+	"encoding/json"
+	"encoding/pem"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"github.com/tonisco/simple-bank-go/token"
+	"github.com/tonisco/simple-bank-go/util"
+)
+
+func TestJWKSAPI(t *testing.T) {
+	_, privateKey, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+
+	privateKeyDER, err := x509.MarshalPKCS8PrivateKey(privateKey)
+	require.NoError(t, err)
+	privateKeyFile := filepath.Join(t.TempDir(), "token.pem")
+	require.NoError(t, os.WriteFile(privateKeyFile, pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: privateKeyDER}), 0600))
+
+	testCases := []struct {
+		name          string
+		config        util.Config
+		checkResponse func(t *testing.T, recorder *httptest.ResponseRecorder)
+	}{
+		{
+			name: "PublicKeys",
+			config: util.Config{
+				TokenMakerType:      token.MakerJWTEdDSA,
+				TokenPrivateKeyFile: privateKeyFile,
+				AccessTokenDuration: time.Minute,
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+				require.Equal(t, "application/json", recorder.Header().Get("Content-Type"))
+				require.Equal(t, "public, max-age=300", recorder.Header().Get("Cache-Control"))
+
+				var jwks token.JSONWebKeySet
+				require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &jwks))
+				require.Len(t, jwks.Keys, 1)
+				require.Equal(t, "OKP", jwks.Keys[0].KeyType)
+				require.Equal(t, "EdDSA", jwks.Keys[0].Algorithm)
+			},
+		},
+		{
+			name: "SymmetricKey",
+			config: util.Config{
+				TokenSymmetricKey:   util.RandomString(32),
+				AccessTokenDuration: time.Minute,
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusNotFound, recorder.Code)
+				require.NotContains(t, recorder.Body.String(), "kty")
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			server, err := NewServer(tc.config, nil, nil, nil, token.NewMemoryDenylist(time.Hour))
+			require.NoError(t, err)
+
+			request := httptest.NewRequest(http.MethodGet, JWKSPath, nil)
+			recorder := httptest.NewRecorder()
+			server.JWKS(recorder, request, nil)
+			tc.checkResponse(t, recorder)
+		})
+	}
+}
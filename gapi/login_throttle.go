@@ -0,0 +1,123 @@
+package gapi
+
+import (
+	"context"
+	"time"
+
+	"github.com/hibiken/asynq"
+	"github.com/rs/zerolog/log"
+	db "github.com/tonisco/simple-bank-go/db/sqlc"
+	"github.com/tonisco/simple-bank-go/worker"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/durationpb"
+)
+
+// the same error is returned for an unknown username and a wrong password,
+// so that logins cannot be used to find out who has an account
+const loginFailedMessage = "incorrect username or password"
+
+// checkLoginLock returns a ResourceExhausted error while the username or the client ip is locked out
+func (server *Server) checkLoginLock(ctx context.Context, username string, clientIP string) error {
+	locks, err := server.store.ListActiveLoginLocks(ctx, db.ListActiveLoginLocksParams{
+		Username: username,
+		ClientIp: clientIP,
+	})
+	if err != nil {
+		return status.Errorf(codes.Internal, "failed to check login lockout: %s", err)
+	}
+
+	if len(locks) == 0 {
+		return nil
+	}
+
+	// the locks are sorted so the first one lasts the longest
+	lockedUntil := locks[0].LockedUntil.Time
+	statusExhausted := status.Newf(codes.ResourceExhausted,
+		"too many failed login attempts, try again after %s", lockedUntil.UTC().Format(time.RFC3339))
+
+	statusDetails, err := statusExhausted.WithDetails(&errdetails.RetryInfo{
+		RetryDelay: durationpb.New(time.Until(lockedUntil)),
+	})
+	if err != nil {
+		return statusExhausted.Err()
+	}
+
+	return statusDetails.Err()
+}
+
+// failLogin counts a failed login against the username and the client ip, emails the user if that locked
+// their account, and returns the error for the failed login. user is nil when no such user exists
+func (server *Server) failLogin(ctx context.Context, username string, clientIP string, user *db.User) error {
+	if err := server.recordFailedLogin(ctx, username, clientIP, user); err != nil {
+		return err
+	}
+
+	return status.Error(codes.Unauthenticated, loginFailedMessage)
+}
+
+// failCode counts a wrong TOTP code, recovery code or re-entered password against the user and the client ip,
+// so they run into the same lockout as failed logins, and returns err. Errors other than Unauthenticated are
+// returned as they are
+func (server *Server) failCode(ctx context.Context, user *db.User, clientIP string, err error) error {
+	if status.Code(err) != codes.Unauthenticated {
+		return err
+	}
+
+	if recordErr := server.recordFailedLogin(ctx, user.Username, clientIP, user); recordErr != nil {
+		return recordErr
+	}
+
+	return err
+}
+
+// resetLoginThrottle forgets the failed attempts of a user once they have proved who they are
+func (server *Server) resetLoginThrottle(ctx context.Context, username string) error {
+	// the failed attempts of the client ip are kept, or an attacker could clear them by logging in to their own account
+	err := server.store.DeleteLoginThrottle(ctx, db.DeleteLoginThrottleParams{
+		Scope:   db.LoginThrottleScopeUser,
+		Subject: username,
+	})
+	if err != nil {
+		return status.Errorf(codes.Internal, "failed to reset failed login attempts")
+	}
+
+	return nil
+}
+
+// recordFailedLogin counts a failed attempt against the username and the client ip and emails the user
+// if that locked their account
+func (server *Server) recordFailedLogin(ctx context.Context, username string, clientIP string, user *db.User) error {
+	result, err := server.store.RecordFailedLoginTx(ctx, db.RecordFailedLoginTxParams{
+		Username:           username,
+		ClientIP:           clientIP,
+		MaxUserAttempts:    server.config.LoginMaxAttempts,
+		MaxIPAttempts:      server.config.LoginMaxAttemptsPerIP,
+		LockoutDuration:    server.config.LoginLockoutDuration,
+		MaxLockoutDuration: server.config.LoginMaxLockoutDuration,
+		AttemptWindow:      server.config.LoginAttemptWindow,
+	})
+	if err != nil {
+		return status.Errorf(codes.Internal, "failed to record failed login: %s", err)
+	}
+
+	if result.UserLocked && user != nil {
+		payload := &worker.PayloadSendLockoutEmail{
+			Username:    user.Username,
+			LockedUntil: result.UserThrottle.LockedUntil.Time,
+		}
+		opts := []asynq.Option{
+			asynq.MaxRetry(10),
+			asynq.Queue(worker.QueueCritical),
+		}
+
+		// the attempt has failed either way, so a lost email is only logged
+		err = server.taskDistributor.DistributeTaskSendLockoutEmail(ctx, payload, opts...)
+		if err != nil {
+			log.Error().Err(err).Str("username", user.Username).Msg("failed to send lockout email")
+		}
+	}
+
+	return nil
+}
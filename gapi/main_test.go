@@ -6,11 +6,15 @@ import (
 	"testing"
 	"time"
 
+	"github.com/google/uuid"
 	"github.com/stretchr/testify/require"
+	mockdb "github.com/tonisco/simple-bank-go/db/mock"
 	db "github.com/tonisco/simple-bank-go/db/sqlc"
+	"github.com/tonisco/simple-bank-go/fx"
 	"github.com/tonisco/simple-bank-go/token"
 	"github.com/tonisco/simple-bank-go/util"
 	"github.com/tonisco/simple-bank-go/worker"
+	"go.uber.org/mock/gomock"
 	"google.golang.org/grpc/metadata"
 )
 
@@ -18,18 +22,48 @@ func newTestServer(t *testing.T, store db.Store, taskDistributor worker.TaskDist
 	config := util.Config{
 		TokenSymmetricKey:   util.RandomString(32),
 		AccessTokenDuration: time.Minute,
+		FXQuoteDuration:     30 * time.Second,
+		HoldDuration:        24 * time.Hour,
 	}
 
-	server, err := NewServer(config, store, taskDistributor)
+	rates, err := fx.NewStaticRateProvider(map[string]map[string]string{
+		util.USD: {util.EUR: "0.92"},
+	})
+	require.NoError(t, err)
+
+	if store == nil {
+		store = mockdb.NewMockStore(gomock.NewController(t))
+	}
+
+	// every authorized request checks that the token was issued after the last password change
+	if mockStore, ok := store.(*mockdb.MockStore); ok {
+		mockStore.EXPECT().
+			GetUserPasswordChangedAt(gomock.Any(), gomock.Any()).
+			AnyTimes().
+			Return(time.Time{}, nil)
+	}
+
+	server, err := NewServer(config, store, taskDistributor, rates, token.NewMemoryDenylist(time.Hour))
 	require.NoError(t, err)
 
 	return server
 }
 
 func newContextWithBearerToken(t *testing.T, tokenMaker token.Maker, username string, role string, duration time.Duration) context.Context {
-	accessToken, _, err := tokenMaker.CreateToken(username, role, duration)
+	accessToken, _, err := tokenMaker.CreateSessionToken(username, role, uuid.New(), duration)
 	require.NoError(t, err)
 
+	return newContextWithToken(accessToken)
+}
+
+func newContextWithElevatedToken(t *testing.T, tokenMaker token.Maker, username string, role string, duration time.Duration) context.Context {
+	elevatedToken, _, err := tokenMaker.CreateElevatedToken(username, role, uuid.New(), duration)
+	require.NoError(t, err)
+
+	return newContextWithToken(elevatedToken)
+}
+
+func newContextWithToken(accessToken string) context.Context {
 	bearerToken := fmt.Sprintf("%s %s", authorizationBearer, accessToken)
 	md := metadata.MD{
 		authorizationHeader: []string{
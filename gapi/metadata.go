@@ -2,6 +2,9 @@ package gapi
 
 import (
 	"context"
+	"fmt"
+	"net"
+	"strings"
 
 	"google.golang.org/grpc/metadata"
 	"google.golang.org/grpc/peer"
@@ -21,6 +24,7 @@ type Metadata struct {
 func (server *Server) extractMetadata(ctx context.Context) *Metadata {
 	mtdt := &Metadata{}
 
+	var forwardedFor []string
 	if md, ok := metadata.FromIncomingContext(ctx); ok {
 		if userAgents := md.Get(grpcGatewayUserAgentHeader); len(userAgents) > 0 {
 			mtdt.UserAgent = userAgents[0]
@@ -30,14 +34,84 @@ func (server *Server) extractMetadata(ctx context.Context) *Metadata {
 			mtdt.UserAgent = userAgents[0]
 		}
 
-		if clientIPs := md.Get(xForwardedForHeader); len(clientIPs) > 0 {
-			mtdt.ClientIP = clientIPs[0]
+		for _, value := range md.Get(xForwardedForHeader) {
+			forwardedFor = append(forwardedFor, strings.Split(value, ",")...)
 		}
 	}
 
+	// the gateway runs in process, so its requests have no peer and it appends the remote address of the http
+	// request to x-forwarded-for. Direct gRPC requests have a peer, which is forwarding only if it is a trusted proxy
 	if p, ok := peer.FromContext(ctx); ok {
-		mtdt.ClientIP = p.Addr.String()
+		forwardedFor = append(forwardedFor, p.Addr.String())
 	}
 
+	mtdt.ClientIP = server.clientIP(forwardedFor)
 	return mtdt
 }
+
+// clientIP walks the hops from the closest one back and returns the first that is not a trusted proxy,
+// as every hop before it may have been made up by the client
+func (server *Server) clientIP(hops []string) string {
+	clientIP := ""
+	for i := len(hops) - 1; i >= 0; i-- {
+		clientIP = hopIP(hops[i])
+		if !server.isTrustedProxy(clientIP) {
+			break
+		}
+	}
+	return clientIP
+}
+
+// hopIP drops the port from a hop, so every connection from a host counts as the same ip
+func hopIP(hop string) string {
+	hop = strings.TrimSpace(hop)
+	if host, _, err := net.SplitHostPort(hop); err == nil {
+		return host
+	}
+	return hop
+}
+
+func (server *Server) isTrustedProxy(ip string) bool {
+	parsed := net.ParseIP(ip)
+	if parsed == nil {
+		return false
+	}
+
+	for _, network := range server.trustedProxies {
+		if network.Contains(parsed) {
+			return true
+		}
+	}
+	return false
+}
+
+// parseTrustedProxies parses ips and cidrs, a single ip is trusted on its own
+func parseTrustedProxies(proxies []string) ([]*net.IPNet, error) {
+	networks := make([]*net.IPNet, 0, len(proxies))
+	for _, proxy := range proxies {
+		proxy = strings.TrimSpace(proxy)
+		if proxy == "" {
+			continue
+		}
+
+		if !strings.Contains(proxy, "/") {
+			ip := net.ParseIP(proxy)
+			if ip == nil {
+				return nil, fmt.Errorf("invalid trusted proxy %q", proxy)
+			}
+			bits := 8 * net.IPv6len
+			if ip.To4() != nil {
+				ip, bits = ip.To4(), 8*net.IPv4len
+			}
+			networks = append(networks, &net.IPNet{IP: ip, Mask: net.CIDRMask(bits, bits)})
+			continue
+		}
+
+		_, network, err := net.ParseCIDR(proxy)
+		if err != nil {
+			return nil, fmt.Errorf("invalid trusted proxy %q: %w", proxy, err)
+		}
+		networks = append(networks, network)
+	}
+	return networks, nil
+}
@@ -0,0 +1,66 @@
+package gapi
+
+import (
+	"context"
+	"net"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+)
+
+func TestExtractMetadataClientIP(t *testing.T) {
+	testCases := []struct {
+		name           string
+		trustedProxies []string
+		forwardedFor   string
+		peerAddr       net.Addr
+		clientIP       string
+	}{
+		{
+			// the gateway appends the remote address, so whatever the client put before it is ignored
+			name:         "GatewaySpoofedForwardedFor",
+			forwardedFor: "198.51.100.1, 203.0.113.7",
+			clientIP:     "203.0.113.7",
+		},
+		{
+			name:           "GatewayBehindTrustedProxy",
+			trustedProxies: []string{"10.0.0.0/8"},
+			forwardedFor:   "198.51.100.1, 203.0.113.7, 10.0.0.2",
+			clientIP:       "203.0.113.7",
+		},
+		{
+			name:         "GRPCSpoofedForwardedFor",
+			forwardedFor: "198.51.100.1",
+			peerAddr:     &net.TCPAddr{IP: net.ParseIP("203.0.113.7"), Port: 54321},
+			clientIP:     "203.0.113.7",
+		},
+		{
+			name:           "GRPCTrustedPeer",
+			trustedProxies: []string{"10.0.0.2"},
+			forwardedFor:   "203.0.113.7",
+			peerAddr:       &net.TCPAddr{IP: net.ParseIP("10.0.0.2"), Port: 54321},
+			clientIP:       "203.0.113.7",
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			server := newTestServer(t, nil, nil)
+
+			trustedProxies, err := parseTrustedProxies(tc.trustedProxies)
+			require.NoError(t, err)
+			server.trustedProxies = trustedProxies
+
+			ctx := metadata.NewIncomingContext(context.Background(), metadata.Pairs(xForwardedForHeader, tc.forwardedFor))
+			if tc.peerAddr != nil {
+				ctx = peer.NewContext(ctx, &peer.Peer{Addr: tc.peerAddr})
+			}
+
+			require.Equal(t, tc.clientIP, server.extractMetadata(ctx).ClientIP)
+		})
+	}
+}
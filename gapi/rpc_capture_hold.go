@@ -0,0 +1,67 @@
+package gapi
+
+import (
+	"context"
+
+	db "github.com/tonisco/simple-bank-go/db/sqlc"
+	"github.com/tonisco/simple-bank-go/pb"
+	"github.com/tonisco/simple-bank-go/util"
+	"github.com/tonisco/simple-bank-go/val"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+)
+
+// CaptureHold pays a hold to the authenticated user's account. Without an amount the whole hold is captured
+func (server *Server) CaptureHold(ctx context.Context, req *pb.CaptureHoldRequest) (*pb.CaptureHoldResponse, error) {
+	authPayload, err := server.authorizeUser(ctx, []string{util.BankerRole, util.DepositorRole})
+
+	if err != nil {
+		return nil, unauthenticatedError(err)
+	}
+
+	violations := validateCaptureHoldRequest(req)
+
+	if violations != nil {
+		return nil, invalidArgumentError(violations)
+	}
+
+	hold, err := server.authorizeHold(ctx, authPayload, req.GetId(), false)
+	if err != nil {
+		return nil, err
+	}
+
+	amount := hold.Amount
+	if req.Amount != nil {
+		amount = req.GetAmount()
+	}
+
+	result, err := server.store.CaptureHoldTx(ctx, db.CaptureHoldTxParams{
+		ID:     hold.ID,
+		Amount: amount,
+	})
+
+	if err != nil {
+		return nil, holdError(err, "failed to capture hold")
+	}
+
+	rsp := &pb.CaptureHoldResponse{
+		Hold:        convertHold(result.Hold),
+		Transfer:    convertTransfer(result.Transfer.Transfer),
+		FromAccount: convertAccount(result.Transfer.FromAccount),
+		ToAccount:   convertAccount(result.Transfer.ToAccount),
+	}
+	return rsp, nil
+}
+
+func validateCaptureHoldRequest(req *pb.CaptureHoldRequest) (violations []*errdetails.BadRequest_FieldViolation) {
+	if err := val.ValidateID(req.GetId()); err != nil {
+		violations = append(violations, fieldViolation("id", err))
+	}
+
+	if req.Amount != nil {
+		if err := val.ValidateAmount(req.GetAmount()); err != nil {
+			violations = append(violations, fieldViolation("amount", err))
+		}
+	}
+
+	return violations
+}
@@ -0,0 +1,193 @@
+package gapi
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/stretchr/testify/require"
+	mockdb "github.com/tonisco/simple-bank-go/db/mock"
+	db "github.com/tonisco/simple-bank-go/db/sqlc"
+	"github.com/tonisco/simple-bank-go/pb"
+	"github.com/tonisco/simple-bank-go/token"
+	"github.com/tonisco/simple-bank-go/util"
+	"go.uber.org/mock/gomock"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func TestCaptureHoldAPI(t *testing.T) {
+	payer, _ := randomUser(t)
+	payee, _ := randomUser(t)
+	banker, _ := randomUser(t)
+	banker.Role = util.BankerRole
+
+	account1 := randomAccount(payer.Username)
+	account1.ID = 1
+	account2 := randomAccount(payee.Username)
+	account2.ID = 2
+	account2.Currency = account1.Currency
+
+	hold := randomHold(account1, account2)
+	partial := hold.Amount - 1
+
+	captured := func(amount int64) db.CaptureHoldTxResult {
+		capturedHold := hold
+		capturedHold.Status = db.HoldStatusCaptured
+		capturedHold.TransferID = pgtype.Int8{Int64: 7, Valid: true}
+		return db.CaptureHoldTxResult{
+			Hold: capturedHold,
+			Transfer: db.TransferTxResult{
+				Transfer:    db.Transfer{ID: 7, FromAccountID: account1.ID, ToAccountID: account2.ID, Amount: amount},
+				FromAccount: account1,
+				ToAccount:   account2,
+			},
+		}
+	}
+
+	testCases := []struct {
+		name          string
+		req           *pb.CaptureHoldRequest
+		buildStubs    func(store *mockdb.MockStore)
+		buildContext  func(t *testing.T, tokenMaker token.Maker) context.Context
+		checkResponse func(t *testing.T, res *pb.CaptureHoldResponse, err error)
+	}{
+		{
+			name: "OK",
+			req:  &pb.CaptureHoldRequest{Id: hold.ID},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetHold(gomock.Any(), gomock.Eq(hold.ID)).Times(1).Return(hold, nil)
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account2.ID)).Times(1).Return(account2, nil)
+
+				arg := db.CaptureHoldTxParams{ID: hold.ID, Amount: hold.Amount}
+				store.EXPECT().
+					CaptureHoldTx(gomock.Any(), gomock.Eq(arg)).
+					Times(1).
+					Return(captured(hold.Amount), nil)
+			},
+			buildContext: func(t *testing.T, tokenMaker token.Maker) context.Context {
+				return newContextWithBearerToken(t, tokenMaker, payee.Username, payee.Role, time.Minute)
+			},
+			checkResponse: func(t *testing.T, res *pb.CaptureHoldResponse, err error) {
+				require.NoError(t, err)
+				require.Equal(t, db.HoldStatusCaptured, res.GetHold().GetStatus())
+				require.Equal(t, int64(7), res.GetHold().GetTransferId())
+				require.Equal(t, hold.Amount, res.GetTransfer().GetAmount())
+				requireAccountMatch(t, account1, res.GetFromAccount())
+				requireAccountMatch(t, account2, res.GetToAccount())
+			},
+		},
+		{
+			name: "PartialCapture",
+			req:  &pb.CaptureHoldRequest{Id: hold.ID, Amount: &partial},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetHold(gomock.Any(), gomock.Eq(hold.ID)).Times(1).Return(hold, nil)
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Any()).Times(0)
+
+				arg := db.CaptureHoldTxParams{ID: hold.ID, Amount: partial}
+				store.EXPECT().
+					CaptureHoldTx(gomock.Any(), gomock.Eq(arg)).
+					Times(1).
+					Return(captured(partial), nil)
+			},
+			buildContext: func(t *testing.T, tokenMaker token.Maker) context.Context {
+				return newContextWithBearerToken(t, tokenMaker, banker.Username, banker.Role, time.Minute)
+			},
+			checkResponse: func(t *testing.T, res *pb.CaptureHoldResponse, err error) {
+				require.NoError(t, err)
+				require.Equal(t, partial, res.GetTransfer().GetAmount())
+			},
+		},
+		{
+			name: "PayerCannotCapture",
+			req:  &pb.CaptureHoldRequest{Id: hold.ID},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetHold(gomock.Any(), gomock.Eq(hold.ID)).Times(1).Return(hold, nil)
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account2.ID)).Times(1).Return(account2, nil)
+				store.EXPECT().CaptureHoldTx(gomock.Any(), gomock.Any()).Times(0)
+			},
+			buildContext: func(t *testing.T, tokenMaker token.Maker) context.Context {
+				return newContextWithBearerToken(t, tokenMaker, payer.Username, payer.Role, time.Minute)
+			},
+			checkResponse: func(t *testing.T, res *pb.CaptureHoldResponse, err error) {
+				require.Error(t, err)
+				st, ok := status.FromError(err)
+				require.True(t, ok)
+				require.Equal(t, codes.PermissionDenied, st.Code())
+			},
+		},
+		{
+			name: "HoldNotActive",
+			req:  &pb.CaptureHoldRequest{Id: hold.ID},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetHold(gomock.Any(), gomock.Eq(hold.ID)).Times(1).Return(hold, nil)
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account2.ID)).Times(1).Return(account2, nil)
+				store.EXPECT().
+					CaptureHoldTx(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(db.CaptureHoldTxResult{}, db.ErrHoldNotActive)
+			},
+			buildContext: func(t *testing.T, tokenMaker token.Maker) context.Context {
+				return newContextWithBearerToken(t, tokenMaker, payee.Username, payee.Role, time.Minute)
+			},
+			checkResponse: func(t *testing.T, res *pb.CaptureHoldResponse, err error) {
+				require.Error(t, err)
+				st, ok := status.FromError(err)
+				require.True(t, ok)
+				require.Equal(t, codes.FailedPrecondition, st.Code())
+			},
+		},
+		{
+			name: "HoldNotFound",
+			req:  &pb.CaptureHoldRequest{Id: hold.ID},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetHold(gomock.Any(), gomock.Eq(hold.ID)).Times(1).Return(db.Hold{}, db.ErrRecordNotFound)
+				store.EXPECT().CaptureHoldTx(gomock.Any(), gomock.Any()).Times(0)
+			},
+			buildContext: func(t *testing.T, tokenMaker token.Maker) context.Context {
+				return newContextWithBearerToken(t, tokenMaker, payee.Username, payee.Role, time.Minute)
+			},
+			checkResponse: func(t *testing.T, res *pb.CaptureHoldResponse, err error) {
+				require.Error(t, err)
+				st, ok := status.FromError(err)
+				require.True(t, ok)
+				require.Equal(t, codes.NotFound, st.Code())
+			},
+		},
+		{
+			name: "NoAuthorization",
+			req:  &pb.CaptureHoldRequest{Id: hold.ID},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetHold(gomock.Any(), gomock.Any()).Times(0)
+				store.EXPECT().CaptureHoldTx(gomock.Any(), gomock.Any()).Times(0)
+			},
+			buildContext: func(t *testing.T, tokenMaker token.Maker) context.Context {
+				return context.Background()
+			},
+			checkResponse: func(t *testing.T, res *pb.CaptureHoldResponse, err error) {
+				require.Error(t, err)
+				st, ok := status.FromError(err)
+				require.True(t, ok)
+				require.Equal(t, codes.Unauthenticated, st.Code())
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			storeCtrl := gomock.NewController(t)
+			defer storeCtrl.Finish()
+			store := mockdb.NewMockStore(storeCtrl)
+
+			tc.buildStubs(store)
+			server := newTestServer(t, store, nil)
+
+			ctx := tc.buildContext(t, server.tokenMaker)
+			res, err := server.CaptureHold(ctx, tc.req)
+			tc.checkResponse(t, res, err)
+		})
+	}
+}
@@ -0,0 +1,34 @@
+package gapi
+
+import (
+	"context"
+
+	db "github.com/tonisco/simple-bank-go/db/sqlc"
+	"github.com/tonisco/simple-bank-go/pb"
+	"github.com/tonisco/simple-bank-go/util"
+)
+
+// CloseAccount closes an active or frozen account that holds no money
+func (server *Server) CloseAccount(ctx context.Context, req *pb.CloseAccountRequest) (*pb.CloseAccountResponse, error) {
+	_, err := server.authorizeUser(ctx, []string{util.BankerRole})
+
+	if err != nil {
+		return nil, unauthenticatedError(err)
+	}
+
+	violations := validateAccountID(req.GetId())
+
+	if violations != nil {
+		return nil, invalidArgumentError(violations)
+	}
+
+	account, err := server.changeAccountStatus(ctx, req.GetId(), "", db.AccountStatusClosed)
+	if err != nil {
+		return nil, err
+	}
+
+	rsp := &pb.CloseAccountResponse{
+		Account: convertAccount(account),
+	}
+	return rsp, nil
+}
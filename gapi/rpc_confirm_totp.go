@@ -0,0 +1,90 @@
+package gapi
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	db "github.com/tonisco/simple-bank-go/db/sqlc"
+	"github.com/tonisco/simple-bank-go/pb"
+	"github.com/tonisco/simple-bank-go/totp"
+	"github.com/tonisco/simple-bank-go/util"
+	"github.com/tonisco/simple-bank-go/val"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// recoveryCodeCount is how many recovery codes a user gets when turning on two-factor authentication
+const recoveryCodeCount = 10
+
+// ConfirmTotp turns on two-factor authentication once the user proves their authenticator works.
+// The recovery codes are returned in plain text only here, the database keeps their hashes
+func (server *Server) ConfirmTotp(ctx context.Context, req *pb.ConfirmTotpRequest) (*pb.ConfirmTotpResponse, error) {
+	authPayload, err := server.authorizeUser(ctx, []string{util.BankerRole, util.DepositorRole})
+
+	if err != nil {
+		return nil, unauthenticatedError(err)
+	}
+
+	violations := validateConfirmTotpRequest(req)
+
+	if violations != nil {
+		return nil, invalidArgumentError(violations)
+	}
+
+	userTotp, err := server.store.GetUserTotp(ctx, authPayload.Username)
+	if err != nil {
+		if errors.Is(err, db.ErrRecordNotFound) {
+			return nil, status.Errorf(codes.FailedPrecondition, "two-factor authentication has not been enrolled")
+		}
+		return nil, status.Errorf(codes.Internal, "failed to get two-factor authentication: %s", err)
+	}
+
+	if userTotp.ConfirmedAt.Valid {
+		return nil, status.Errorf(codes.FailedPrecondition, "two-factor authentication is already on")
+	}
+
+	step, ok := totp.Validate(userTotp.Secret, req.GetCode(), time.Now())
+	if !ok {
+		return nil, status.Errorf(codes.InvalidArgument, "code is not valid")
+	}
+
+	recoveryCodes, err := totp.GenerateRecoveryCodes(recoveryCodeCount)
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "failed to generate recovery codes: %s", err)
+	}
+
+	hashedCodes := make([]string, len(recoveryCodes))
+	for i, code := range recoveryCodes {
+		hashedCodes[i], err = util.HashPassword(totp.NormalizeRecoveryCode(code))
+		if err != nil {
+			return nil, status.Errorf(codes.Internal, "failed to hash recovery code: %s", err)
+		}
+	}
+
+	_, err = server.store.ConfirmTotpTx(ctx, db.ConfirmTotpTxParams{
+		Username:            authPayload.Username,
+		Step:                step,
+		HashedRecoveryCodes: hashedCodes,
+	})
+	if err != nil {
+		if errors.Is(err, db.ErrRecordNotFound) {
+			return nil, status.Errorf(codes.FailedPrecondition, "two-factor authentication is already on")
+		}
+		return nil, status.Errorf(codes.Internal, "failed to confirm two-factor authentication: %s", err)
+	}
+
+	rsp := &pb.ConfirmTotpResponse{
+		RecoveryCodes: recoveryCodes,
+	}
+	return rsp, nil
+}
+
+func validateConfirmTotpRequest(req *pb.ConfirmTotpRequest) (violations []*errdetails.BadRequest_FieldViolation) {
+	if err := val.ValidateTotpCode(req.GetCode()); err != nil {
+		violations = append(violations, fieldViolation("code", err))
+	}
+
+	return violations
+}
@@ -0,0 +1,184 @@
+package gapi
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/stretchr/testify/require"
+	mockdb "github.com/tonisco/simple-bank-go/db/mock"
+	db "github.com/tonisco/simple-bank-go/db/sqlc"
+	"github.com/tonisco/simple-bank-go/pb"
+	"github.com/tonisco/simple-bank-go/token"
+	"github.com/tonisco/simple-bank-go/totp"
+	"github.com/tonisco/simple-bank-go/util"
+	"go.uber.org/mock/gomock"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func TestConfirmTotpAPI(t *testing.T) {
+	user, _ := randomUser(t)
+
+	secret, err := totp.GenerateSecret()
+	require.NoError(t, err)
+
+	userTotp := db.UserTotp{
+		Username: user.Username,
+		Secret:   secret,
+	}
+
+	step := totp.Step(time.Now())
+	code, err := totp.Code(secret, step)
+	require.NoError(t, err)
+
+	testCases := []struct {
+		name          string
+		req           *pb.ConfirmTotpRequest
+		buildStubs    func(store *mockdb.MockStore)
+		buildContext  func(t *testing.T, tokenMaker token.Maker) context.Context
+		checkResponse func(t *testing.T, res *pb.ConfirmTotpResponse, err error)
+	}{
+		{
+			name: "OK",
+			req: &pb.ConfirmTotpRequest{
+				Code: code,
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetUserTotp(gomock.Any(), gomock.Eq(user.Username)).
+					Times(1).
+					Return(userTotp, nil)
+
+				store.EXPECT().
+					ConfirmTotpTx(gomock.Any(), gomock.Any()).
+					Times(1).
+					DoAndReturn(func(_ context.Context, arg db.ConfirmTotpTxParams) (db.ConfirmTotpTxResult, error) {
+						require.Equal(t, user.Username, arg.Username)
+						require.Equal(t, step, arg.Step)
+						require.Len(t, arg.HashedRecoveryCodes, recoveryCodeCount)
+						return db.ConfirmTotpTxResult{}, nil
+					})
+			},
+			buildContext: func(t *testing.T, tokenMaker token.Maker) context.Context {
+				return newContextWithBearerToken(t, tokenMaker, user.Username, user.Role, time.Minute)
+			},
+			checkResponse: func(t *testing.T, res *pb.ConfirmTotpResponse, err error) {
+				require.NoError(t, err)
+				require.Len(t, res.GetRecoveryCodes(), recoveryCodeCount)
+			},
+		},
+		{
+			name: "IncorrectCode",
+			req: &pb.ConfirmTotpRequest{
+				Code: "000000",
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetUserTotp(gomock.Any(), gomock.Eq(user.Username)).
+					Times(1).
+					Return(db.UserTotp{Username: user.Username, Secret: "AAAAAAAA"}, nil)
+
+				store.EXPECT().
+					ConfirmTotpTx(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			buildContext: func(t *testing.T, tokenMaker token.Maker) context.Context {
+				return newContextWithBearerToken(t, tokenMaker, user.Username, user.Role, time.Minute)
+			},
+			checkResponse: func(t *testing.T, res *pb.ConfirmTotpResponse, err error) {
+				require.Error(t, err)
+				st, ok := status.FromError(err)
+				require.True(t, ok)
+				require.Equal(t, codes.InvalidArgument, st.Code())
+			},
+		},
+		{
+			name: "AlreadyConfirmed",
+			req: &pb.ConfirmTotpRequest{
+				Code: code,
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				confirmed := userTotp
+				confirmed.ConfirmedAt = pgtype.Timestamptz{Time: time.Now(), Valid: true}
+
+				store.EXPECT().
+					GetUserTotp(gomock.Any(), gomock.Eq(user.Username)).
+					Times(1).
+					Return(confirmed, nil)
+
+				store.EXPECT().
+					ConfirmTotpTx(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			buildContext: func(t *testing.T, tokenMaker token.Maker) context.Context {
+				return newContextWithBearerToken(t, tokenMaker, user.Username, user.Role, time.Minute)
+			},
+			checkResponse: func(t *testing.T, res *pb.ConfirmTotpResponse, err error) {
+				require.Error(t, err)
+				st, ok := status.FromError(err)
+				require.True(t, ok)
+				require.Equal(t, codes.FailedPrecondition, st.Code())
+			},
+		},
+		{
+			name: "NotEnrolled",
+			req: &pb.ConfirmTotpRequest{
+				Code: code,
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetUserTotp(gomock.Any(), gomock.Eq(user.Username)).
+					Times(1).
+					Return(db.UserTotp{}, db.ErrRecordNotFound)
+			},
+			buildContext: func(t *testing.T, tokenMaker token.Maker) context.Context {
+				return newContextWithBearerToken(t, tokenMaker, user.Username, user.Role, time.Minute)
+			},
+			checkResponse: func(t *testing.T, res *pb.ConfirmTotpResponse, err error) {
+				require.Error(t, err)
+				st, ok := status.FromError(err)
+				require.True(t, ok)
+				require.Equal(t, codes.FailedPrecondition, st.Code())
+			},
+		},
+		{
+			name: "ChallengeToken",
+			req: &pb.ConfirmTotpRequest{
+				Code: code,
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetUserTotp(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			buildContext: func(t *testing.T, tokenMaker token.Maker) context.Context {
+				return newContextWithBearerToken(t, tokenMaker, user.Username, util.TotpChallengeRole, time.Minute)
+			},
+			checkResponse: func(t *testing.T, res *pb.ConfirmTotpResponse, err error) {
+				require.Error(t, err)
+				st, ok := status.FromError(err)
+				require.True(t, ok)
+				require.Equal(t, codes.Unauthenticated, st.Code())
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			storeCtrl := gomock.NewController(t)
+			defer storeCtrl.Finish()
+			store := mockdb.NewMockStore(storeCtrl)
+
+			tc.buildStubs(store)
+			server := newTestServer(t, store, nil)
+
+			ctx := tc.buildContext(t, server.tokenMaker)
+			res, err := server.ConfirmTotp(ctx, tc.req)
+			tc.checkResponse(t, res, err)
+		})
+	}
+}
@@ -0,0 +1,73 @@
+package gapi
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	db "github.com/tonisco/simple-bank-go/db/sqlc"
+	"github.com/tonisco/simple-bank-go/fx"
+	"github.com/tonisco/simple-bank-go/pb"
+	"github.com/tonisco/simple-bank-go/util"
+	"github.com/tonisco/simple-bank-go/val"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// CreateFxQuote locks the current exchange rate for the user for a short time,
+// to be used by a single cross-currency transfer
+func (server *Server) CreateFxQuote(ctx context.Context, req *pb.CreateFxQuoteRequest) (*pb.CreateFxQuoteResponse, error) {
+	authPayload, err := server.authorizeUser(ctx, []string{util.BankerRole, util.DepositorRole})
+
+	if err != nil {
+		return nil, unauthenticatedError(err)
+	}
+
+	violations := validateCreateFxQuoteRequest(req)
+
+	if violations != nil {
+		return nil, invalidArgumentError(violations)
+	}
+
+	rate, err := server.rates.Rate(ctx, req.GetFromCurrency(), req.GetToCurrency())
+	if err != nil {
+		if errors.Is(err, fx.ErrRateNotFound) {
+			return nil, status.Errorf(codes.NotFound, "%s", err)
+		}
+		return nil, status.Errorf(codes.Unavailable, "failed to get exchange rate: %s", err)
+	}
+
+	quote, err := server.store.CreateFxQuote(ctx, db.CreateFxQuoteParams{
+		ID:           uuid.New(),
+		Username:     authPayload.Username,
+		FromCurrency: req.GetFromCurrency(),
+		ToCurrency:   req.GetToCurrency(),
+		Rate:         rate,
+		ExpiresAt:    time.Now().Add(server.config.FXQuoteDuration),
+	})
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "failed to create fx quote: %s", err)
+	}
+
+	rsp := &pb.CreateFxQuoteResponse{
+		Quote: convertFxQuote(quote),
+	}
+	return rsp, nil
+}
+
+func validateCreateFxQuoteRequest(req *pb.CreateFxQuoteRequest) (violations []*errdetails.BadRequest_FieldViolation) {
+	if err := val.ValidateCurrency(req.GetFromCurrency()); err != nil {
+		violations = append(violations, fieldViolation("from_currency", err))
+	}
+
+	if err := val.ValidateCurrency(req.GetToCurrency()); err != nil {
+		violations = append(violations, fieldViolation("to_currency", err))
+	} else if req.GetToCurrency() == req.GetFromCurrency() {
+		violations = append(violations, fieldViolation("to_currency", fmt.Errorf("must be different from from_currency")))
+	}
+
+	return violations
+}
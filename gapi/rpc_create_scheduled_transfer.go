@@ -0,0 +1,115 @@
+package gapi
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	db "github.com/tonisco/simple-bank-go/db/sqlc"
+	"github.com/tonisco/simple-bank-go/pb"
+	"github.com/tonisco/simple-bank-go/util"
+	"github.com/tonisco/simple-bank-go/val"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// CreateScheduledTransfer schedules a transfer between two accounts of the same currency.
+// Without a schedule the transfer is made once at start_at, with one it recurs from start_at,
+// or from the first time the schedule fires when start_at is not set
+func (server *Server) CreateScheduledTransfer(ctx context.Context, req *pb.CreateScheduledTransferRequest) (*pb.CreateScheduledTransferResponse, error) {
+	authPayload, err := server.authorizeUser(ctx, []string{util.BankerRole, util.DepositorRole})
+
+	if err != nil {
+		return nil, unauthenticatedError(err)
+	}
+
+	violations := validateCreateScheduledTransferRequest(req)
+
+	if violations != nil {
+		return nil, invalidArgumentError(violations)
+	}
+
+	// every run is made without the user, so a large one needs the step-up when it is scheduled
+	if err := server.requireStepUp(authPayload, req.GetAmount(), req.GetCurrency()); err != nil {
+		return nil, err
+	}
+
+	fromAccount, err := server.validAccount(ctx, req.GetFromAccountId(), req.GetCurrency())
+	if err != nil {
+		return nil, err
+	}
+
+	if fromAccount.Owner != authPayload.Username {
+		return nil, status.Errorf(codes.PermissionDenied, "from account does not belong to the authenticated user")
+	}
+
+	_, err = server.validAccount(ctx, req.GetToAccountId(), req.GetCurrency())
+	if err != nil {
+		return nil, err
+	}
+
+	var nextRunAt time.Time
+	if req.StartAt != nil {
+		nextRunAt = req.GetStartAt().AsTime()
+	} else {
+		nextRunAt, err = util.NextRun(req.GetSchedule(), time.Now())
+		if err != nil {
+			return nil, status.Errorf(codes.Internal, "failed to compute next run: %s", err)
+		}
+	}
+
+	scheduled, err := server.store.CreateScheduledTransfer(ctx, db.CreateScheduledTransferParams{
+		Owner:         authPayload.Username,
+		FromAccountID: req.GetFromAccountId(),
+		ToAccountID:   req.GetToAccountId(),
+		Amount:        req.GetAmount(),
+		Schedule:      req.GetSchedule(),
+		NextRunAt:     nextRunAt,
+	})
+
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "failed to create scheduled transfer: %s", err)
+	}
+
+	rsp := &pb.CreateScheduledTransferResponse{
+		ScheduledTransfer: convertScheduledTransfer(scheduled),
+	}
+	return rsp, nil
+}
+
+func validateCreateScheduledTransferRequest(req *pb.CreateScheduledTransferRequest) (violations []*errdetails.BadRequest_FieldViolation) {
+	if err := val.ValidateID(req.GetFromAccountId()); err != nil {
+		violations = append(violations, fieldViolation("from_account_id", err))
+	}
+
+	if err := val.ValidateID(req.GetToAccountId()); err != nil {
+		violations = append(violations, fieldViolation("to_account_id", err))
+	} else if req.GetToAccountId() == req.GetFromAccountId() {
+		violations = append(violations, fieldViolation("to_account_id", fmt.Errorf("must be different from from_account_id")))
+	}
+
+	if err := val.ValidateAmount(req.GetAmount()); err != nil {
+		violations = append(violations, fieldViolation("amount", err))
+	}
+
+	if err := val.ValidateCurrency(req.GetCurrency()); err != nil {
+		violations = append(violations, fieldViolation("currency", err))
+	}
+
+	if req.GetSchedule() != "" {
+		if err := val.ValidateSchedule(req.GetSchedule()); err != nil {
+			violations = append(violations, fieldViolation("schedule", err))
+		}
+	}
+
+	if req.StartAt != nil || req.GetSchedule() == "" {
+		if err := val.ValidateTimestamp(req.GetStartAt()); err != nil {
+			violations = append(violations, fieldViolation("start_at", err))
+		} else if !req.GetStartAt().AsTime().After(time.Now()) {
+			violations = append(violations, fieldViolation("start_at", fmt.Errorf("must be in the future")))
+		}
+	}
+
+	return violations
+}
@@ -0,0 +1,310 @@
+package gapi
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	mockdb "github.com/tonisco/simple-bank-go/db/mock"
+	db "github.com/tonisco/simple-bank-go/db/sqlc"
+	"github.com/tonisco/simple-bank-go/pb"
+	"github.com/tonisco/simple-bank-go/token"
+	"github.com/tonisco/simple-bank-go/util"
+	"go.uber.org/mock/gomock"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+func randomScheduledTransfer(owner string, fromAccountID int64, toAccountID int64) db.ScheduledTransfer {
+	return db.ScheduledTransfer{
+		ID:            util.RandomInt(1, 1000),
+		Owner:         owner,
+		FromAccountID: fromAccountID,
+		ToAccountID:   toAccountID,
+		Amount:        util.RandomInt(1, 100),
+		Schedule:      "0 9 1 * *",
+		Status:        db.ScheduledTransferStatusActive,
+		NextRunAt:     time.Now().Add(24 * time.Hour).UTC().Truncate(time.Second),
+		CreatedAt:     time.Now().UTC().Truncate(time.Second),
+	}
+}
+
+func requireScheduledTransferMatch(t *testing.T, scheduled db.ScheduledTransfer, got *pb.ScheduledTransfer) {
+	require.NotNil(t, got)
+	require.Equal(t, scheduled.ID, got.GetId())
+	require.Equal(t, scheduled.Owner, got.GetOwner())
+	require.Equal(t, scheduled.FromAccountID, got.GetFromAccountId())
+	require.Equal(t, scheduled.ToAccountID, got.GetToAccountId())
+	require.Equal(t, scheduled.Amount, got.GetAmount())
+	require.Equal(t, scheduled.Schedule, got.GetSchedule())
+	require.Equal(t, scheduled.Status, got.GetStatus())
+	require.WithinDuration(t, scheduled.NextRunAt, got.GetNextRunAt().AsTime(), time.Second)
+}
+
+func TestCreateScheduledTransferAPI(t *testing.T) {
+	user, _ := randomUser(t)
+	user1, _ := randomUser(t)
+
+	account1 := randomAccount(user.Username)
+	account1.ID = 1
+	account2 := randomAccount(user1.Username)
+	account2.ID = 2
+	account2.Currency = account1.Currency
+
+	startAt := time.Now().Add(time.Hour).UTC().Truncate(time.Second)
+
+	testCases := []struct {
+		name          string
+		req           *pb.CreateScheduledTransferRequest
+		buildStubs    func(store *mockdb.MockStore)
+		buildContext  func(t *testing.T, tokenMaker token.Maker) context.Context
+		checkResponse func(t *testing.T, res *pb.CreateScheduledTransferResponse, err error)
+	}{
+		{
+			name: "OK",
+			req: &pb.CreateScheduledTransferRequest{
+				FromAccountId: account1.ID,
+				ToAccountId:   account2.ID,
+				Amount:        10,
+				Currency:      account1.Currency,
+				Schedule:      "0 9 1 * *",
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account1.ID)).Times(1).Return(account1, nil)
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account2.ID)).Times(1).Return(account2, nil)
+
+				nextRunAt, err := util.NextRun("0 9 1 * *", time.Now())
+				require.NoError(t, err)
+
+				store.EXPECT().
+					CreateScheduledTransfer(gomock.Any(), gomock.Any()).
+					Times(1).
+					DoAndReturn(func(_ context.Context, arg db.CreateScheduledTransferParams) (db.ScheduledTransfer, error) {
+						require.Equal(t, user.Username, arg.Owner)
+						require.Equal(t, account1.ID, arg.FromAccountID)
+						require.Equal(t, account2.ID, arg.ToAccountID)
+						require.Equal(t, int64(10), arg.Amount)
+						require.Equal(t, "0 9 1 * *", arg.Schedule)
+						require.Equal(t, nextRunAt, arg.NextRunAt)
+
+						return db.ScheduledTransfer{
+							ID:            1,
+							Owner:         arg.Owner,
+							FromAccountID: arg.FromAccountID,
+							ToAccountID:   arg.ToAccountID,
+							Amount:        arg.Amount,
+							Schedule:      arg.Schedule,
+							Status:        db.ScheduledTransferStatusActive,
+							NextRunAt:     arg.NextRunAt,
+						}, nil
+					})
+			},
+			buildContext: func(t *testing.T, tokenMaker token.Maker) context.Context {
+				return newContextWithBearerToken(t, tokenMaker, user.Username, user.Role, time.Minute)
+			},
+			checkResponse: func(t *testing.T, res *pb.CreateScheduledTransferResponse, err error) {
+				require.NoError(t, err)
+				scheduled := res.GetScheduledTransfer()
+				require.NotNil(t, scheduled)
+				require.Equal(t, user.Username, scheduled.GetOwner())
+				require.Equal(t, db.ScheduledTransferStatusActive, scheduled.GetStatus())
+			},
+		},
+		{
+			name: "OneOff",
+			req: &pb.CreateScheduledTransferRequest{
+				FromAccountId: account1.ID,
+				ToAccountId:   account2.ID,
+				Amount:        10,
+				Currency:      account1.Currency,
+				StartAt:       timestamppb.New(startAt),
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account1.ID)).Times(1).Return(account1, nil)
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account2.ID)).Times(1).Return(account2, nil)
+
+				arg := db.CreateScheduledTransferParams{
+					Owner:         user.Username,
+					FromAccountID: account1.ID,
+					ToAccountID:   account2.ID,
+					Amount:        10,
+					NextRunAt:     startAt,
+				}
+				store.EXPECT().
+					CreateScheduledTransfer(gomock.Any(), gomock.Eq(arg)).
+					Times(1).
+					Return(db.ScheduledTransfer{ID: 1, Owner: user.Username, Status: db.ScheduledTransferStatusActive, NextRunAt: startAt}, nil)
+			},
+			buildContext: func(t *testing.T, tokenMaker token.Maker) context.Context {
+				return newContextWithBearerToken(t, tokenMaker, user.Username, user.Role, time.Minute)
+			},
+			checkResponse: func(t *testing.T, res *pb.CreateScheduledTransferResponse, err error) {
+				require.NoError(t, err)
+				require.Empty(t, res.GetScheduledTransfer().GetSchedule())
+				require.Equal(t, startAt, res.GetScheduledTransfer().GetNextRunAt().AsTime())
+			},
+		},
+		{
+			name: "OneOffWithoutStartAt",
+			req: &pb.CreateScheduledTransferRequest{
+				FromAccountId: account1.ID,
+				ToAccountId:   account2.ID,
+				Amount:        10,
+				Currency:      account1.Currency,
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Any()).Times(0)
+				store.EXPECT().CreateScheduledTransfer(gomock.Any(), gomock.Any()).Times(0)
+			},
+			buildContext: func(t *testing.T, tokenMaker token.Maker) context.Context {
+				return newContextWithBearerToken(t, tokenMaker, user.Username, user.Role, time.Minute)
+			},
+			checkResponse: func(t *testing.T, res *pb.CreateScheduledTransferResponse, err error) {
+				require.Error(t, err)
+				st, ok := status.FromError(err)
+				require.True(t, ok)
+				require.Equal(t, codes.InvalidArgument, st.Code())
+			},
+		},
+		{
+			name: "InvalidSchedule",
+			req: &pb.CreateScheduledTransferRequest{
+				FromAccountId: account1.ID,
+				ToAccountId:   account2.ID,
+				Amount:        10,
+				Currency:      account1.Currency,
+				Schedule:      "* * * * *",
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Any()).Times(0)
+				store.EXPECT().CreateScheduledTransfer(gomock.Any(), gomock.Any()).Times(0)
+			},
+			buildContext: func(t *testing.T, tokenMaker token.Maker) context.Context {
+				return newContextWithBearerToken(t, tokenMaker, user.Username, user.Role, time.Minute)
+			},
+			checkResponse: func(t *testing.T, res *pb.CreateScheduledTransferResponse, err error) {
+				require.Error(t, err)
+				st, ok := status.FromError(err)
+				require.True(t, ok)
+				require.Equal(t, codes.InvalidArgument, st.Code())
+			},
+		},
+		{
+			name: "StartAtInPast",
+			req: &pb.CreateScheduledTransferRequest{
+				FromAccountId: account1.ID,
+				ToAccountId:   account2.ID,
+				Amount:        10,
+				Currency:      account1.Currency,
+				StartAt:       timestamppb.New(time.Now().Add(-time.Hour)),
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Any()).Times(0)
+				store.EXPECT().CreateScheduledTransfer(gomock.Any(), gomock.Any()).Times(0)
+			},
+			buildContext: func(t *testing.T, tokenMaker token.Maker) context.Context {
+				return newContextWithBearerToken(t, tokenMaker, user.Username, user.Role, time.Minute)
+			},
+			checkResponse: func(t *testing.T, res *pb.CreateScheduledTransferResponse, err error) {
+				require.Error(t, err)
+				st, ok := status.FromError(err)
+				require.True(t, ok)
+				require.Equal(t, codes.InvalidArgument, st.Code())
+			},
+		},
+		{
+			name: "FromAccountNotOwned",
+			req: &pb.CreateScheduledTransferRequest{
+				FromAccountId: account2.ID,
+				ToAccountId:   account1.ID,
+				Amount:        10,
+				Currency:      account1.Currency,
+				Schedule:      "0 9 1 * *",
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account2.ID)).Times(1).Return(account2, nil)
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account1.ID)).Times(0)
+				store.EXPECT().CreateScheduledTransfer(gomock.Any(), gomock.Any()).Times(0)
+			},
+			buildContext: func(t *testing.T, tokenMaker token.Maker) context.Context {
+				return newContextWithBearerToken(t, tokenMaker, user.Username, user.Role, time.Minute)
+			},
+			checkResponse: func(t *testing.T, res *pb.CreateScheduledTransferResponse, err error) {
+				require.Error(t, err)
+				st, ok := status.FromError(err)
+				require.True(t, ok)
+				require.Equal(t, codes.PermissionDenied, st.Code())
+			},
+		},
+		{
+			name: "NoAuthorization",
+			req: &pb.CreateScheduledTransferRequest{
+				FromAccountId: account1.ID,
+				ToAccountId:   account2.ID,
+				Amount:        10,
+				Currency:      account1.Currency,
+				Schedule:      "0 9 1 * *",
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Any()).Times(0)
+				store.EXPECT().CreateScheduledTransfer(gomock.Any(), gomock.Any()).Times(0)
+			},
+			buildContext: func(t *testing.T, tokenMaker token.Maker) context.Context {
+				return context.Background()
+			},
+			checkResponse: func(t *testing.T, res *pb.CreateScheduledTransferResponse, err error) {
+				require.Error(t, err)
+				st, ok := status.FromError(err)
+				require.True(t, ok)
+				require.Equal(t, codes.Unauthenticated, st.Code())
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			storeCtrl := gomock.NewController(t)
+			defer storeCtrl.Finish()
+			store := mockdb.NewMockStore(storeCtrl)
+
+			tc.buildStubs(store)
+			server := newTestServer(t, store, nil)
+
+			ctx := tc.buildContext(t, server.tokenMaker)
+			res, err := server.CreateScheduledTransfer(ctx, tc.req)
+			tc.checkResponse(t, res, err)
+		})
+	}
+}
+
+func TestCreateScheduledTransferStepUpAPI(t *testing.T) {
+	user, _ := randomUser(t)
+
+	store := mockdb.NewMockStore(gomock.NewController(t))
+	store.EXPECT().
+		CreateScheduledTransfer(gomock.Any(), gomock.Any()).
+		Times(0)
+
+	server := newTestServer(t, store, nil)
+	server.config.StepUpTransferThresholds = util.CurrencyAmounts{util.KWD: 1000}
+	server.config.ElevatedTokenDuration = time.Minute
+
+	req := &pb.CreateScheduledTransferRequest{
+		FromAccountId: 1,
+		ToAccountId:   2,
+		Amount:        1000001,
+		Currency:      util.KWD,
+		Schedule:      "0 9 1 * *",
+	}
+
+	ctx := newContextWithBearerToken(t, server.tokenMaker, user.Username, user.Role, time.Minute)
+	_, err := server.CreateScheduledTransfer(ctx, req)
+	require.Error(t, err)
+	st, ok := status.FromError(err)
+	require.True(t, ok)
+	require.Equal(t, codes.PermissionDenied, st.Code())
+}
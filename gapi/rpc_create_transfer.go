@@ -0,0 +1,169 @@
+package gapi
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/google/uuid"
+	db "github.com/tonisco/simple-bank-go/db/sqlc"
+	"github.com/tonisco/simple-bank-go/pb"
+	"github.com/tonisco/simple-bank-go/util"
+	"github.com/tonisco/simple-bank-go/val"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func (server *Server) CreateTransfer(ctx context.Context, req *pb.CreateTransferRequest) (*pb.CreateTransferResponse, error) {
+	authPayload, err := server.authorizeUser(ctx, []string{util.BankerRole, util.DepositorRole})
+
+	if err != nil {
+		return nil, unauthenticatedError(err)
+	}
+
+	violations := validateCreateTransferRequest(req)
+
+	if violations != nil {
+		return nil, invalidArgumentError(violations)
+	}
+
+	amount := transferAmount(req)
+
+	if err := server.requireStepUp(authPayload, amount, req.GetCurrency()); err != nil {
+		return nil, err
+	}
+
+	idempotency, err := server.extractIdempotency(ctx, authPayload.Username, "CreateTransfer", req)
+	if err != nil {
+		return nil, invalidArgumentError([]*errdetails.BadRequest_FieldViolation{fieldViolation(idempotencyKeyHeader, err)})
+	}
+
+	fromAccount, err := server.validAccount(ctx, req.GetFromAccountId(), req.GetCurrency())
+	if err != nil {
+		return nil, err
+	}
+
+	if fromAccount.Owner != authPayload.Username {
+		return nil, status.Errorf(codes.PermissionDenied, "from account does not belong to the authenticated user")
+	}
+
+	var txResult db.TransferTxResult
+	if req.GetFxQuoteId() == "" {
+		_, err = server.validAccount(ctx, req.GetToAccountId(), req.GetCurrency())
+		if err != nil {
+			return nil, err
+		}
+
+		args := db.TransferTxParams{
+			FromAccountID: req.GetFromAccountId(),
+			ToAccountID:   req.GetToAccountId(),
+			Amount:        amount,
+			Idempotency:   idempotency,
+		}
+
+		txResult, err = server.store.TransferTx(ctx, args)
+	} else {
+		// the quote fixes the to currency, the transaction checks it against the to account
+		args := db.CrossCurrencyTransferTxParams{
+			FromAccountID: req.GetFromAccountId(),
+			ToAccountID:   req.GetToAccountId(),
+			Amount:        amount,
+			QuoteID:       uuid.MustParse(req.GetFxQuoteId()),
+			Username:      authPayload.Username,
+			Idempotency:   idempotency,
+		}
+
+		txResult, err = server.store.CrossCurrencyTransferTx(ctx, args)
+	}
+
+	if err != nil {
+		var limitErr *db.TransferLimitError
+		if errors.As(err, &limitErr) {
+			return nil, transferLimitError(limitErr)
+		}
+		if db.IsLedgerRejection(err) {
+			return nil, status.Errorf(codes.FailedPrecondition, "%s", err)
+		}
+		if errors.Is(err, db.ErrIdempotencyKeyReused) {
+			return nil, status.Errorf(codes.AlreadyExists, "%s", err)
+		}
+		if errors.Is(err, db.ErrRecordNotFound) || db.ErrorCode(err) == db.ForeignKeyViolation {
+			return nil, status.Errorf(codes.NotFound, "account not found")
+		}
+		return nil, status.Errorf(codes.Internal, "failed to transfer money: %s", err)
+	}
+
+	rsp := &pb.CreateTransferResponse{
+		Transfer:    convertTransfer(txResult.Transfer),
+		FromAccount: convertAccount(txResult.FromAccount),
+		ToAccount:   convertAccount(txResult.ToAccount),
+		FromEntry:   convertEntry(txResult.FromEntry),
+		ToEntry:     convertEntry(txResult.ToEntry),
+	}
+	return rsp, nil
+}
+
+// transferAmount returns the requested amount in minor units, the request may give it
+// either as minor units in amount or as a decimal string in amount_decimal
+func transferAmount(req *pb.CreateTransferRequest) int64 {
+	if req.GetAmountDecimal() == "" {
+		return req.GetAmount()
+	}
+
+	// the decimal amount has already been validated against the currency
+	money, _ := util.ParseMoney(req.GetAmountDecimal(), req.GetCurrency())
+	return money.Amount
+}
+
+// validAccount checks that the account exists and holds the given currency
+func (server *Server) validAccount(ctx context.Context, accountID int64, currency string) (db.Account, error) {
+	account, err := server.store.GetAccount(ctx, accountID)
+
+	if err != nil {
+		if errors.Is(err, db.ErrRecordNotFound) {
+			return db.Account{}, status.Errorf(codes.NotFound, "account [%d] not found", accountID)
+		}
+		return db.Account{}, status.Errorf(codes.Internal, "failed to get account: %s", err)
+	}
+
+	if account.Currency != currency {
+		return db.Account{}, status.Errorf(codes.InvalidArgument, "account [%d] currency mismatch: %s vs %s", accountID, account.Currency, currency)
+	}
+
+	return account, nil
+}
+
+func validateCreateTransferRequest(req *pb.CreateTransferRequest) (violations []*errdetails.BadRequest_FieldViolation) {
+	if err := val.ValidateID(req.GetFromAccountId()); err != nil {
+		violations = append(violations, fieldViolation("from_account_id", err))
+	}
+
+	if err := val.ValidateID(req.GetToAccountId()); err != nil {
+		violations = append(violations, fieldViolation("to_account_id", err))
+	} else if req.GetToAccountId() == req.GetFromAccountId() {
+		violations = append(violations, fieldViolation("to_account_id", fmt.Errorf("must be different from from_account_id")))
+	}
+
+	if req.GetAmountDecimal() == "" {
+		if err := val.ValidateAmount(req.GetAmount()); err != nil {
+			violations = append(violations, fieldViolation("amount", err))
+		}
+	} else if req.GetAmount() != 0 {
+		violations = append(violations, fieldViolation("amount_decimal", fmt.Errorf("cannot be set together with amount")))
+	} else if err := val.ValidateDecimalAmount(req.GetAmountDecimal(), req.GetCurrency()); err != nil {
+		violations = append(violations, fieldViolation("amount_decimal", err))
+	}
+
+	if err := val.ValidateCurrency(req.GetCurrency()); err != nil {
+		violations = append(violations, fieldViolation("currency", err))
+	}
+
+	if req.GetFxQuoteId() != "" {
+		if err := val.ValidateUUID(req.GetFxQuoteId()); err != nil {
+			violations = append(violations, fieldViolation("fx_quote_id", err))
+		}
+	}
+
+	return violations
+}
@@ -0,0 +1,67 @@
+package gapi
+
+import (
+	"context"
+	"errors"
+
+	db "github.com/tonisco/simple-bank-go/db/sqlc"
+	"github.com/tonisco/simple-bank-go/pb"
+	"github.com/tonisco/simple-bank-go/util"
+	"github.com/tonisco/simple-bank-go/val"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// DeleteAccount closes the account rather than deleting it, so that its entries and transfers are kept.
+// Like CloseAccount it needs the account to have a zero balance and no active holds, and owners can only close active accounts
+func (server *Server) DeleteAccount(ctx context.Context, req *pb.DeleteAccountRequest) (*pb.DeleteAccountResponse, error) {
+	authPayload, err := server.authorizeUser(ctx, []string{util.BankerRole, util.DepositorRole})
+
+	if err != nil {
+		return nil, unauthenticatedError(err)
+	}
+
+	violations := validateDeleteAccountRequest(req)
+
+	if violations != nil {
+		return nil, invalidArgumentError(violations)
+	}
+
+	account, err := server.store.GetAccount(ctx, req.GetId())
+
+	if err != nil {
+		if errors.Is(err, db.ErrRecordNotFound) {
+			return nil, status.Errorf(codes.NotFound, "account not found")
+		}
+		return nil, status.Errorf(codes.Internal, "failed to get account: %s", err)
+	}
+
+	if !isAccountOwnerOrBanker(authPayload, account.Owner) {
+		return nil, status.Errorf(codes.PermissionDenied, "account does not belong to the authenticated user")
+	}
+
+	// only a banker may close a frozen account, or its owner could close it and have it reopened to lift the freeze
+	fromStatus := db.AccountStatusActive
+	if authPayload.Role == util.BankerRole {
+		fromStatus = ""
+	}
+
+	_, err = server.changeAccountStatus(ctx, account.ID, fromStatus, db.AccountStatusClosed)
+	if err != nil {
+		return nil, err
+	}
+
+	rsp := &pb.DeleteAccountResponse{
+		Message: "Account has been closed successfully",
+	}
+	return rsp, nil
+}
+
+func validateDeleteAccountRequest(req *pb.DeleteAccountRequest) (violations []*errdetails.BadRequest_FieldViolation) {
+	if err := val.ValidateID(req.GetId()); err != nil {
+		violations = append(violations, fieldViolation("id", err))
+	}
+
+	return violations
+}
@@ -0,0 +1,50 @@
+package gapi
+
+import (
+	"context"
+
+	"github.com/tonisco/simple-bank-go/pb"
+	"github.com/tonisco/simple-bank-go/util"
+	"github.com/tonisco/simple-bank-go/val"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func (server *Server) DeleteScheduledTransfer(ctx context.Context, req *pb.DeleteScheduledTransferRequest) (*pb.DeleteScheduledTransferResponse, error) {
+	authPayload, err := server.authorizeUser(ctx, []string{util.BankerRole, util.DepositorRole})
+
+	if err != nil {
+		return nil, unauthenticatedError(err)
+	}
+
+	violations := validateDeleteScheduledTransferRequest(req)
+
+	if violations != nil {
+		return nil, invalidArgumentError(violations)
+	}
+
+	scheduled, err := server.ownScheduledTransfer(ctx, authPayload, req.GetId())
+	if err != nil {
+		return nil, err
+	}
+
+	err = server.store.DeleteScheduledTransfer(ctx, scheduled.ID)
+
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "failed to delete scheduled transfer: %s", err)
+	}
+
+	rsp := &pb.DeleteScheduledTransferResponse{
+		Message: "Scheduled transfer has been deleted successfully",
+	}
+	return rsp, nil
+}
+
+func validateDeleteScheduledTransferRequest(req *pb.DeleteScheduledTransferRequest) (violations []*errdetails.BadRequest_FieldViolation) {
+	if err := val.ValidateID(req.GetId()); err != nil {
+		violations = append(violations, fieldViolation("id", err))
+	}
+
+	return violations
+}
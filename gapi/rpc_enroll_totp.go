@@ -0,0 +1,49 @@
+package gapi
+
+import (
+	"context"
+	"errors"
+
+	db "github.com/tonisco/simple-bank-go/db/sqlc"
+	"github.com/tonisco/simple-bank-go/pb"
+	"github.com/tonisco/simple-bank-go/totp"
+	"github.com/tonisco/simple-bank-go/util"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// totpIssuer is the name authenticator apps show next to the codes
+const totpIssuer = "Simple Bank"
+
+// EnrollTotp creates a new TOTP secret for the authenticated user. Two-factor login is not required
+// until the secret is confirmed with ConfirmTotp, and enrolling again before that replaces the secret
+func (server *Server) EnrollTotp(ctx context.Context, req *pb.EnrollTotpRequest) (*pb.EnrollTotpResponse, error) {
+	authPayload, err := server.authorizeUser(ctx, []string{util.BankerRole, util.DepositorRole})
+
+	if err != nil {
+		return nil, unauthenticatedError(err)
+	}
+
+	secret, err := totp.GenerateSecret()
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "failed to generate secret: %s", err)
+	}
+
+	userTotp, err := server.store.CreateUserTotp(ctx, db.CreateUserTotpParams{
+		Username: authPayload.Username,
+		Secret:   secret,
+	})
+	if err != nil {
+		// the upsert leaves a confirmed secret alone and returns no row
+		if errors.Is(err, db.ErrRecordNotFound) {
+			return nil, status.Errorf(codes.FailedPrecondition, "two-factor authentication is already on")
+		}
+		return nil, status.Errorf(codes.Internal, "failed to enroll totp: %s", err)
+	}
+
+	rsp := &pb.EnrollTotpResponse{
+		Secret: userTotp.Secret,
+		Uri:    totp.URI(totpIssuer, userTotp.Username, userTotp.Secret),
+	}
+	return rsp, nil
+}
@@ -0,0 +1,34 @@
+package gapi
+
+import (
+	"context"
+
+	db "github.com/tonisco/simple-bank-go/db/sqlc"
+	"github.com/tonisco/simple-bank-go/pb"
+	"github.com/tonisco/simple-bank-go/util"
+)
+
+// FreezeAccount stops an active account from sending or receiving money
+func (server *Server) FreezeAccount(ctx context.Context, req *pb.FreezeAccountRequest) (*pb.FreezeAccountResponse, error) {
+	_, err := server.authorizeUser(ctx, []string{util.BankerRole})
+
+	if err != nil {
+		return nil, unauthenticatedError(err)
+	}
+
+	violations := validateAccountID(req.GetId())
+
+	if violations != nil {
+		return nil, invalidArgumentError(violations)
+	}
+
+	account, err := server.changeAccountStatus(ctx, req.GetId(), db.AccountStatusActive, db.AccountStatusFrozen)
+	if err != nil {
+		return nil, err
+	}
+
+	rsp := &pb.FreezeAccountResponse{
+		Account: convertAccount(account),
+	}
+	return rsp, nil
+}
@@ -0,0 +1,83 @@
+package gapi
+
+import (
+	"context"
+	"errors"
+
+	db "github.com/tonisco/simple-bank-go/db/sqlc"
+	"github.com/tonisco/simple-bank-go/pb"
+	"github.com/tonisco/simple-bank-go/util"
+	"github.com/tonisco/simple-bank-go/val"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+func (server *Server) GetAccountStatement(ctx context.Context, req *pb.GetAccountStatementRequest) (*pb.GetAccountStatementResponse, error) {
+	authPayload, err := server.authorizeUser(ctx, []string{util.BankerRole, util.DepositorRole})
+
+	if err != nil {
+		return nil, unauthenticatedError(err)
+	}
+
+	violations := validateGetAccountStatementRequest(req)
+
+	if violations != nil {
+		return nil, invalidArgumentError(violations)
+	}
+
+	account, err := server.store.GetAccount(ctx, req.GetAccountId())
+
+	if err != nil {
+		if errors.Is(err, db.ErrRecordNotFound) {
+			return nil, status.Errorf(codes.NotFound, "account not found")
+		}
+		return nil, status.Errorf(codes.Internal, "failed to get account: %s", err)
+	}
+
+	if !isAccountOwnerOrBanker(authPayload, account.Owner) {
+		return nil, status.Errorf(codes.PermissionDenied, "account does not belong to the authenticated user")
+	}
+
+	statement, err := server.store.GetAccountStatement(ctx, db.AccountStatementParams{
+		AccountID: account.ID,
+		From:      req.GetFrom().AsTime(),
+		To:        req.GetTo().AsTime(),
+	})
+
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "failed to get account statement: %s", err)
+	}
+
+	rsp := &pb.GetAccountStatementResponse{
+		AccountId:      statement.AccountID,
+		From:           timestamppb.New(statement.From),
+		To:             timestamppb.New(statement.To),
+		OpeningBalance: statement.OpeningBalance,
+		ClosingBalance: statement.ClosingBalance,
+		Entries:        make([]*pb.StatementEntry, len(statement.Entries)),
+	}
+	for i, entry := range statement.Entries {
+		rsp.Entries[i] = convertStatementEntry(entry)
+	}
+	return rsp, nil
+}
+
+func validateGetAccountStatementRequest(req *pb.GetAccountStatementRequest) (violations []*errdetails.BadRequest_FieldViolation) {
+	if err := val.ValidateID(req.GetAccountId()); err != nil {
+		violations = append(violations, fieldViolation("account_id", err))
+	}
+
+	if err := val.ValidateTimestamp(req.GetFrom()); err != nil {
+		violations = append(violations, fieldViolation("from", err))
+	}
+
+	if err := val.ValidateTimestamp(req.GetTo()); err != nil {
+		violations = append(violations, fieldViolation("to", err))
+	} else if err := val.ValidateTimeRange(req.GetFrom().AsTime(), req.GetTo().AsTime()); err != nil {
+		violations = append(violations, fieldViolation("to", err))
+	}
+
+	return violations
+}
@@ -0,0 +1,236 @@
+package gapi
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	mockdb "github.com/tonisco/simple-bank-go/db/mock"
+	db "github.com/tonisco/simple-bank-go/db/sqlc"
+	"github.com/tonisco/simple-bank-go/pb"
+	"github.com/tonisco/simple-bank-go/token"
+	"github.com/tonisco/simple-bank-go/util"
+	"go.uber.org/mock/gomock"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+func TestGetAccountStatementAPI(t *testing.T) {
+	user, _ := randomUser(t)
+	other, _ := randomUser(t)
+	banker, _ := randomUser(t)
+	banker.Role = util.BankerRole
+
+	account := randomAccount(user.Username)
+	to := time.Now().Truncate(time.Second)
+	from := to.Add(-24 * time.Hour)
+
+	statement := db.AccountStatement{
+		AccountID:      account.ID,
+		From:           from,
+		To:             to,
+		OpeningBalance: 100,
+		ClosingBalance: 130,
+		Entries: []db.ListStatementEntriesRow{
+			{ID: 1, AccountID: account.ID, Amount: 50, RunningBalance: 150, CreatedAt: from.Add(time.Hour)},
+			{ID: 2, AccountID: account.ID, Amount: -20, RunningBalance: 130, CreatedAt: from.Add(2 * time.Hour)},
+		},
+	}
+
+	validRequest := &pb.GetAccountStatementRequest{
+		AccountId: account.ID,
+		From:      timestamppb.New(from),
+		To:        timestamppb.New(to),
+	}
+
+	testCases := []struct {
+		name          string
+		req           *pb.GetAccountStatementRequest
+		buildStubs    func(store *mockdb.MockStore)
+		buildContext  func(t *testing.T, tokenMaker token.Maker) context.Context
+		checkResponse func(t *testing.T, res *pb.GetAccountStatementResponse, err error)
+	}{
+		{
+			name: "OK",
+			req:  validRequest,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account.ID)).Times(1).Return(account, nil)
+
+				arg := db.AccountStatementParams{
+					AccountID: account.ID,
+					From:      from.UTC(),
+					To:        to.UTC(),
+				}
+				store.EXPECT().
+					GetAccountStatement(gomock.Any(), gomock.Eq(arg)).
+					Times(1).
+					Return(statement, nil)
+			},
+			buildContext: func(t *testing.T, tokenMaker token.Maker) context.Context {
+				return newContextWithBearerToken(t, tokenMaker, user.Username, user.Role, time.Minute)
+			},
+			checkResponse: func(t *testing.T, res *pb.GetAccountStatementResponse, err error) {
+				require.NoError(t, err)
+				require.Equal(t, account.ID, res.GetAccountId())
+				require.Equal(t, statement.OpeningBalance, res.GetOpeningBalance())
+				require.Equal(t, statement.ClosingBalance, res.GetClosingBalance())
+				require.Len(t, res.GetEntries(), len(statement.Entries))
+				for i, entry := range res.GetEntries() {
+					require.Equal(t, statement.Entries[i].ID, entry.GetId())
+					require.Equal(t, statement.Entries[i].Amount, entry.GetAmount())
+					require.Equal(t, statement.Entries[i].RunningBalance, entry.GetRunningBalance())
+				}
+			},
+		},
+		{
+			name: "BankerCanReadAnyAccount",
+			req:  validRequest,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account.ID)).Times(1).Return(account, nil)
+				store.EXPECT().
+					GetAccountStatement(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(statement, nil)
+			},
+			buildContext: func(t *testing.T, tokenMaker token.Maker) context.Context {
+				return newContextWithBearerToken(t, tokenMaker, banker.Username, banker.Role, time.Minute)
+			},
+			checkResponse: func(t *testing.T, res *pb.GetAccountStatementResponse, err error) {
+				require.NoError(t, err)
+				require.Len(t, res.GetEntries(), len(statement.Entries))
+			},
+		},
+		{
+			name: "PermissionDenied",
+			req:  validRequest,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account.ID)).Times(1).Return(account, nil)
+				store.EXPECT().GetAccountStatement(gomock.Any(), gomock.Any()).Times(0)
+			},
+			buildContext: func(t *testing.T, tokenMaker token.Maker) context.Context {
+				return newContextWithBearerToken(t, tokenMaker, other.Username, other.Role, time.Minute)
+			},
+			checkResponse: func(t *testing.T, res *pb.GetAccountStatementResponse, err error) {
+				require.Error(t, err)
+				st, ok := status.FromError(err)
+				require.True(t, ok)
+				require.Equal(t, codes.PermissionDenied, st.Code())
+			},
+		},
+		{
+			name: "InvalidRange",
+			req: &pb.GetAccountStatementRequest{
+				AccountId: account.ID,
+				From:      timestamppb.New(to),
+				To:        timestamppb.New(from),
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Any()).Times(0)
+				store.EXPECT().GetAccountStatement(gomock.Any(), gomock.Any()).Times(0)
+			},
+			buildContext: func(t *testing.T, tokenMaker token.Maker) context.Context {
+				return newContextWithBearerToken(t, tokenMaker, user.Username, user.Role, time.Minute)
+			},
+			checkResponse: func(t *testing.T, res *pb.GetAccountStatementResponse, err error) {
+				require.Error(t, err)
+				st, ok := status.FromError(err)
+				require.True(t, ok)
+				require.Equal(t, codes.InvalidArgument, st.Code())
+			},
+		},
+		{
+			name: "MissingFrom",
+			req: &pb.GetAccountStatementRequest{
+				AccountId: account.ID,
+				To:        timestamppb.New(to),
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Any()).Times(0)
+				store.EXPECT().GetAccountStatement(gomock.Any(), gomock.Any()).Times(0)
+			},
+			buildContext: func(t *testing.T, tokenMaker token.Maker) context.Context {
+				return newContextWithBearerToken(t, tokenMaker, user.Username, user.Role, time.Minute)
+			},
+			checkResponse: func(t *testing.T, res *pb.GetAccountStatementResponse, err error) {
+				require.Error(t, err)
+				st, ok := status.FromError(err)
+				require.True(t, ok)
+				require.Equal(t, codes.InvalidArgument, st.Code())
+			},
+		},
+		{
+			name: "AccountNotFound",
+			req:  validRequest,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account.ID)).Times(1).Return(db.Account{}, db.ErrRecordNotFound)
+				store.EXPECT().GetAccountStatement(gomock.Any(), gomock.Any()).Times(0)
+			},
+			buildContext: func(t *testing.T, tokenMaker token.Maker) context.Context {
+				return newContextWithBearerToken(t, tokenMaker, user.Username, user.Role, time.Minute)
+			},
+			checkResponse: func(t *testing.T, res *pb.GetAccountStatementResponse, err error) {
+				require.Error(t, err)
+				st, ok := status.FromError(err)
+				require.True(t, ok)
+				require.Equal(t, codes.NotFound, st.Code())
+			},
+		},
+		{
+			name: "InternalError",
+			req:  validRequest,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account.ID)).Times(1).Return(account, nil)
+				store.EXPECT().
+					GetAccountStatement(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(db.AccountStatement{}, sql.ErrConnDone)
+			},
+			buildContext: func(t *testing.T, tokenMaker token.Maker) context.Context {
+				return newContextWithBearerToken(t, tokenMaker, user.Username, user.Role, time.Minute)
+			},
+			checkResponse: func(t *testing.T, res *pb.GetAccountStatementResponse, err error) {
+				require.Error(t, err)
+				st, ok := status.FromError(err)
+				require.True(t, ok)
+				require.Equal(t, codes.Internal, st.Code())
+			},
+		},
+		{
+			name: "NoAuthorization",
+			req:  validRequest,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Any()).Times(0)
+				store.EXPECT().GetAccountStatement(gomock.Any(), gomock.Any()).Times(0)
+			},
+			buildContext: func(t *testing.T, tokenMaker token.Maker) context.Context {
+				return context.Background()
+			},
+			checkResponse: func(t *testing.T, res *pb.GetAccountStatementResponse, err error) {
+				require.Error(t, err)
+				st, ok := status.FromError(err)
+				require.True(t, ok)
+				require.Equal(t, codes.Unauthenticated, st.Code())
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			storeCtrl := gomock.NewController(t)
+			defer storeCtrl.Finish()
+			store := mockdb.NewMockStore(storeCtrl)
+
+			tc.buildStubs(store)
+			server := newTestServer(t, store, nil)
+
+			ctx := tc.buildContext(t, server.tokenMaker)
+			res, err := server.GetAccountStatement(ctx, tc.req)
+			tc.checkResponse(t, res, err)
+		})
+	}
+}
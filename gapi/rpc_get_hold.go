@@ -0,0 +1,82 @@
+package gapi
+
+import (
+	"context"
+	"errors"
+
+	db "github.com/tonisco/simple-bank-go/db/sqlc"
+	"github.com/tonisco/simple-bank-go/pb"
+	"github.com/tonisco/simple-bank-go/token"
+	"github.com/tonisco/simple-bank-go/util"
+	"github.com/tonisco/simple-bank-go/val"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func (server *Server) GetHold(ctx context.Context, req *pb.GetHoldRequest) (*pb.GetHoldResponse, error) {
+	authPayload, err := server.authorizeUser(ctx, []string{util.BankerRole, util.DepositorRole})
+
+	if err != nil {
+		return nil, unauthenticatedError(err)
+	}
+
+	violations := validateGetHoldRequest(req)
+
+	if violations != nil {
+		return nil, invalidArgumentError(violations)
+	}
+
+	hold, err := server.authorizeHold(ctx, authPayload, req.GetId(), true)
+	if err != nil {
+		return nil, err
+	}
+
+	rsp := &pb.GetHoldResponse{
+		Hold: convertHold(hold),
+	}
+	return rsp, nil
+}
+
+// authorizeHold gets a hold the authenticated user can act on: one paying to their account or,
+// when payer is set, one placed on their account. Bankers can act on any hold
+func (server *Server) authorizeHold(ctx context.Context, authPayload *token.Payload, id int64, payer bool) (db.Hold, error) {
+	hold, err := server.store.GetHold(ctx, id)
+
+	if err != nil {
+		if errors.Is(err, db.ErrRecordNotFound) {
+			return db.Hold{}, status.Errorf(codes.NotFound, "hold not found")
+		}
+		return db.Hold{}, status.Errorf(codes.Internal, "failed to get hold: %s", err)
+	}
+
+	if authPayload.Role == util.BankerRole {
+		return hold, nil
+	}
+
+	accountIDs := []int64{hold.ToAccountID}
+	if payer {
+		accountIDs = append(accountIDs, hold.AccountID)
+	}
+
+	for _, accountID := range accountIDs {
+		account, err := server.store.GetAccount(ctx, accountID)
+		if err != nil {
+			return db.Hold{}, status.Errorf(codes.Internal, "failed to get account: %s", err)
+		}
+
+		if account.Owner == authPayload.Username {
+			return hold, nil
+		}
+	}
+
+	return db.Hold{}, status.Errorf(codes.PermissionDenied, "hold does not belong to the authenticated user")
+}
+
+func validateGetHoldRequest(req *pb.GetHoldRequest) (violations []*errdetails.BadRequest_FieldViolation) {
+	if err := val.ValidateID(req.GetId()); err != nil {
+		violations = append(violations, fieldViolation("id", err))
+	}
+
+	return violations
+}
@@ -0,0 +1,65 @@
+package gapi
+
+import (
+	"context"
+	"errors"
+
+	db "github.com/tonisco/simple-bank-go/db/sqlc"
+	"github.com/tonisco/simple-bank-go/pb"
+	"github.com/tonisco/simple-bank-go/token"
+	"github.com/tonisco/simple-bank-go/util"
+	"github.com/tonisco/simple-bank-go/val"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func (server *Server) GetScheduledTransfer(ctx context.Context, req *pb.GetScheduledTransferRequest) (*pb.GetScheduledTransferResponse, error) {
+	authPayload, err := server.authorizeUser(ctx, []string{util.BankerRole, util.DepositorRole})
+
+	if err != nil {
+		return nil, unauthenticatedError(err)
+	}
+
+	violations := validateGetScheduledTransferRequest(req)
+
+	if violations != nil {
+		return nil, invalidArgumentError(violations)
+	}
+
+	scheduled, err := server.ownScheduledTransfer(ctx, authPayload, req.GetId())
+	if err != nil {
+		return nil, err
+	}
+
+	rsp := &pb.GetScheduledTransferResponse{
+		ScheduledTransfer: convertScheduledTransfer(scheduled),
+	}
+	return rsp, nil
+}
+
+// ownScheduledTransfer gets a scheduled transfer the authenticated user owns, bankers can get any
+func (server *Server) ownScheduledTransfer(ctx context.Context, authPayload *token.Payload, id int64) (db.ScheduledTransfer, error) {
+	scheduled, err := server.store.GetScheduledTransfer(ctx, id)
+
+	if err != nil {
+		if errors.Is(err, db.ErrRecordNotFound) {
+			return db.ScheduledTransfer{}, status.Errorf(codes.NotFound, "scheduled transfer not found")
+		}
+		return db.ScheduledTransfer{}, status.Errorf(codes.Internal, "failed to get scheduled transfer: %s", err)
+	}
+
+	if !isAccountOwnerOrBanker(authPayload, scheduled.Owner) {
+		return db.ScheduledTransfer{}, status.Errorf(codes.PermissionDenied, "scheduled transfer does not belong to the authenticated user")
+	}
+
+	return scheduled, nil
+}
+
+func validateGetScheduledTransferRequest(req *pb.GetScheduledTransferRequest) (violations []*errdetails.BadRequest_FieldViolation) {
+	if err := val.ValidateID(req.GetId()); err != nil {
+		violations = append(violations, fieldViolation("id", err))
+	}
+
+	return violations
+}
@@ -0,0 +1,68 @@
+package gapi
+
+import (
+	"context"
+	"errors"
+
+	db "github.com/tonisco/simple-bank-go/db/sqlc"
+	"github.com/tonisco/simple-bank-go/pb"
+	"github.com/tonisco/simple-bank-go/util"
+	"github.com/tonisco/simple-bank-go/val"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func (server *Server) GetTransfer(ctx context.Context, req *pb.GetTransferRequest) (*pb.GetTransferResponse, error) {
+	authPayload, err := server.authorizeUser(ctx, []string{util.BankerRole, util.DepositorRole})
+
+	if err != nil {
+		return nil, unauthenticatedError(err)
+	}
+
+	violations := validateGetTransferRequest(req)
+
+	if violations != nil {
+		return nil, invalidArgumentError(violations)
+	}
+
+	transfer, err := server.store.GetTransfer(ctx, req.GetId())
+
+	if err != nil {
+		if errors.Is(err, db.ErrRecordNotFound) {
+			return nil, status.Errorf(codes.NotFound, "transfer not found")
+		}
+		return nil, status.Errorf(codes.Internal, "failed to get transfer: %s", err)
+	}
+
+	if authPayload.Role != util.BankerRole {
+		allowed := false
+		for _, accountID := range []int64{transfer.FromAccountID, transfer.ToAccountID} {
+			account, err := server.store.GetAccount(ctx, accountID)
+			if err != nil {
+				return nil, status.Errorf(codes.Internal, "failed to get account: %s", err)
+			}
+			if account.Owner == authPayload.Username {
+				allowed = true
+				break
+			}
+		}
+
+		if !allowed {
+			return nil, status.Errorf(codes.PermissionDenied, "transfer does not involve the authenticated user's accounts")
+		}
+	}
+
+	rsp := &pb.GetTransferResponse{
+		Transfer: convertTransfer(transfer),
+	}
+	return rsp, nil
+}
+
+func validateGetTransferRequest(req *pb.GetTransferRequest) (violations []*errdetails.BadRequest_FieldViolation) {
+	if err := val.ValidateID(req.GetId()); err != nil {
+		violations = append(violations, fieldViolation("id", err))
+	}
+
+	return violations
+}
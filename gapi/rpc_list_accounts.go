@@ -0,0 +1,104 @@
+package gapi
+
+import (
+	"context"
+
+	db "github.com/tonisco/simple-bank-go/db/sqlc"
+	"github.com/tonisco/simple-bank-go/pb"
+	"github.com/tonisco/simple-bank-go/util"
+	"github.com/tonisco/simple-bank-go/val"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+const (
+	defaultPageID   = 1
+	defaultPageSize = 10
+)
+
+func (server *Server) ListAccounts(ctx context.Context, req *pb.ListAccountsRequest) (*pb.ListAccountsResponse, error) {
+	authPayload, err := server.authorizeUser(ctx, []string{util.BankerRole, util.DepositorRole})
+
+	if err != nil {
+		return nil, unauthenticatedError(err)
+	}
+
+	violations := validateListAccountsRequest(req)
+
+	if violations != nil {
+		return nil, invalidArgumentError(violations)
+	}
+
+	owner := authPayload.Username
+	if req.Owner != nil {
+		owner = req.GetOwner()
+	}
+
+	if !isAccountOwnerOrBanker(authPayload, owner) {
+		return nil, status.Errorf(codes.PermissionDenied, "cannot list other user's accounts")
+	}
+
+	if owner == util.SystemUsername {
+		return nil, status.Errorf(codes.PermissionDenied, "cannot list the bank's own accounts")
+	}
+
+	pageID, pageSize := pagination(req.GetPageId(), req.GetPageSize())
+
+	accounts, err := server.store.ListAccounts(ctx, db.ListAccountsParams{
+		Owner:  owner,
+		Limit:  pageSize,
+		Offset: (pageID - 1) * pageSize,
+	})
+
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "failed to list accounts: %s", err)
+	}
+
+	rsp := &pb.ListAccountsResponse{
+		Accounts: make([]*pb.Account, len(accounts)),
+	}
+	for i, account := range accounts {
+		rsp.Accounts[i] = convertAccount(account)
+	}
+	return rsp, nil
+}
+
+func validateListAccountsRequest(req *pb.ListAccountsRequest) (violations []*errdetails.BadRequest_FieldViolation) {
+	if req.Owner != nil {
+		if err := val.ValidateUsername(req.GetOwner()); err != nil {
+			violations = append(violations, fieldViolation("owner", err))
+		}
+	}
+
+	violations = append(violations, validatePagination(req.GetPageId(), req.GetPageSize())...)
+
+	return violations
+}
+
+// validatePagination checks the page fields that were set, unset fields fall back to the defaults
+func validatePagination(pageID, pageSize int32) (violations []*errdetails.BadRequest_FieldViolation) {
+	if pageID != 0 {
+		if err := val.ValidatePageID(pageID); err != nil {
+			violations = append(violations, fieldViolation("page_id", err))
+		}
+	}
+
+	if pageSize != 0 {
+		if err := val.ValidatePageSize(pageSize); err != nil {
+			violations = append(violations, fieldViolation("page_size", err))
+		}
+	}
+
+	return violations
+}
+
+func pagination(pageID, pageSize int32) (int32, int32) {
+	if pageID == 0 {
+		pageID = defaultPageID
+	}
+	if pageSize == 0 {
+		pageSize = defaultPageSize
+	}
+	return pageID, pageSize
+}
@@ -0,0 +1,62 @@
+package gapi
+
+import (
+	"context"
+
+	db "github.com/tonisco/simple-bank-go/db/sqlc"
+	"github.com/tonisco/simple-bank-go/pb"
+	"github.com/tonisco/simple-bank-go/util"
+	"github.com/tonisco/simple-bank-go/val"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func (server *Server) ListScheduledTransferRuns(ctx context.Context, req *pb.ListScheduledTransferRunsRequest) (*pb.ListScheduledTransferRunsResponse, error) {
+	authPayload, err := server.authorizeUser(ctx, []string{util.BankerRole, util.DepositorRole})
+
+	if err != nil {
+		return nil, unauthenticatedError(err)
+	}
+
+	violations := validateListScheduledTransferRunsRequest(req)
+
+	if violations != nil {
+		return nil, invalidArgumentError(violations)
+	}
+
+	scheduled, err := server.ownScheduledTransfer(ctx, authPayload, req.GetScheduledTransferId())
+	if err != nil {
+		return nil, err
+	}
+
+	pageID, pageSize := pagination(req.GetPageId(), req.GetPageSize())
+
+	runs, err := server.store.ListScheduledTransferRuns(ctx, db.ListScheduledTransferRunsParams{
+		ScheduledTransferID: scheduled.ID,
+		Limit:               pageSize,
+		Offset:              (pageID - 1) * pageSize,
+	})
+
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "failed to list scheduled transfer runs: %s", err)
+	}
+
+	rsp := &pb.ListScheduledTransferRunsResponse{
+		Runs: make([]*pb.ScheduledTransferRun, len(runs)),
+	}
+	for i, run := range runs {
+		rsp.Runs[i] = convertScheduledTransferRun(run)
+	}
+	return rsp, nil
+}
+
+func validateListScheduledTransferRunsRequest(req *pb.ListScheduledTransferRunsRequest) (violations []*errdetails.BadRequest_FieldViolation) {
+	if err := val.ValidateID(req.GetScheduledTransferId()); err != nil {
+		violations = append(violations, fieldViolation("scheduled_transfer_id", err))
+	}
+
+	violations = append(violations, validatePagination(req.GetPageId(), req.GetPageSize())...)
+
+	return violations
+}
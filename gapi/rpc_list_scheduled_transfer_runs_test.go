@@ -0,0 +1,196 @@
+package gapi
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/stretchr/testify/require"
+	mockdb "github.com/tonisco/simple-bank-go/db/mock"
+	db "github.com/tonisco/simple-bank-go/db/sqlc"
+	"github.com/tonisco/simple-bank-go/pb"
+	"github.com/tonisco/simple-bank-go/token"
+	"github.com/tonisco/simple-bank-go/util"
+	"go.uber.org/mock/gomock"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func TestListScheduledTransferRunsAPI(t *testing.T) {
+	user, _ := randomUser(t)
+	user1, _ := randomUser(t)
+	banker, _ := randomUser(t)
+	banker.Role = util.BankerRole
+
+	scheduled := randomScheduledTransfer(user.Username, 1, 2)
+
+	n := 5
+	runs := make([]db.ScheduledTransferRun, n)
+	for i := 0; i < n; i++ {
+		runs[i] = db.ScheduledTransferRun{
+			ID:                  util.RandomInt(1, 1000),
+			ScheduledTransferID: scheduled.ID,
+			RunAt:               time.Now().UTC().Truncate(time.Second),
+			Status:              db.ScheduledTransferRunSucceeded,
+			TransferID:          pgtype.Int8{Int64: util.RandomInt(1, 1000), Valid: true},
+			CreatedAt:           time.Now().UTC().Truncate(time.Second),
+		}
+	}
+
+	testCases := []struct {
+		name          string
+		req           *pb.ListScheduledTransferRunsRequest
+		buildStubs    func(store *mockdb.MockStore)
+		buildContext  func(t *testing.T, tokenMaker token.Maker) context.Context
+		checkResponse func(t *testing.T, res *pb.ListScheduledTransferRunsResponse, err error)
+	}{
+		{
+			name: "OK",
+			req: &pb.ListScheduledTransferRunsRequest{
+				ScheduledTransferId: scheduled.ID,
+				PageId:              1,
+				PageSize:            int32(n),
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetScheduledTransfer(gomock.Any(), gomock.Eq(scheduled.ID)).Times(1).Return(scheduled, nil)
+
+				arg := db.ListScheduledTransferRunsParams{
+					ScheduledTransferID: scheduled.ID,
+					Limit:               int32(n),
+					Offset:              0,
+				}
+				store.EXPECT().
+					ListScheduledTransferRuns(gomock.Any(), gomock.Eq(arg)).
+					Times(1).
+					Return(runs, nil)
+			},
+			buildContext: func(t *testing.T, tokenMaker token.Maker) context.Context {
+				return newContextWithBearerToken(t, tokenMaker, user.Username, user.Role, time.Minute)
+			},
+			checkResponse: func(t *testing.T, res *pb.ListScheduledTransferRunsResponse, err error) {
+				require.NoError(t, err)
+				require.Len(t, res.GetRuns(), n)
+				for i, run := range res.GetRuns() {
+					require.Equal(t, runs[i].ID, run.GetId())
+					require.Equal(t, scheduled.ID, run.GetScheduledTransferId())
+					require.Equal(t, runs[i].Status, run.GetStatus())
+					require.Equal(t, runs[i].TransferID.Int64, run.GetTransferId())
+				}
+			},
+		},
+		{
+			name: "Banker",
+			req: &pb.ListScheduledTransferRunsRequest{
+				ScheduledTransferId: scheduled.ID,
+				PageId:              2,
+				PageSize:            int32(n),
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetScheduledTransfer(gomock.Any(), gomock.Eq(scheduled.ID)).Times(1).Return(scheduled, nil)
+
+				arg := db.ListScheduledTransferRunsParams{
+					ScheduledTransferID: scheduled.ID,
+					Limit:               int32(n),
+					Offset:              int32(n),
+				}
+				store.EXPECT().
+					ListScheduledTransferRuns(gomock.Any(), gomock.Eq(arg)).
+					Times(1).
+					Return(runs, nil)
+			},
+			buildContext: func(t *testing.T, tokenMaker token.Maker) context.Context {
+				return newContextWithBearerToken(t, tokenMaker, banker.Username, banker.Role, time.Minute)
+			},
+			checkResponse: func(t *testing.T, res *pb.ListScheduledTransferRunsResponse, err error) {
+				require.NoError(t, err)
+				require.Len(t, res.GetRuns(), n)
+			},
+		},
+		{
+			name: "OtherUser",
+			req:  &pb.ListScheduledTransferRunsRequest{ScheduledTransferId: scheduled.ID},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetScheduledTransfer(gomock.Any(), gomock.Eq(scheduled.ID)).Times(1).Return(scheduled, nil)
+				store.EXPECT().ListScheduledTransferRuns(gomock.Any(), gomock.Any()).Times(0)
+			},
+			buildContext: func(t *testing.T, tokenMaker token.Maker) context.Context {
+				return newContextWithBearerToken(t, tokenMaker, user1.Username, user1.Role, time.Minute)
+			},
+			checkResponse: func(t *testing.T, res *pb.ListScheduledTransferRunsResponse, err error) {
+				require.Error(t, err)
+				st, ok := status.FromError(err)
+				require.True(t, ok)
+				require.Equal(t, codes.PermissionDenied, st.Code())
+			},
+		},
+		{
+			name: "NotFound",
+			req:  &pb.ListScheduledTransferRunsRequest{ScheduledTransferId: scheduled.ID},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetScheduledTransfer(gomock.Any(), gomock.Eq(scheduled.ID)).Times(1).Return(db.ScheduledTransfer{}, db.ErrRecordNotFound)
+				store.EXPECT().ListScheduledTransferRuns(gomock.Any(), gomock.Any()).Times(0)
+			},
+			buildContext: func(t *testing.T, tokenMaker token.Maker) context.Context {
+				return newContextWithBearerToken(t, tokenMaker, user.Username, user.Role, time.Minute)
+			},
+			checkResponse: func(t *testing.T, res *pb.ListScheduledTransferRunsResponse, err error) {
+				require.Error(t, err)
+				st, ok := status.FromError(err)
+				require.True(t, ok)
+				require.Equal(t, codes.NotFound, st.Code())
+			},
+		},
+		{
+			name: "InvalidID",
+			req:  &pb.ListScheduledTransferRunsRequest{ScheduledTransferId: 0},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetScheduledTransfer(gomock.Any(), gomock.Any()).Times(0)
+				store.EXPECT().ListScheduledTransferRuns(gomock.Any(), gomock.Any()).Times(0)
+			},
+			buildContext: func(t *testing.T, tokenMaker token.Maker) context.Context {
+				return newContextWithBearerToken(t, tokenMaker, user.Username, user.Role, time.Minute)
+			},
+			checkResponse: func(t *testing.T, res *pb.ListScheduledTransferRunsResponse, err error) {
+				require.Error(t, err)
+				st, ok := status.FromError(err)
+				require.True(t, ok)
+				require.Equal(t, codes.InvalidArgument, st.Code())
+			},
+		},
+		{
+			name: "NoAuthorization",
+			req:  &pb.ListScheduledTransferRunsRequest{ScheduledTransferId: scheduled.ID},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetScheduledTransfer(gomock.Any(), gomock.Any()).Times(0)
+				store.EXPECT().ListScheduledTransferRuns(gomock.Any(), gomock.Any()).Times(0)
+			},
+			buildContext: func(t *testing.T, tokenMaker token.Maker) context.Context {
+				return context.Background()
+			},
+			checkResponse: func(t *testing.T, res *pb.ListScheduledTransferRunsResponse, err error) {
+				require.Error(t, err)
+				st, ok := status.FromError(err)
+				require.True(t, ok)
+				require.Equal(t, codes.Unauthenticated, st.Code())
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			storeCtrl := gomock.NewController(t)
+			defer storeCtrl.Finish()
+			store := mockdb.NewMockStore(storeCtrl)
+
+			tc.buildStubs(store)
+			server := newTestServer(t, store, nil)
+
+			ctx := tc.buildContext(t, server.tokenMaker)
+			res, err := server.ListScheduledTransferRuns(ctx, tc.req)
+			tc.checkResponse(t, res, err)
+		})
+	}
+}
@@ -0,0 +1,68 @@
+package gapi
+
+import (
+	"context"
+
+	db "github.com/tonisco/simple-bank-go/db/sqlc"
+	"github.com/tonisco/simple-bank-go/pb"
+	"github.com/tonisco/simple-bank-go/util"
+	"github.com/tonisco/simple-bank-go/val"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func (server *Server) ListScheduledTransfers(ctx context.Context, req *pb.ListScheduledTransfersRequest) (*pb.ListScheduledTransfersResponse, error) {
+	authPayload, err := server.authorizeUser(ctx, []string{util.BankerRole, util.DepositorRole})
+
+	if err != nil {
+		return nil, unauthenticatedError(err)
+	}
+
+	violations := validateListScheduledTransfersRequest(req)
+
+	if violations != nil {
+		return nil, invalidArgumentError(violations)
+	}
+
+	owner := authPayload.Username
+	if req.Owner != nil {
+		owner = req.GetOwner()
+	}
+
+	if !isAccountOwnerOrBanker(authPayload, owner) {
+		return nil, status.Errorf(codes.PermissionDenied, "cannot list other user's scheduled transfers")
+	}
+
+	pageID, pageSize := pagination(req.GetPageId(), req.GetPageSize())
+
+	scheduled, err := server.store.ListScheduledTransfers(ctx, db.ListScheduledTransfersParams{
+		Owner:  owner,
+		Limit:  pageSize,
+		Offset: (pageID - 1) * pageSize,
+	})
+
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "failed to list scheduled transfers: %s", err)
+	}
+
+	rsp := &pb.ListScheduledTransfersResponse{
+		ScheduledTransfers: make([]*pb.ScheduledTransfer, len(scheduled)),
+	}
+	for i, s := range scheduled {
+		rsp.ScheduledTransfers[i] = convertScheduledTransfer(s)
+	}
+	return rsp, nil
+}
+
+func validateListScheduledTransfersRequest(req *pb.ListScheduledTransfersRequest) (violations []*errdetails.BadRequest_FieldViolation) {
+	if req.Owner != nil {
+		if err := val.ValidateUsername(req.GetOwner()); err != nil {
+			violations = append(violations, fieldViolation("owner", err))
+		}
+	}
+
+	violations = append(violations, validatePagination(req.GetPageId(), req.GetPageSize())...)
+
+	return violations
+}
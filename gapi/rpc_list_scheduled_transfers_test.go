@@ -0,0 +1,190 @@
+package gapi
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	mockdb "github.com/tonisco/simple-bank-go/db/mock"
+	db "github.com/tonisco/simple-bank-go/db/sqlc"
+	"github.com/tonisco/simple-bank-go/pb"
+	"github.com/tonisco/simple-bank-go/token"
+	"github.com/tonisco/simple-bank-go/util"
+	"go.uber.org/mock/gomock"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func TestListScheduledTransfersAPI(t *testing.T) {
+	user, _ := randomUser(t)
+	user1, _ := randomUser(t)
+	banker, _ := randomUser(t)
+	banker.Role = util.BankerRole
+
+	n := 5
+	scheduled := make([]db.ScheduledTransfer, n)
+	for i := 0; i < n; i++ {
+		scheduled[i] = randomScheduledTransfer(user.Username, 1, 2)
+	}
+
+	testCases := []struct {
+		name          string
+		req           *pb.ListScheduledTransfersRequest
+		buildStubs    func(store *mockdb.MockStore)
+		buildContext  func(t *testing.T, tokenMaker token.Maker) context.Context
+		checkResponse func(t *testing.T, res *pb.ListScheduledTransfersResponse, err error)
+	}{
+		{
+			name: "OK",
+			req: &pb.ListScheduledTransfersRequest{
+				PageId:   1,
+				PageSize: int32(n),
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				arg := db.ListScheduledTransfersParams{
+					Owner:  user.Username,
+					Limit:  int32(n),
+					Offset: 0,
+				}
+				store.EXPECT().
+					ListScheduledTransfers(gomock.Any(), gomock.Eq(arg)).
+					Times(1).
+					Return(scheduled, nil)
+			},
+			buildContext: func(t *testing.T, tokenMaker token.Maker) context.Context {
+				return newContextWithBearerToken(t, tokenMaker, user.Username, user.Role, time.Minute)
+			},
+			checkResponse: func(t *testing.T, res *pb.ListScheduledTransfersResponse, err error) {
+				require.NoError(t, err)
+				require.Len(t, res.GetScheduledTransfers(), n)
+				for i, got := range res.GetScheduledTransfers() {
+					requireScheduledTransferMatch(t, scheduled[i], got)
+				}
+			},
+		},
+		{
+			name: "DefaultPagination",
+			req:  &pb.ListScheduledTransfersRequest{},
+			buildStubs: func(store *mockdb.MockStore) {
+				arg := db.ListScheduledTransfersParams{
+					Owner:  user.Username,
+					Limit:  defaultPageSize,
+					Offset: 0,
+				}
+				store.EXPECT().
+					ListScheduledTransfers(gomock.Any(), gomock.Eq(arg)).
+					Times(1).
+					Return(scheduled, nil)
+			},
+			buildContext: func(t *testing.T, tokenMaker token.Maker) context.Context {
+				return newContextWithBearerToken(t, tokenMaker, user.Username, user.Role, time.Minute)
+			},
+			checkResponse: func(t *testing.T, res *pb.ListScheduledTransfersResponse, err error) {
+				require.NoError(t, err)
+				require.Len(t, res.GetScheduledTransfers(), n)
+			},
+		},
+		{
+			name: "BankerListsOtherUser",
+			req: &pb.ListScheduledTransfersRequest{
+				Owner:    &user.Username,
+				PageId:   2,
+				PageSize: int32(n),
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				arg := db.ListScheduledTransfersParams{
+					Owner:  user.Username,
+					Limit:  int32(n),
+					Offset: int32(n),
+				}
+				store.EXPECT().
+					ListScheduledTransfers(gomock.Any(), gomock.Eq(arg)).
+					Times(1).
+					Return(scheduled, nil)
+			},
+			buildContext: func(t *testing.T, tokenMaker token.Maker) context.Context {
+				return newContextWithBearerToken(t, tokenMaker, banker.Username, banker.Role, time.Minute)
+			},
+			checkResponse: func(t *testing.T, res *pb.ListScheduledTransfersResponse, err error) {
+				require.NoError(t, err)
+				require.Len(t, res.GetScheduledTransfers(), n)
+			},
+		},
+		{
+			name: "DepositorListsOtherUser",
+			req: &pb.ListScheduledTransfersRequest{
+				Owner: &user.Username,
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					ListScheduledTransfers(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			buildContext: func(t *testing.T, tokenMaker token.Maker) context.Context {
+				return newContextWithBearerToken(t, tokenMaker, user1.Username, user1.Role, time.Minute)
+			},
+			checkResponse: func(t *testing.T, res *pb.ListScheduledTransfersResponse, err error) {
+				require.Error(t, err)
+				st, ok := status.FromError(err)
+				require.True(t, ok)
+				require.Equal(t, codes.PermissionDenied, st.Code())
+			},
+		},
+		{
+			name: "InvalidPageSize",
+			req: &pb.ListScheduledTransfersRequest{
+				PageSize: 1000,
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					ListScheduledTransfers(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			buildContext: func(t *testing.T, tokenMaker token.Maker) context.Context {
+				return newContextWithBearerToken(t, tokenMaker, user.Username, user.Role, time.Minute)
+			},
+			checkResponse: func(t *testing.T, res *pb.ListScheduledTransfersResponse, err error) {
+				require.Error(t, err)
+				st, ok := status.FromError(err)
+				require.True(t, ok)
+				require.Equal(t, codes.InvalidArgument, st.Code())
+			},
+		},
+		{
+			name: "NoAuthorization",
+			req:  &pb.ListScheduledTransfersRequest{},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					ListScheduledTransfers(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			buildContext: func(t *testing.T, tokenMaker token.Maker) context.Context {
+				return context.Background()
+			},
+			checkResponse: func(t *testing.T, res *pb.ListScheduledTransfersResponse, err error) {
+				require.Error(t, err)
+				st, ok := status.FromError(err)
+				require.True(t, ok)
+				require.Equal(t, codes.Unauthenticated, st.Code())
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			storeCtrl := gomock.NewController(t)
+			defer storeCtrl.Finish()
+			store := mockdb.NewMockStore(storeCtrl)
+
+			tc.buildStubs(store)
+			server := newTestServer(t, store, nil)
+
+			ctx := tc.buildContext(t, server.tokenMaker)
+			res, err := server.ListScheduledTransfers(ctx, tc.req)
+			tc.checkResponse(t, res, err)
+		})
+	}
+}
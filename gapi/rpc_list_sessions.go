@@ -0,0 +1,69 @@
+package gapi
+
+import (
+	"context"
+
+	db "github.com/tonisco/simple-bank-go/db/sqlc"
+	"github.com/tonisco/simple-bank-go/pb"
+	"github.com/tonisco/simple-bank-go/util"
+	"github.com/tonisco/simple-bank-go/val"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// ListSessions lists the sessions of a user that are neither blocked nor expired, newest first
+func (server *Server) ListSessions(ctx context.Context, req *pb.ListSessionsRequest) (*pb.ListSessionsResponse, error) {
+	authPayload, err := server.authorizeUser(ctx, []string{util.BankerRole, util.DepositorRole})
+
+	if err != nil {
+		return nil, unauthenticatedError(err)
+	}
+
+	violations := validateListSessionsRequest(req)
+
+	if violations != nil {
+		return nil, invalidArgumentError(violations)
+	}
+
+	username := authPayload.Username
+	if req.Username != nil {
+		username = req.GetUsername()
+	}
+
+	if !isAccountOwnerOrBanker(authPayload, username) {
+		return nil, status.Errorf(codes.PermissionDenied, "cannot list other user's sessions")
+	}
+
+	pageID, pageSize := pagination(req.GetPageId(), req.GetPageSize())
+
+	sessions, err := server.store.ListSessions(ctx, db.ListSessionsParams{
+		Username: username,
+		Limit:    pageSize,
+		Offset:   (pageID - 1) * pageSize,
+	})
+
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "failed to list sessions: %s", err)
+	}
+
+	rsp := &pb.ListSessionsResponse{
+		Sessions: make([]*pb.Session, len(sessions)),
+	}
+	for i, session := range sessions {
+		rsp.Sessions[i] = convertSession(session)
+	}
+	return rsp, nil
+}
+
+func validateListSessionsRequest(req *pb.ListSessionsRequest) (violations []*errdetails.BadRequest_FieldViolation) {
+	if req.Username != nil {
+		if err := val.ValidateUsername(req.GetUsername()); err != nil {
+			violations = append(violations, fieldViolation("username", err))
+		}
+	}
+
+	violations = append(violations, validatePagination(req.GetPageId(), req.GetPageSize())...)
+
+	return violations
+}
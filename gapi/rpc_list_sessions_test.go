@@ -0,0 +1,191 @@
+package gapi
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	mockdb "github.com/tonisco/simple-bank-go/db/mock"
+	db "github.com/tonisco/simple-bank-go/db/sqlc"
+	"github.com/tonisco/simple-bank-go/pb"
+	"github.com/tonisco/simple-bank-go/token"
+	"github.com/tonisco/simple-bank-go/util"
+	"go.uber.org/mock/gomock"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func TestListSessionsAPI(t *testing.T) {
+	user, _ := randomUser(t)
+	user1, _ := randomUser(t)
+	banker, _ := randomUser(t)
+	banker.Role = util.BankerRole
+
+	n := 5
+	sessions := make([]db.Session, n)
+	for i := 0; i < n; i++ {
+		sessions[i] = randomSession(user.Username)
+	}
+
+	testCases := []struct {
+		name          string
+		req           *pb.ListSessionsRequest
+		buildStubs    func(store *mockdb.MockStore)
+		buildContext  func(t *testing.T, tokenMaker token.Maker) context.Context
+		checkResponse func(t *testing.T, res *pb.ListSessionsResponse, err error)
+	}{
+		{
+			name: "OK",
+			req: &pb.ListSessionsRequest{
+				PageId:   1,
+				PageSize: int32(n),
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				arg := db.ListSessionsParams{
+					Username: user.Username,
+					Limit:    int32(n),
+					Offset:   0,
+				}
+				store.EXPECT().
+					ListSessions(gomock.Any(), gomock.Eq(arg)).
+					Times(1).
+					Return(sessions, nil)
+			},
+			buildContext: func(t *testing.T, tokenMaker token.Maker) context.Context {
+				return newContextWithBearerToken(t, tokenMaker, user.Username, user.Role, time.Minute)
+			},
+			checkResponse: func(t *testing.T, res *pb.ListSessionsResponse, err error) {
+				require.NoError(t, err)
+				require.Len(t, res.GetSessions(), n)
+				for i, session := range res.GetSessions() {
+					require.Equal(t, sessions[i].ID.String(), session.GetId())
+					require.Equal(t, user.Username, session.GetUsername())
+				}
+			},
+		},
+		{
+			name: "DefaultPagination",
+			req:  &pb.ListSessionsRequest{},
+			buildStubs: func(store *mockdb.MockStore) {
+				arg := db.ListSessionsParams{
+					Username: user.Username,
+					Limit:    defaultPageSize,
+					Offset:   0,
+				}
+				store.EXPECT().
+					ListSessions(gomock.Any(), gomock.Eq(arg)).
+					Times(1).
+					Return(sessions, nil)
+			},
+			buildContext: func(t *testing.T, tokenMaker token.Maker) context.Context {
+				return newContextWithBearerToken(t, tokenMaker, user.Username, user.Role, time.Minute)
+			},
+			checkResponse: func(t *testing.T, res *pb.ListSessionsResponse, err error) {
+				require.NoError(t, err)
+				require.Len(t, res.GetSessions(), n)
+			},
+		},
+		{
+			name: "BankerListsOtherUser",
+			req: &pb.ListSessionsRequest{
+				Username: &user.Username,
+				PageId:   2,
+				PageSize: int32(n),
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				arg := db.ListSessionsParams{
+					Username: user.Username,
+					Limit:    int32(n),
+					Offset:   int32(n),
+				}
+				store.EXPECT().
+					ListSessions(gomock.Any(), gomock.Eq(arg)).
+					Times(1).
+					Return(sessions, nil)
+			},
+			buildContext: func(t *testing.T, tokenMaker token.Maker) context.Context {
+				return newContextWithBearerToken(t, tokenMaker, banker.Username, banker.Role, time.Minute)
+			},
+			checkResponse: func(t *testing.T, res *pb.ListSessionsResponse, err error) {
+				require.NoError(t, err)
+				require.Len(t, res.GetSessions(), n)
+			},
+		},
+		{
+			name: "DepositorListsOtherUser",
+			req: &pb.ListSessionsRequest{
+				Username: &user.Username,
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					ListSessions(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			buildContext: func(t *testing.T, tokenMaker token.Maker) context.Context {
+				return newContextWithBearerToken(t, tokenMaker, user1.Username, user1.Role, time.Minute)
+			},
+			checkResponse: func(t *testing.T, res *pb.ListSessionsResponse, err error) {
+				require.Error(t, err)
+				st, ok := status.FromError(err)
+				require.True(t, ok)
+				require.Equal(t, codes.PermissionDenied, st.Code())
+			},
+		},
+		{
+			name: "InvalidPageSize",
+			req: &pb.ListSessionsRequest{
+				PageSize: 1000,
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					ListSessions(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			buildContext: func(t *testing.T, tokenMaker token.Maker) context.Context {
+				return newContextWithBearerToken(t, tokenMaker, user.Username, user.Role, time.Minute)
+			},
+			checkResponse: func(t *testing.T, res *pb.ListSessionsResponse, err error) {
+				require.Error(t, err)
+				st, ok := status.FromError(err)
+				require.True(t, ok)
+				require.Equal(t, codes.InvalidArgument, st.Code())
+			},
+		},
+		{
+			name: "NoAuthorization",
+			req:  &pb.ListSessionsRequest{},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					ListSessions(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			buildContext: func(t *testing.T, tokenMaker token.Maker) context.Context {
+				return context.Background()
+			},
+			checkResponse: func(t *testing.T, res *pb.ListSessionsResponse, err error) {
+				require.Error(t, err)
+				st, ok := status.FromError(err)
+				require.True(t, ok)
+				require.Equal(t, codes.Unauthenticated, st.Code())
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			storeCtrl := gomock.NewController(t)
+			defer storeCtrl.Finish()
+			store := mockdb.NewMockStore(storeCtrl)
+
+			tc.buildStubs(store)
+			server := newTestServer(t, store, nil)
+
+			ctx := tc.buildContext(t, server.tokenMaker)
+			res, err := server.ListSessions(ctx, tc.req)
+			tc.checkResponse(t, res, err)
+		})
+	}
+}
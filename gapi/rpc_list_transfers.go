@@ -0,0 +1,72 @@
+package gapi
+
+import (
+	"context"
+	"errors"
+
+	db "github.com/tonisco/simple-bank-go/db/sqlc"
+	"github.com/tonisco/simple-bank-go/pb"
+	"github.com/tonisco/simple-bank-go/util"
+	"github.com/tonisco/simple-bank-go/val"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func (server *Server) ListTransfers(ctx context.Context, req *pb.ListTransfersRequest) (*pb.ListTransfersResponse, error) {
+	authPayload, err := server.authorizeUser(ctx, []string{util.BankerRole, util.DepositorRole})
+
+	if err != nil {
+		return nil, unauthenticatedError(err)
+	}
+
+	violations := validateListTransfersRequest(req)
+
+	if violations != nil {
+		return nil, invalidArgumentError(violations)
+	}
+
+	account, err := server.store.GetAccount(ctx, req.GetAccountId())
+
+	if err != nil {
+		if errors.Is(err, db.ErrRecordNotFound) {
+			return nil, status.Errorf(codes.NotFound, "account not found")
+		}
+		return nil, status.Errorf(codes.Internal, "failed to get account: %s", err)
+	}
+
+	if !isAccountOwnerOrBanker(authPayload, account.Owner) {
+		return nil, status.Errorf(codes.PermissionDenied, "account does not belong to the authenticated user")
+	}
+
+	pageID, pageSize := pagination(req.GetPageId(), req.GetPageSize())
+
+	transfers, err := server.store.ListTransfers(ctx, db.ListTransfersParams{
+		FromAccountID: account.ID,
+		ToAccountID:   account.ID,
+		Limit:         pageSize,
+		Offset:        (pageID - 1) * pageSize,
+	})
+
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "failed to list transfers: %s", err)
+	}
+
+	rsp := &pb.ListTransfersResponse{
+		Transfers: make([]*pb.Transfer, len(transfers)),
+	}
+	for i, transfer := range transfers {
+		rsp.Transfers[i] = convertTransfer(transfer)
+	}
+	return rsp, nil
+}
+
+func validateListTransfersRequest(req *pb.ListTransfersRequest) (violations []*errdetails.BadRequest_FieldViolation) {
+	if err := val.ValidateID(req.GetAccountId()); err != nil {
+		violations = append(violations, fieldViolation("account_id", err))
+	}
+
+	violations = append(violations, validatePagination(req.GetPageId(), req.GetPageSize())...)
+
+	return violations
+}
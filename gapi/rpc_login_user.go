@@ -4,6 +4,7 @@ import (
 	"context"
 	"errors"
 
+	"github.com/google/uuid"
 	db "github.com/tonisco/simple-bank-go/db/sqlc"
 	"github.com/tonisco/simple-bank-go/pb"
 	"github.com/tonisco/simple-bank-go/util"
@@ -21,47 +22,97 @@ func (server *Server) LoginUser(ctx context.Context, req *pb.LoginUserRequest) (
 		return nil, invalidArgumentError(violations)
 	}
 
+	clientIP := server.extractMetadata(ctx).ClientIP
+
+	if err := server.checkLoginLock(ctx, req.GetUsername(), clientIP); err != nil {
+		return nil, err
+	}
+
 	user, err := server.store.GetUser(ctx, req.GetUsername())
+	if err == nil && user.Role == util.SystemRole {
+		// the bank's own user cannot log in, so it is treated the same as an unknown user
+		err = db.ErrRecordNotFound
+	}
 
 	if err != nil {
 		if errors.Is(err, db.ErrRecordNotFound) {
-			return nil, status.Errorf(codes.NotFound, "user no found")
+			// spend as long as a wrong password does, so the time taken does not give away who has an account
+			_ = util.CheckDummyPassword(req.GetPassword())
+			return nil, server.failLogin(ctx, req.GetUsername(), clientIP, nil)
 		}
 		return nil, status.Errorf(codes.Internal, "failed to find user")
 	}
 
 	err = util.CheckPassword(req.GetPassword(), user.HashedPassword)
 	if err != nil {
-		return nil, status.Errorf(codes.Unauthenticated, "incorrect password")
+		return nil, server.failLogin(ctx, user.Username, clientIP, &user)
 	}
 
-	accessToken, accessPayload, err := server.tokenMaker.CreateToken(
-		user.Username,
+	userTotp, err := server.store.GetUserTotp(ctx, user.Username)
+	if err != nil && !errors.Is(err, db.ErrRecordNotFound) {
+		return nil, status.Errorf(codes.Internal, "failed to get two-factor authentication")
+	}
+
+	// with two-factor authentication on, the password only earns a challenge to exchange with a TOTP code.
+	// The failed attempts are kept until the code is right, so that the password does not buy fresh guesses at it
+	if err == nil && userTotp.ConfirmedAt.Valid {
+		challengeToken, challengePayload, err := server.tokenMaker.CreateToken(
+			user.Username,
+			util.TotpChallengeRole,
+			server.config.TotpChallengeDuration,
+		)
+		if err != nil {
+			return nil, status.Errorf(codes.Internal, "failed to create challenge token")
+		}
+
+		rsp := &pb.LoginUserResponse{
+			TwoFactorRequired:       true,
+			ChallengeToken:          challengeToken,
+			ChallengeTokenExpiresAt: timestamppb.New(challengePayload.ExpiredAt),
+		}
+		return rsp, nil
+	}
+
+	if err := server.resetLoginThrottle(ctx, user.Username); err != nil {
+		return nil, err
+	}
+
+	return server.createLoginSession(ctx, user)
+}
+
+// createLoginSession issues the access and refresh tokens of a new session once a user has proved who they are
+func (server *Server) createLoginSession(ctx context.Context, user db.User) (*pb.LoginUserResponse, error) {
+	refreshToken, refreshPayload, err := server.tokenMaker.CreateRefreshToken(user.Username,
 		user.Role,
-		server.config.AccessTokenDuration,
+		uuid.Nil,
+		server.config.RefreshTokenDuration,
 	)
 	if err != nil {
-		return nil, status.Errorf(codes.Internal, "failed to create access token")
+		return nil, status.Errorf(codes.Internal, "failed to create token")
 	}
 
-	refreshToken, refreshPayload, err := server.tokenMaker.CreateToken(req.Username,
+	// the refresh token id is the id of the session and of its family, which the access token is tied to
+	accessToken, accessPayload, err := server.tokenMaker.CreateSessionToken(
+		user.Username,
 		user.Role,
-		server.config.RefreshTokenDuration,
+		refreshPayload.ID,
+		server.config.AccessTokenDuration,
 	)
 	if err != nil {
-		return nil, status.Errorf(codes.Internal, "failed to create token")
+		return nil, status.Errorf(codes.Internal, "failed to create access token")
 	}
 
 	mtdt := server.extractMetadata(ctx)
 
 	session, err := server.store.CreateSession(ctx, db.CreateSessionParams{
 		ID:           refreshPayload.ID,
-		Username:     req.Username,
+		Username:     user.Username,
 		RefreshToken: refreshToken,
 		UserAgent:    mtdt.UserAgent,
-		ClientIp:     mtdt.UserAgent,
+		ClientIp:     mtdt.ClientIP,
 		IsBlocked:    false,
 		ExpiresAt:    refreshPayload.ExpiredAt,
+		FamilyID:     refreshPayload.ID,
 	})
 
 	if err != nil {
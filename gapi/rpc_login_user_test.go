@@ -0,0 +1,402 @@
+package gapi
+
+import (
+	"context"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/stretchr/testify/require"
+	mockdb "github.com/tonisco/simple-bank-go/db/mock"
+	db "github.com/tonisco/simple-bank-go/db/sqlc"
+	"github.com/tonisco/simple-bank-go/pb"
+	"github.com/tonisco/simple-bank-go/util"
+	"github.com/tonisco/simple-bank-go/worker"
+	mockwk "github.com/tonisco/simple-bank-go/worker/mock"
+	"go.uber.org/mock/gomock"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+)
+
+func TestLoginUserAPI(t *testing.T) {
+	user, password := randomUser(t)
+	// the port of the peer address is dropped from the throttled ip
+	clientIP := "203.0.113.7"
+
+	testCases := []struct {
+		name          string
+		req           *pb.LoginUserRequest
+		buildStubs    func(store *mockdb.MockStore, taskDistributor *mockwk.MockTaskDistributor)
+		checkResponse func(t *testing.T, server *Server, res *pb.LoginUserResponse, err error)
+	}{
+		{
+			name: "OK",
+			req: &pb.LoginUserRequest{
+				Username: user.Username,
+				Password: password,
+			},
+			buildStubs: func(store *mockdb.MockStore, taskDistributor *mockwk.MockTaskDistributor) {
+				store.EXPECT().
+					ListActiveLoginLocks(gomock.Any(), gomock.Eq(db.ListActiveLoginLocksParams{
+						Username: user.Username,
+						ClientIp: clientIP,
+					})).
+					Times(1).
+					Return([]db.LoginThrottle{}, nil)
+
+				store.EXPECT().
+					GetUser(gomock.Any(), gomock.Eq(user.Username)).
+					Times(1).
+					Return(user, nil)
+
+				store.EXPECT().
+					DeleteLoginThrottle(gomock.Any(), gomock.Eq(db.DeleteLoginThrottleParams{
+						Scope:   db.LoginThrottleScopeUser,
+						Subject: user.Username,
+					})).
+					Times(1).
+					Return(nil)
+
+				store.EXPECT().
+					GetUserTotp(gomock.Any(), gomock.Eq(user.Username)).
+					Times(1).
+					Return(db.UserTotp{}, db.ErrRecordNotFound)
+
+				store.EXPECT().
+					CreateSession(gomock.Any(), gomock.Any()).
+					Times(1).
+					DoAndReturn(func(_ context.Context, arg db.CreateSessionParams) (db.Session, error) {
+						return db.Session{ID: arg.ID, Username: arg.Username}, nil
+					})
+			},
+			checkResponse: func(t *testing.T, server *Server, res *pb.LoginUserResponse, err error) {
+				require.NoError(t, err)
+				require.False(t, res.GetTwoFactorRequired())
+				require.NotEmpty(t, res.GetAccessToken())
+				require.NotEmpty(t, res.GetRefreshToken())
+				require.Empty(t, res.GetChallengeToken())
+			},
+		},
+		{
+			name: "TwoFactorRequired",
+			req: &pb.LoginUserRequest{
+				Username: user.Username,
+				Password: password,
+			},
+			buildStubs: func(store *mockdb.MockStore, taskDistributor *mockwk.MockTaskDistributor) {
+				store.EXPECT().
+					ListActiveLoginLocks(gomock.Any(), gomock.Eq(db.ListActiveLoginLocksParams{
+						Username: user.Username,
+						ClientIp: clientIP,
+					})).
+					Times(1).
+					Return([]db.LoginThrottle{}, nil)
+
+				store.EXPECT().
+					GetUser(gomock.Any(), gomock.Eq(user.Username)).
+					Times(1).
+					Return(user, nil)
+
+				store.EXPECT().
+					DeleteLoginThrottle(gomock.Any(), gomock.Any()).
+					Times(0)
+
+				store.EXPECT().
+					GetUserTotp(gomock.Any(), gomock.Eq(user.Username)).
+					Times(1).
+					Return(db.UserTotp{
+						Username:    user.Username,
+						ConfirmedAt: pgtype.Timestamptz{Time: time.Now(), Valid: true},
+					}, nil)
+
+				store.EXPECT().
+					CreateSession(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkResponse: func(t *testing.T, server *Server, res *pb.LoginUserResponse, err error) {
+				require.NoError(t, err)
+				require.True(t, res.GetTwoFactorRequired())
+				require.Empty(t, res.GetAccessToken())
+				require.Empty(t, res.GetRefreshToken())
+
+				payload, err := server.tokenMaker.VerifyToken(res.GetChallengeToken())
+				require.NoError(t, err)
+				require.Equal(t, util.TotpChallengeRole, payload.Role)
+				require.Equal(t, user.Username, payload.Username)
+			},
+		},
+		{
+			name: "UnconfirmedTotp",
+			req: &pb.LoginUserRequest{
+				Username: user.Username,
+				Password: password,
+			},
+			buildStubs: func(store *mockdb.MockStore, taskDistributor *mockwk.MockTaskDistributor) {
+				store.EXPECT().
+					ListActiveLoginLocks(gomock.Any(), gomock.Eq(db.ListActiveLoginLocksParams{
+						Username: user.Username,
+						ClientIp: clientIP,
+					})).
+					Times(1).
+					Return([]db.LoginThrottle{}, nil)
+
+				store.EXPECT().
+					GetUser(gomock.Any(), gomock.Eq(user.Username)).
+					Times(1).
+					Return(user, nil)
+
+				store.EXPECT().
+					DeleteLoginThrottle(gomock.Any(), gomock.Eq(db.DeleteLoginThrottleParams{
+						Scope:   db.LoginThrottleScopeUser,
+						Subject: user.Username,
+					})).
+					Times(1).
+					Return(nil)
+
+				store.EXPECT().
+					GetUserTotp(gomock.Any(), gomock.Eq(user.Username)).
+					Times(1).
+					Return(db.UserTotp{Username: user.Username}, nil)
+
+				store.EXPECT().
+					CreateSession(gomock.Any(), gomock.Any()).
+					Times(1).
+					DoAndReturn(func(_ context.Context, arg db.CreateSessionParams) (db.Session, error) {
+						return db.Session{ID: arg.ID, Username: arg.Username}, nil
+					})
+			},
+			checkResponse: func(t *testing.T, server *Server, res *pb.LoginUserResponse, err error) {
+				require.NoError(t, err)
+				require.False(t, res.GetTwoFactorRequired())
+				require.NotEmpty(t, res.GetAccessToken())
+			},
+		},
+		{
+			name: "IncorrectPassword",
+			req: &pb.LoginUserRequest{
+				Username: user.Username,
+				Password: "incorrect",
+			},
+			buildStubs: func(store *mockdb.MockStore, taskDistributor *mockwk.MockTaskDistributor) {
+				store.EXPECT().
+					ListActiveLoginLocks(gomock.Any(), gomock.Eq(db.ListActiveLoginLocksParams{
+						Username: user.Username,
+						ClientIp: clientIP,
+					})).
+					Times(1).
+					Return([]db.LoginThrottle{}, nil)
+
+				store.EXPECT().
+					GetUser(gomock.Any(), gomock.Eq(user.Username)).
+					Times(1).
+					Return(user, nil)
+
+				store.EXPECT().
+					RecordFailedLoginTx(gomock.Any(), gomock.Any()).
+					Times(1).
+					DoAndReturn(func(_ context.Context, arg db.RecordFailedLoginTxParams) (db.RecordFailedLoginTxResult, error) {
+						require.Equal(t, user.Username, arg.Username)
+						require.Equal(t, clientIP, arg.ClientIP)
+						return db.RecordFailedLoginTxResult{}, nil
+					})
+
+				store.EXPECT().
+					DeleteLoginThrottle(gomock.Any(), gomock.Any()).
+					Times(0)
+
+				store.EXPECT().
+					GetUserTotp(gomock.Any(), gomock.Any()).
+					Times(0)
+
+				taskDistributor.EXPECT().
+					DistributeTaskSendLockoutEmail(gomock.Any(), gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkResponse: func(t *testing.T, server *Server, res *pb.LoginUserResponse, err error) {
+				require.Error(t, err)
+				st, ok := status.FromError(err)
+				require.True(t, ok)
+				require.Equal(t, codes.Unauthenticated, st.Code())
+				require.Equal(t, loginFailedMessage, st.Message())
+			},
+		},
+		{
+			name: "UserNotFound",
+			req: &pb.LoginUserRequest{
+				Username: "notfound",
+				Password: password,
+			},
+			buildStubs: func(store *mockdb.MockStore, taskDistributor *mockwk.MockTaskDistributor) {
+				store.EXPECT().
+					ListActiveLoginLocks(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return([]db.LoginThrottle{}, nil)
+
+				store.EXPECT().
+					GetUser(gomock.Any(), gomock.Eq("notfound")).
+					Times(1).
+					Return(db.User{}, db.ErrRecordNotFound)
+
+				// unknown usernames lock like real ones, but there is no one to email
+				store.EXPECT().
+					RecordFailedLoginTx(gomock.Any(), gomock.Any()).
+					Times(1).
+					DoAndReturn(func(_ context.Context, arg db.RecordFailedLoginTxParams) (db.RecordFailedLoginTxResult, error) {
+						require.Equal(t, "notfound", arg.Username)
+						return db.RecordFailedLoginTxResult{UserLocked: true}, nil
+					})
+
+				taskDistributor.EXPECT().
+					DistributeTaskSendLockoutEmail(gomock.Any(), gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkResponse: func(t *testing.T, server *Server, res *pb.LoginUserResponse, err error) {
+				require.Error(t, err)
+				st, ok := status.FromError(err)
+				require.True(t, ok)
+				require.Equal(t, codes.Unauthenticated, st.Code())
+				require.Equal(t, loginFailedMessage, st.Message())
+			},
+		},
+		{
+			name: "SystemUser",
+			req: &pb.LoginUserRequest{
+				Username: util.SystemUsername,
+				Password: password,
+			},
+			buildStubs: func(store *mockdb.MockStore, taskDistributor *mockwk.MockTaskDistributor) {
+				store.EXPECT().
+					ListActiveLoginLocks(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return([]db.LoginThrottle{}, nil)
+
+				store.EXPECT().
+					GetUser(gomock.Any(), gomock.Eq(util.SystemUsername)).
+					Times(1).
+					Return(db.User{Username: util.SystemUsername, Role: util.SystemRole}, nil)
+
+				store.EXPECT().
+					RecordFailedLoginTx(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(db.RecordFailedLoginTxResult{}, nil)
+
+				store.EXPECT().
+					CreateSession(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkResponse: func(t *testing.T, server *Server, res *pb.LoginUserResponse, err error) {
+				require.Error(t, err)
+				st, ok := status.FromError(err)
+				require.True(t, ok)
+				require.Equal(t, codes.Unauthenticated, st.Code())
+				require.Equal(t, loginFailedMessage, st.Message())
+			},
+		},
+		{
+			name: "LockoutEmail",
+			req: &pb.LoginUserRequest{
+				Username: user.Username,
+				Password: "incorrect",
+			},
+			buildStubs: func(store *mockdb.MockStore, taskDistributor *mockwk.MockTaskDistributor) {
+				lockedUntil := time.Now().Add(time.Minute)
+
+				store.EXPECT().
+					ListActiveLoginLocks(gomock.Any(), gomock.Eq(db.ListActiveLoginLocksParams{
+						Username: user.Username,
+						ClientIp: clientIP,
+					})).
+					Times(1).
+					Return([]db.LoginThrottle{}, nil)
+
+				store.EXPECT().
+					GetUser(gomock.Any(), gomock.Eq(user.Username)).
+					Times(1).
+					Return(user, nil)
+
+				store.EXPECT().
+					RecordFailedLoginTx(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(db.RecordFailedLoginTxResult{
+						UserThrottle: db.LoginThrottle{
+							Scope:       db.LoginThrottleScopeUser,
+							Subject:     user.Username,
+							LockedUntil: pgtype.Timestamptz{Time: lockedUntil, Valid: true},
+						},
+						UserLocked: true,
+					}, nil)
+
+				taskDistributor.EXPECT().
+					DistributeTaskSendLockoutEmail(gomock.Any(), gomock.Eq(&worker.PayloadSendLockoutEmail{
+						Username:    user.Username,
+						LockedUntil: lockedUntil,
+					}), gomock.Any()).
+					Times(1).
+					Return(nil)
+			},
+			checkResponse: func(t *testing.T, server *Server, res *pb.LoginUserResponse, err error) {
+				require.Error(t, err)
+				st, ok := status.FromError(err)
+				require.True(t, ok)
+				require.Equal(t, codes.Unauthenticated, st.Code())
+			},
+		},
+		{
+			name: "Locked",
+			req: &pb.LoginUserRequest{
+				Username: user.Username,
+				Password: password,
+			},
+			buildStubs: func(store *mockdb.MockStore, taskDistributor *mockwk.MockTaskDistributor) {
+				store.EXPECT().
+					ListActiveLoginLocks(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return([]db.LoginThrottle{{
+						Scope:       db.LoginThrottleScopeIP,
+						Subject:     clientIP,
+						LockedUntil: pgtype.Timestamptz{Time: time.Now().Add(time.Minute), Valid: true},
+					}}, nil)
+
+				store.EXPECT().
+					GetUser(gomock.Any(), gomock.Any()).
+					Times(0)
+
+				store.EXPECT().
+					RecordFailedLoginTx(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkResponse: func(t *testing.T, server *Server, res *pb.LoginUserResponse, err error) {
+				require.Error(t, err)
+				st, ok := status.FromError(err)
+				require.True(t, ok)
+				require.Equal(t, codes.ResourceExhausted, st.Code())
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			storeCtrl := gomock.NewController(t)
+			defer storeCtrl.Finish()
+			store := mockdb.NewMockStore(storeCtrl)
+
+			taskCtrl := gomock.NewController(t)
+			defer taskCtrl.Finish()
+			taskDistributor := mockwk.NewMockTaskDistributor(taskCtrl)
+
+			tc.buildStubs(store, taskDistributor)
+			server := newTestServer(t, store, taskDistributor)
+			server.config.TotpChallengeDuration = time.Minute
+
+			ctx := peer.NewContext(context.Background(), &peer.Peer{
+				Addr: &net.TCPAddr{IP: net.ParseIP(clientIP), Port: 54321},
+			})
+			res, err := server.LoginUser(ctx, tc.req)
+			tc.checkResponse(t, server, res, err)
+		})
+	}
+}
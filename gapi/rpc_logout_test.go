@@ -0,0 +1,201 @@
+package gapi
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/require"
+	mockdb "github.com/tonisco/simple-bank-go/db/mock"
+	db "github.com/tonisco/simple-bank-go/db/sqlc"
+	"github.com/tonisco/simple-bank-go/pb"
+	"github.com/tonisco/simple-bank-go/util"
+	"go.uber.org/mock/gomock"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func TestLogoutAPI(t *testing.T) {
+	user, _ := randomUser(t)
+
+	testCases := []struct {
+		name          string
+		buildRequest  func(refreshToken string) *pb.LogoutRequest
+		buildStubs    func(store *mockdb.MockStore, session db.Session)
+		checkResponse func(t *testing.T, res *pb.LogoutResponse, err error)
+	}{
+		{
+			name: "OK",
+			buildRequest: func(refreshToken string) *pb.LogoutRequest {
+				return &pb.LogoutRequest{RefreshToken: refreshToken}
+			},
+			buildStubs: func(store *mockdb.MockStore, session db.Session) {
+				store.EXPECT().
+					GetSession(gomock.Any(), gomock.Eq(session.ID)).
+					Times(1).
+					Return(session, nil)
+				store.EXPECT().
+					BlockSession(gomock.Any(), gomock.Eq(session.ID)).
+					Times(1).
+					Return(session, nil)
+			},
+			checkResponse: func(t *testing.T, res *pb.LogoutResponse, err error) {
+				require.NoError(t, err)
+				require.NotEmpty(t, res.GetMessage())
+			},
+		},
+		{
+			name: "MismatchedToken",
+			buildRequest: func(refreshToken string) *pb.LogoutRequest {
+				return &pb.LogoutRequest{RefreshToken: refreshToken}
+			},
+			buildStubs: func(store *mockdb.MockStore, session db.Session) {
+				session.RefreshToken = util.RandomString(32)
+				store.EXPECT().
+					GetSession(gomock.Any(), gomock.Eq(session.ID)).
+					Times(1).
+					Return(session, nil)
+				store.EXPECT().
+					BlockSession(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkResponse: func(t *testing.T, res *pb.LogoutResponse, err error) {
+				require.Error(t, err)
+				st, ok := status.FromError(err)
+				require.True(t, ok)
+				require.Equal(t, codes.Unauthenticated, st.Code())
+			},
+		},
+		{
+			name: "SessionNotFound",
+			buildRequest: func(refreshToken string) *pb.LogoutRequest {
+				return &pb.LogoutRequest{RefreshToken: refreshToken}
+			},
+			buildStubs: func(store *mockdb.MockStore, session db.Session) {
+				store.EXPECT().
+					GetSession(gomock.Any(), gomock.Eq(session.ID)).
+					Times(1).
+					Return(db.Session{}, db.ErrRecordNotFound)
+				store.EXPECT().
+					BlockSession(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkResponse: func(t *testing.T, res *pb.LogoutResponse, err error) {
+				require.Error(t, err)
+				st, ok := status.FromError(err)
+				require.True(t, ok)
+				require.Equal(t, codes.NotFound, st.Code())
+			},
+		},
+		{
+			name: "InvalidToken",
+			buildRequest: func(refreshToken string) *pb.LogoutRequest {
+				return &pb.LogoutRequest{RefreshToken: "invalid"}
+			},
+			buildStubs: func(store *mockdb.MockStore, session db.Session) {
+				store.EXPECT().
+					GetSession(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkResponse: func(t *testing.T, res *pb.LogoutResponse, err error) {
+				require.Error(t, err)
+				st, ok := status.FromError(err)
+				require.True(t, ok)
+				require.Equal(t, codes.Unauthenticated, st.Code())
+			},
+		},
+		{
+			name: "MissingToken",
+			buildRequest: func(refreshToken string) *pb.LogoutRequest {
+				return &pb.LogoutRequest{}
+			},
+			buildStubs: func(store *mockdb.MockStore, session db.Session) {
+				store.EXPECT().
+					GetSession(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkResponse: func(t *testing.T, res *pb.LogoutResponse, err error) {
+				require.Error(t, err)
+				st, ok := status.FromError(err)
+				require.True(t, ok)
+				require.Equal(t, codes.InvalidArgument, st.Code())
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			storeCtrl := gomock.NewController(t)
+			defer storeCtrl.Finish()
+			store := mockdb.NewMockStore(storeCtrl)
+
+			server := newTestServer(t, store, nil)
+
+			refreshToken, refreshPayload, err := server.tokenMaker.CreateRefreshToken(user.Username, user.Role, uuid.Nil, time.Hour)
+			require.NoError(t, err)
+
+			session := db.Session{
+				ID:           refreshPayload.ID,
+				Username:     user.Username,
+				RefreshToken: refreshToken,
+				ExpiresAt:    refreshPayload.ExpiredAt,
+			}
+			tc.buildStubs(store, session)
+
+			res, err := server.Logout(context.Background(), tc.buildRequest(refreshToken))
+			tc.checkResponse(t, res, err)
+		})
+	}
+}
+
+func TestLogoutRevokesRotatedRefreshTokens(t *testing.T) {
+	user, _ := randomUser(t)
+
+	storeCtrl := gomock.NewController(t)
+	defer storeCtrl.Finish()
+	store := mockdb.NewMockStore(storeCtrl)
+
+	server := newTestServer(t, store, nil)
+
+	// a refresh token of the family that was rotated before the logout
+	firstToken, firstPayload, err := server.tokenMaker.CreateRefreshToken(user.Username, user.Role, uuid.Nil, time.Hour)
+	require.NoError(t, err)
+	rotatedToken, rotatedPayload, err := server.tokenMaker.CreateRefreshToken(user.Username, user.Role, firstPayload.SessionID, time.Hour)
+	require.NoError(t, err)
+	require.Equal(t, firstPayload.ID, rotatedPayload.SessionID)
+
+	session := db.Session{
+		ID:           firstPayload.ID,
+		Username:     user.Username,
+		RefreshToken: firstToken,
+		ExpiresAt:    firstPayload.ExpiredAt,
+		FamilyID:     firstPayload.ID,
+	}
+
+	store.EXPECT().
+		GetSession(gomock.Any(), gomock.Eq(session.ID)).
+		Times(1).
+		Return(session, nil)
+	store.EXPECT().
+		BlockSession(gomock.Any(), gomock.Eq(session.ID)).
+		Times(1).
+		Return(session, nil)
+	store.EXPECT().
+		RotateSessionTx(gomock.Any(), gomock.Any()).
+		Times(0)
+
+	_, err = server.Logout(context.Background(), &pb.LogoutRequest{RefreshToken: firstToken})
+	require.NoError(t, err)
+
+	_, err = server.RenewAccessToken(context.Background(), &pb.RenewAccessTokenRequest{RefreshToken: rotatedToken})
+	require.Error(t, err)
+	st, ok := status.FromError(err)
+	require.True(t, ok)
+	require.Equal(t, codes.Unauthenticated, st.Code())
+
+	_, err = server.authorizeUser(newContextWithToken(rotatedToken), []string{util.DepositorRole})
+	require.Error(t, err)
+}
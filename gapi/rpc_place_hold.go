@@ -0,0 +1,123 @@
+package gapi
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	db "github.com/tonisco/simple-bank-go/db/sqlc"
+	"github.com/tonisco/simple-bank-go/pb"
+	"github.com/tonisco/simple-bank-go/util"
+	"github.com/tonisco/simple-bank-go/val"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// maxHoldDuration is the longest a hold can keep funds reserved
+const maxHoldDuration = 30 * 24 * time.Hour
+
+// PlaceHold reserves funds on the authenticated user's account for a payment to another account of the same currency.
+// Without expires_at the hold expires after the configured hold duration
+func (server *Server) PlaceHold(ctx context.Context, req *pb.PlaceHoldRequest) (*pb.PlaceHoldResponse, error) {
+	authPayload, err := server.authorizeUser(ctx, []string{util.BankerRole, util.DepositorRole})
+
+	if err != nil {
+		return nil, unauthenticatedError(err)
+	}
+
+	violations := validatePlaceHoldRequest(req)
+
+	if violations != nil {
+		return nil, invalidArgumentError(violations)
+	}
+
+	// a hold can be captured without the user, so a large one needs the same step-up as a large transfer
+	if err := server.requireStepUp(authPayload, req.GetAmount(), req.GetCurrency()); err != nil {
+		return nil, err
+	}
+
+	account, err := server.validAccount(ctx, req.GetAccountId(), req.GetCurrency())
+	if err != nil {
+		return nil, err
+	}
+
+	if account.Owner != authPayload.Username {
+		return nil, status.Errorf(codes.PermissionDenied, "account does not belong to the authenticated user")
+	}
+
+	_, err = server.validAccount(ctx, req.GetToAccountId(), req.GetCurrency())
+	if err != nil {
+		return nil, err
+	}
+
+	expiresAt := time.Now().Add(server.config.HoldDuration)
+	if req.ExpiresAt != nil {
+		expiresAt = req.GetExpiresAt().AsTime()
+	}
+
+	result, err := server.store.PlaceHoldTx(ctx, db.PlaceHoldTxParams{
+		AccountID:   req.GetAccountId(),
+		ToAccountID: req.GetToAccountId(),
+		Amount:      req.GetAmount(),
+		ExpiresAt:   expiresAt,
+	})
+
+	if err != nil {
+		return nil, holdError(err, "failed to place hold")
+	}
+
+	rsp := &pb.PlaceHoldResponse{
+		Hold:    convertHold(result.Hold),
+		Account: convertAccount(result.Account),
+	}
+	return rsp, nil
+}
+
+// holdError maps the errors of the hold transactions to gRPC status errors
+func holdError(err error, msg string) error {
+	var limitErr *db.TransferLimitError
+	if errors.As(err, &limitErr) {
+		return transferLimitError(limitErr)
+	}
+	if db.IsLedgerRejection(err) {
+		return status.Errorf(codes.FailedPrecondition, "%s", err)
+	}
+	if errors.Is(err, db.ErrRecordNotFound) {
+		return status.Errorf(codes.NotFound, "hold not found")
+	}
+	return status.Errorf(codes.Internal, "%s: %s", msg, err)
+}
+
+func validatePlaceHoldRequest(req *pb.PlaceHoldRequest) (violations []*errdetails.BadRequest_FieldViolation) {
+	if err := val.ValidateID(req.GetAccountId()); err != nil {
+		violations = append(violations, fieldViolation("account_id", err))
+	}
+
+	if err := val.ValidateID(req.GetToAccountId()); err != nil {
+		violations = append(violations, fieldViolation("to_account_id", err))
+	} else if req.GetToAccountId() == req.GetAccountId() {
+		violations = append(violations, fieldViolation("to_account_id", fmt.Errorf("must be different from account_id")))
+	}
+
+	if err := val.ValidateAmount(req.GetAmount()); err != nil {
+		violations = append(violations, fieldViolation("amount", err))
+	}
+
+	if err := val.ValidateCurrency(req.GetCurrency()); err != nil {
+		violations = append(violations, fieldViolation("currency", err))
+	}
+
+	if req.ExpiresAt != nil {
+		if err := val.ValidateTimestamp(req.GetExpiresAt()); err != nil {
+			violations = append(violations, fieldViolation("expires_at", err))
+		} else if expiresAt := req.GetExpiresAt().AsTime(); !expiresAt.After(time.Now()) {
+			violations = append(violations, fieldViolation("expires_at", fmt.Errorf("must be in the future")))
+		} else if expiresAt.After(time.Now().Add(maxHoldDuration)) {
+			violations = append(violations, fieldViolation("expires_at", fmt.Errorf("must be within %s", maxHoldDuration)))
+		}
+	}
+
+	return violations
+}
@@ -0,0 +1,262 @@
+package gapi
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	mockdb "github.com/tonisco/simple-bank-go/db/mock"
+	db "github.com/tonisco/simple-bank-go/db/sqlc"
+	"github.com/tonisco/simple-bank-go/pb"
+	"github.com/tonisco/simple-bank-go/token"
+	"github.com/tonisco/simple-bank-go/util"
+	"go.uber.org/mock/gomock"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+func randomHold(account db.Account, toAccount db.Account) db.Hold {
+	return db.Hold{
+		ID:          util.RandomInt(1, 1000),
+		AccountID:   account.ID,
+		ToAccountID: toAccount.ID,
+		Amount:      util.RandomInt(2, 100),
+		Status:      db.HoldStatusActive,
+		ExpiresAt:   time.Now().Add(time.Hour).UTC().Truncate(time.Second),
+		CreatedAt:   time.Now().UTC().Truncate(time.Second),
+	}
+}
+
+func TestPlaceHoldAPI(t *testing.T) {
+	user, _ := randomUser(t)
+	user1, _ := randomUser(t)
+
+	account1 := randomAccount(user.Username)
+	account1.ID = 1
+	account2 := randomAccount(user1.Username)
+	account2.ID = 2
+	account2.Currency = account1.Currency
+
+	expiresAt := time.Now().Add(48 * time.Hour).UTC().Truncate(time.Second)
+
+	testCases := []struct {
+		name          string
+		req           *pb.PlaceHoldRequest
+		buildStubs    func(store *mockdb.MockStore)
+		buildContext  func(t *testing.T, tokenMaker token.Maker) context.Context
+		checkResponse func(t *testing.T, res *pb.PlaceHoldResponse, err error)
+	}{
+		{
+			name: "OK",
+			req: &pb.PlaceHoldRequest{
+				AccountId:   account1.ID,
+				ToAccountId: account2.ID,
+				Amount:      10,
+				Currency:    account1.Currency,
+				ExpiresAt:   timestamppb.New(expiresAt),
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account1.ID)).Times(1).Return(account1, nil)
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account2.ID)).Times(1).Return(account2, nil)
+
+				arg := db.PlaceHoldTxParams{
+					AccountID:   account1.ID,
+					ToAccountID: account2.ID,
+					Amount:      10,
+					ExpiresAt:   expiresAt,
+				}
+				heldAccount := account1
+				heldAccount.HeldAmount = 10
+				store.EXPECT().
+					PlaceHoldTx(gomock.Any(), gomock.Eq(arg)).
+					Times(1).
+					Return(db.PlaceHoldTxResult{
+						Hold: db.Hold{
+							ID:          1,
+							AccountID:   account1.ID,
+							ToAccountID: account2.ID,
+							Amount:      10,
+							Status:      db.HoldStatusActive,
+							ExpiresAt:   expiresAt,
+						},
+						Account: heldAccount,
+					}, nil)
+			},
+			buildContext: func(t *testing.T, tokenMaker token.Maker) context.Context {
+				return newContextWithBearerToken(t, tokenMaker, user.Username, user.Role, time.Minute)
+			},
+			checkResponse: func(t *testing.T, res *pb.PlaceHoldResponse, err error) {
+				require.NoError(t, err)
+				require.Equal(t, db.HoldStatusActive, res.GetHold().GetStatus())
+				require.Equal(t, int64(10), res.GetHold().GetAmount())
+				require.Equal(t, account1.Balance, res.GetAccount().GetBalance())
+				require.Equal(t, account1.Balance-10, res.GetAccount().GetAvailableBalance())
+			},
+		},
+		{
+			name: "DefaultExpiry",
+			req: &pb.PlaceHoldRequest{
+				AccountId:   account1.ID,
+				ToAccountId: account2.ID,
+				Amount:      10,
+				Currency:    account1.Currency,
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account1.ID)).Times(1).Return(account1, nil)
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account2.ID)).Times(1).Return(account2, nil)
+				store.EXPECT().
+					PlaceHoldTx(gomock.Any(), gomock.Any()).
+					Times(1).
+					DoAndReturn(func(_ context.Context, arg db.PlaceHoldTxParams) (db.PlaceHoldTxResult, error) {
+						require.WithinDuration(t, time.Now().Add(24*time.Hour), arg.ExpiresAt, time.Second)
+						return db.PlaceHoldTxResult{}, nil
+					})
+			},
+			buildContext: func(t *testing.T, tokenMaker token.Maker) context.Context {
+				return newContextWithBearerToken(t, tokenMaker, user.Username, user.Role, time.Minute)
+			},
+			checkResponse: func(t *testing.T, res *pb.PlaceHoldResponse, err error) {
+				require.NoError(t, err)
+			},
+		},
+		{
+			name: "ExpiryTooFar",
+			req: &pb.PlaceHoldRequest{
+				AccountId:   account1.ID,
+				ToAccountId: account2.ID,
+				Amount:      10,
+				Currency:    account1.Currency,
+				ExpiresAt:   timestamppb.New(time.Now().Add(maxHoldDuration + time.Hour)),
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Any()).Times(0)
+				store.EXPECT().PlaceHoldTx(gomock.Any(), gomock.Any()).Times(0)
+			},
+			buildContext: func(t *testing.T, tokenMaker token.Maker) context.Context {
+				return newContextWithBearerToken(t, tokenMaker, user.Username, user.Role, time.Minute)
+			},
+			checkResponse: func(t *testing.T, res *pb.PlaceHoldResponse, err error) {
+				require.Error(t, err)
+				st, ok := status.FromError(err)
+				require.True(t, ok)
+				require.Equal(t, codes.InvalidArgument, st.Code())
+			},
+		},
+		{
+			name: "NotOwner",
+			req: &pb.PlaceHoldRequest{
+				AccountId:   account2.ID,
+				ToAccountId: account1.ID,
+				Amount:      10,
+				Currency:    account1.Currency,
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account2.ID)).Times(1).Return(account2, nil)
+				store.EXPECT().PlaceHoldTx(gomock.Any(), gomock.Any()).Times(0)
+			},
+			buildContext: func(t *testing.T, tokenMaker token.Maker) context.Context {
+				return newContextWithBearerToken(t, tokenMaker, user.Username, user.Role, time.Minute)
+			},
+			checkResponse: func(t *testing.T, res *pb.PlaceHoldResponse, err error) {
+				require.Error(t, err)
+				st, ok := status.FromError(err)
+				require.True(t, ok)
+				require.Equal(t, codes.PermissionDenied, st.Code())
+			},
+		},
+		{
+			name: "InsufficientFunds",
+			req: &pb.PlaceHoldRequest{
+				AccountId:   account1.ID,
+				ToAccountId: account2.ID,
+				Amount:      10,
+				Currency:    account1.Currency,
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account1.ID)).Times(1).Return(account1, nil)
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account2.ID)).Times(1).Return(account2, nil)
+				store.EXPECT().
+					PlaceHoldTx(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(db.PlaceHoldTxResult{}, db.ErrInsufficientFunds)
+			},
+			buildContext: func(t *testing.T, tokenMaker token.Maker) context.Context {
+				return newContextWithBearerToken(t, tokenMaker, user.Username, user.Role, time.Minute)
+			},
+			checkResponse: func(t *testing.T, res *pb.PlaceHoldResponse, err error) {
+				require.Error(t, err)
+				st, ok := status.FromError(err)
+				require.True(t, ok)
+				require.Equal(t, codes.FailedPrecondition, st.Code())
+			},
+		},
+		{
+			name: "NoAuthorization",
+			req: &pb.PlaceHoldRequest{
+				AccountId:   account1.ID,
+				ToAccountId: account2.ID,
+				Amount:      10,
+				Currency:    account1.Currency,
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Any()).Times(0)
+				store.EXPECT().PlaceHoldTx(gomock.Any(), gomock.Any()).Times(0)
+			},
+			buildContext: func(t *testing.T, tokenMaker token.Maker) context.Context {
+				return context.Background()
+			},
+			checkResponse: func(t *testing.T, res *pb.PlaceHoldResponse, err error) {
+				require.Error(t, err)
+				st, ok := status.FromError(err)
+				require.True(t, ok)
+				require.Equal(t, codes.Unauthenticated, st.Code())
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			storeCtrl := gomock.NewController(t)
+			defer storeCtrl.Finish()
+			store := mockdb.NewMockStore(storeCtrl)
+
+			tc.buildStubs(store)
+			server := newTestServer(t, store, nil)
+
+			ctx := tc.buildContext(t, server.tokenMaker)
+			res, err := server.PlaceHold(ctx, tc.req)
+			tc.checkResponse(t, res, err)
+		})
+	}
+}
+
+func TestPlaceHoldStepUpAPI(t *testing.T) {
+	user, _ := randomUser(t)
+
+	store := mockdb.NewMockStore(gomock.NewController(t))
+	store.EXPECT().
+		PlaceHoldTx(gomock.Any(), gomock.Any()).
+		Times(0)
+
+	server := newTestServer(t, store, nil)
+	server.config.StepUpTransferThresholds = util.CurrencyAmounts{util.JPY: 1000}
+	server.config.ElevatedTokenDuration = time.Minute
+
+	req := &pb.PlaceHoldRequest{
+		AccountId:   1,
+		ToAccountId: 2,
+		Amount:      1001,
+		Currency:    util.JPY,
+	}
+
+	ctx := newContextWithBearerToken(t, server.tokenMaker, user.Username, user.Role, time.Minute)
+	_, err := server.PlaceHold(ctx, req)
+	require.Error(t, err)
+	st, ok := status.FromError(err)
+	require.True(t, ok)
+	require.Equal(t, codes.PermissionDenied, st.Code())
+}
@@ -0,0 +1,101 @@
+package gapi
+
+import (
+	"context"
+	"errors"
+
+	db "github.com/tonisco/simple-bank-go/db/sqlc"
+	"github.com/tonisco/simple-bank-go/pb"
+	"github.com/tonisco/simple-bank-go/util"
+	"github.com/tonisco/simple-bank-go/val"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+// ReauthenticateUser checks the password of the logged in user again, and their TOTP code when two-factor
+// authentication is on, and issues a short-lived elevated token for operations that need a recent login
+func (server *Server) ReauthenticateUser(ctx context.Context, req *pb.ReauthenticateUserRequest) (*pb.ReauthenticateUserResponse, error) {
+	authPayload, err := server.authorizeUser(ctx, []string{util.BankerRole, util.DepositorRole})
+
+	if err != nil {
+		return nil, unauthenticatedError(err)
+	}
+
+	violations := validateReauthenticateUserRequest(req)
+
+	if violations != nil {
+		return nil, invalidArgumentError(violations)
+	}
+
+	// wrong passwords and codes count as failed logins, so a stolen access token cannot be used to guess them
+	clientIP := server.extractMetadata(ctx).ClientIP
+
+	if err := server.checkLoginLock(ctx, authPayload.Username, clientIP); err != nil {
+		return nil, err
+	}
+
+	user, err := server.store.GetUser(ctx, authPayload.Username)
+	if err != nil {
+		if errors.Is(err, db.ErrRecordNotFound) {
+			return nil, status.Errorf(codes.NotFound, "user not found")
+		}
+		return nil, status.Errorf(codes.Internal, "failed to find user")
+	}
+
+	err = util.CheckPassword(req.GetPassword(), user.HashedPassword)
+	if err != nil {
+		return nil, server.failCode(ctx, &user, clientIP, status.Errorf(codes.Unauthenticated, "incorrect password"))
+	}
+
+	userTotp, err := server.store.GetUserTotp(ctx, user.Username)
+	if err != nil && !errors.Is(err, db.ErrRecordNotFound) {
+		return nil, status.Errorf(codes.Internal, "failed to get two-factor authentication")
+	}
+
+	if err == nil && userTotp.ConfirmedAt.Valid {
+		if req.TotpCode == nil {
+			return nil, status.Errorf(codes.Unauthenticated, "totp_code is required when two-factor authentication is on")
+		}
+
+		err = server.useTotpCode(ctx, user.Username, req.GetTotpCode())
+		if err != nil {
+			return nil, server.failCode(ctx, &user, clientIP, err)
+		}
+	}
+
+	if err := server.resetLoginThrottle(ctx, user.Username); err != nil {
+		return nil, err
+	}
+
+	elevatedToken, elevatedPayload, err := server.tokenMaker.CreateElevatedToken(
+		user.Username,
+		user.Role,
+		authPayload.SessionID,
+		server.config.ElevatedTokenDuration,
+	)
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "failed to create elevated token")
+	}
+
+	rsp := &pb.ReauthenticateUserResponse{
+		ElevatedToken:          elevatedToken,
+		ElevatedTokenExpiresAt: timestamppb.New(elevatedPayload.ExpiredAt),
+	}
+	return rsp, nil
+}
+
+func validateReauthenticateUserRequest(req *pb.ReauthenticateUserRequest) (violations []*errdetails.BadRequest_FieldViolation) {
+	if err := val.ValidatePassword(req.GetPassword()); err != nil {
+		violations = append(violations, fieldViolation("password", err))
+	}
+
+	if req.TotpCode != nil {
+		if err := val.ValidateTotpCode(req.GetTotpCode()); err != nil {
+			violations = append(violations, fieldViolation("totp_code", err))
+		}
+	}
+
+	return violations
+}
@@ -0,0 +1,51 @@
+package gapi
+
+import (
+	"context"
+
+	"github.com/tonisco/simple-bank-go/pb"
+	"github.com/tonisco/simple-bank-go/util"
+	"github.com/tonisco/simple-bank-go/val"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+)
+
+// ReleaseHold cancels a hold paying to the authenticated user's account.
+// The account the hold is placed on cannot release it, it has to wait for the hold to expire
+func (server *Server) ReleaseHold(ctx context.Context, req *pb.ReleaseHoldRequest) (*pb.ReleaseHoldResponse, error) {
+	authPayload, err := server.authorizeUser(ctx, []string{util.BankerRole, util.DepositorRole})
+
+	if err != nil {
+		return nil, unauthenticatedError(err)
+	}
+
+	violations := validateReleaseHoldRequest(req)
+
+	if violations != nil {
+		return nil, invalidArgumentError(violations)
+	}
+
+	hold, err := server.authorizeHold(ctx, authPayload, req.GetId(), false)
+	if err != nil {
+		return nil, err
+	}
+
+	result, err := server.store.ReleaseHoldTx(ctx, hold.ID)
+
+	if err != nil {
+		return nil, holdError(err, "failed to release hold")
+	}
+
+	rsp := &pb.ReleaseHoldResponse{
+		Hold:    convertHold(result.Hold),
+		Account: convertAccount(result.Account),
+	}
+	return rsp, nil
+}
+
+func validateReleaseHoldRequest(req *pb.ReleaseHoldRequest) (violations []*errdetails.BadRequest_FieldViolation) {
+	if err := val.ValidateID(req.GetId()); err != nil {
+		violations = append(violations, fieldViolation("id", err))
+	}
+
+	return violations
+}
@@ -0,0 +1,126 @@
+package gapi
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	db "github.com/tonisco/simple-bank-go/db/sqlc"
+	"github.com/tonisco/simple-bank-go/pb"
+	"github.com/tonisco/simple-bank-go/token"
+	"github.com/tonisco/simple-bank-go/val"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+// RenewAccessToken exchanges a refresh token for a new access token and a new refresh token.
+// The old refresh token cannot be used again, presenting it a second time revokes every session of the login.
+// The new tokens carry the user's current role and the login still ends when its first refresh token would have expired
+func (server *Server) RenewAccessToken(ctx context.Context, req *pb.RenewAccessTokenRequest) (*pb.RenewAccessTokenResponse, error) {
+	violations := validateRenewAccessTokenRequest(req)
+
+	if violations != nil {
+		return nil, invalidArgumentError(violations)
+	}
+
+	refreshPayload, err := server.tokenMaker.VerifyToken(req.GetRefreshToken())
+	if err != nil {
+		return nil, status.Errorf(codes.Unauthenticated, "invalid refresh token: %s", err)
+	}
+
+	if refreshPayload.Type != token.TokenTypeRefresh {
+		return nil, status.Errorf(codes.Unauthenticated, "invalid refresh token: not a refresh token")
+	}
+
+	if err := server.denylist.Check(ctx, refreshPayload); err != nil {
+		return nil, status.Errorf(codes.Unauthenticated, "invalid refresh token: %s", err)
+	}
+
+	if err := server.checkPasswordChange(ctx, refreshPayload); err != nil {
+		return nil, status.Errorf(codes.Unauthenticated, "invalid refresh token: %s", err)
+	}
+
+	// the role may have changed since the login, so the new tokens carry the one the user has now
+	user, err := server.store.GetUser(ctx, refreshPayload.Username)
+	if err != nil {
+		if errors.Is(err, db.ErrRecordNotFound) {
+			return nil, status.Errorf(codes.Unauthenticated, "invalid refresh token: user not found")
+		}
+		return nil, status.Errorf(codes.Internal, "failed to get user: %s", err)
+	}
+
+	// the new refresh token expires with the one it replaces, so renewing never makes a login last longer
+	refreshToken, newRefreshPayload, err := server.tokenMaker.CreateRefreshToken(
+		user.Username,
+		user.Role,
+		refreshPayload.SessionID,
+		time.Until(refreshPayload.ExpiredAt),
+	)
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "failed to create refresh token")
+	}
+
+	mtdt := server.extractMetadata(ctx)
+
+	session, err := server.store.RotateSessionTx(ctx, db.RotateSessionTxParams{
+		ID:           refreshPayload.ID,
+		Username:     refreshPayload.Username,
+		RefreshToken: req.GetRefreshToken(),
+		NewSession: db.CreateSessionParams{
+			ID:           newRefreshPayload.ID,
+			Username:     refreshPayload.Username,
+			RefreshToken: refreshToken,
+			UserAgent:    mtdt.UserAgent,
+			ClientIp:     mtdt.ClientIP,
+			IsBlocked:    false,
+			ExpiresAt:    newRefreshPayload.ExpiredAt,
+		},
+	})
+	if err != nil {
+		if errors.Is(err, db.ErrRecordNotFound) {
+			return nil, status.Errorf(codes.NotFound, "session not found")
+		}
+		if errors.Is(err, db.ErrRefreshTokenReused) {
+			// the access tokens of the family are as compromised as its refresh tokens
+			if err := server.denylist.RevokeID(ctx, session.FamilyID); err != nil {
+				return nil, status.Errorf(codes.Internal, "failed to revoke tokens: %s", err)
+			}
+			return nil, status.Errorf(codes.Unauthenticated, "%s", err)
+		}
+		if errors.Is(err, db.ErrSessionBlocked) ||
+			errors.Is(err, db.ErrSessionExpired) ||
+			errors.Is(err, db.ErrSessionMismatch) {
+			return nil, status.Errorf(codes.Unauthenticated, "%s", err)
+		}
+		return nil, status.Errorf(codes.Internal, "failed to rotate session: %s", err)
+	}
+
+	accessToken, accessPayload, err := server.tokenMaker.CreateSessionToken(
+		user.Username,
+		user.Role,
+		session.FamilyID,
+		server.config.AccessTokenDuration,
+	)
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "failed to create access token")
+	}
+
+	rsp := &pb.RenewAccessTokenResponse{
+		SessionId:             session.ID.String(),
+		AccessToken:           accessToken,
+		AccessTokenExpiresAt:  timestamppb.New(accessPayload.ExpiredAt),
+		RefreshToken:          refreshToken,
+		RefreshTokenExpiresAt: timestamppb.New(newRefreshPayload.ExpiredAt),
+	}
+	return rsp, nil
+}
+
+func validateRenewAccessTokenRequest(req *pb.RenewAccessTokenRequest) (violations []*errdetails.BadRequest_FieldViolation) {
+	if err := val.ValidateToken(req.GetRefreshToken()); err != nil {
+		violations = append(violations, fieldViolation("refresh_token", err))
+	}
+
+	return violations
+}
@@ -0,0 +1,195 @@
+package gapi
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/require"
+	mockdb "github.com/tonisco/simple-bank-go/db/mock"
+	db "github.com/tonisco/simple-bank-go/db/sqlc"
+	"github.com/tonisco/simple-bank-go/pb"
+	"github.com/tonisco/simple-bank-go/util"
+	"go.uber.org/mock/gomock"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func TestRenewAccessTokenAPI(t *testing.T) {
+	user, _ := randomUser(t)
+
+	testCases := []struct {
+		name          string
+		buildRequest  func(refreshToken string) *pb.RenewAccessTokenRequest
+		buildStubs    func(store *mockdb.MockStore, session db.Session)
+		checkResponse func(t *testing.T, session db.Session, res *pb.RenewAccessTokenResponse, err error)
+	}{
+		{
+			name: "OK",
+			buildRequest: func(refreshToken string) *pb.RenewAccessTokenRequest {
+				return &pb.RenewAccessTokenRequest{RefreshToken: refreshToken}
+			},
+			buildStubs: func(store *mockdb.MockStore, session db.Session) {
+				store.EXPECT().
+					RotateSessionTx(gomock.Any(), gomock.Any()).
+					Times(1).
+					DoAndReturn(func(_ context.Context, arg db.RotateSessionTxParams) (db.Session, error) {
+						require.Equal(t, session.ID, arg.ID)
+						require.Equal(t, session.Username, arg.Username)
+						require.Equal(t, session.RefreshToken, arg.RefreshToken)
+						require.Equal(t, session.Username, arg.NewSession.Username)
+						require.NotEqual(t, session.ID, arg.NewSession.ID)
+						return db.Session{ID: arg.NewSession.ID, Username: arg.NewSession.Username}, nil
+					})
+			},
+			checkResponse: func(t *testing.T, session db.Session, res *pb.RenewAccessTokenResponse, err error) {
+				require.NoError(t, err)
+				require.NotEmpty(t, res.GetAccessToken())
+				require.NotEmpty(t, res.GetRefreshToken())
+				require.NotEqual(t, session.RefreshToken, res.GetRefreshToken())
+				require.NotEqual(t, session.ID.String(), res.GetSessionId())
+			},
+		},
+		{
+			name: "ReusedToken",
+			buildRequest: func(refreshToken string) *pb.RenewAccessTokenRequest {
+				return &pb.RenewAccessTokenRequest{RefreshToken: refreshToken}
+			},
+			buildStubs: func(store *mockdb.MockStore, session db.Session) {
+				store.EXPECT().
+					RotateSessionTx(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(db.Session{}, db.ErrRefreshTokenReused)
+			},
+			checkResponse: func(t *testing.T, session db.Session, res *pb.RenewAccessTokenResponse, err error) {
+				require.Error(t, err)
+				st, ok := status.FromError(err)
+				require.True(t, ok)
+				require.Equal(t, codes.Unauthenticated, st.Code())
+			},
+		},
+		{
+			name: "BlockedSession",
+			buildRequest: func(refreshToken string) *pb.RenewAccessTokenRequest {
+				return &pb.RenewAccessTokenRequest{RefreshToken: refreshToken}
+			},
+			buildStubs: func(store *mockdb.MockStore, session db.Session) {
+				store.EXPECT().
+					RotateSessionTx(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(db.Session{}, db.ErrSessionBlocked)
+			},
+			checkResponse: func(t *testing.T, session db.Session, res *pb.RenewAccessTokenResponse, err error) {
+				require.Error(t, err)
+				st, ok := status.FromError(err)
+				require.True(t, ok)
+				require.Equal(t, codes.Unauthenticated, st.Code())
+			},
+		},
+		{
+			name: "SessionNotFound",
+			buildRequest: func(refreshToken string) *pb.RenewAccessTokenRequest {
+				return &pb.RenewAccessTokenRequest{RefreshToken: refreshToken}
+			},
+			buildStubs: func(store *mockdb.MockStore, session db.Session) {
+				store.EXPECT().
+					RotateSessionTx(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(db.Session{}, db.ErrRecordNotFound)
+			},
+			checkResponse: func(t *testing.T, session db.Session, res *pb.RenewAccessTokenResponse, err error) {
+				require.Error(t, err)
+				st, ok := status.FromError(err)
+				require.True(t, ok)
+				require.Equal(t, codes.NotFound, st.Code())
+			},
+		},
+		{
+			name: "InvalidToken",
+			buildRequest: func(refreshToken string) *pb.RenewAccessTokenRequest {
+				return &pb.RenewAccessTokenRequest{RefreshToken: "invalid"}
+			},
+			buildStubs: func(store *mockdb.MockStore, session db.Session) {
+				store.EXPECT().
+					RotateSessionTx(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkResponse: func(t *testing.T, session db.Session, res *pb.RenewAccessTokenResponse, err error) {
+				require.Error(t, err)
+				st, ok := status.FromError(err)
+				require.True(t, ok)
+				require.Equal(t, codes.Unauthenticated, st.Code())
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			storeCtrl := gomock.NewController(t)
+			defer storeCtrl.Finish()
+			store := mockdb.NewMockStore(storeCtrl)
+
+			server := newTestServer(t, store, nil)
+
+			refreshToken, refreshPayload, err := server.tokenMaker.CreateRefreshToken(user.Username, user.Role, uuid.Nil, time.Hour)
+			require.NoError(t, err)
+
+			session := db.Session{
+				ID:           refreshPayload.ID,
+				Username:     user.Username,
+				RefreshToken: refreshToken,
+				ExpiresAt:    refreshPayload.ExpiredAt,
+				FamilyID:     refreshPayload.ID,
+			}
+			tc.buildStubs(store, session)
+			store.EXPECT().
+				GetUser(gomock.Any(), gomock.Eq(user.Username)).
+				AnyTimes().
+				Return(user, nil)
+
+			res, err := server.RenewAccessToken(context.Background(), tc.buildRequest(refreshToken))
+			tc.checkResponse(t, session, res, err)
+		})
+	}
+}
+
+func TestRenewAccessTokenCurrentRoleAPI(t *testing.T) {
+	user, _ := randomUser(t)
+
+	store := mockdb.NewMockStore(gomock.NewController(t))
+	server := newTestServer(t, store, nil)
+	server.config.RefreshTokenDuration = 24 * time.Hour
+
+	refreshToken, refreshPayload, err := server.tokenMaker.CreateRefreshToken(user.Username, util.BankerRole, uuid.Nil, time.Hour)
+	require.NoError(t, err)
+
+	// a banker took the user's role away after the login
+	user.Role = util.DepositorRole
+	store.EXPECT().
+		GetUser(gomock.Any(), gomock.Eq(user.Username)).
+		Times(1).
+		Return(user, nil)
+
+	store.EXPECT().
+		RotateSessionTx(gomock.Any(), gomock.Any()).
+		Times(1).
+		DoAndReturn(func(_ context.Context, arg db.RotateSessionTxParams) (db.Session, error) {
+			return db.Session{ID: arg.NewSession.ID, FamilyID: refreshPayload.SessionID}, nil
+		})
+
+	res, err := server.RenewAccessToken(context.Background(), &pb.RenewAccessTokenRequest{RefreshToken: refreshToken})
+	require.NoError(t, err)
+
+	accessPayload, err := server.tokenMaker.VerifyToken(res.GetAccessToken())
+	require.NoError(t, err)
+	require.Equal(t, util.DepositorRole, accessPayload.Role)
+
+	newRefreshPayload, err := server.tokenMaker.VerifyToken(res.GetRefreshToken())
+	require.NoError(t, err)
+	require.Equal(t, util.DepositorRole, newRefreshPayload.Role)
+	// renewing does not make the login last longer than its first refresh token
+	require.WithinDuration(t, refreshPayload.ExpiredAt, newRefreshPayload.ExpiredAt, time.Second)
+}
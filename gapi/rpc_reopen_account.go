@@ -0,0 +1,34 @@
+package gapi
+
+import (
+	"context"
+
+	db "github.com/tonisco/simple-bank-go/db/sqlc"
+	"github.com/tonisco/simple-bank-go/pb"
+	"github.com/tonisco/simple-bank-go/util"
+)
+
+// ReopenAccount makes a closed account active again
+func (server *Server) ReopenAccount(ctx context.Context, req *pb.ReopenAccountRequest) (*pb.ReopenAccountResponse, error) {
+	_, err := server.authorizeUser(ctx, []string{util.BankerRole})
+
+	if err != nil {
+		return nil, unauthenticatedError(err)
+	}
+
+	violations := validateAccountID(req.GetId())
+
+	if violations != nil {
+		return nil, invalidArgumentError(violations)
+	}
+
+	account, err := server.changeAccountStatus(ctx, req.GetId(), db.AccountStatusClosed, db.AccountStatusActive)
+	if err != nil {
+		return nil, err
+	}
+
+	rsp := &pb.ReopenAccountResponse{
+		Account: convertAccount(account),
+	}
+	return rsp, nil
+}
@@ -0,0 +1,60 @@
+package gapi
+
+import (
+	"context"
+	"errors"
+
+	"github.com/hibiken/asynq"
+	db "github.com/tonisco/simple-bank-go/db/sqlc"
+	"github.com/tonisco/simple-bank-go/pb"
+	"github.com/tonisco/simple-bank-go/val"
+	"github.com/tonisco/simple-bank-go/worker"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+const passwordResetMessage = "If the email belongs to an account, a password reset link has been sent to it"
+
+// RequestPasswordReset emails a reset link to the user with the given email.
+// The response is the same whether or not the email is registered, so it cannot be used to find out who has an account
+func (server *Server) RequestPasswordReset(ctx context.Context, req *pb.RequestPasswordResetRequest) (*pb.RequestPasswordResetResponse, error) {
+	violations := validateRequestPasswordResetRequest(req)
+
+	if violations != nil {
+		return nil, invalidArgumentError(violations)
+	}
+
+	rsp := &pb.RequestPasswordResetResponse{
+		Message: passwordResetMessage,
+	}
+
+	user, err := server.store.GetUserByEmail(ctx, req.GetEmail())
+	if err != nil {
+		if errors.Is(err, db.ErrRecordNotFound) {
+			return rsp, nil
+		}
+		return nil, status.Errorf(codes.Internal, "failed to get user: %s", err)
+	}
+
+	payload := &worker.PayloadSendPasswordResetEmail{Username: user.Username}
+	opts := []asynq.Option{
+		asynq.MaxRetry(10),
+		asynq.Queue(worker.QueueCritical),
+	}
+
+	err = server.taskDistributor.DistributeTaskSendPasswordResetEmail(ctx, payload, opts...)
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "failed to send password reset email: %s", err)
+	}
+
+	return rsp, nil
+}
+
+func validateRequestPasswordResetRequest(req *pb.RequestPasswordResetRequest) (violations []*errdetails.BadRequest_FieldViolation) {
+	if err := val.ValidateEmail(req.GetEmail()); err != nil {
+		violations = append(violations, fieldViolation("email", err))
+	}
+
+	return violations
+}
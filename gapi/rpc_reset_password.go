@@ -0,0 +1,64 @@
+package gapi
+
+import (
+	"context"
+	"errors"
+
+	db "github.com/tonisco/simple-bank-go/db/sqlc"
+	"github.com/tonisco/simple-bank-go/pb"
+	"github.com/tonisco/simple-bank-go/util"
+	"github.com/tonisco/simple-bank-go/val"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// ResetPassword sets a new password with the code from a password reset email.
+// Every session of the user is blocked and their access tokens, issued before the new password_changed_at, stop working,
+// so whoever knew the old password is logged out
+func (server *Server) ResetPassword(ctx context.Context, req *pb.ResetPasswordRequest) (*pb.ResetPasswordResponse, error) {
+	violations := validateResetPasswordRequest(req)
+
+	if violations != nil {
+		return nil, invalidArgumentError(violations)
+	}
+
+	hashedPassword, err := util.HashPassword(req.GetPassword())
+
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "failed to hash password: %s", err)
+	}
+
+	_, err = server.store.ResetPasswordTx(ctx, db.ResetPasswordTxParams{
+		ResetID:        req.GetResetId(),
+		SecretCode:     util.HashSecretCode(req.GetSecretCode()),
+		HashedPassword: hashedPassword,
+	})
+	if err != nil {
+		if errors.Is(err, db.ErrRecordNotFound) {
+			return nil, status.Errorf(codes.NotFound, "password reset code is invalid, used or expired")
+		}
+		return nil, status.Errorf(codes.Internal, "failed to reset password: %s", err)
+	}
+
+	rsp := &pb.ResetPasswordResponse{
+		Message: "Password has been reset successfully",
+	}
+	return rsp, nil
+}
+
+func validateResetPasswordRequest(req *pb.ResetPasswordRequest) (violations []*errdetails.BadRequest_FieldViolation) {
+	if err := val.ValidateID(req.GetResetId()); err != nil {
+		violations = append(violations, fieldViolation("reset_id", err))
+	}
+
+	if err := val.ValidateSecretCode(req.GetSecretCode()); err != nil {
+		violations = append(violations, fieldViolation("secret_code", err))
+	}
+
+	if err := val.ValidatePassword(req.GetPassword()); err != nil {
+		violations = append(violations, fieldViolation("password", err))
+	}
+
+	return violations
+}
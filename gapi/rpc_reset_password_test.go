@@ -0,0 +1,131 @@
+package gapi
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	mockdb "github.com/tonisco/simple-bank-go/db/mock"
+	db "github.com/tonisco/simple-bank-go/db/sqlc"
+	"github.com/tonisco/simple-bank-go/pb"
+	"github.com/tonisco/simple-bank-go/util"
+	"go.uber.org/mock/gomock"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func TestResetPasswordAPI(t *testing.T) {
+	user, _ := randomUser(t)
+	password := util.RandomString(8)
+	secretCode := util.RandomString(32)
+
+	testCases := []struct {
+		name          string
+		req           *pb.ResetPasswordRequest
+		buildStubs    func(store *mockdb.MockStore)
+		checkResponse func(t *testing.T, server *Server, res *pb.ResetPasswordResponse, err error)
+	}{
+		{
+			name: "OK",
+			req: &pb.ResetPasswordRequest{
+				ResetId:    1,
+				SecretCode: secretCode,
+				Password:   password,
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					ResetPasswordTx(gomock.Any(), gomock.Any()).
+					Times(1).
+					DoAndReturn(func(_ context.Context, arg db.ResetPasswordTxParams) (db.ResetPasswordTxResult, error) {
+						require.Equal(t, int64(1), arg.ResetID)
+						require.Equal(t, util.HashSecretCode(secretCode), arg.SecretCode)
+						require.NoError(t, util.CheckPassword(password, arg.HashedPassword))
+
+						updatedUser := user
+						updatedUser.HashedPassword = arg.HashedPassword
+						updatedUser.PasswordChangedAt = time.Now()
+						return db.ResetPasswordTxResult{User: updatedUser, BlockedSessions: 2}, nil
+					})
+			},
+			checkResponse: func(t *testing.T, server *Server, res *pb.ResetPasswordResponse, err error) {
+				require.NoError(t, err)
+				require.NotEmpty(t, res.GetMessage())
+			},
+		},
+		{
+			name: "InvalidCode",
+			req: &pb.ResetPasswordRequest{
+				ResetId:    1,
+				SecretCode: secretCode,
+				Password:   password,
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					ResetPasswordTx(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(db.ResetPasswordTxResult{}, db.ErrRecordNotFound)
+			},
+			checkResponse: func(t *testing.T, server *Server, res *pb.ResetPasswordResponse, err error) {
+				require.Error(t, err)
+				st, ok := status.FromError(err)
+				require.True(t, ok)
+				require.Equal(t, codes.NotFound, st.Code())
+			},
+		},
+		{
+			name: "ShortPassword",
+			req: &pb.ResetPasswordRequest{
+				ResetId:    1,
+				SecretCode: secretCode,
+				Password:   "abc",
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					ResetPasswordTx(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkResponse: func(t *testing.T, server *Server, res *pb.ResetPasswordResponse, err error) {
+				require.Error(t, err)
+				st, ok := status.FromError(err)
+				require.True(t, ok)
+				require.Equal(t, codes.InvalidArgument, st.Code())
+			},
+		},
+		{
+			name: "InvalidSecretCode",
+			req: &pb.ResetPasswordRequest{
+				ResetId:    1,
+				SecretCode: "short",
+				Password:   password,
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					ResetPasswordTx(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkResponse: func(t *testing.T, server *Server, res *pb.ResetPasswordResponse, err error) {
+				require.Error(t, err)
+				st, ok := status.FromError(err)
+				require.True(t, ok)
+				require.Equal(t, codes.InvalidArgument, st.Code())
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			storeCtrl := gomock.NewController(t)
+			defer storeCtrl.Finish()
+			store := mockdb.NewMockStore(storeCtrl)
+
+			tc.buildStubs(store)
+			server := newTestServer(t, store, nil)
+
+			res, err := server.ResetPassword(context.Background(), tc.req)
+			tc.checkResponse(t, server, res, err)
+		})
+	}
+}
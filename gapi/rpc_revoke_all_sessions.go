@@ -0,0 +1,70 @@
+package gapi
+
+import (
+	"context"
+
+	"github.com/tonisco/simple-bank-go/pb"
+	"github.com/tonisco/simple-bank-go/util"
+	"github.com/tonisco/simple-bank-go/val"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// RevokeAllSessions blocks every active session of a user, logging them out everywhere.
+// Bankers may revoke the sessions of any user, for example when their credentials have leaked
+func (server *Server) RevokeAllSessions(ctx context.Context, req *pb.RevokeAllSessionsRequest) (*pb.RevokeAllSessionsResponse, error) {
+	authPayload, err := server.authorizeUser(ctx, []string{util.BankerRole, util.DepositorRole})
+
+	if err != nil {
+		return nil, unauthenticatedError(err)
+	}
+
+	violations := validateRevokeAllSessionsRequest(req)
+
+	if violations != nil {
+		return nil, invalidArgumentError(violations)
+	}
+
+	username := authPayload.Username
+	if req.Username != nil {
+		username = req.GetUsername()
+	}
+
+	if !isAccountOwnerOrBanker(authPayload, username) {
+		return nil, status.Errorf(codes.PermissionDenied, "cannot revoke other user's sessions")
+	}
+
+	revoked, err := server.store.BlockUserSessions(ctx, username)
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "failed to block sessions: %s", err)
+	}
+
+	// the access tokens of every session family go with it
+	familyIDs, err := server.store.ListUserSessionFamilies(ctx, username)
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "failed to list sessions: %s", err)
+	}
+
+	for _, familyID := range familyIDs {
+		err = server.denylist.RevokeID(ctx, familyID)
+		if err != nil {
+			return nil, status.Errorf(codes.Internal, "failed to revoke tokens: %s", err)
+		}
+	}
+
+	rsp := &pb.RevokeAllSessionsResponse{
+		Revoked: revoked,
+	}
+	return rsp, nil
+}
+
+func validateRevokeAllSessionsRequest(req *pb.RevokeAllSessionsRequest) (violations []*errdetails.BadRequest_FieldViolation) {
+	if req.Username != nil {
+		if err := val.ValidateUsername(req.GetUsername()); err != nil {
+			violations = append(violations, fieldViolation("username", err))
+		}
+	}
+
+	return violations
+}
@@ -0,0 +1,67 @@
+package gapi
+
+import (
+	"context"
+	"errors"
+
+	"github.com/google/uuid"
+	db "github.com/tonisco/simple-bank-go/db/sqlc"
+	"github.com/tonisco/simple-bank-go/pb"
+	"github.com/tonisco/simple-bank-go/util"
+	"github.com/tonisco/simple-bank-go/val"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// RevokeSession blocks a single session. Users may revoke their own sessions and bankers those of any user
+func (server *Server) RevokeSession(ctx context.Context, req *pb.RevokeSessionRequest) (*pb.RevokeSessionResponse, error) {
+	authPayload, err := server.authorizeUser(ctx, []string{util.BankerRole, util.DepositorRole})
+
+	if err != nil {
+		return nil, unauthenticatedError(err)
+	}
+
+	violations := validateRevokeSessionRequest(req)
+
+	if violations != nil {
+		return nil, invalidArgumentError(violations)
+	}
+
+	session, err := server.store.GetSession(ctx, uuid.MustParse(req.GetSessionId()))
+	if err != nil {
+		if errors.Is(err, db.ErrRecordNotFound) {
+			return nil, status.Errorf(codes.NotFound, "session not found")
+		}
+		return nil, status.Errorf(codes.Internal, "failed to get session: %s", err)
+	}
+
+	if !isAccountOwnerOrBanker(authPayload, session.Username) {
+		return nil, status.Errorf(codes.PermissionDenied, "cannot revoke other user's sessions")
+	}
+
+	if !session.IsBlocked {
+		session, err = server.store.BlockSession(ctx, session.ID)
+		if err != nil {
+			return nil, status.Errorf(codes.Internal, "failed to block session: %s", err)
+		}
+	}
+
+	err = server.denylist.RevokeID(ctx, session.FamilyID)
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "failed to revoke tokens: %s", err)
+	}
+
+	rsp := &pb.RevokeSessionResponse{
+		Session: convertSession(session),
+	}
+	return rsp, nil
+}
+
+func validateRevokeSessionRequest(req *pb.RevokeSessionRequest) (violations []*errdetails.BadRequest_FieldViolation) {
+	if err := val.ValidateUUID(req.GetSessionId()); err != nil {
+		violations = append(violations, fieldViolation("session_id", err))
+	}
+
+	return violations
+}
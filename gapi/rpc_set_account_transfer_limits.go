@@ -0,0 +1,86 @@
+package gapi
+
+import (
+	"context"
+	"errors"
+
+	"github.com/jackc/pgx/v5/pgtype"
+	db "github.com/tonisco/simple-bank-go/db/sqlc"
+	"github.com/tonisco/simple-bank-go/pb"
+	"github.com/tonisco/simple-bank-go/util"
+	"github.com/tonisco/simple-bank-go/val"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// SetAccountTransferLimits replaces the account's overrides of its owner's role limits.
+// A limit left out of the request is cleared, so the role's limit applies again
+func (server *Server) SetAccountTransferLimits(ctx context.Context, req *pb.SetAccountTransferLimitsRequest) (*pb.SetAccountTransferLimitsResponse, error) {
+	_, err := server.authorizeUser(ctx, []string{util.BankerRole})
+
+	if err != nil {
+		return nil, unauthenticatedError(err)
+	}
+
+	violations := validateSetAccountTransferLimitsRequest(req)
+
+	if violations != nil {
+		return nil, invalidArgumentError(violations)
+	}
+
+	account, err := server.store.GetAccount(ctx, req.GetAccountId())
+
+	if err != nil {
+		if errors.Is(err, db.ErrRecordNotFound) {
+			return nil, status.Errorf(codes.NotFound, "account not found")
+		}
+		return nil, status.Errorf(codes.Internal, "failed to get account: %s", err)
+	}
+
+	if account.Kind != db.AccountKindCustomer {
+		return nil, status.Errorf(codes.FailedPrecondition, "%s", db.ErrSystemAccount)
+	}
+
+	limit, err := server.store.SetAccountTransferLimit(ctx, db.SetAccountTransferLimitParams{
+		AccountID:   account.ID,
+		PerTransfer: pgtype.Int8{Int64: req.GetPerTransfer(), Valid: req.PerTransfer != nil},
+		Daily:       pgtype.Int8{Int64: req.GetDaily(), Valid: req.Daily != nil},
+		Monthly:     pgtype.Int8{Int64: req.GetMonthly(), Valid: req.Monthly != nil},
+	})
+
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "failed to set transfer limits: %s", err)
+	}
+
+	rsp := &pb.SetAccountTransferLimitsResponse{
+		Limits: convertAccountTransferLimits(limit),
+	}
+	return rsp, nil
+}
+
+func validateSetAccountTransferLimitsRequest(req *pb.SetAccountTransferLimitsRequest) (violations []*errdetails.BadRequest_FieldViolation) {
+	if err := val.ValidateID(req.GetAccountId()); err != nil {
+		violations = append(violations, fieldViolation("account_id", err))
+	}
+
+	if req.PerTransfer != nil {
+		if err := val.ValidateLimit(req.GetPerTransfer()); err != nil {
+			violations = append(violations, fieldViolation("per_transfer", err))
+		}
+	}
+
+	if req.Daily != nil {
+		if err := val.ValidateLimit(req.GetDaily()); err != nil {
+			violations = append(violations, fieldViolation("daily", err))
+		}
+	}
+
+	if req.Monthly != nil {
+		if err := val.ValidateLimit(req.GetMonthly()); err != nil {
+			violations = append(violations, fieldViolation("monthly", err))
+		}
+	}
+
+	return violations
+}
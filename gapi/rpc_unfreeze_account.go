@@ -0,0 +1,34 @@
+package gapi
+
+import (
+	"context"
+
+	db "github.com/tonisco/simple-bank-go/db/sqlc"
+	"github.com/tonisco/simple-bank-go/pb"
+	"github.com/tonisco/simple-bank-go/util"
+)
+
+// UnfreezeAccount lets a frozen account send and receive money again
+func (server *Server) UnfreezeAccount(ctx context.Context, req *pb.UnfreezeAccountRequest) (*pb.UnfreezeAccountResponse, error) {
+	_, err := server.authorizeUser(ctx, []string{util.BankerRole})
+
+	if err != nil {
+		return nil, unauthenticatedError(err)
+	}
+
+	violations := validateAccountID(req.GetId())
+
+	if violations != nil {
+		return nil, invalidArgumentError(violations)
+	}
+
+	account, err := server.changeAccountStatus(ctx, req.GetId(), db.AccountStatusFrozen, db.AccountStatusActive)
+	if err != nil {
+		return nil, err
+	}
+
+	rsp := &pb.UnfreezeAccountResponse{
+		Account: convertAccount(account),
+	}
+	return rsp, nil
+}
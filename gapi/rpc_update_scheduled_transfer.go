@@ -0,0 +1,117 @@
+package gapi
+
+import (
+	"context"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgtype"
+	db "github.com/tonisco/simple-bank-go/db/sqlc"
+	"github.com/tonisco/simple-bank-go/pb"
+	"github.com/tonisco/simple-bank-go/util"
+	"github.com/tonisco/simple-bank-go/val"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// UpdateScheduledTransfer changes the amount or schedule of a scheduled transfer, or pauses and resumes it.
+// A new schedule, or resuming after the transfer fell due, moves the next run to the next time the schedule fires
+func (server *Server) UpdateScheduledTransfer(ctx context.Context, req *pb.UpdateScheduledTransferRequest) (*pb.UpdateScheduledTransferResponse, error) {
+	authPayload, err := server.authorizeUser(ctx, []string{util.BankerRole, util.DepositorRole})
+
+	if err != nil {
+		return nil, unauthenticatedError(err)
+	}
+
+	violations := validateUpdateScheduledTransferRequest(req)
+
+	if violations != nil {
+		return nil, invalidArgumentError(violations)
+	}
+
+	scheduled, err := server.ownScheduledTransfer(ctx, authPayload, req.GetId())
+	if err != nil {
+		return nil, err
+	}
+
+	if scheduled.Status == db.ScheduledTransferStatusCompleted {
+		return nil, status.Errorf(codes.FailedPrecondition, "scheduled transfer has already completed")
+	}
+
+	// raising the amount needs the same step-up as scheduling it in the first place
+	if req.Amount != nil && len(server.config.StepUpTransferThresholds) > 0 {
+		fromAccount, err := server.store.GetAccount(ctx, scheduled.FromAccountID)
+		if err != nil {
+			return nil, status.Errorf(codes.Internal, "failed to get from account: %s", err)
+		}
+
+		if err := server.requireStepUp(authPayload, req.GetAmount(), fromAccount.Currency); err != nil {
+			return nil, err
+		}
+	}
+
+	args := db.UpdateScheduledTransferParams{
+		ID: scheduled.ID,
+		Amount: pgtype.Int8{
+			Int64: req.GetAmount(),
+			Valid: req.Amount != nil,
+		},
+		Schedule: pgtype.Text{
+			String: req.GetSchedule(),
+			Valid:  req.Schedule != nil,
+		},
+	}
+
+	if req.Paused != nil {
+		args.Status = pgtype.Text{String: db.ScheduledTransferStatusActive, Valid: true}
+		if req.GetPaused() {
+			args.Status.String = db.ScheduledTransferStatusPaused
+		}
+	}
+
+	schedule := scheduled.Schedule
+	if req.Schedule != nil {
+		schedule = req.GetSchedule()
+	}
+
+	now := time.Now()
+	resumedLate := req.Paused != nil && !req.GetPaused() && scheduled.NextRunAt.Before(now)
+	if schedule != "" && (req.Schedule != nil || resumedLate) {
+		nextRunAt, err := util.NextRun(schedule, now)
+		if err != nil {
+			return nil, status.Errorf(codes.Internal, "failed to compute next run: %s", err)
+		}
+		args.NextRunAt = pgtype.Timestamptz{Time: nextRunAt, Valid: true}
+	}
+
+	scheduled, err = server.store.UpdateScheduledTransfer(ctx, args)
+
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "failed to update scheduled transfer: %s", err)
+	}
+
+	rsp := &pb.UpdateScheduledTransferResponse{
+		ScheduledTransfer: convertScheduledTransfer(scheduled),
+	}
+	return rsp, nil
+}
+
+func validateUpdateScheduledTransferRequest(req *pb.UpdateScheduledTransferRequest) (violations []*errdetails.BadRequest_FieldViolation) {
+	if err := val.ValidateID(req.GetId()); err != nil {
+		violations = append(violations, fieldViolation("id", err))
+	}
+
+	if req.Amount != nil {
+		if err := val.ValidateAmount(req.GetAmount()); err != nil {
+			violations = append(violations, fieldViolation("amount", err))
+		}
+	}
+
+	if req.Schedule != nil {
+		if err := val.ValidateSchedule(req.GetSchedule()); err != nil {
+			violations = append(violations, fieldViolation("schedule", err))
+		}
+	}
+
+	return violations
+}
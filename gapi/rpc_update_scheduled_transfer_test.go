@@ -0,0 +1,284 @@
+package gapi
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/stretchr/testify/require"
+	mockdb "github.com/tonisco/simple-bank-go/db/mock"
+	db "github.com/tonisco/simple-bank-go/db/sqlc"
+	"github.com/tonisco/simple-bank-go/pb"
+	"github.com/tonisco/simple-bank-go/token"
+	"github.com/tonisco/simple-bank-go/util"
+	"go.uber.org/mock/gomock"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func TestUpdateScheduledTransferAPI(t *testing.T) {
+	user, _ := randomUser(t)
+	user1, _ := randomUser(t)
+	banker, _ := randomUser(t)
+	banker.Role = util.BankerRole
+
+	scheduled := randomScheduledTransfer(user.Username, 1, 2)
+
+	paused := scheduled
+	paused.Status = db.ScheduledTransferStatusPaused
+	paused.NextRunAt = time.Now().Add(-24 * time.Hour).UTC().Truncate(time.Second)
+
+	completed := scheduled
+	completed.Schedule = ""
+	completed.Status = db.ScheduledTransferStatusCompleted
+
+	newAmount := int64(50)
+	newSchedule := "0 12 * * 1"
+	pause := true
+	resume := false
+
+	testCases := []struct {
+		name          string
+		req           *pb.UpdateScheduledTransferRequest
+		buildStubs    func(store *mockdb.MockStore)
+		buildContext  func(t *testing.T, tokenMaker token.Maker) context.Context
+		checkResponse func(t *testing.T, res *pb.UpdateScheduledTransferResponse, err error)
+	}{
+		{
+			name: "UpdateAmount",
+			req:  &pb.UpdateScheduledTransferRequest{Id: scheduled.ID, Amount: &newAmount},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetScheduledTransfer(gomock.Any(), gomock.Eq(scheduled.ID)).Times(1).Return(scheduled, nil)
+
+				arg := db.UpdateScheduledTransferParams{
+					ID:     scheduled.ID,
+					Amount: pgtype.Int8{Int64: newAmount, Valid: true},
+				}
+				updated := scheduled
+				updated.Amount = newAmount
+				store.EXPECT().
+					UpdateScheduledTransfer(gomock.Any(), gomock.Eq(arg)).
+					Times(1).
+					Return(updated, nil)
+			},
+			buildContext: func(t *testing.T, tokenMaker token.Maker) context.Context {
+				return newContextWithBearerToken(t, tokenMaker, user.Username, user.Role, time.Minute)
+			},
+			checkResponse: func(t *testing.T, res *pb.UpdateScheduledTransferResponse, err error) {
+				require.NoError(t, err)
+				updated := scheduled
+				updated.Amount = newAmount
+				requireScheduledTransferMatch(t, updated, res.GetScheduledTransfer())
+			},
+		},
+		{
+			name: "UpdateSchedule",
+			req:  &pb.UpdateScheduledTransferRequest{Id: scheduled.ID, Schedule: &newSchedule},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetScheduledTransfer(gomock.Any(), gomock.Eq(scheduled.ID)).Times(1).Return(scheduled, nil)
+
+				nextRunAt, err := util.NextRun(newSchedule, time.Now())
+				require.NoError(t, err)
+
+				arg := db.UpdateScheduledTransferParams{
+					ID:        scheduled.ID,
+					Schedule:  pgtype.Text{String: newSchedule, Valid: true},
+					NextRunAt: pgtype.Timestamptz{Time: nextRunAt, Valid: true},
+				}
+				store.EXPECT().
+					UpdateScheduledTransfer(gomock.Any(), gomock.Eq(arg)).
+					Times(1).
+					Return(scheduled, nil)
+			},
+			buildContext: func(t *testing.T, tokenMaker token.Maker) context.Context {
+				return newContextWithBearerToken(t, tokenMaker, user.Username, user.Role, time.Minute)
+			},
+			checkResponse: func(t *testing.T, res *pb.UpdateScheduledTransferResponse, err error) {
+				require.NoError(t, err)
+			},
+		},
+		{
+			name: "Pause",
+			req:  &pb.UpdateScheduledTransferRequest{Id: scheduled.ID, Paused: &pause},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetScheduledTransfer(gomock.Any(), gomock.Eq(scheduled.ID)).Times(1).Return(scheduled, nil)
+
+				arg := db.UpdateScheduledTransferParams{
+					ID:     scheduled.ID,
+					Status: pgtype.Text{String: db.ScheduledTransferStatusPaused, Valid: true},
+				}
+				updated := scheduled
+				updated.Status = db.ScheduledTransferStatusPaused
+				store.EXPECT().
+					UpdateScheduledTransfer(gomock.Any(), gomock.Eq(arg)).
+					Times(1).
+					Return(updated, nil)
+			},
+			buildContext: func(t *testing.T, tokenMaker token.Maker) context.Context {
+				return newContextWithBearerToken(t, tokenMaker, user.Username, user.Role, time.Minute)
+			},
+			checkResponse: func(t *testing.T, res *pb.UpdateScheduledTransferResponse, err error) {
+				require.NoError(t, err)
+				require.Equal(t, db.ScheduledTransferStatusPaused, res.GetScheduledTransfer().GetStatus())
+			},
+		},
+		{
+			name: "ResumeAfterMissedRun",
+			req:  &pb.UpdateScheduledTransferRequest{Id: paused.ID, Paused: &resume},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetScheduledTransfer(gomock.Any(), gomock.Eq(paused.ID)).Times(1).Return(paused, nil)
+
+				store.EXPECT().
+					UpdateScheduledTransfer(gomock.Any(), gomock.Any()).
+					Times(1).
+					DoAndReturn(func(_ context.Context, arg db.UpdateScheduledTransferParams) (db.ScheduledTransfer, error) {
+						require.Equal(t, db.ScheduledTransferStatusActive, arg.Status.String)
+						require.True(t, arg.NextRunAt.Valid)
+						require.True(t, arg.NextRunAt.Time.After(time.Now()))
+
+						updated := paused
+						updated.Status = arg.Status.String
+						updated.NextRunAt = arg.NextRunAt.Time
+						return updated, nil
+					})
+			},
+			buildContext: func(t *testing.T, tokenMaker token.Maker) context.Context {
+				return newContextWithBearerToken(t, tokenMaker, user.Username, user.Role, time.Minute)
+			},
+			checkResponse: func(t *testing.T, res *pb.UpdateScheduledTransferResponse, err error) {
+				require.NoError(t, err)
+				require.Equal(t, db.ScheduledTransferStatusActive, res.GetScheduledTransfer().GetStatus())
+			},
+		},
+		{
+			name: "BankerOverride",
+			req:  &pb.UpdateScheduledTransferRequest{Id: scheduled.ID, Paused: &pause},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetScheduledTransfer(gomock.Any(), gomock.Eq(scheduled.ID)).Times(1).Return(scheduled, nil)
+				store.EXPECT().UpdateScheduledTransfer(gomock.Any(), gomock.Any()).Times(1).Return(scheduled, nil)
+			},
+			buildContext: func(t *testing.T, tokenMaker token.Maker) context.Context {
+				return newContextWithBearerToken(t, tokenMaker, banker.Username, banker.Role, time.Minute)
+			},
+			checkResponse: func(t *testing.T, res *pb.UpdateScheduledTransferResponse, err error) {
+				require.NoError(t, err)
+			},
+		},
+		{
+			name: "NotOwner",
+			req:  &pb.UpdateScheduledTransferRequest{Id: scheduled.ID, Paused: &pause},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetScheduledTransfer(gomock.Any(), gomock.Eq(scheduled.ID)).Times(1).Return(scheduled, nil)
+				store.EXPECT().UpdateScheduledTransfer(gomock.Any(), gomock.Any()).Times(0)
+			},
+			buildContext: func(t *testing.T, tokenMaker token.Maker) context.Context {
+				return newContextWithBearerToken(t, tokenMaker, user1.Username, user1.Role, time.Minute)
+			},
+			checkResponse: func(t *testing.T, res *pb.UpdateScheduledTransferResponse, err error) {
+				require.Error(t, err)
+				st, ok := status.FromError(err)
+				require.True(t, ok)
+				require.Equal(t, codes.PermissionDenied, st.Code())
+			},
+		},
+		{
+			name: "Completed",
+			req:  &pb.UpdateScheduledTransferRequest{Id: completed.ID, Amount: &newAmount},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetScheduledTransfer(gomock.Any(), gomock.Eq(completed.ID)).Times(1).Return(completed, nil)
+				store.EXPECT().UpdateScheduledTransfer(gomock.Any(), gomock.Any()).Times(0)
+			},
+			buildContext: func(t *testing.T, tokenMaker token.Maker) context.Context {
+				return newContextWithBearerToken(t, tokenMaker, user.Username, user.Role, time.Minute)
+			},
+			checkResponse: func(t *testing.T, res *pb.UpdateScheduledTransferResponse, err error) {
+				require.Error(t, err)
+				st, ok := status.FromError(err)
+				require.True(t, ok)
+				require.Equal(t, codes.FailedPrecondition, st.Code())
+			},
+		},
+		{
+			name: "NotFound",
+			req:  &pb.UpdateScheduledTransferRequest{Id: scheduled.ID, Amount: &newAmount},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetScheduledTransfer(gomock.Any(), gomock.Eq(scheduled.ID)).Times(1).Return(db.ScheduledTransfer{}, db.ErrRecordNotFound)
+				store.EXPECT().UpdateScheduledTransfer(gomock.Any(), gomock.Any()).Times(0)
+			},
+			buildContext: func(t *testing.T, tokenMaker token.Maker) context.Context {
+				return newContextWithBearerToken(t, tokenMaker, user.Username, user.Role, time.Minute)
+			},
+			checkResponse: func(t *testing.T, res *pb.UpdateScheduledTransferResponse, err error) {
+				require.Error(t, err)
+				st, ok := status.FromError(err)
+				require.True(t, ok)
+				require.Equal(t, codes.NotFound, st.Code())
+			},
+		},
+		{
+			name: "NoAuthorization",
+			req:  &pb.UpdateScheduledTransferRequest{Id: scheduled.ID, Amount: &newAmount},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetScheduledTransfer(gomock.Any(), gomock.Any()).Times(0)
+				store.EXPECT().UpdateScheduledTransfer(gomock.Any(), gomock.Any()).Times(0)
+			},
+			buildContext: func(t *testing.T, tokenMaker token.Maker) context.Context {
+				return context.Background()
+			},
+			checkResponse: func(t *testing.T, res *pb.UpdateScheduledTransferResponse, err error) {
+				require.Error(t, err)
+				st, ok := status.FromError(err)
+				require.True(t, ok)
+				require.Equal(t, codes.Unauthenticated, st.Code())
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			storeCtrl := gomock.NewController(t)
+			defer storeCtrl.Finish()
+			store := mockdb.NewMockStore(storeCtrl)
+
+			tc.buildStubs(store)
+			server := newTestServer(t, store, nil)
+
+			ctx := tc.buildContext(t, server.tokenMaker)
+			res, err := server.UpdateScheduledTransfer(ctx, tc.req)
+			tc.checkResponse(t, res, err)
+		})
+	}
+}
+
+func TestUpdateScheduledTransferStepUpAPI(t *testing.T) {
+	user, _ := randomUser(t)
+
+	account := randomAccount(user.Username)
+	account.ID = 1
+	account.Currency = util.USD
+	scheduled := randomScheduledTransfer(user.Username, account.ID, 2)
+	newAmount := int64(100001)
+
+	store := mockdb.NewMockStore(gomock.NewController(t))
+	store.EXPECT().GetScheduledTransfer(gomock.Any(), gomock.Eq(scheduled.ID)).Times(1).Return(scheduled, nil)
+	store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account.ID)).Times(1).Return(account, nil)
+	store.EXPECT().
+		UpdateScheduledTransfer(gomock.Any(), gomock.Any()).
+		Times(0)
+
+	server := newTestServer(t, store, nil)
+	server.config.StepUpTransferThresholds = util.CurrencyAmounts{util.USD: 1000}
+	server.config.ElevatedTokenDuration = time.Minute
+
+	req := &pb.UpdateScheduledTransferRequest{Id: scheduled.ID, Amount: &newAmount}
+
+	ctx := newContextWithBearerToken(t, server.tokenMaker, user.Username, user.Role, time.Minute)
+	_, err := server.UpdateScheduledTransfer(ctx, req)
+	require.Error(t, err)
+	st, ok := status.FromError(err)
+	require.True(t, ok)
+	require.Equal(t, codes.PermissionDenied, st.Code())
+}
@@ -56,6 +56,7 @@ func (server *Server) UpdateUser(ctx context.Context, req *pb.UpdateUserRequest)
 			Valid:  true,
 		}
 
+		// tokens issued before the change stop working straight away
 		args.PasswordChangedAt = pgtype.Timestamptz{
 			Time:  time.Now(),
 			Valid: true,
@@ -70,6 +71,7 @@ func (server *Server) UpdateUser(ctx context.Context, req *pb.UpdateUserRequest)
 		}
 		return nil, status.Errorf(codes.Internal, "failed to create user: %s", err)
 	}
+
 	rsp := &pb.UpdateUserResponse{
 		User: convertUser(user),
 	}
@@ -0,0 +1,160 @@
+package gapi
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	db "github.com/tonisco/simple-bank-go/db/sqlc"
+	"github.com/tonisco/simple-bank-go/pb"
+	"github.com/tonisco/simple-bank-go/totp"
+	"github.com/tonisco/simple-bank-go/util"
+	"github.com/tonisco/simple-bank-go/val"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// VerifyLoginTotp finishes a login that LoginUser answered with a challenge.
+// Either a TOTP code or one of the user's unused recovery codes completes it, and each challenge completes one login
+func (server *Server) VerifyLoginTotp(ctx context.Context, req *pb.VerifyLoginTotpRequest) (*pb.LoginUserResponse, error) {
+	violations := validateVerifyLoginTotpRequest(req)
+
+	if violations != nil {
+		return nil, invalidArgumentError(violations)
+	}
+
+	challengePayload, err := server.tokenMaker.VerifyToken(req.GetChallengeToken())
+	if err != nil {
+		return nil, status.Errorf(codes.Unauthenticated, "invalid challenge token: %s", err)
+	}
+
+	if challengePayload.Role != util.TotpChallengeRole {
+		return nil, status.Errorf(codes.Unauthenticated, "invalid challenge token")
+	}
+
+	// a challenge that has already completed a login is revoked
+	if err := server.denylist.Check(ctx, challengePayload); err != nil {
+		return nil, status.Errorf(codes.Unauthenticated, "invalid challenge token: %s", err)
+	}
+
+	// a password reset after the challenge was issued also cancels it
+	if err := server.checkPasswordChange(ctx, challengePayload); err != nil {
+		return nil, status.Errorf(codes.Unauthenticated, "invalid challenge token: %s", err)
+	}
+
+	// wrong codes count as failed logins, so guessing codes locks the user out like guessing passwords
+	clientIP := server.extractMetadata(ctx).ClientIP
+
+	if err := server.checkLoginLock(ctx, challengePayload.Username, clientIP); err != nil {
+		return nil, err
+	}
+
+	user, err := server.store.GetUser(ctx, challengePayload.Username)
+	if err != nil {
+		if errors.Is(err, db.ErrRecordNotFound) {
+			return nil, status.Errorf(codes.NotFound, "user not found")
+		}
+		return nil, status.Errorf(codes.Internal, "failed to find user")
+	}
+
+	if req.Code != nil {
+		err = server.useTotpCode(ctx, user.Username, req.GetCode())
+	} else {
+		err = server.useRecoveryCode(ctx, user.Username, req.GetRecoveryCode())
+	}
+	if err != nil {
+		return nil, server.failCode(ctx, &user, clientIP, err)
+	}
+
+	// the challenge cannot be used again with a later code to open a second session
+	if err := server.denylist.RevokeID(ctx, challengePayload.ID); err != nil {
+		return nil, status.Errorf(codes.Internal, "failed to revoke challenge token: %s", err)
+	}
+
+	if err := server.resetLoginThrottle(ctx, user.Username); err != nil {
+		return nil, err
+	}
+
+	return server.createLoginSession(ctx, user)
+}
+
+// useTotpCode checks a TOTP code and records its time step so it cannot be used a second time
+func (server *Server) useTotpCode(ctx context.Context, username string, code string) error {
+	userTotp, err := server.store.GetUserTotp(ctx, username)
+	if err != nil {
+		if errors.Is(err, db.ErrRecordNotFound) {
+			return status.Errorf(codes.FailedPrecondition, "two-factor authentication is not on")
+		}
+		return status.Errorf(codes.Internal, "failed to get two-factor authentication: %s", err)
+	}
+
+	step, ok := totp.Validate(userTotp.Secret, code, time.Now())
+	if !ok {
+		return status.Errorf(codes.Unauthenticated, "incorrect code")
+	}
+
+	_, err = server.store.UseTotpStep(ctx, db.UseTotpStepParams{
+		Step:     step,
+		Username: username,
+	})
+	if err != nil {
+		if errors.Is(err, db.ErrRecordNotFound) {
+			return status.Errorf(codes.Unauthenticated, "code has already been used")
+		}
+		return status.Errorf(codes.Internal, "failed to use code: %s", err)
+	}
+
+	return nil
+}
+
+// useRecoveryCode finds the unused recovery code matching code and marks it used
+func (server *Server) useRecoveryCode(ctx context.Context, username string, code string) error {
+	recoveryCodes, err := server.store.ListUnusedTotpRecoveryCodes(ctx, username)
+	if err != nil {
+		return status.Errorf(codes.Internal, "failed to list recovery codes: %s", err)
+	}
+
+	code = totp.NormalizeRecoveryCode(code)
+	for _, recoveryCode := range recoveryCodes {
+		if util.CheckPassword(code, recoveryCode.HashedCode) != nil {
+			continue
+		}
+
+		_, err = server.store.UseTotpRecoveryCode(ctx, recoveryCode.ID)
+		if err != nil {
+			if errors.Is(err, db.ErrRecordNotFound) {
+				return status.Errorf(codes.Unauthenticated, "recovery code has already been used")
+			}
+			return status.Errorf(codes.Internal, "failed to use recovery code: %s", err)
+		}
+		return nil
+	}
+
+	return status.Errorf(codes.Unauthenticated, "incorrect recovery code")
+}
+
+func validateVerifyLoginTotpRequest(req *pb.VerifyLoginTotpRequest) (violations []*errdetails.BadRequest_FieldViolation) {
+	if err := val.ValidateToken(req.GetChallengeToken()); err != nil {
+		violations = append(violations, fieldViolation("challenge_token", err))
+	}
+
+	if (req.Code == nil) == (req.RecoveryCode == nil) {
+		violations = append(violations, fieldViolation("code", fmt.Errorf("exactly one of code and recovery_code must be set")))
+	}
+
+	if req.Code != nil {
+		if err := val.ValidateTotpCode(req.GetCode()); err != nil {
+			violations = append(violations, fieldViolation("code", err))
+		}
+	}
+
+	if req.RecoveryCode != nil {
+		if err := val.ValidateRecoveryCode(req.GetRecoveryCode()); err != nil {
+			violations = append(violations, fieldViolation("recovery_code", err))
+		}
+	}
+
+	return violations
+}
@@ -0,0 +1,429 @@
+package gapi
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/stretchr/testify/require"
+	mockdb "github.com/tonisco/simple-bank-go/db/mock"
+	db "github.com/tonisco/simple-bank-go/db/sqlc"
+	"github.com/tonisco/simple-bank-go/pb"
+	"github.com/tonisco/simple-bank-go/token"
+	"github.com/tonisco/simple-bank-go/totp"
+	"github.com/tonisco/simple-bank-go/util"
+	"go.uber.org/mock/gomock"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+)
+
+func TestVerifyLoginTotpAPI(t *testing.T) {
+	user, _ := randomUser(t)
+
+	secret, err := totp.GenerateSecret()
+	require.NoError(t, err)
+
+	userTotp := db.UserTotp{
+		Username:    user.Username,
+		Secret:      secret,
+		ConfirmedAt: pgtype.Timestamptz{Time: time.Now(), Valid: true},
+	}
+
+	step := totp.Step(time.Now())
+	code, err := totp.Code(secret, step)
+	require.NoError(t, err)
+
+	recoveryCode := "abcde-fghij"
+	hashedRecoveryCode, err := util.HashPassword(totp.NormalizeRecoveryCode(recoveryCode))
+	require.NoError(t, err)
+
+	challengeToken := func(t *testing.T, tokenMaker token.Maker, role string) string {
+		challengeToken, _, err := tokenMaker.CreateToken(user.Username, role, time.Minute)
+		require.NoError(t, err)
+		return challengeToken
+	}
+
+	testCases := []struct {
+		name          string
+		buildReq      func(t *testing.T, tokenMaker token.Maker) *pb.VerifyLoginTotpRequest
+		buildStubs    func(store *mockdb.MockStore)
+		checkResponse func(t *testing.T, res *pb.LoginUserResponse, err error)
+	}{
+		{
+			name: "OK",
+			buildReq: func(t *testing.T, tokenMaker token.Maker) *pb.VerifyLoginTotpRequest {
+				return &pb.VerifyLoginTotpRequest{
+					ChallengeToken: challengeToken(t, tokenMaker, util.TotpChallengeRole),
+					Code:           proto.String(code),
+				}
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					ListActiveLoginLocks(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return([]db.LoginThrottle{}, nil)
+
+				store.EXPECT().
+					GetUser(gomock.Any(), gomock.Eq(user.Username)).
+					Times(1).
+					Return(user, nil)
+
+				store.EXPECT().
+					GetUserTotp(gomock.Any(), gomock.Eq(user.Username)).
+					Times(1).
+					Return(userTotp, nil)
+
+				store.EXPECT().
+					UseTotpStep(gomock.Any(), gomock.Eq(db.UseTotpStepParams{Step: step, Username: user.Username})).
+					Times(1).
+					Return(userTotp, nil)
+
+				store.EXPECT().
+					CreateSession(gomock.Any(), gomock.Any()).
+					Times(1).
+					DoAndReturn(func(_ context.Context, arg db.CreateSessionParams) (db.Session, error) {
+						return db.Session{ID: arg.ID, Username: arg.Username}, nil
+					})
+
+				store.EXPECT().
+					DeleteLoginThrottle(gomock.Any(), gomock.Eq(db.DeleteLoginThrottleParams{
+						Scope:   db.LoginThrottleScopeUser,
+						Subject: user.Username,
+					})).
+					Times(1).
+					Return(nil)
+			},
+			checkResponse: func(t *testing.T, res *pb.LoginUserResponse, err error) {
+				require.NoError(t, err)
+				require.NotEmpty(t, res.GetAccessToken())
+				require.NotEmpty(t, res.GetRefreshToken())
+				require.Equal(t, user.Username, res.GetUser().GetUsername())
+			},
+		},
+		{
+			name: "RecoveryCode",
+			buildReq: func(t *testing.T, tokenMaker token.Maker) *pb.VerifyLoginTotpRequest {
+				return &pb.VerifyLoginTotpRequest{
+					ChallengeToken: challengeToken(t, tokenMaker, util.TotpChallengeRole),
+					RecoveryCode:   proto.String(recoveryCode),
+				}
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					ListActiveLoginLocks(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return([]db.LoginThrottle{}, nil)
+
+				store.EXPECT().
+					GetUser(gomock.Any(), gomock.Eq(user.Username)).
+					Times(1).
+					Return(user, nil)
+
+				store.EXPECT().
+					ListUnusedTotpRecoveryCodes(gomock.Any(), gomock.Eq(user.Username)).
+					Times(1).
+					Return([]db.TotpRecoveryCode{{ID: 7, Username: user.Username, HashedCode: hashedRecoveryCode}}, nil)
+
+				store.EXPECT().
+					UseTotpRecoveryCode(gomock.Any(), gomock.Eq(int64(7))).
+					Times(1)
+
+				store.EXPECT().
+					CreateSession(gomock.Any(), gomock.Any()).
+					Times(1).
+					DoAndReturn(func(_ context.Context, arg db.CreateSessionParams) (db.Session, error) {
+						return db.Session{ID: arg.ID, Username: arg.Username}, nil
+					})
+
+				store.EXPECT().
+					DeleteLoginThrottle(gomock.Any(), gomock.Eq(db.DeleteLoginThrottleParams{
+						Scope:   db.LoginThrottleScopeUser,
+						Subject: user.Username,
+					})).
+					Times(1).
+					Return(nil)
+			},
+			checkResponse: func(t *testing.T, res *pb.LoginUserResponse, err error) {
+				require.NoError(t, err)
+				require.NotEmpty(t, res.GetAccessToken())
+			},
+		},
+		{
+			name: "IncorrectRecoveryCode",
+			buildReq: func(t *testing.T, tokenMaker token.Maker) *pb.VerifyLoginTotpRequest {
+				return &pb.VerifyLoginTotpRequest{
+					ChallengeToken: challengeToken(t, tokenMaker, util.TotpChallengeRole),
+					RecoveryCode:   proto.String("zzzzz-zzzzz"),
+				}
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					ListActiveLoginLocks(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return([]db.LoginThrottle{}, nil)
+
+				store.EXPECT().
+					GetUser(gomock.Any(), gomock.Eq(user.Username)).
+					Times(1).
+					Return(user, nil)
+
+				store.EXPECT().
+					ListUnusedTotpRecoveryCodes(gomock.Any(), gomock.Eq(user.Username)).
+					Times(1).
+					Return([]db.TotpRecoveryCode{{ID: 7, Username: user.Username, HashedCode: hashedRecoveryCode}}, nil)
+
+				store.EXPECT().
+					UseTotpRecoveryCode(gomock.Any(), gomock.Any()).
+					Times(0)
+
+				store.EXPECT().
+					CreateSession(gomock.Any(), gomock.Any()).
+					Times(0)
+
+				store.EXPECT().
+					RecordFailedLoginTx(gomock.Any(), gomock.Any()).
+					Times(1).
+					DoAndReturn(func(_ context.Context, arg db.RecordFailedLoginTxParams) (db.RecordFailedLoginTxResult, error) {
+						require.Equal(t, user.Username, arg.Username)
+						return db.RecordFailedLoginTxResult{}, nil
+					})
+			},
+			checkResponse: func(t *testing.T, res *pb.LoginUserResponse, err error) {
+				require.Error(t, err)
+				st, ok := status.FromError(err)
+				require.True(t, ok)
+				require.Equal(t, codes.Unauthenticated, st.Code())
+			},
+		},
+		{
+			name: "CodeAlreadyUsed",
+			buildReq: func(t *testing.T, tokenMaker token.Maker) *pb.VerifyLoginTotpRequest {
+				return &pb.VerifyLoginTotpRequest{
+					ChallengeToken: challengeToken(t, tokenMaker, util.TotpChallengeRole),
+					Code:           proto.String(code),
+				}
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					ListActiveLoginLocks(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return([]db.LoginThrottle{}, nil)
+
+				store.EXPECT().
+					GetUser(gomock.Any(), gomock.Eq(user.Username)).
+					Times(1).
+					Return(user, nil)
+
+				store.EXPECT().
+					GetUserTotp(gomock.Any(), gomock.Eq(user.Username)).
+					Times(1).
+					Return(userTotp, nil)
+
+				store.EXPECT().
+					UseTotpStep(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(db.UserTotp{}, db.ErrRecordNotFound)
+
+				store.EXPECT().
+					CreateSession(gomock.Any(), gomock.Any()).
+					Times(0)
+
+				store.EXPECT().
+					RecordFailedLoginTx(gomock.Any(), gomock.Any()).
+					Times(1).
+					DoAndReturn(func(_ context.Context, arg db.RecordFailedLoginTxParams) (db.RecordFailedLoginTxResult, error) {
+						require.Equal(t, user.Username, arg.Username)
+						return db.RecordFailedLoginTxResult{}, nil
+					})
+			},
+			checkResponse: func(t *testing.T, res *pb.LoginUserResponse, err error) {
+				require.Error(t, err)
+				st, ok := status.FromError(err)
+				require.True(t, ok)
+				require.Equal(t, codes.Unauthenticated, st.Code())
+			},
+		},
+		{
+			name: "Locked",
+			buildReq: func(t *testing.T, tokenMaker token.Maker) *pb.VerifyLoginTotpRequest {
+				return &pb.VerifyLoginTotpRequest{
+					ChallengeToken: challengeToken(t, tokenMaker, util.TotpChallengeRole),
+					Code:           proto.String(code),
+				}
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					ListActiveLoginLocks(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return([]db.LoginThrottle{{
+						Scope:       db.LoginThrottleScopeUser,
+						Subject:     user.Username,
+						LockedUntil: pgtype.Timestamptz{Time: time.Now().Add(time.Minute), Valid: true},
+					}}, nil)
+
+				store.EXPECT().
+					GetUser(gomock.Any(), gomock.Any()).
+					Times(0)
+
+				store.EXPECT().
+					UseTotpStep(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkResponse: func(t *testing.T, res *pb.LoginUserResponse, err error) {
+				require.Error(t, err)
+				st, ok := status.FromError(err)
+				require.True(t, ok)
+				require.Equal(t, codes.ResourceExhausted, st.Code())
+			},
+		},
+		{
+			name: "PasswordChangedAfterChallenge",
+			buildReq: func(t *testing.T, tokenMaker token.Maker) *pb.VerifyLoginTotpRequest {
+				return &pb.VerifyLoginTotpRequest{
+					ChallengeToken: challengeToken(t, tokenMaker, util.TotpChallengeRole),
+					Code:           proto.String(code),
+				}
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				// the password was reset after the challenge was issued
+				store.EXPECT().
+					GetUserPasswordChangedAt(gomock.Any(), gomock.Eq(user.Username)).
+					Times(1).
+					Return(time.Now().Add(time.Minute), nil)
+
+				store.EXPECT().
+					GetUser(gomock.Any(), gomock.Any()).
+					Times(0)
+
+				store.EXPECT().
+					CreateSession(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkResponse: func(t *testing.T, res *pb.LoginUserResponse, err error) {
+				require.Error(t, err)
+				st, ok := status.FromError(err)
+				require.True(t, ok)
+				require.Equal(t, codes.Unauthenticated, st.Code())
+			},
+		},
+		{
+			name: "AccessTokenAsChallenge",
+			buildReq: func(t *testing.T, tokenMaker token.Maker) *pb.VerifyLoginTotpRequest {
+				return &pb.VerifyLoginTotpRequest{
+					ChallengeToken: challengeToken(t, tokenMaker, util.DepositorRole),
+					Code:           proto.String(code),
+				}
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetUser(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkResponse: func(t *testing.T, res *pb.LoginUserResponse, err error) {
+				require.Error(t, err)
+				st, ok := status.FromError(err)
+				require.True(t, ok)
+				require.Equal(t, codes.Unauthenticated, st.Code())
+			},
+		},
+		{
+			name: "MissingCode",
+			buildReq: func(t *testing.T, tokenMaker token.Maker) *pb.VerifyLoginTotpRequest {
+				return &pb.VerifyLoginTotpRequest{
+					ChallengeToken: challengeToken(t, tokenMaker, util.TotpChallengeRole),
+				}
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetUser(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkResponse: func(t *testing.T, res *pb.LoginUserResponse, err error) {
+				require.Error(t, err)
+				st, ok := status.FromError(err)
+				require.True(t, ok)
+				require.Equal(t, codes.InvalidArgument, st.Code())
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			storeCtrl := gomock.NewController(t)
+			defer storeCtrl.Finish()
+			store := mockdb.NewMockStore(storeCtrl)
+
+			tc.buildStubs(store)
+			server := newTestServer(t, store, nil)
+
+			res, err := server.VerifyLoginTotp(context.Background(), tc.buildReq(t, server.tokenMaker))
+			tc.checkResponse(t, res, err)
+		})
+	}
+}
+
+func TestVerifyLoginTotpChallengeUsedOnceAPI(t *testing.T) {
+	user, _ := randomUser(t)
+
+	recoveryCode := "abcde-fghij"
+	hashedRecoveryCode, err := util.HashPassword(totp.NormalizeRecoveryCode(recoveryCode))
+	require.NoError(t, err)
+
+	store := mockdb.NewMockStore(gomock.NewController(t))
+
+	// only the first login gets past the challenge
+	store.EXPECT().
+		ListActiveLoginLocks(gomock.Any(), gomock.Any()).
+		Times(1).
+		Return([]db.LoginThrottle{}, nil)
+
+	store.EXPECT().
+		GetUser(gomock.Any(), gomock.Eq(user.Username)).
+		Times(1).
+		Return(user, nil)
+
+	store.EXPECT().
+		ListUnusedTotpRecoveryCodes(gomock.Any(), gomock.Eq(user.Username)).
+		Times(1).
+		Return([]db.TotpRecoveryCode{{ID: 7, Username: user.Username, HashedCode: hashedRecoveryCode}}, nil)
+
+	store.EXPECT().
+		UseTotpRecoveryCode(gomock.Any(), gomock.Eq(int64(7))).
+		Times(1)
+
+	store.EXPECT().
+		DeleteLoginThrottle(gomock.Any(), gomock.Any()).
+		Times(1).
+		Return(nil)
+
+	store.EXPECT().
+		CreateSession(gomock.Any(), gomock.Any()).
+		Times(1).
+		DoAndReturn(func(_ context.Context, arg db.CreateSessionParams) (db.Session, error) {
+			return db.Session{ID: arg.ID, Username: arg.Username}, nil
+		})
+
+	server := newTestServer(t, store, nil)
+
+	challengeToken, _, err := server.tokenMaker.CreateToken(user.Username, util.TotpChallengeRole, time.Minute)
+	require.NoError(t, err)
+
+	req := &pb.VerifyLoginTotpRequest{
+		ChallengeToken: challengeToken,
+		RecoveryCode:   proto.String(recoveryCode),
+	}
+
+	_, err = server.VerifyLoginTotp(context.Background(), req)
+	require.NoError(t, err)
+
+	req.RecoveryCode = proto.String("klmno-pqrst")
+	_, err = server.VerifyLoginTotp(context.Background(), req)
+	require.Error(t, err)
+	st, ok := status.FromError(err)
+	require.True(t, ok)
+	require.Equal(t, codes.Unauthenticated, st.Code())
+}
@@ -2,8 +2,10 @@ package gapi
 
 import (
 	"fmt"
+	"net"
 
 	db "github.com/tonisco/simple-bank-go/db/sqlc"
+	"github.com/tonisco/simple-bank-go/fx"
 	"github.com/tonisco/simple-bank-go/pb"
 	"github.com/tonisco/simple-bank-go/token"
 	"github.com/tonisco/simple-bank-go/util"
@@ -15,21 +17,34 @@ type Server struct {
 	config          util.Config
 	store           db.Store
 	tokenMaker      token.Maker
+	keyring         *token.Keyring
 	taskDistributor worker.TaskDistributor
+	rates           fx.RateProvider
+	denylist        token.Denylist
+	trustedProxies  []*net.IPNet
 }
 
 // New server creates a GRPC server and set up routing
-func NewServer(config util.Config, store db.Store, taskDistributor worker.TaskDistributor) (*Server, error) {
-	tokenMaker, err := token.NewPasetoMaker(config.TokenSymmetricKey)
+func NewServer(config util.Config, store db.Store, taskDistributor worker.TaskDistributor, rates fx.RateProvider, denylist token.Denylist) (*Server, error) {
+	keyring, err := token.LoadKeyring(config.TokenMakerType, config.TokenSymmetricKey, config.TokenPrivateKeyFile, config.TokenKeyringFile)
 	if err != nil {
 		return nil, fmt.Errorf("cannot create token maker: %v", err)
 	}
 
+	trustedProxies, err := parseTrustedProxies(config.TrustedProxies)
+	if err != nil {
+		return nil, fmt.Errorf("cannot parse trusted proxies: %v", err)
+	}
+
 	server := &Server{
 		config:          config,
 		store:           store,
-		tokenMaker:      tokenMaker,
+		tokenMaker:      keyring,
+		keyring:         keyring,
 		taskDistributor: taskDistributor,
+		rates:           rates,
+		denylist:        denylist,
+		trustedProxies:  trustedProxies,
 	}
 
 	return server, nil
@@ -2,6 +2,7 @@ package main
 
 import (
 	"context"
+	"flag"
 	"net"
 	"net/http"
 	"os"
@@ -11,13 +12,16 @@ import (
 	"github.com/hibiken/asynq"
 	"github.com/jackc/pgx/v5/pgxpool"
 	"github.com/rakyll/statik/fs"
+	"github.com/redis/go-redis/v9"
 	"github.com/rs/zerolog"
 	"github.com/rs/zerolog/log"
 	"github.com/tonisco/simple-bank-go/api"
 	db "github.com/tonisco/simple-bank-go/db/sqlc"
+	"github.com/tonisco/simple-bank-go/fx"
 	"github.com/tonisco/simple-bank-go/gapi"
 	"github.com/tonisco/simple-bank-go/mail"
 	"github.com/tonisco/simple-bank-go/pb"
+	"github.com/tonisco/simple-bank-go/token"
 	"github.com/tonisco/simple-bank-go/util"
 	"github.com/tonisco/simple-bank-go/worker"
 	"google.golang.org/grpc"
@@ -46,19 +50,35 @@ func main() {
 		log.Fatal().Err(err).Msg("Failed to connect to db:")
 	}
 
-	runDBMigration(config.MigrationURL, config.DBSource)
-
 	store := db.NewStore(connPool)
 
+	if len(os.Args) > 1 && os.Args[1] == "reconcile" {
+		runReconcile(store, os.Args[2:])
+		return
+	}
+
+	runDBMigration(config.MigrationURL, config.DBSource)
+
 	redisOpt := asynq.RedisClientOpt{
 		Addr: config.RedisAddress,
 	}
 
+	rates, err := fx.LoadStaticRateProvider(config.FXRatesFile)
+	if err != nil {
+		log.Fatal().Err(err).Msg("cannot load exchange rates")
+	}
+
+	// revocations are kept for as long as the longest lived token
+	denylist := token.NewRedisDenylist(redis.NewClient(&redis.Options{
+		Addr: config.RedisAddress,
+	}), config.RefreshTokenDuration)
+
 	taskDistributor := worker.NewRedisTaskDistributor(redisOpt)
-	go runTaskProcessor(config, redisOpt, store)
+	go runTaskProcessor(config, redisOpt, store, taskDistributor)
+	go runTaskScheduler(config, redisOpt)
 
-	go runGatewayServer(config, store, taskDistributor)
-	runGRPCServer(config, store, taskDistributor)
+	go runGatewayServer(config, store, taskDistributor, rates, denylist)
+	runGRPCServer(config, store, taskDistributor, rates, denylist)
 }
 
 func runDBMigration(migrationURL, dbSource string) {
@@ -74,9 +94,26 @@ func runDBMigration(migrationURL, dbSource string) {
 	log.Info().Msg("db migrated successfully")
 }
 
-func runTaskProcessor(config util.Config, redisOpt asynq.RedisClientOpt, store db.Store) {
+// runReconcile checks the ledger once and exits with status 1 if any account has drifted,
+// so it can be run by hand or from cron
+func runReconcile(store db.Store, args []string) {
+	flags := flag.NewFlagSet("reconcile", flag.ExitOnError)
+	freeze := flags.Bool("freeze", false, "freeze accounts whose balance does not match their entries")
+	flags.Parse(args)
+
+	result, err := worker.ReconcileLedger(context.Background(), store, *freeze)
+	if err != nil {
+		log.Fatal().Err(err).Msg("failed to reconcile ledger")
+	}
+
+	if len(result.Drifts) > 0 {
+		os.Exit(1)
+	}
+}
+
+func runTaskProcessor(config util.Config, redisOpt asynq.RedisClientOpt, store db.Store, taskDistributor worker.TaskDistributor) {
 	mailer := mail.NewGmailSender(config.EmailSenderName, config.EmailSenderAddress, config.EmailSenderPassword)
-	taskProcessor := worker.NewRedisTaskProcessor(redisOpt, store, mailer)
+	taskProcessor := worker.NewRedisTaskProcessor(redisOpt, store, mailer, taskDistributor)
 	log.Info().Msg("started task processor")
 	err := taskProcessor.Start()
 	if err != nil {
@@ -84,8 +121,17 @@ func runTaskProcessor(config util.Config, redisOpt asynq.RedisClientOpt, store d
 	}
 }
 
-func runGRPCServer(config util.Config, store db.Store, taskDistributor worker.TaskDistributor) {
-	server, err := gapi.NewServer(config, store, taskDistributor)
+func runTaskScheduler(config util.Config, redisOpt asynq.RedisClientOpt) {
+	taskScheduler := worker.NewRedisTaskScheduler(redisOpt, config)
+	log.Info().Msg("started task scheduler")
+	err := taskScheduler.Start()
+	if err != nil {
+		log.Fatal().Err(err).Msg("failed to start task scheduler")
+	}
+}
+
+func runGRPCServer(config util.Config, store db.Store, taskDistributor worker.TaskDistributor, rates fx.RateProvider, denylist token.Denylist) {
+	server, err := gapi.NewServer(config, store, taskDistributor, rates, denylist)
 	if err != nil {
 		log.Fatal().Err(err).Msg("Cannot start server")
 	}
@@ -108,8 +154,8 @@ func runGRPCServer(config util.Config, store db.Store, taskDistributor worker.Ta
 	}
 }
 
-func runGatewayServer(config util.Config, store db.Store, taskDistributor worker.TaskDistributor) {
-	server, err := gapi.NewServer(config, store, taskDistributor)
+func runGatewayServer(config util.Config, store db.Store, taskDistributor worker.TaskDistributor, rates fx.RateProvider, denylist token.Denylist) {
+	server, err := gapi.NewServer(config, store, taskDistributor, rates, denylist)
 	if err != nil {
 		log.Fatal().Err(err).Msg("Cannot start server")
 	}
@@ -123,7 +169,9 @@ func runGatewayServer(config util.Config, store db.Store, taskDistributor worker
 		},
 	})
 
-	grpcMux := runtime.NewServeMux(jsonOption)
+	headerMatcher := runtime.WithIncomingHeaderMatcher(gapi.HeaderMatcher)
+
+	grpcMux := runtime.NewServeMux(jsonOption, headerMatcher)
 	ctx, cancel := context.WithCancel(context.Background())
 	defer cancel()
 
@@ -132,6 +180,16 @@ func runGatewayServer(config util.Config, store db.Store, taskDistributor worker
 		log.Fatal().Msg("cannot register handler server")
 	}
 
+	err = grpcMux.HandlePath(http.MethodGet, gapi.ExportAccountStatementPath, server.ExportAccountStatement)
+	if err != nil {
+		log.Fatal().Err(err).Msg("cannot register statement export handler")
+	}
+
+	err = grpcMux.HandlePath(http.MethodGet, gapi.JWKSPath, server.JWKS)
+	if err != nil {
+		log.Fatal().Err(err).Msg("cannot register jwks handler")
+	}
+
 	mux := http.NewServeMux()
 	mux.Handle("/", grpcMux)
 
@@ -158,8 +216,8 @@ func runGatewayServer(config util.Config, store db.Store, taskDistributor worker
 	}
 }
 
-func runGinServer(config util.Config, store db.Store) {
-	server, err := api.NewServer(config, store)
+func runGinServer(config util.Config, store db.Store, taskDistributor worker.TaskDistributor, rates fx.RateProvider, denylist token.Denylist) {
+	server, err := api.NewServer(config, store, taskDistributor, rates, denylist)
 	if err != nil {
 		log.Fatal().Err(err).Msg("Cannot start server")
 	}
@@ -0,0 +1,165 @@
+package statement
+
+import (
+	"encoding/xml"
+	"fmt"
+	"io"
+	"strconv"
+	"time"
+)
+
+const camt053Namespace = "urn:iso:std:iso:20022:tech:xsd:camt.053.001.02"
+
+// Camt053Exporter renders a statement as an ISO 20022 camt.053.001.02 bank to customer statement
+type Camt053Exporter struct{}
+
+func (exporter *Camt053Exporter) ContentType() string {
+	return "application/xml"
+}
+
+func (exporter *Camt053Exporter) FileExtension() string {
+	return "xml"
+}
+
+type camtDocument struct {
+	XMLName   xml.Name      `xml:"Document"`
+	Namespace string        `xml:"xmlns,attr"`
+	GrpHdr    camtGrpHdr    `xml:"BkToCstmrStmt>GrpHdr"`
+	Stmt      camtStatement `xml:"BkToCstmrStmt>Stmt"`
+}
+
+type camtGrpHdr struct {
+	MsgID   string `xml:"MsgId"`
+	CreDtTm string `xml:"CreDtTm"`
+}
+
+type camtStatement struct {
+	ID      string        `xml:"Id"`
+	CreDtTm string        `xml:"CreDtTm"`
+	FrDtTm  string        `xml:"FrToDt>FrDtTm"`
+	ToDtTm  string        `xml:"FrToDt>ToDtTm"`
+	Acct    camtAccount   `xml:"Acct"`
+	Bal     []camtBalance `xml:"Bal"`
+	Ntry    []camtEntry   `xml:"Ntry"`
+}
+
+type camtAccount struct {
+	ID    string `xml:"Id>Othr>Id"`
+	Ccy   string `xml:"Ccy"`
+	Owner string `xml:"Ownr>Nm"`
+	Svcr  string `xml:"Svcr>FinInstnId>Othr>Id"`
+}
+
+type camtAmount struct {
+	Ccy   string `xml:"Ccy,attr"`
+	Value string `xml:",chardata"`
+}
+
+type camtBalance struct {
+	Code      string     `xml:"Tp>CdOrPrtry>Cd"`
+	Amt       camtAmount `xml:"Amt"`
+	CdtDbtInd string     `xml:"CdtDbtInd"`
+	DtTm      string     `xml:"Dt>DtTm"`
+}
+
+type camtEntry struct {
+	NtryRef      string     `xml:"NtryRef"`
+	Amt          camtAmount `xml:"Amt"`
+	CdtDbtInd    string     `xml:"CdtDbtInd"`
+	Sts          string     `xml:"Sts"`
+	BookgDt      string     `xml:"BookgDt>DtTm"`
+	ValDt        string     `xml:"ValDt>DtTm"`
+	AcctSvcrRef  string     `xml:"AcctSvcrRef"`
+	BkTxCd       string     `xml:"BkTxCd>Prtry>Cd"`
+	AddtlNtryInf string     `xml:"AddtlNtryInf"`
+}
+
+func (exporter *Camt053Exporter) Export(w io.Writer, statement Statement) error {
+	currency := statement.Account.Currency
+	statementID := fmt.Sprintf("%d-%s", statement.Account.ID, statement.Period.To.UTC().Format("20060102"))
+
+	doc := camtDocument{
+		Namespace: camt053Namespace,
+		GrpHdr: camtGrpHdr{
+			MsgID:   fmt.Sprintf("%s-%d", statementID, statement.GeneratedAt.Unix()),
+			CreDtTm: camtTime(statement.GeneratedAt),
+		},
+		Stmt: camtStatement{
+			ID:      statementID,
+			CreDtTm: camtTime(statement.GeneratedAt),
+			FrDtTm:  camtTime(statement.Period.From),
+			ToDtTm:  camtTime(statement.Period.To),
+			Acct: camtAccount{
+				ID:    strconv.FormatInt(statement.Account.ID, 10),
+				Ccy:   currency,
+				Owner: statement.Account.Owner,
+				Svcr:  BankID,
+			},
+			Bal: []camtBalance{
+				camtBalanceOf("OPBD", statement.Period.OpeningBalance, currency, statement.Period.From),
+				camtBalanceOf("CLBD", statement.Period.ClosingBalance, currency, statement.Period.To),
+			},
+			Ntry: make([]camtEntry, len(statement.Period.Entries)),
+		},
+	}
+
+	for i, entry := range statement.Period.Entries {
+		amount, indicator := camtAmountOf(entry.Amount, currency)
+		code := "DEPOSIT"
+		if entry.Amount < 0 {
+			code = "WITHDRAWAL"
+		}
+		if entry.TransferID.Valid {
+			code = "TRANSFER"
+		}
+
+		doc.Stmt.Ntry[i] = camtEntry{
+			NtryRef:      strconv.FormatInt(entry.ID, 10),
+			Amt:          amount,
+			CdtDbtInd:    indicator,
+			Sts:          "BOOK",
+			BookgDt:      camtTime(entry.CreatedAt),
+			ValDt:        camtTime(entry.CreatedAt),
+			AcctSvcrRef:  strconv.FormatInt(entry.ID, 10),
+			BkTxCd:       code,
+			AddtlNtryInf: description(entry),
+		}
+	}
+
+	if _, err := io.WriteString(w, xml.Header); err != nil {
+		return err
+	}
+
+	encoder := xml.NewEncoder(w)
+	encoder.Indent("", "  ")
+	if err := encoder.Encode(doc); err != nil {
+		return err
+	}
+
+	_, err := io.WriteString(w, "\n")
+	return err
+}
+
+// camtAmountOf splits a signed amount into the unsigned amount and credit/debit indicator camt.053 expects
+func camtAmountOf(amount int64, currency string) (camtAmount, string) {
+	indicator := "CRDT"
+	if amount < 0 {
+		indicator = "DBIT"
+		amount = -amount
+	}
+	return camtAmount{Ccy: currency, Value: formatAmount(amount, currency)}, indicator
+}
+
+func camtBalanceOf(code string, balance int64, currency string, at time.Time) camtBalance {
+	amount, indicator := camtAmountOf(balance, currency)
+	return camtBalance{
+		Code:      code,
+		Amt:       amount,
+		CdtDbtInd: indicator,
+		DtTm:      camtTime(at),
+	}
+}
+
+func camtTime(t time.Time) string {
+	return t.UTC().Format("2006-01-02T15:04:05Z")
+}
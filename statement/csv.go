@@ -0,0 +1,52 @@
+package statement
+
+import (
+	"encoding/csv"
+	"io"
+	"strconv"
+	"time"
+)
+
+// CSVExporter renders a statement as one CSV row per entry
+type CSVExporter struct{}
+
+func (exporter *CSVExporter) ContentType() string {
+	return "text/csv; charset=utf-8"
+}
+
+func (exporter *CSVExporter) FileExtension() string {
+	return "csv"
+}
+
+func (exporter *CSVExporter) Export(w io.Writer, statement Statement) error {
+	writer := csv.NewWriter(w)
+
+	err := writer.Write([]string{"date", "entry_id", "transfer_id", "description", "amount", "balance", "currency"})
+	if err != nil {
+		return err
+	}
+
+	currency := statement.Account.Currency
+	for _, entry := range statement.Period.Entries {
+		transferID := ""
+		if entry.TransferID.Valid {
+			transferID = strconv.FormatInt(entry.TransferID.Int64, 10)
+		}
+
+		err := writer.Write([]string{
+			entry.CreatedAt.UTC().Format(time.RFC3339),
+			strconv.FormatInt(entry.ID, 10),
+			transferID,
+			description(entry),
+			formatAmount(entry.Amount, currency),
+			formatAmount(entry.RunningBalance, currency),
+			currency,
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	writer.Flush()
+	return writer.Error()
+}
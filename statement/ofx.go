@@ -0,0 +1,147 @@
+package statement
+
+import (
+	"encoding/xml"
+	"fmt"
+	"io"
+	"strconv"
+	"time"
+)
+
+const ofxHeader = `<?xml version="1.0" encoding="UTF-8" standalone="no"?>
+<?OFX OFXHEADER="200" VERSION="220" SECURITY="NONE" OLDFILEUID="NONE" NEWFILEUID="NONE"?>
+`
+
+// OFXExporter renders a statement as an OFX 2.2 bank statement response
+type OFXExporter struct{}
+
+func (exporter *OFXExporter) ContentType() string {
+	return "application/x-ofx"
+}
+
+func (exporter *OFXExporter) FileExtension() string {
+	return "ofx"
+}
+
+type ofxDocument struct {
+	XMLName xml.Name     `xml:"OFX"`
+	SignOn  ofxSignOn    `xml:"SIGNONMSGSRSV1>SONRS"`
+	Bank    ofxStmtTrnRs `xml:"BANKMSGSRSV1>STMTTRNRS"`
+}
+
+type ofxStatus struct {
+	Code     int    `xml:"CODE"`
+	Severity string `xml:"SEVERITY"`
+}
+
+type ofxSignOn struct {
+	Status   ofxStatus `xml:"STATUS"`
+	DTServer string    `xml:"DTSERVER"`
+	Language string    `xml:"LANGUAGE"`
+}
+
+type ofxStmtTrnRs struct {
+	TrnUID string    `xml:"TRNUID"`
+	Status ofxStatus `xml:"STATUS"`
+	StmtRs ofxStmtRs `xml:"STMTRS"`
+}
+
+type ofxStmtRs struct {
+	CurDef       string          `xml:"CURDEF"`
+	BankAcctFrom ofxBankAcct     `xml:"BANKACCTFROM"`
+	BankTranList ofxBankTranList `xml:"BANKTRANLIST"`
+	LedgerBal    ofxBalance      `xml:"LEDGERBAL"`
+}
+
+type ofxBankAcct struct {
+	BankID   string `xml:"BANKID"`
+	AcctID   string `xml:"ACCTID"`
+	AcctType string `xml:"ACCTTYPE"`
+}
+
+type ofxBankTranList struct {
+	DTStart      string           `xml:"DTSTART"`
+	DTEnd        string           `xml:"DTEND"`
+	Transactions []ofxTransaction `xml:"STMTTRN"`
+}
+
+type ofxTransaction struct {
+	TrnType  string `xml:"TRNTYPE"`
+	DTPosted string `xml:"DTPOSTED"`
+	TrnAmt   string `xml:"TRNAMT"`
+	FitID    string `xml:"FITID"`
+	RefNum   string `xml:"REFNUM,omitempty"`
+	Name     string `xml:"NAME"`
+}
+
+type ofxBalance struct {
+	BalAmt string `xml:"BALAMT"`
+	DTAsOf string `xml:"DTASOF"`
+}
+
+func (exporter *OFXExporter) Export(w io.Writer, statement Statement) error {
+	doc := ofxDocument{
+		SignOn: ofxSignOn{
+			Status:   ofxStatus{Code: 0, Severity: "INFO"},
+			DTServer: ofxTime(statement.GeneratedAt),
+			Language: "ENG",
+		},
+		Bank: ofxStmtTrnRs{
+			TrnUID: fmt.Sprintf("%d-%d", statement.Account.ID, statement.GeneratedAt.Unix()),
+			Status: ofxStatus{Code: 0, Severity: "INFO"},
+			StmtRs: ofxStmtRs{
+				CurDef: statement.Account.Currency,
+				BankAcctFrom: ofxBankAcct{
+					BankID:   BankID,
+					AcctID:   strconv.FormatInt(statement.Account.ID, 10),
+					AcctType: "CHECKING",
+				},
+				BankTranList: ofxBankTranList{
+					DTStart:      ofxTime(statement.Period.From),
+					DTEnd:        ofxTime(statement.Period.To),
+					Transactions: make([]ofxTransaction, len(statement.Period.Entries)),
+				},
+				LedgerBal: ofxBalance{
+					BalAmt: formatAmount(statement.Period.ClosingBalance, statement.Account.Currency),
+					DTAsOf: ofxTime(statement.Period.To),
+				},
+			},
+		},
+	}
+
+	for i, entry := range statement.Period.Entries {
+		transaction := ofxTransaction{
+			TrnType:  "CREDIT",
+			DTPosted: ofxTime(entry.CreatedAt),
+			TrnAmt:   formatAmount(entry.Amount, statement.Account.Currency),
+			FitID:    strconv.FormatInt(entry.ID, 10),
+			Name:     description(entry),
+		}
+		if entry.Amount < 0 {
+			transaction.TrnType = "DEBIT"
+		}
+		if entry.TransferID.Valid {
+			transaction.TrnType = "XFER"
+			transaction.RefNum = strconv.FormatInt(entry.TransferID.Int64, 10)
+		}
+		doc.Bank.StmtRs.BankTranList.Transactions[i] = transaction
+	}
+
+	if _, err := io.WriteString(w, ofxHeader); err != nil {
+		return err
+	}
+
+	encoder := xml.NewEncoder(w)
+	encoder.Indent("", "  ")
+	if err := encoder.Encode(doc); err != nil {
+		return err
+	}
+
+	_, err := io.WriteString(w, "\n")
+	return err
+}
+
+// ofxTime formats a time in the OFX datetime format, always in UTC
+func ofxTime(t time.Time) string {
+	return t.UTC().Format("20060102150405.000") + "[0:UTC]"
+}
@@ -0,0 +1,84 @@
+package statement
+
+import (
+	"fmt"
+	"io"
+	"strconv"
+	"time"
+
+	db "github.com/tonisco/simple-bank-go/db/sqlc"
+	"github.com/tonisco/simple-bank-go/util"
+)
+
+// BankID identifies the bank in exported files
+const BankID = "SIMPLEBANK"
+
+const (
+	FormatCSV     = "csv"
+	FormatOFX     = "ofx"
+	FormatCamt053 = "camt053"
+)
+
+// Statement is an account statement together with the account it belongs to
+type Statement struct {
+	Account     db.Account
+	Period      db.AccountStatement
+	GeneratedAt time.Time
+}
+
+// Exporter renders a statement in a file format that bookkeeping software can import
+type Exporter interface {
+	// Export writes the statement to w as it is rendered
+	Export(w io.Writer, statement Statement) error
+	// ContentType is the MIME type of the rendered statement
+	ContentType() string
+	// FileExtension is the extension of a downloaded statement, without the dot
+	FileExtension() string
+}
+
+// NewExporter returns the exporter for a format
+func NewExporter(format string) (Exporter, error) {
+	switch format {
+	case FormatCSV:
+		return &CSVExporter{}, nil
+	case FormatOFX:
+		return &OFXExporter{}, nil
+	case FormatCamt053:
+		return &Camt053Exporter{}, nil
+	}
+	return nil, fmt.Errorf("unsupported statement format %q", format)
+}
+
+// FileName is the name a downloaded statement is saved under
+func FileName(exporter Exporter, statement Statement) string {
+	return fmt.Sprintf("statement-%d-%s-%s.%s",
+		statement.Account.ID,
+		statement.Period.From.UTC().Format("20060102"),
+		statement.Period.To.UTC().Format("20060102"),
+		exporter.FileExtension(),
+	)
+}
+
+// formatAmount formats an amount in minor units as a decimal string in the currency's major unit,
+// e.g. -1234 USD as "-12.34" and 1234 JPY as "1234"
+func formatAmount(amount int64, currency string) string {
+	money, err := util.NewMoney(amount, currency)
+	if err != nil {
+		return strconv.FormatInt(amount, 10)
+	}
+	return money.Decimal()
+}
+
+// description explains where the money of an entry came from or went to
+func description(entry db.ListStatementEntriesRow) string {
+	switch {
+	case entry.TransferID.Valid && entry.Amount < 0:
+		return fmt.Sprintf("Transfer to account %d", entry.CounterpartyAccountID)
+	case entry.TransferID.Valid:
+		return fmt.Sprintf("Transfer from account %d", entry.CounterpartyAccountID)
+	case entry.Amount < 0:
+		return "Withdrawal"
+	default:
+		return "Deposit"
+	}
+}
@@ -0,0 +1,120 @@
+package statement
+
+import (
+	"bytes"
+	"flag"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/stretchr/testify/require"
+	db "github.com/tonisco/simple-bank-go/db/sqlc"
+	"github.com/tonisco/simple-bank-go/util"
+)
+
+var update = flag.Bool("update", false, "update the golden files")
+
+func testStatement() Statement {
+	from := time.Date(2023, time.September, 1, 0, 0, 0, 0, time.UTC)
+	to := time.Date(2023, time.October, 1, 0, 0, 0, 0, time.UTC)
+
+	return Statement{
+		Account: db.Account{
+			ID:       42,
+			Owner:    "alice",
+			Balance:  12500,
+			Currency: util.USD,
+		},
+		Period: db.AccountStatement{
+			AccountID:      42,
+			From:           from,
+			To:             to,
+			OpeningBalance: 10000,
+			ClosingBalance: 12500,
+			Entries: []db.ListStatementEntriesRow{
+				{
+					ID:             101,
+					AccountID:      42,
+					Amount:         5000,
+					CreatedAt:      from.Add(26 * time.Hour),
+					RunningBalance: 15000,
+				},
+				{
+					ID:                    102,
+					AccountID:             42,
+					Amount:                -3550,
+					CreatedAt:             from.Add(7*24*time.Hour + 30*time.Minute),
+					TransferID:            pgtype.Int8{Int64: 7, Valid: true},
+					CounterpartyAccountID: 43,
+					RunningBalance:        11450,
+				},
+				{
+					ID:                    105,
+					AccountID:             42,
+					Amount:                1050,
+					CreatedAt:             from.Add(20*24*time.Hour + 15*time.Second),
+					TransferID:            pgtype.Int8{Int64: 9, Valid: true},
+					CounterpartyAccountID: 44,
+					RunningBalance:        12500,
+				},
+			},
+		},
+		GeneratedAt: time.Date(2023, time.October, 2, 8, 30, 0, 0, time.UTC),
+	}
+}
+
+func TestExportGolden(t *testing.T) {
+	testCases := []struct {
+		format string
+		golden string
+	}{
+		{format: FormatCSV, golden: "statement.csv"},
+		{format: FormatOFX, golden: "statement.ofx"},
+		{format: FormatCamt053, golden: "statement.camt053.xml"},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.format, func(t *testing.T) {
+			exporter, err := NewExporter(tc.format)
+			require.NoError(t, err)
+
+			var buf bytes.Buffer
+			err = exporter.Export(&buf, testStatement())
+			require.NoError(t, err)
+
+			golden := filepath.Join("testdata", tc.golden)
+			if *update {
+				err = os.WriteFile(golden, buf.Bytes(), 0644)
+				require.NoError(t, err)
+			}
+
+			want, err := os.ReadFile(golden)
+			require.NoError(t, err)
+			require.Equal(t, string(want), buf.String())
+		})
+	}
+}
+
+func TestNewExporterUnsupportedFormat(t *testing.T) {
+	exporter, err := NewExporter("pdf")
+	require.Error(t, err)
+	require.Nil(t, exporter)
+}
+
+func TestFileName(t *testing.T) {
+	exporter, err := NewExporter(FormatOFX)
+	require.NoError(t, err)
+
+	require.Equal(t, "statement-42-20230901-20231001.ofx", FileName(exporter, testStatement()))
+}
+
+func TestFormatAmount(t *testing.T) {
+	require.Equal(t, "0.00", formatAmount(0, util.USD))
+	require.Equal(t, "0.05", formatAmount(5, util.USD))
+	require.Equal(t, "12.34", formatAmount(1234, util.EUR))
+	require.Equal(t, "-12.34", formatAmount(-1234, util.CAD))
+	require.Equal(t, "1234", formatAmount(1234, util.JPY))
+	require.Equal(t, "-1.234", formatAmount(-1234, util.KWD))
+}
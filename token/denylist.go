@@ -0,0 +1,77 @@
+package token
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+var ErrRevokedToken = errors.New("token has been revoked")
+
+// Denylist revokes tokens and login sessions before they expire.
+// Revocations only need to be remembered for as long as the longest lived token, after that the tokens have expired anyway.
+// Tokens issued before a password change are not revoked here, they are rejected against users.password_changed_at
+type Denylist interface {
+	// RevokeID revokes the token with the id, or every token of the login session with the id
+	RevokeID(ctx context.Context, id uuid.UUID) error
+
+	// Check returns ErrRevokedToken if the token has been revoked
+	Check(ctx context.Context, payload *Payload) error
+}
+
+// MemoryDenylist keeps revocations in the process, so they are lost on restart and not shared between servers
+type MemoryDenylist struct {
+	mu  sync.Mutex
+	ttl time.Duration
+	ids map[uuid.UUID]time.Time
+}
+
+// NewMemoryDenylist creates a denylist that remembers each revocation for ttl
+func NewMemoryDenylist(ttl time.Duration) *MemoryDenylist {
+	return &MemoryDenylist{
+		ttl: ttl,
+		ids: make(map[uuid.UUID]time.Time),
+	}
+}
+
+func (denylist *MemoryDenylist) RevokeID(ctx context.Context, id uuid.UUID) error {
+	denylist.mu.Lock()
+	defer denylist.mu.Unlock()
+
+	denylist.purge()
+	denylist.ids[id] = time.Now().Add(denylist.ttl)
+	return nil
+}
+
+func (denylist *MemoryDenylist) Check(ctx context.Context, payload *Payload) error {
+	denylist.mu.Lock()
+	defer denylist.mu.Unlock()
+
+	now := time.Now()
+
+	if expiresAt, ok := denylist.ids[payload.ID]; ok && now.Before(expiresAt) {
+		return ErrRevokedToken
+	}
+
+	if payload.SessionID != uuid.Nil {
+		if expiresAt, ok := denylist.ids[payload.SessionID]; ok && now.Before(expiresAt) {
+			return ErrRevokedToken
+		}
+	}
+
+	return nil
+}
+
+// purge drops the revocations that have outlived every token they could apply to
+func (denylist *MemoryDenylist) purge() {
+	now := time.Now()
+
+	for id, expiresAt := range denylist.ids {
+		if now.After(expiresAt) {
+			delete(denylist.ids, id)
+		}
+	}
+}
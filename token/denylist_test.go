@@ -0,0 +1,44 @@
+package token
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/require"
+	"github.com/tonisco/simple-bank-go/util"
+)
+
+func TestMemoryDenylistRevokeID(t *testing.T) {
+	denylist := NewMemoryDenylist(time.Minute)
+
+	payload, err := NewPayload(util.RandomOwner(), util.DepositorRole, time.Minute)
+	require.NoError(t, err)
+	payload.SessionID = uuid.New()
+
+	require.NoError(t, denylist.Check(context.Background(), payload))
+
+	err = denylist.RevokeID(context.Background(), payload.SessionID)
+	require.NoError(t, err)
+	require.ErrorIs(t, denylist.Check(context.Background(), payload), ErrRevokedToken)
+
+	other, err := NewPayload(payload.Username, util.DepositorRole, time.Minute)
+	require.NoError(t, err)
+	require.NoError(t, denylist.Check(context.Background(), other))
+
+	err = denylist.RevokeID(context.Background(), other.ID)
+	require.NoError(t, err)
+	require.ErrorIs(t, denylist.Check(context.Background(), other), ErrRevokedToken)
+}
+
+func TestMemoryDenylistExpiry(t *testing.T) {
+	denylist := NewMemoryDenylist(-time.Second)
+
+	payload, err := NewPayload(util.RandomOwner(), util.DepositorRole, time.Minute)
+	require.NoError(t, err)
+
+	err = denylist.RevokeID(context.Background(), payload.ID)
+	require.NoError(t, err)
+	require.NoError(t, denylist.Check(context.Background(), payload))
+}
@@ -0,0 +1,64 @@
+package token
+
+import (
+	"crypto/ed25519"
+	"crypto/rsa"
+	"encoding/base64"
+	"math/big"
+)
+
+// JSONWebKey is a public key of a keyring in the JWK format (RFC 7517)
+type JSONWebKey struct {
+	KeyType   string `json:"kty"`
+	KeyID     string `json:"kid,omitempty"`
+	Use       string `json:"use"`
+	Algorithm string `json:"alg,omitempty"`
+	Curve     string `json:"crv,omitempty"`
+	X         string `json:"x,omitempty"`
+	Modulus   string `json:"n,omitempty"`
+	Exponent  string `json:"e,omitempty"`
+}
+
+// JSONWebKeySet is the document served at /.well-known/jwks.json
+type JSONWebKeySet struct {
+	Keys []JSONWebKey `json:"keys"`
+}
+
+// JWKS returns the public keys of the keys that are not retired, the active key first.
+// It is empty for keyrings of symmetric keys, which must never be published
+func (keyring *Keyring) JWKS() JSONWebKeySet {
+	set := JSONWebKeySet{Keys: []JSONWebKey{}}
+
+	if !IsPublicKeyMaker(keyring.makerType) {
+		return set
+	}
+
+	for _, key := range keyring.verifiers {
+		jwk := JSONWebKey{
+			KeyID: key.id,
+			Use:   "sig",
+		}
+
+		switch publicKey := key.publicKey.(type) {
+		case ed25519.PublicKey:
+			jwk.KeyType = "OKP"
+			jwk.Curve = "Ed25519"
+			jwk.X = base64.RawURLEncoding.EncodeToString(publicKey)
+			// PASETO keys are bound to v2.public rather than to a JWS algorithm
+			if keyring.makerType == MakerJWTEdDSA {
+				jwk.Algorithm = SigningMethodEdDSA.Alg()
+			}
+		case *rsa.PublicKey:
+			jwk.KeyType = "RSA"
+			jwk.Algorithm = "RS256"
+			jwk.Modulus = base64.RawURLEncoding.EncodeToString(publicKey.N.Bytes())
+			jwk.Exponent = base64.RawURLEncoding.EncodeToString(big.NewInt(int64(publicKey.E)).Bytes())
+		default:
+			continue
+		}
+
+		set.Keys = append(set.Keys, jwk)
+	}
+
+	return set
+}
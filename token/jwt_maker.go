@@ -6,19 +6,26 @@ import (
 	"time"
 
 	"github.com/dgrijalva/jwt-go"
+	"github.com/google/uuid"
 )
 
 const minSecretKeySize = 32
 
 type JWTMaker struct {
 	secretKey string
+	// keyID is put in the kid header of the tokens when it is set
+	keyID string
 }
 
 func NewJWTMaker(secretKey string) (Maker, error) {
+	return newJWTMaker(secretKey, "")
+}
+
+func newJWTMaker(secretKey string, keyID string) (Maker, error) {
 	if len(secretKey) < minSecretKeySize {
 		return nil, fmt.Errorf("invalid key size: must be at least %d characters", minSecretKeySize)
 	}
-	return &JWTMaker{secretKey}, nil
+	return &JWTMaker{secretKey: secretKey, keyID: keyID}, nil
 }
 
 // create token for a specific user name and duration
@@ -28,7 +35,55 @@ func (maker *JWTMaker) CreateToken(username string, role string, duration time.D
 		return "", payload, err
 	}
 
+	return maker.signPayload(payload)
+}
+
+// create token for a user that belongs to a login session
+func (maker *JWTMaker) CreateSessionToken(username string, role string, sessionID uuid.UUID, duration time.Duration) (string, *Payload, error) {
+	payload, err := NewPayload(username, role, duration)
+	if err != nil {
+		return "", payload, err
+	}
+	payload.Type = TokenTypeAccess
+	payload.SessionID = sessionID
+
+	return maker.signPayload(payload)
+}
+
+// create a refresh token of a session family, a new family is started when familyID is uuid.Nil
+func (maker *JWTMaker) CreateRefreshToken(username string, role string, familyID uuid.UUID, duration time.Duration) (string, *Payload, error) {
+	payload, err := NewPayload(username, role, duration)
+	if err != nil {
+		return "", payload, err
+	}
+	payload.Type = TokenTypeRefresh
+	payload.SessionID = familyID
+	if familyID == uuid.Nil {
+		payload.SessionID = payload.ID
+	}
+
+	return maker.signPayload(payload)
+}
+
+// create a short-lived token for a user who has just re-entered their password
+func (maker *JWTMaker) CreateElevatedToken(username string, role string, sessionID uuid.UUID, duration time.Duration) (string, *Payload, error) {
+	payload, err := NewPayload(username, role, duration)
+	if err != nil {
+		return "", payload, err
+	}
+	payload.Type = TokenTypeAccess
+	payload.SessionID = sessionID
+	payload.AuthTime = payload.IssuedAt
+	payload.Assurance = AssuranceElevated
+
+	return maker.signPayload(payload)
+}
+
+func (maker *JWTMaker) signPayload(payload *Payload) (string, *Payload, error) {
 	jwtToken := jwt.NewWithClaims(jwt.SigningMethodHS256, payload)
+	if maker.keyID != "" {
+		jwtToken.Header[keyIDHeader] = maker.keyID
+	}
 	token, err := jwtToken.SignedString([]byte(maker.secretKey))
 	return token, payload, err
 }
@@ -0,0 +1,176 @@
+package token
+
+import (
+	"crypto"
+	"crypto/ed25519"
+	"crypto/rsa"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/dgrijalva/jwt-go"
+	"github.com/google/uuid"
+)
+
+const minRSAKeyBits = 2048
+
+// JWTPublicMaker signs JWTs with an Ed25519 (EdDSA) or RSA (RS256) key, so services holding only
+// the public key can check tokens but not create them
+type JWTPublicMaker struct {
+	method     jwt.SigningMethod
+	privateKey crypto.Signer
+	publicKey  crypto.PublicKey
+	// keyID is put in the kid header of the tokens when it is set
+	keyID string
+}
+
+// NewJWTPublicMaker creates a maker that signs tokens with privateKey, which must suit the signing method:
+// an Ed25519 key for SigningMethodEdDSA or an RSA key of at least 2048 bits for jwt.SigningMethodRS256
+func NewJWTPublicMaker(method jwt.SigningMethod, privateKey crypto.PrivateKey) (Maker, error) {
+	return newJWTPublicMaker(method, privateKey, "")
+}
+
+func newJWTPublicMaker(method jwt.SigningMethod, privateKey crypto.PrivateKey, keyID string) (Maker, error) {
+	signer, ok := privateKey.(crypto.Signer)
+	if !ok {
+		return nil, fmt.Errorf("invalid key: %T cannot sign", privateKey)
+	}
+
+	if err := checkJWTPublicKey(method, signer.Public()); err != nil {
+		return nil, err
+	}
+
+	maker := &JWTPublicMaker{
+		method:     method,
+		privateKey: signer,
+		publicKey:  signer.Public(),
+		keyID:      keyID,
+	}
+
+	return maker, nil
+}
+
+// NewJWTPublicVerifier creates a verifier for tokens signed with the signing method by the private key of publicKey
+func NewJWTPublicVerifier(method jwt.SigningMethod, publicKey crypto.PublicKey) (Verifier, error) {
+	if err := checkJWTPublicKey(method, publicKey); err != nil {
+		return nil, err
+	}
+
+	verifier := &JWTPublicMaker{
+		method:    method,
+		publicKey: publicKey,
+	}
+
+	return verifier, nil
+}
+
+func checkJWTPublicKey(method jwt.SigningMethod, publicKey crypto.PublicKey) error {
+	switch method {
+	case SigningMethodEdDSA:
+		if _, ok := publicKey.(ed25519.PublicKey); !ok {
+			return fmt.Errorf("invalid key: %s needs an Ed25519 key, got %T", method.Alg(), publicKey)
+		}
+	case jwt.SigningMethodRS256:
+		key, ok := publicKey.(*rsa.PublicKey)
+		if !ok {
+			return fmt.Errorf("invalid key: %s needs an RSA key, got %T", method.Alg(), publicKey)
+		}
+		if key.N.BitLen() < minRSAKeyBits {
+			return fmt.Errorf("invalid key size: RSA keys must be at least %d bits", minRSAKeyBits)
+		}
+	default:
+		return fmt.Errorf("unsupported signing method %s", method.Alg())
+	}
+
+	return nil
+}
+
+// create token for a specific user name and duration
+func (maker *JWTPublicMaker) CreateToken(username string, role string, duration time.Duration) (string, *Payload, error) {
+	payload, err := NewPayload(username, role, duration)
+	if err != nil {
+		return "", payload, err
+	}
+
+	return maker.signPayload(payload)
+}
+
+// create token for a user that belongs to a login session
+func (maker *JWTPublicMaker) CreateSessionToken(username string, role string, sessionID uuid.UUID, duration time.Duration) (string, *Payload, error) {
+	payload, err := NewPayload(username, role, duration)
+	if err != nil {
+		return "", payload, err
+	}
+	payload.Type = TokenTypeAccess
+	payload.SessionID = sessionID
+
+	return maker.signPayload(payload)
+}
+
+// create a refresh token of a session family, a new family is started when familyID is uuid.Nil
+func (maker *JWTPublicMaker) CreateRefreshToken(username string, role string, familyID uuid.UUID, duration time.Duration) (string, *Payload, error) {
+	payload, err := NewPayload(username, role, duration)
+	if err != nil {
+		return "", payload, err
+	}
+	payload.Type = TokenTypeRefresh
+	payload.SessionID = familyID
+	if familyID == uuid.Nil {
+		payload.SessionID = payload.ID
+	}
+
+	return maker.signPayload(payload)
+}
+
+// create a short-lived token for a user who has just re-entered their password
+func (maker *JWTPublicMaker) CreateElevatedToken(username string, role string, sessionID uuid.UUID, duration time.Duration) (string, *Payload, error) {
+	payload, err := NewPayload(username, role, duration)
+	if err != nil {
+		return "", payload, err
+	}
+	payload.Type = TokenTypeAccess
+	payload.SessionID = sessionID
+	payload.AuthTime = payload.IssuedAt
+	payload.Assurance = AssuranceElevated
+
+	return maker.signPayload(payload)
+}
+
+func (maker *JWTPublicMaker) signPayload(payload *Payload) (string, *Payload, error) {
+	if maker.privateKey == nil {
+		return "", payload, ErrCannotSign
+	}
+
+	jwtToken := jwt.NewWithClaims(maker.method, payload)
+	if maker.keyID != "" {
+		jwtToken.Header[keyIDHeader] = maker.keyID
+	}
+	token, err := jwtToken.SignedString(maker.privateKey)
+	return token, payload, err
+}
+
+// checks if token is valid
+func (maker *JWTPublicMaker) VerifyToken(token string) (*Payload, error) {
+	// only the maker's own algorithm is accepted, so a token cannot pick "none" or an HMAC keyed with the public key
+	keyFunc := func(t *jwt.Token) (interface{}, error) {
+		if t.Method.Alg() != maker.method.Alg() {
+			return nil, ErrInvalidToken
+		}
+		return maker.publicKey, nil
+	}
+
+	jwtToken, err := jwt.ParseWithClaims(token, &Payload{}, keyFunc)
+	if err != nil {
+		verr, ok := err.(*jwt.ValidationError)
+		if ok && errors.Is(verr.Inner, ErrExpiredToken) {
+			return nil, ErrExpiredToken
+		}
+		return nil, ErrInvalidToken
+	}
+
+	payload, ok := jwtToken.Claims.(*Payload)
+	if !ok {
+		return nil, ErrInvalidToken
+	}
+	return payload, nil
+}
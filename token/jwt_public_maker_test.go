@@ -0,0 +1,120 @@
+package token
+
+import (
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/pem"
+	"testing"
+	"time"
+
+	"github.com/dgrijalva/jwt-go"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/require"
+	"github.com/tonisco/simple-bank-go/util"
+)
+
+func randomRSAKey(t *testing.T) *rsa.PrivateKey {
+	privateKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+	return privateKey
+}
+
+func TestJWTPublicMaker(t *testing.T) {
+	testCases := []struct {
+		name       string
+		method     jwt.SigningMethod
+		privateKey crypto.Signer
+	}{
+		{
+			name:       "EdDSA",
+			method:     SigningMethodEdDSA,
+			privateKey: randomEd25519Key(t),
+		},
+		{
+			name:       "RS256",
+			method:     jwt.SigningMethodRS256,
+			privateKey: randomRSAKey(t),
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			maker, err := NewJWTPublicMaker(tc.method, tc.privateKey)
+			require.NoError(t, err)
+
+			username := util.RandomOwner()
+			sessionID := uuid.New()
+
+			token, _, err := maker.CreateSessionToken(username, util.DepositorRole, sessionID, time.Minute)
+			require.NoError(t, err)
+
+			payload, err := maker.VerifyToken(token)
+			require.NoError(t, err)
+			require.Equal(t, username, payload.Username)
+			require.Equal(t, sessionID, payload.SessionID)
+
+			verifier, err := NewJWTPublicVerifier(tc.method, tc.privateKey.Public())
+			require.NoError(t, err)
+
+			payload, err = verifier.VerifyToken(token)
+			require.NoError(t, err)
+			require.Equal(t, username, payload.Username)
+
+			_, _, err = verifier.(Maker).CreateToken(username, util.DepositorRole, time.Minute)
+			require.ErrorIs(t, err, ErrCannotSign)
+
+			token, _, err = maker.CreateToken(username, util.DepositorRole, -time.Minute)
+			require.NoError(t, err)
+
+			payload, err = verifier.VerifyToken(token)
+			require.EqualError(t, err, ErrExpiredToken.Error())
+			require.Nil(t, payload)
+		})
+	}
+}
+
+func TestJWTPublicMakerInvalidKey(t *testing.T) {
+	_, err := NewJWTPublicMaker(SigningMethodEdDSA, randomRSAKey(t))
+	require.Error(t, err)
+
+	_, err = NewJWTPublicMaker(jwt.SigningMethodRS256, randomEd25519Key(t))
+	require.Error(t, err)
+
+	smallKey, err := rsa.GenerateKey(rand.Reader, 1024)
+	require.NoError(t, err)
+
+	_, err = NewJWTPublicMaker(jwt.SigningMethodRS256, smallKey)
+	require.Error(t, err)
+}
+
+// a token signed with HMAC using the public key as the secret must not pass as an RS256 token
+func TestInvalidJWTPublicTokenAlgConfusion(t *testing.T) {
+	privateKey := randomRSAKey(t)
+
+	verifier, err := NewJWTPublicVerifier(jwt.SigningMethodRS256, privateKey.Public())
+	require.NoError(t, err)
+
+	publicKeyDER := x509.MarshalPKCS1PublicKey(&privateKey.PublicKey)
+	publicKeyPEM := pem.EncodeToMemory(&pem.Block{Type: "RSA PUBLIC KEY", Bytes: publicKeyDER})
+
+	claims, err := NewPayload(util.RandomOwner(), util.DepositorRole, time.Minute)
+	require.NoError(t, err)
+
+	token, err := jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString(publicKeyPEM)
+	require.NoError(t, err)
+
+	payload, err := verifier.VerifyToken(token)
+	require.EqualError(t, err, ErrInvalidToken.Error())
+	require.Nil(t, payload)
+
+	token, err = jwt.NewWithClaims(jwt.SigningMethodNone, claims).SignedString(jwt.UnsafeAllowNoneSignatureType)
+	require.NoError(t, err)
+
+	payload, err = verifier.VerifyToken(token)
+	require.EqualError(t, err, ErrInvalidToken.Error())
+	require.Nil(t, payload)
+}
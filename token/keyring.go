@@ -0,0 +1,266 @@
+package token
+
+import (
+	"crypto"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/dgrijalva/jwt-go"
+	"github.com/google/uuid"
+	"github.com/o1egl/paseto"
+)
+
+// keyIDHeader is the JWT header that names the key a token was signed with
+const keyIDHeader = "kid"
+
+// keyFooter is the PASETO footer that names the key a token was made with.
+// The footer is not encrypted but it is authenticated, so it cannot be changed
+type keyFooter struct {
+	KeyID string `json:"kid"`
+}
+
+func pasetoFooter(keyID string) interface{} {
+	if keyID == "" {
+		return nil
+	}
+	return &keyFooter{KeyID: keyID}
+}
+
+// tokenKeyID reads the key ID of a PASETO or JWT token without checking the token.
+// It is empty for tokens made without a key ID
+func tokenKeyID(token string) string {
+	if strings.HasPrefix(token, "v2.") {
+		var footer keyFooter
+		if err := paseto.ParseFooter(token, &footer); err != nil {
+			return ""
+		}
+		return footer.KeyID
+	}
+
+	jwtToken, _, err := new(jwt.Parser).ParseUnverified(token, &Payload{})
+	if err != nil {
+		return ""
+	}
+
+	keyID, _ := jwtToken.Header[keyIDHeader].(string)
+	return keyID
+}
+
+// KeyringKey is one of the keys of a keyring. Symmetric keyrings use Secret,
+// public-key keyrings use PrivateKey, or only PublicKey for a key that can no longer sign
+type KeyringKey struct {
+	ID         string
+	Secret     string
+	PrivateKey crypto.Signer
+	PublicKey  crypto.PublicKey
+	// retired keys are kept in the config but tokens made with them are refused
+	Retired bool
+}
+
+type keyringVerifier struct {
+	id        string
+	verifier  Verifier
+	publicKey crypto.PublicKey
+}
+
+// Keyring is a Maker that signs with its active key and verifies with any key that is not retired,
+// picked by the key ID in the token. Keys can be rotated by adding a new active key and keeping the
+// old one until the tokens made with it have expired. With public keys that other services fetch from JWKS,
+// the new key is added as a non-active key first and only made active once caches of the key set have expired
+type Keyring struct {
+	makerType   string
+	activeKeyID string
+	active      Maker
+	// verifiers of the keys that are not retired, the active key first
+	verifiers []keyringVerifier
+}
+
+// NewKeyring creates a keyring of the given maker type that signs with the key activeKeyID
+func NewKeyring(makerType string, activeKeyID string, keys []KeyringKey) (*Keyring, error) {
+	keyring := &Keyring{
+		makerType:   makerType,
+		activeKeyID: activeKeyID,
+	}
+
+	seen := make(map[string]bool, len(keys))
+
+	for _, key := range keys {
+		if seen[key.ID] {
+			return nil, fmt.Errorf("duplicate token key %q", key.ID)
+		}
+		seen[key.ID] = true
+
+		if key.Retired {
+			if key.ID == activeKeyID {
+				return nil, fmt.Errorf("active token key %q is retired", key.ID)
+			}
+			continue
+		}
+
+		publicKey := key.PublicKey
+		if key.PrivateKey != nil {
+			publicKey = key.PrivateKey.Public()
+		}
+
+		verifier, err := newKeyVerifier(makerType, key.Secret, publicKey)
+		if err != nil {
+			return nil, fmt.Errorf("invalid token key %q: %w", key.ID, err)
+		}
+
+		entry := keyringVerifier{
+			id:        key.ID,
+			verifier:  verifier,
+			publicKey: publicKey,
+		}
+
+		if key.ID != activeKeyID {
+			keyring.verifiers = append(keyring.verifiers, entry)
+			continue
+		}
+
+		keyring.active, err = newKeyMaker(makerType, key.ID, key.Secret, key.PrivateKey)
+		if err != nil {
+			return nil, fmt.Errorf("invalid active token key %q: %w", key.ID, err)
+		}
+		keyring.verifiers = append([]keyringVerifier{entry}, keyring.verifiers...)
+	}
+
+	if keyring.active == nil {
+		return nil, fmt.Errorf("active token key %q not found", activeKeyID)
+	}
+
+	return keyring, nil
+}
+
+// ActiveKeyID is the ID of the key new tokens are made with
+func (keyring *Keyring) ActiveKeyID() string {
+	return keyring.activeKeyID
+}
+
+// create token for a specific user name and duration
+func (keyring *Keyring) CreateToken(username string, role string, duration time.Duration) (string, *Payload, error) {
+	return keyring.active.CreateToken(username, role, duration)
+}
+
+// create token for a user that belongs to a login session
+func (keyring *Keyring) CreateSessionToken(username string, role string, sessionID uuid.UUID, duration time.Duration) (string, *Payload, error) {
+	return keyring.active.CreateSessionToken(username, role, sessionID, duration)
+}
+
+// create a refresh token of a session family, a new family is started when familyID is uuid.Nil
+func (keyring *Keyring) CreateRefreshToken(username string, role string, familyID uuid.UUID, duration time.Duration) (string, *Payload, error) {
+	return keyring.active.CreateRefreshToken(username, role, familyID, duration)
+}
+
+// create a short-lived token for a user who has just re-entered their password
+func (keyring *Keyring) CreateElevatedToken(username string, role string, sessionID uuid.UUID, duration time.Duration) (string, *Payload, error) {
+	return keyring.active.CreateElevatedToken(username, role, sessionID, duration)
+}
+
+// checks if token is valid with the key it names. Tokens made before the keyring have no key ID,
+// they are tried against every key that is not retired
+func (keyring *Keyring) VerifyToken(token string) (*Payload, error) {
+	keyID := tokenKeyID(token)
+
+	for _, key := range keyring.verifiers {
+		if keyID != "" && key.id != keyID {
+			continue
+		}
+
+		payload, err := key.verifier.VerifyToken(token)
+		if err == nil || errors.Is(err, ErrExpiredToken) || keyID != "" {
+			return payload, err
+		}
+	}
+
+	return nil, ErrInvalidToken
+}
+
+type keyringConfig struct {
+	ActiveKeyID string             `json:"active_key_id"`
+	Keys        []keyringConfigKey `json:"keys"`
+}
+
+type keyringConfigKey struct {
+	ID             string `json:"id"`
+	Secret         string `json:"secret"`
+	PrivateKeyFile string `json:"private_key_file"`
+	PublicKeyFile  string `json:"public_key_file"`
+	Retired        bool   `json:"retired"`
+}
+
+// LoadKeyring reads the keyring of the given maker type from the JSON file at keyringFile.
+// Without a keyring file, the keyring holds the single key from symmetricKey or privateKeyFile,
+// without a key ID so its tokens look like the ones of a plain maker
+func LoadKeyring(makerType string, symmetricKey string, privateKeyFile string, keyringFile string) (*Keyring, error) {
+	if keyringFile == "" {
+		key := KeyringKey{Secret: symmetricKey}
+
+		if IsPublicKeyMaker(makerType) {
+			var err error
+			key.PrivateKey, err = readPrivateKeyFile(privateKeyFile)
+			if err != nil {
+				return nil, err
+			}
+		}
+
+		return NewKeyring(makerType, "", []KeyringKey{key})
+	}
+
+	data, err := os.ReadFile(keyringFile)
+	if err != nil {
+		return nil, fmt.Errorf("cannot read token keyring: %w", err)
+	}
+
+	var file keyringConfig
+	if err := json.Unmarshal(data, &file); err != nil {
+		return nil, fmt.Errorf("cannot parse token keyring: %w", err)
+	}
+
+	keys := make([]KeyringKey, len(file.Keys))
+	for i, fileKey := range file.Keys {
+		keys[i] = KeyringKey{
+			ID:      fileKey.ID,
+			Secret:  fileKey.Secret,
+			Retired: fileKey.Retired,
+		}
+
+		// retired keys do not need their key files any more
+		if fileKey.Retired || !IsPublicKeyMaker(makerType) {
+			continue
+		}
+
+		if fileKey.PrivateKeyFile != "" {
+			keys[i].PrivateKey, err = readPrivateKeyFile(fileKey.PrivateKeyFile)
+			if err != nil {
+				return nil, fmt.Errorf("token key %q: %w", fileKey.ID, err)
+			}
+			continue
+		}
+
+		publicKeyPEM, err := os.ReadFile(fileKey.PublicKeyFile)
+		if err != nil {
+			return nil, fmt.Errorf("cannot read public key of token key %q: %w", fileKey.ID, err)
+		}
+
+		keys[i].PublicKey, err = ParsePublicKeyPEM(publicKeyPEM)
+		if err != nil {
+			return nil, fmt.Errorf("token key %q: %w", fileKey.ID, err)
+		}
+	}
+
+	return NewKeyring(makerType, file.ActiveKeyID, keys)
+}
+
+func readPrivateKeyFile(privateKeyFile string) (crypto.Signer, error) {
+	privateKeyPEM, err := os.ReadFile(privateKeyFile)
+	if err != nil {
+		return nil, fmt.Errorf("cannot read token private key: %w", err)
+	}
+
+	return ParsePrivateKeyPEM(privateKeyPEM)
+}
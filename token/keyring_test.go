@@ -0,0 +1,223 @@
+package token
+
+import (
+	"crypto/ed25519"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"github.com/tonisco/simple-bank-go/util"
+)
+
+func TestKeyringRotation(t *testing.T) {
+	for _, makerType := range []string{MakerPasetoLocal, MakerJWTHS256} {
+		t.Run(makerType, func(t *testing.T) {
+			oldKey := KeyringKey{ID: "k1", Secret: util.RandomString(32)}
+			newKey := KeyringKey{ID: "k2", Secret: util.RandomString(32)}
+
+			oldKeyring, err := NewKeyring(makerType, "k1", []KeyringKey{oldKey})
+			require.NoError(t, err)
+
+			oldToken, _, err := oldKeyring.CreateToken(util.RandomOwner(), util.DepositorRole, time.Minute)
+			require.NoError(t, err)
+			require.Equal(t, "k1", tokenKeyID(oldToken))
+
+			// untagged tokens from before the keyring was set up
+			legacyMaker, err := NewMaker(makerType, oldKey.Secret, nil)
+			require.NoError(t, err)
+			legacyToken, _, err := legacyMaker.CreateToken(util.RandomOwner(), util.DepositorRole, time.Minute)
+			require.NoError(t, err)
+			require.Empty(t, tokenKeyID(legacyToken))
+
+			keyring, err := NewKeyring(makerType, "k2", []KeyringKey{oldKey, newKey})
+			require.NoError(t, err)
+			require.Equal(t, "k2", keyring.ActiveKeyID())
+
+			newToken, _, err := keyring.CreateToken(util.RandomOwner(), util.DepositorRole, time.Minute)
+			require.NoError(t, err)
+			require.Equal(t, "k2", tokenKeyID(newToken))
+
+			for _, token := range []string{oldToken, legacyToken, newToken} {
+				_, err = keyring.VerifyToken(token)
+				require.NoError(t, err)
+			}
+
+			// tokens of the new key are not known to the old keyring
+			_, err = oldKeyring.VerifyToken(newToken)
+			require.ErrorIs(t, err, ErrInvalidToken)
+
+			oldKey.Retired = true
+			keyring, err = NewKeyring(makerType, "k2", []KeyringKey{oldKey, newKey})
+			require.NoError(t, err)
+
+			_, err = keyring.VerifyToken(oldToken)
+			require.ErrorIs(t, err, ErrInvalidToken)
+			_, err = keyring.VerifyToken(legacyToken)
+			require.ErrorIs(t, err, ErrInvalidToken)
+			_, err = keyring.VerifyToken(newToken)
+			require.NoError(t, err)
+
+			expiredToken, _, err := keyring.CreateToken(util.RandomOwner(), util.DepositorRole, -time.Minute)
+			require.NoError(t, err)
+			_, err = keyring.VerifyToken(expiredToken)
+			require.ErrorIs(t, err, ErrExpiredToken)
+		})
+	}
+}
+
+func TestKeyringKeyIDCannotBeForged(t *testing.T) {
+	keyring, err := NewKeyring(MakerJWTHS256, "k2", []KeyringKey{
+		{ID: "k1", Secret: util.RandomString(32)},
+		{ID: "k2", Secret: util.RandomString(32)},
+	})
+	require.NoError(t, err)
+
+	token, _, err := keyring.CreateToken(util.RandomOwner(), util.DepositorRole, time.Minute)
+	require.NoError(t, err)
+
+	// pointing the token at the other key makes the signature fail
+	parts := strings.SplitN(token, ".", 2)
+	headerJSON, err := base64.RawURLEncoding.DecodeString(parts[0])
+	require.NoError(t, err)
+
+	var header map[string]interface{}
+	require.NoError(t, json.Unmarshal(headerJSON, &header))
+	header[keyIDHeader] = "k1"
+	headerJSON, err = json.Marshal(header)
+	require.NoError(t, err)
+
+	forged := base64.RawURLEncoding.EncodeToString(headerJSON) + "." + parts[1]
+	require.Equal(t, "k1", tokenKeyID(forged))
+
+	_, err = keyring.VerifyToken(forged)
+	require.ErrorIs(t, err, ErrInvalidToken)
+}
+
+func TestNewKeyringErrors(t *testing.T) {
+	secret := util.RandomString(32)
+
+	_, err := NewKeyring(MakerPasetoLocal, "missing", []KeyringKey{{ID: "k1", Secret: secret}})
+	require.Error(t, err)
+
+	_, err = NewKeyring(MakerPasetoLocal, "k1", []KeyringKey{{ID: "k1", Secret: secret, Retired: true}})
+	require.Error(t, err)
+
+	_, err = NewKeyring(MakerPasetoLocal, "k1", []KeyringKey{{ID: "k1", Secret: secret}, {ID: "k1", Secret: secret}})
+	require.Error(t, err)
+
+	_, err = NewKeyring(MakerPasetoLocal, "k1", []KeyringKey{{ID: "k1", Secret: "short"}})
+	require.Error(t, err)
+
+	// a key with only a public key cannot be the active key
+	_, err = NewKeyring(MakerPasetoPublic, "k1", []KeyringKey{{ID: "k1", PublicKey: randomEd25519Key(t).Public()}})
+	require.Error(t, err)
+}
+
+func TestKeyringPublicKeys(t *testing.T) {
+	oldKey := randomRSAKey(t)
+	newKey := randomRSAKey(t)
+	retiredKey := randomRSAKey(t)
+
+	oldKeyring, err := NewKeyring(MakerJWTRS256, "k1", []KeyringKey{{ID: "k1", PrivateKey: oldKey}})
+	require.NoError(t, err)
+	oldToken, _, err := oldKeyring.CreateToken(util.RandomOwner(), util.DepositorRole, time.Minute)
+	require.NoError(t, err)
+
+	keyring, err := NewKeyring(MakerJWTRS256, "k2", []KeyringKey{
+		{ID: "k1", PublicKey: oldKey.Public()},
+		{ID: "k2", PrivateKey: newKey},
+		{ID: "k0", PrivateKey: retiredKey, Retired: true},
+	})
+	require.NoError(t, err)
+
+	_, err = keyring.VerifyToken(oldToken)
+	require.NoError(t, err)
+
+	jwks := keyring.JWKS()
+	require.Len(t, jwks.Keys, 2)
+	require.Equal(t, "k2", jwks.Keys[0].KeyID)
+	require.Equal(t, "k1", jwks.Keys[1].KeyID)
+
+	for _, key := range jwks.Keys {
+		require.Equal(t, "RSA", key.KeyType)
+		require.Equal(t, "RS256", key.Algorithm)
+		require.Equal(t, "sig", key.Use)
+		require.Equal(t, "AQAB", key.Exponent)
+	}
+	require.Equal(t, base64.RawURLEncoding.EncodeToString(newKey.N.Bytes()), jwks.Keys[0].Modulus)
+
+	edKey := randomEd25519Key(t)
+	for _, makerType := range []string{MakerJWTEdDSA, MakerPasetoPublic} {
+		keyring, err = NewKeyring(makerType, "ed", []KeyringKey{{ID: "ed", PrivateKey: edKey}})
+		require.NoError(t, err)
+
+		jwks = keyring.JWKS()
+		require.Len(t, jwks.Keys, 1)
+		require.Equal(t, "OKP", jwks.Keys[0].KeyType)
+		require.Equal(t, "Ed25519", jwks.Keys[0].Curve)
+		require.Equal(t, base64.RawURLEncoding.EncodeToString([]byte(edKey.Public().(ed25519.PublicKey))), jwks.Keys[0].X)
+	}
+	require.Empty(t, jwks.Keys[0].Algorithm)
+
+	// symmetric keys are never published
+	keyring, err = NewKeyring(MakerPasetoLocal, "k1", []KeyringKey{{ID: "k1", Secret: util.RandomString(32)}})
+	require.NoError(t, err)
+	require.Empty(t, keyring.JWKS().Keys)
+}
+
+func TestLoadKeyring(t *testing.T) {
+	dir := t.TempDir()
+
+	oldKey := randomEd25519Key(t)
+	newKey := randomEd25519Key(t)
+
+	privateKeyDER, err := x509.MarshalPKCS8PrivateKey(newKey)
+	require.NoError(t, err)
+	privateKeyFile := filepath.Join(dir, "k2.pem")
+	require.NoError(t, os.WriteFile(privateKeyFile, pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: privateKeyDER}), 0600))
+
+	publicKeyDER, err := x509.MarshalPKIXPublicKey(oldKey.Public())
+	require.NoError(t, err)
+	publicKeyFile := filepath.Join(dir, "k1.pub.pem")
+	require.NoError(t, os.WriteFile(publicKeyFile, pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: publicKeyDER}), 0600))
+
+	keyringFile := filepath.Join(dir, "keyring.json")
+	require.NoError(t, os.WriteFile(keyringFile, []byte(`{
+		"active_key_id": "k2",
+		"keys": [
+			{"id": "k2", "private_key_file": "`+privateKeyFile+`"},
+			{"id": "k1", "public_key_file": "`+publicKeyFile+`"},
+			{"id": "k0", "private_key_file": "missing.pem", "retired": true}
+		]
+	}`), 0600))
+
+	keyring, err := LoadKeyring(MakerJWTEdDSA, "", "", keyringFile)
+	require.NoError(t, err)
+	require.Equal(t, "k2", keyring.ActiveKeyID())
+	require.Len(t, keyring.JWKS().Keys, 2)
+
+	oldKeyring, err := NewKeyring(MakerJWTEdDSA, "k1", []KeyringKey{{ID: "k1", PrivateKey: oldKey}})
+	require.NoError(t, err)
+	oldToken, _, err := oldKeyring.CreateToken(util.RandomOwner(), util.DepositorRole, time.Minute)
+	require.NoError(t, err)
+
+	_, err = keyring.VerifyToken(oldToken)
+	require.NoError(t, err)
+
+	// without a keyring file the single configured key makes untagged tokens
+	keyring, err = LoadKeyring(MakerPasetoLocal, util.RandomString(32), "", "")
+	require.NoError(t, err)
+	token, _, err := keyring.CreateToken(util.RandomOwner(), util.DepositorRole, time.Minute)
+	require.NoError(t, err)
+	require.Empty(t, tokenKeyID(token))
+
+	_, err = LoadKeyring(MakerJWTEdDSA, "", "", filepath.Join(dir, "missing.json"))
+	require.Error(t, err)
+}
@@ -1,12 +1,140 @@
 package token
 
-import "time"
+import (
+	"crypto"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/dgrijalva/jwt-go"
+	"github.com/google/uuid"
+)
+
+// the kinds of token maker that can be picked in the config
+const (
+	// PASETO v2.local, encrypted with a shared symmetric key
+	MakerPasetoLocal = "paseto_local"
+	// PASETO v2.public, signed with an Ed25519 key
+	MakerPasetoPublic = "paseto_public"
+	// JWT signed with a shared HMAC secret
+	MakerJWTHS256 = "jwt_hs256"
+	// JWT signed with an Ed25519 key
+	MakerJWTEdDSA = "jwt_eddsa"
+	// JWT signed with an RSA key
+	MakerJWTRS256 = "jwt_rs256"
+)
+
+// Verifier checks tokens without being able to create them
+type Verifier interface {
+	// checks if token is valid
+	VerifyToken(token string) (*Payload, error)
+}
 
 // Make is a unique interface for managing tokens
 type Maker interface {
+	Verifier
+
 	// create token for a specific user name and duration
 	CreateToken(username string, role string, duration time.Duration) (string, *Payload, error)
 
-	// checks if token is valid
-	VerifyToken(token string) (*Payload, error)
+	// create token for a user that belongs to a login session, so revoking the session revokes the token
+	CreateSessionToken(username string, role string, sessionID uuid.UUID, duration time.Duration) (string, *Payload, error)
+
+	// create a refresh token of a session family, so revoking the family revokes the token.
+	// A new family, named after the token ID, is started when familyID is uuid.Nil
+	CreateRefreshToken(username string, role string, familyID uuid.UUID, duration time.Duration) (string, *Payload, error)
+
+	// create a short-lived token for a user who has just re-entered their password, for operations that need a recent login
+	CreateElevatedToken(username string, role string, sessionID uuid.UUID, duration time.Duration) (string, *Payload, error)
+}
+
+// IsPublicKeyMaker reports whether tokens of the maker type are signed with a private key
+// and checked with the public one, rather than with a shared secret
+func IsPublicKeyMaker(makerType string) bool {
+	switch makerType {
+	case MakerPasetoPublic, MakerJWTEdDSA, MakerJWTRS256:
+		return true
+	}
+	return false
+}
+
+// NewMaker creates a token maker of the given type. Symmetric makers use symmetricKey and public-key makers
+// sign with the PEM encoded privateKeyPEM. An empty type is PASETO v2.local
+func NewMaker(makerType string, symmetricKey string, privateKeyPEM []byte) (Maker, error) {
+	var privateKey crypto.Signer
+
+	if IsPublicKeyMaker(makerType) {
+		var err error
+		privateKey, err = ParsePrivateKeyPEM(privateKeyPEM)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return newKeyMaker(makerType, "", symmetricKey, privateKey)
+}
+
+// NewVerifier creates a verifier for tokens of the given type. Symmetric types need symmetricKey,
+// so a service holding it can also create tokens, public-key types only need the PEM encoded publicKeyPEM
+func NewVerifier(makerType string, symmetricKey string, publicKeyPEM []byte) (Verifier, error) {
+	var publicKey crypto.PublicKey
+
+	if IsPublicKeyMaker(makerType) {
+		var err error
+		publicKey, err = ParsePublicKeyPEM(publicKeyPEM)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return newKeyVerifier(makerType, symmetricKey, publicKey)
+}
+
+// newKeyMaker creates a maker of the given type that tags its tokens with keyID, when it is set
+func newKeyMaker(makerType string, keyID string, symmetricKey string, privateKey crypto.Signer) (Maker, error) {
+	switch makerType {
+	case "", MakerPasetoLocal:
+		return newPasetoMaker(symmetricKey, keyID)
+	case MakerJWTHS256:
+		return newJWTMaker(symmetricKey, keyID)
+	case MakerPasetoPublic:
+		return newPasetoPublicMaker(privateKey, keyID)
+	case MakerJWTEdDSA:
+		return newJWTPublicMaker(SigningMethodEdDSA, privateKey, keyID)
+	case MakerJWTRS256:
+		return newJWTPublicMaker(jwt.SigningMethodRS256, privateKey, keyID)
+	}
+
+	return nil, fmt.Errorf("unknown token maker type %q", makerType)
+}
+
+func newKeyVerifier(makerType string, symmetricKey string, publicKey crypto.PublicKey) (Verifier, error) {
+	switch makerType {
+	case "", MakerPasetoLocal:
+		return NewPasetoMaker(symmetricKey)
+	case MakerJWTHS256:
+		return NewJWTMaker(symmetricKey)
+	case MakerPasetoPublic:
+		return NewPasetoPublicVerifier(publicKey)
+	case MakerJWTEdDSA:
+		return NewJWTPublicVerifier(SigningMethodEdDSA, publicKey)
+	case MakerJWTRS256:
+		return NewJWTPublicVerifier(jwt.SigningMethodRS256, publicKey)
+	}
+
+	return nil, fmt.Errorf("unknown token maker type %q", makerType)
+}
+
+// LoadMaker creates a token maker of the given type, reading the private key from privateKeyFile for public-key makers
+func LoadMaker(makerType string, symmetricKey string, privateKeyFile string) (Maker, error) {
+	if !IsPublicKeyMaker(makerType) {
+		return NewMaker(makerType, symmetricKey, nil)
+	}
+
+	privateKeyPEM, err := os.ReadFile(privateKeyFile)
+	if err != nil {
+		return nil, fmt.Errorf("cannot read token private key: %w", err)
+	}
+
+	return NewMaker(makerType, symmetricKey, privateKeyPEM)
 }
@@ -0,0 +1,75 @@
+package token
+
+import (
+	"crypto/x509"
+	"encoding/pem"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"github.com/tonisco/simple-bank-go/util"
+)
+
+func TestNewMakerAndVerifier(t *testing.T) {
+	symmetricKey := util.RandomString(32)
+
+	ed25519Key := randomEd25519Key(t)
+	rsaKey := randomRSAKey(t)
+
+	ed25519PrivateDER, err := x509.MarshalPKCS8PrivateKey(ed25519Key)
+	require.NoError(t, err)
+	ed25519PublicDER, err := x509.MarshalPKIXPublicKey(ed25519Key.Public())
+	require.NoError(t, err)
+
+	ed25519PrivatePEM := pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: ed25519PrivateDER})
+	ed25519PublicPEM := pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: ed25519PublicDER})
+	rsaPrivatePEM := pem.EncodeToMemory(&pem.Block{Type: "RSA PRIVATE KEY", Bytes: x509.MarshalPKCS1PrivateKey(rsaKey)})
+	rsaPublicPEM := pem.EncodeToMemory(&pem.Block{Type: "RSA PUBLIC KEY", Bytes: x509.MarshalPKCS1PublicKey(&rsaKey.PublicKey)})
+
+	testCases := []struct {
+		makerType     string
+		privateKeyPEM []byte
+		publicKeyPEM  []byte
+	}{
+		{"", nil, nil},
+		{MakerPasetoLocal, nil, nil},
+		{MakerJWTHS256, nil, nil},
+		{MakerPasetoPublic, ed25519PrivatePEM, ed25519PublicPEM},
+		{MakerJWTEdDSA, ed25519PrivatePEM, ed25519PublicPEM},
+		{MakerJWTRS256, rsaPrivatePEM, rsaPublicPEM},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.makerType, func(t *testing.T) {
+			maker, err := NewMaker(tc.makerType, symmetricKey, tc.privateKeyPEM)
+			require.NoError(t, err)
+
+			verifier, err := NewVerifier(tc.makerType, symmetricKey, tc.publicKeyPEM)
+			require.NoError(t, err)
+
+			username := util.RandomOwner()
+			token, _, err := maker.CreateToken(username, util.DepositorRole, time.Minute)
+			require.NoError(t, err)
+
+			payload, err := verifier.VerifyToken(token)
+			require.NoError(t, err)
+			require.Equal(t, username, payload.Username)
+		})
+	}
+}
+
+func TestNewMakerErrors(t *testing.T) {
+	_, err := NewMaker("unknown", util.RandomString(32), nil)
+	require.Error(t, err)
+
+	_, err = NewMaker(MakerPasetoPublic, "", []byte("not a key"))
+	require.Error(t, err)
+
+	_, err = NewVerifier(MakerJWTRS256, "", nil)
+	require.Error(t, err)
+
+	_, err = LoadMaker(MakerJWTEdDSA, "", "does/not/exist.pem")
+	require.Error(t, err)
+}
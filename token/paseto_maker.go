@@ -5,15 +5,22 @@ import (
 	"time"
 
 	"github.com/aead/chacha20poly1305"
+	"github.com/google/uuid"
 	"github.com/o1egl/paseto"
 )
 
 type PasteoMaker struct {
 	paseto       *paseto.V2
 	symmetricKey []byte
+	// keyID is put in the footer of the tokens when it is set
+	keyID string
 }
 
 func NewPasetoMaker(symmetricKey string) (Maker, error) {
+	return newPasetoMaker(symmetricKey, "")
+}
+
+func newPasetoMaker(symmetricKey string, keyID string) (Maker, error) {
 	if len(symmetricKey) != chacha20poly1305.KeySize {
 		return nil, fmt.Errorf("invalid key size: must be at least %d characters", chacha20poly1305.KeySize)
 	}
@@ -21,6 +28,7 @@ func NewPasetoMaker(symmetricKey string) (Maker, error) {
 	maker := &PasteoMaker{
 		paseto:       paseto.NewV2(),
 		symmetricKey: []byte(symmetricKey),
+		keyID:        keyID,
 	}
 
 	return maker, nil
@@ -33,7 +41,52 @@ func (maker *PasteoMaker) CreateToken(username string, role string, duration tim
 		return "", payload, err
 	}
 
-	token, err := maker.paseto.Encrypt(maker.symmetricKey, payload, nil)
+	return maker.encryptPayload(payload)
+}
+
+// create token for a user that belongs to a login session
+func (maker *PasteoMaker) CreateSessionToken(username string, role string, sessionID uuid.UUID, duration time.Duration) (string, *Payload, error) {
+	payload, err := NewPayload(username, role, duration)
+	if err != nil {
+		return "", payload, err
+	}
+	payload.Type = TokenTypeAccess
+	payload.SessionID = sessionID
+
+	return maker.encryptPayload(payload)
+}
+
+// create a refresh token of a session family, a new family is started when familyID is uuid.Nil
+func (maker *PasteoMaker) CreateRefreshToken(username string, role string, familyID uuid.UUID, duration time.Duration) (string, *Payload, error) {
+	payload, err := NewPayload(username, role, duration)
+	if err != nil {
+		return "", payload, err
+	}
+	payload.Type = TokenTypeRefresh
+	payload.SessionID = familyID
+	if familyID == uuid.Nil {
+		payload.SessionID = payload.ID
+	}
+
+	return maker.encryptPayload(payload)
+}
+
+// create a short-lived token for a user who has just re-entered their password
+func (maker *PasteoMaker) CreateElevatedToken(username string, role string, sessionID uuid.UUID, duration time.Duration) (string, *Payload, error) {
+	payload, err := NewPayload(username, role, duration)
+	if err != nil {
+		return "", payload, err
+	}
+	payload.Type = TokenTypeAccess
+	payload.SessionID = sessionID
+	payload.AuthTime = payload.IssuedAt
+	payload.Assurance = AssuranceElevated
+
+	return maker.encryptPayload(payload)
+}
+
+func (maker *PasteoMaker) encryptPayload(payload *Payload) (string, *Payload, error) {
+	token, err := maker.paseto.Encrypt(maker.symmetricKey, payload, pasetoFooter(maker.keyID))
 	return token, payload, err
 }
 
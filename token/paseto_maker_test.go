@@ -4,6 +4,7 @@ import (
 	"testing"
 	"time"
 
+	"github.com/google/uuid"
 	"github.com/stretchr/testify/require"
 	"github.com/tonisco/simple-bank-go/util"
 )
@@ -52,3 +53,77 @@ func TestExpiredPasetoToken(t *testing.T) {
 	require.Error(t, err, ErrExpiredToken.Error())
 	require.EqualError(t, err, ErrExpiredToken.Error())
 }
+
+func TestPasetoMakerSessionToken(t *testing.T) {
+	maker, err := NewPasetoMaker(util.RandomString(32))
+	require.NoError(t, err)
+
+	sessionID := uuid.New()
+
+	token, _, err := maker.CreateSessionToken(util.RandomOwner(), util.DepositorRole, sessionID, time.Minute)
+	require.NoError(t, err)
+
+	payload, err := maker.VerifyToken(token)
+	require.NoError(t, err)
+	require.Equal(t, sessionID, payload.SessionID)
+	require.NotEqual(t, sessionID, payload.ID)
+	require.Equal(t, TokenTypeAccess, payload.Type)
+}
+
+func TestPasetoMakerRefreshToken(t *testing.T) {
+	maker, err := NewPasetoMaker(util.RandomString(32))
+	require.NoError(t, err)
+
+	// the first refresh token of a login starts a family named after itself
+	token, _, err := maker.CreateRefreshToken(util.RandomOwner(), util.DepositorRole, uuid.Nil, time.Minute)
+	require.NoError(t, err)
+
+	payload, err := maker.VerifyToken(token)
+	require.NoError(t, err)
+	require.Equal(t, TokenTypeRefresh, payload.Type)
+	require.Equal(t, payload.ID, payload.SessionID)
+
+	familyID := payload.SessionID
+
+	token, _, err = maker.CreateRefreshToken(util.RandomOwner(), util.DepositorRole, familyID, time.Minute)
+	require.NoError(t, err)
+
+	payload, err = maker.VerifyToken(token)
+	require.NoError(t, err)
+	require.Equal(t, familyID, payload.SessionID)
+	require.NotEqual(t, familyID, payload.ID)
+
+	// tokens from CreateToken, like TOTP challenges, are neither access nor refresh tokens
+	token, _, err = maker.CreateToken(util.RandomOwner(), util.TotpChallengeRole, time.Minute)
+	require.NoError(t, err)
+
+	payload, err = maker.VerifyToken(token)
+	require.NoError(t, err)
+	require.Empty(t, payload.Type)
+}
+
+func TestPasetoMakerElevatedToken(t *testing.T) {
+	maker, err := NewPasetoMaker(util.RandomString(32))
+	require.NoError(t, err)
+
+	sessionID := uuid.New()
+
+	token, _, err := maker.CreateElevatedToken(util.RandomOwner(), util.DepositorRole, sessionID, time.Minute)
+	require.NoError(t, err)
+
+	payload, err := maker.VerifyToken(token)
+	require.NoError(t, err)
+	require.Equal(t, sessionID, payload.SessionID)
+	require.Equal(t, AssuranceElevated, payload.Assurance)
+	require.WithinDuration(t, time.Now(), payload.AuthTime, time.Second)
+	require.True(t, payload.IsElevated(time.Minute))
+	require.False(t, payload.IsElevated(-time.Second))
+
+	token, _, err = maker.CreateSessionToken(util.RandomOwner(), util.DepositorRole, sessionID, time.Minute)
+	require.NoError(t, err)
+
+	payload, err = maker.VerifyToken(token)
+	require.NoError(t, err)
+	require.Equal(t, AssuranceStandard, payload.Assurance)
+	require.False(t, payload.IsElevated(time.Minute))
+}
@@ -0,0 +1,134 @@
+package token
+
+import (
+	"crypto"
+	"crypto/ed25519"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/o1egl/paseto"
+)
+
+// PasetoPublicMaker signs PASETO v2.public tokens with an Ed25519 key, so services holding only
+// the public key can check tokens but not create them
+type PasetoPublicMaker struct {
+	paseto     *paseto.V2
+	privateKey ed25519.PrivateKey
+	publicKey  ed25519.PublicKey
+	// keyID is put in the footer of the tokens when it is set
+	keyID string
+}
+
+// NewPasetoPublicMaker creates a maker that signs tokens with privateKey, which must be an Ed25519 key
+func NewPasetoPublicMaker(privateKey crypto.PrivateKey) (Maker, error) {
+	return newPasetoPublicMaker(privateKey, "")
+}
+
+func newPasetoPublicMaker(privateKey crypto.PrivateKey, keyID string) (Maker, error) {
+	key, ok := privateKey.(ed25519.PrivateKey)
+	if !ok || len(key) != ed25519.PrivateKeySize {
+		return nil, fmt.Errorf("invalid key: PASETO v2.public needs an Ed25519 private key, got %T", privateKey)
+	}
+
+	maker := &PasetoPublicMaker{
+		paseto:     paseto.NewV2(),
+		privateKey: key,
+		publicKey:  key.Public().(ed25519.PublicKey),
+		keyID:      keyID,
+	}
+
+	return maker, nil
+}
+
+// NewPasetoPublicVerifier creates a verifier for tokens signed by the private key of publicKey, which must be an Ed25519 key
+func NewPasetoPublicVerifier(publicKey crypto.PublicKey) (Verifier, error) {
+	key, ok := publicKey.(ed25519.PublicKey)
+	if !ok || len(key) != ed25519.PublicKeySize {
+		return nil, fmt.Errorf("invalid key: PASETO v2.public needs an Ed25519 public key, got %T", publicKey)
+	}
+
+	verifier := &PasetoPublicMaker{
+		paseto:    paseto.NewV2(),
+		publicKey: key,
+	}
+
+	return verifier, nil
+}
+
+// create token for a specific user name and duration
+func (maker *PasetoPublicMaker) CreateToken(username string, role string, duration time.Duration) (string, *Payload, error) {
+	payload, err := NewPayload(username, role, duration)
+	if err != nil {
+		return "", payload, err
+	}
+
+	return maker.signPayload(payload)
+}
+
+// create token for a user that belongs to a login session
+func (maker *PasetoPublicMaker) CreateSessionToken(username string, role string, sessionID uuid.UUID, duration time.Duration) (string, *Payload, error) {
+	payload, err := NewPayload(username, role, duration)
+	if err != nil {
+		return "", payload, err
+	}
+	payload.Type = TokenTypeAccess
+	payload.SessionID = sessionID
+
+	return maker.signPayload(payload)
+}
+
+// create a refresh token of a session family, a new family is started when familyID is uuid.Nil
+func (maker *PasetoPublicMaker) CreateRefreshToken(username string, role string, familyID uuid.UUID, duration time.Duration) (string, *Payload, error) {
+	payload, err := NewPayload(username, role, duration)
+	if err != nil {
+		return "", payload, err
+	}
+	payload.Type = TokenTypeRefresh
+	payload.SessionID = familyID
+	if familyID == uuid.Nil {
+		payload.SessionID = payload.ID
+	}
+
+	return maker.signPayload(payload)
+}
+
+// create a short-lived token for a user who has just re-entered their password
+func (maker *PasetoPublicMaker) CreateElevatedToken(username string, role string, sessionID uuid.UUID, duration time.Duration) (string, *Payload, error) {
+	payload, err := NewPayload(username, role, duration)
+	if err != nil {
+		return "", payload, err
+	}
+	payload.Type = TokenTypeAccess
+	payload.SessionID = sessionID
+	payload.AuthTime = payload.IssuedAt
+	payload.Assurance = AssuranceElevated
+
+	return maker.signPayload(payload)
+}
+
+func (maker *PasetoPublicMaker) signPayload(payload *Payload) (string, *Payload, error) {
+	if maker.privateKey == nil {
+		return "", payload, ErrCannotSign
+	}
+
+	token, err := maker.paseto.Sign(maker.privateKey, payload, pasetoFooter(maker.keyID))
+	return token, payload, err
+}
+
+// checks if token is valid
+func (maker *PasetoPublicMaker) VerifyToken(token string) (*Payload, error) {
+	payload := &Payload{}
+
+	err := maker.paseto.Verify(token, maker.publicKey, payload, nil)
+	if err != nil {
+		return nil, ErrInvalidToken
+	}
+
+	err = payload.Valid()
+	if err != nil {
+		return nil, err
+	}
+
+	return payload, nil
+}
@@ -0,0 +1,97 @@
+package token
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/require"
+	"github.com/tonisco/simple-bank-go/util"
+)
+
+func randomEd25519Key(t *testing.T) ed25519.PrivateKey {
+	_, privateKey, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+	return privateKey
+}
+
+func TestPasetoPublicMaker(t *testing.T) {
+	privateKey := randomEd25519Key(t)
+
+	maker, err := NewPasetoPublicMaker(privateKey)
+	require.NoError(t, err)
+
+	username := util.RandomOwner()
+	role := util.DepositorRole
+	duration := time.Minute
+
+	issuedAt := time.Now()
+	expiredAt := issuedAt.Add(duration)
+
+	token, payload, err := maker.CreateToken(username, role, duration)
+	require.NoError(t, err)
+	require.NotEmpty(t, token)
+	require.NotEmpty(t, payload)
+
+	payload, err = maker.VerifyToken(token)
+	require.NoError(t, err)
+	require.Equal(t, username, payload.Username)
+	require.Equal(t, role, payload.Role)
+	require.NotZero(t, payload.ID)
+	require.WithinDuration(t, issuedAt, payload.IssuedAt, time.Second)
+	require.WithinDuration(t, expiredAt, payload.ExpiredAt, time.Second)
+
+	// a service with only the public key can check the token
+	verifier, err := NewPasetoPublicVerifier(privateKey.Public())
+	require.NoError(t, err)
+
+	payload, err = verifier.VerifyToken(token)
+	require.NoError(t, err)
+	require.Equal(t, username, payload.Username)
+}
+
+func TestPasetoPublicVerifierCannotSign(t *testing.T) {
+	verifier, err := NewPasetoPublicVerifier(randomEd25519Key(t).Public())
+	require.NoError(t, err)
+
+	// the verifier is only exposed as a Verifier, but must not sign even when used as a maker
+	_, _, err = verifier.(Maker).CreateSessionToken(util.RandomOwner(), util.DepositorRole, uuid.New(), time.Minute)
+	require.ErrorIs(t, err, ErrCannotSign)
+}
+
+func TestPasetoPublicMakerWrongKey(t *testing.T) {
+	maker, err := NewPasetoPublicMaker(randomEd25519Key(t))
+	require.NoError(t, err)
+
+	token, _, err := maker.CreateToken(util.RandomOwner(), util.DepositorRole, time.Minute)
+	require.NoError(t, err)
+
+	verifier, err := NewPasetoPublicVerifier(randomEd25519Key(t).Public())
+	require.NoError(t, err)
+
+	payload, err := verifier.VerifyToken(token)
+	require.EqualError(t, err, ErrInvalidToken.Error())
+	require.Nil(t, payload)
+}
+
+func TestExpiredPasetoPublicToken(t *testing.T) {
+	maker, err := NewPasetoPublicMaker(randomEd25519Key(t))
+	require.NoError(t, err)
+
+	token, _, err := maker.CreateToken(util.RandomOwner(), util.DepositorRole, -time.Minute)
+	require.NoError(t, err)
+
+	payload, err := maker.VerifyToken(token)
+	require.EqualError(t, err, ErrExpiredToken.Error())
+	require.Nil(t, payload)
+}
+
+func TestPasetoPublicMakerInvalidKey(t *testing.T) {
+	_, err := NewPasetoPublicMaker(randomRSAKey(t))
+	require.Error(t, err)
+
+	_, err = NewPasetoPublicVerifier(randomRSAKey(t).Public())
+	require.Error(t, err)
+}
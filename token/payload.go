@@ -10,15 +10,45 @@ import (
 var (
 	ErrExpiredToken = errors.New("token has expired")
 	ErrInvalidToken = errors.New("token has expired")
+	// ErrCannotSign is returned when a verifier that only has a public key is asked to create a token
+	ErrCannotSign = errors.New("token maker has no private key to sign with")
+)
+
+// AssuranceLevel says how strongly the bearer of a token has proved who they are
+type AssuranceLevel int
+
+const (
+	// AssuranceStandard is the level of tokens issued at login and on renewal
+	AssuranceStandard AssuranceLevel = iota
+	// AssuranceElevated is the level of short-lived tokens issued right after the user re-entered their password
+	AssuranceElevated
+)
+
+// TokenType says what a token can be used for
+type TokenType string
+
+const (
+	// TokenTypeAccess is the type of access and elevated tokens, the only tokens that authorize API calls
+	TokenTypeAccess TokenType = "access"
+	// TokenTypeRefresh is the type of refresh tokens, which can only be exchanged for new tokens
+	TokenTypeRefresh TokenType = "refresh"
 )
 
 // payload contains the payload type of the token
 type Payload struct {
-	ID        uuid.UUID `json:"id"`
-	Username  string    `json:"username"`
-	Role      string    `json:"role"`
-	IssuedAt  time.Time `json:"issued_at"`
-	ExpiredAt time.Time `json:"expire_at"`
+	ID       uuid.UUID `json:"id"`
+	Username string    `json:"username"`
+	Role     string    `json:"role"`
+	// Type is empty for tokens that are neither access nor refresh tokens, like TOTP challenges
+	Type TokenType `json:"type,omitempty"`
+	// SessionID is the login session an access token was issued for, or the session family of a refresh token.
+	// Revoking it revokes the token
+	SessionID uuid.UUID `json:"session_id"`
+	// AuthTime is when the user last entered their credentials, it is only set on elevated tokens
+	AuthTime  time.Time      `json:"auth_time"`
+	Assurance AssuranceLevel `json:"assurance"`
+	IssuedAt  time.Time      `json:"issued_at"`
+	ExpiredAt time.Time      `json:"expire_at"`
 }
 
 func NewPayload(username string, role string, duration time.Duration) (*Payload, error) {
@@ -45,3 +75,8 @@ func (payload *Payload) Valid() error {
 
 	return nil
 }
+
+// IsElevated reports whether the token comes from a re-authentication no longer than maxAge ago
+func (payload *Payload) IsElevated(maxAge time.Duration) bool {
+	return payload.Assurance >= AssuranceElevated && time.Since(payload.AuthTime) <= maxAge
+}
@@ -0,0 +1,64 @@
+package token
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/redis/go-redis/v9"
+	"github.com/rs/zerolog/log"
+)
+
+const denylistIDKeyPrefix = "denylist:id:"
+
+// RedisDenylist shares revocations between servers through Redis.
+// Every revocation is also kept in memory, which is what the checks fall back to while Redis cannot be reached
+type RedisDenylist struct {
+	client   *redis.Client
+	ttl      time.Duration
+	fallback *MemoryDenylist
+}
+
+// NewRedisDenylist creates a denylist that remembers each revocation for ttl
+func NewRedisDenylist(client *redis.Client, ttl time.Duration) *RedisDenylist {
+	return &RedisDenylist{
+		client:   client,
+		ttl:      ttl,
+		fallback: NewMemoryDenylist(ttl),
+	}
+}
+
+func (denylist *RedisDenylist) RevokeID(ctx context.Context, id uuid.UUID) error {
+	denylist.fallback.RevokeID(ctx, id)
+
+	err := denylist.client.Set(ctx, denylistIDKeyPrefix+id.String(), 1, denylist.ttl).Err()
+	if err != nil {
+		log.Error().Err(err).Str("id", id.String()).Msg("failed to store token revocation in redis, only this server will reject the token")
+	}
+	return nil
+}
+
+func (denylist *RedisDenylist) Check(ctx context.Context, payload *Payload) error {
+	if err := denylist.fallback.Check(ctx, payload); err != nil {
+		return err
+	}
+
+	keys := []string{denylistIDKeyPrefix + payload.ID.String()}
+	if payload.SessionID != uuid.Nil {
+		keys = append(keys, denylistIDKeyPrefix+payload.SessionID.String())
+	}
+
+	values, err := denylist.client.MGet(ctx, keys...).Result()
+	if err != nil {
+		log.Error().Err(err).Msg("failed to check token revocation in redis, using this server's revocations only")
+		return nil
+	}
+
+	for _, value := range values {
+		if value != nil {
+			return ErrRevokedToken
+		}
+	}
+
+	return nil
+}
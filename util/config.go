@@ -3,6 +3,7 @@ package util
 import (
 	"time"
 
+	"github.com/mitchellh/mapstructure"
 	"github.com/spf13/viper"
 )
 
@@ -21,6 +22,39 @@ type Config struct {
 	EmailSenderName      string        `mapstructure:"EMAIL_SENDER_NAME"`
 	EmailSenderAddress   string        `mapstructure:"EMAIL_SENDER_ADDRESS"`
 	EmailSenderPassword  string        `mapstructure:"EMAIL_SENDER_PASSWORD"`
+	FXRatesFile          string        `mapstructure:"FX_RATES_FILE"`
+	FXQuoteDuration      time.Duration `mapstructure:"FX_QUOTE_DURATION"`
+	HoldDuration         time.Duration `mapstructure:"HOLD_DURATION"`
+	// ReconcileFreezeAccounts makes the nightly reconciliation freeze accounts whose balance has drifted
+	ReconcileFreezeAccounts bool `mapstructure:"RECONCILE_FREEZE_ACCOUNTS"`
+	// TotpChallengeDuration is how long a user has to enter their TOTP code after a password login
+	TotpChallengeDuration time.Duration `mapstructure:"TOTP_CHALLENGE_DURATION"`
+	// ElevatedTokenDuration is how long a token from re-authentication can authorize large transfers
+	ElevatedTokenDuration time.Duration `mapstructure:"ELEVATED_TOKEN_DURATION"`
+	// StepUpTransferThresholds are the amounts, in whole major units of each currency, above which a transfer,
+	// hold or scheduled transfer in that currency needs an elevated token. A currency left out needs one
+	// for any amount, and an empty list turns step-up authentication off
+	StepUpTransferThresholds CurrencyAmounts `mapstructure:"STEP_UP_TRANSFER_THRESHOLDS"`
+	// LoginMaxAttempts is how many failed logins a username gets before it is locked. Zero turns lockout off
+	LoginMaxAttempts int32 `mapstructure:"LOGIN_MAX_ATTEMPTS"`
+	// LoginMaxAttemptsPerIP is how many failed logins a client ip gets, across all usernames, before it is locked
+	LoginMaxAttemptsPerIP int32 `mapstructure:"LOGIN_MAX_ATTEMPTS_PER_IP"`
+	// LoginLockoutDuration is the first lockout, every further failed login doubles it up to LoginMaxLockoutDuration
+	LoginLockoutDuration    time.Duration `mapstructure:"LOGIN_LOCKOUT_DURATION"`
+	LoginMaxLockoutDuration time.Duration `mapstructure:"LOGIN_MAX_LOCKOUT_DURATION"`
+	// LoginAttemptWindow is how long a failed login counts towards a lockout
+	LoginAttemptWindow time.Duration `mapstructure:"LOGIN_ATTEMPT_WINDOW"`
+	// TrustedProxies are the comma separated ips or cidrs of the reverse proxies in front of the servers.
+	// The client ip is the last x-forwarded-for hop not in the list, empty trusts none and uses the remote address
+	TrustedProxies []string `mapstructure:"TRUSTED_PROXIES"`
+	// TokenMakerType picks how tokens are made: paseto_local or jwt_hs256 with TokenSymmetricKey,
+	// or paseto_public, jwt_eddsa or jwt_rs256 signed with the PEM encoded key in TokenPrivateKeyFile.
+	// Empty is paseto_local
+	TokenMakerType      string `mapstructure:"TOKEN_MAKER_TYPE"`
+	TokenPrivateKeyFile string `mapstructure:"TOKEN_PRIVATE_KEY_FILE"`
+	// TokenKeyringFile is a JSON file of key IDs and keys to rotate signing keys with.
+	// When set it replaces TokenSymmetricKey and TokenPrivateKeyFile
+	TokenKeyringFile string `mapstructure:"TOKEN_KEYRING_FILE"`
 }
 
 // LoadConfig reads configuration from the config file or environment variable
@@ -36,6 +70,10 @@ func LoadConfig(path string) (config Config, err error) {
 		return
 	}
 
-	err = viper.Unmarshal(&config)
+	err = viper.Unmarshal(&config, viper.DecodeHook(mapstructure.ComposeDecodeHookFunc(
+		mapstructure.StringToTimeDurationHookFunc(),
+		mapstructure.StringToSliceHookFunc(","),
+		mapstructure.TextUnmarshallerHookFunc(),
+	)))
 	return
 }
@@ -0,0 +1,22 @@
+package util
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestLoadConfig(t *testing.T) {
+	dir := t.TempDir()
+	env := "STEP_UP_TRANSFER_THRESHOLDS=USD:1000,JPY:150000\nTRUSTED_PROXIES=10.0.0.1,10.0.0.2\nHOLD_DURATION=168h\n"
+	err := os.WriteFile(filepath.Join(dir, "app.env"), []byte(env), 0o600)
+	require.NoError(t, err)
+
+	config, err := LoadConfig(dir)
+	require.NoError(t, err)
+	require.Equal(t, CurrencyAmounts{USD: 1000, JPY: 150000}, config.StepUpTransferThresholds)
+	require.Equal(t, []string{"10.0.0.1", "10.0.0.2"}, config.TrustedProxies)
+	require.Equal(t, "168h0m0s", config.HoldDuration.String())
+}
@@ -1,16 +1,88 @@
 package util
 
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+)
+
 // Constants all supported currencies
 const (
 	USD = "USD"
 	EUR = "EUR"
 	CAD = "CAD"
+	GBP = "GBP"
+	JPY = "JPY"
+	KWD = "KWD"
 )
 
-func IsSupported(currency string) bool {
-	switch currency {
-	case USD, CAD, EUR:
-		return true
+// Currency is an ISO 4217 currency. Amounts are stored in its minor unit,
+// Exponent is the number of decimal places between the minor and the major unit
+type Currency struct {
+	Code     string
+	Exponent int
+}
+
+// currencies is the registry of supported currencies. Adding one here also needs
+// the bank's system and fx accounts for it to be created by a migration
+var currencies = map[string]Currency{
+	USD: {Code: USD, Exponent: 2},
+	EUR: {Code: EUR, Exponent: 2},
+	CAD: {Code: CAD, Exponent: 2},
+	GBP: {Code: GBP, Exponent: 2},
+	JPY: {Code: JPY, Exponent: 0},
+	KWD: {Code: KWD, Exponent: 3},
+}
+
+// LookupCurrency returns the supported currency with the given code
+func LookupCurrency(code string) (Currency, bool) {
+	currency, ok := currencies[code]
+	return currency, ok
+}
+
+// SupportedCurrencies returns the codes of all supported currencies in alphabetical order
+func SupportedCurrencies() []string {
+	codes := make([]string, 0, len(currencies))
+	for code := range currencies {
+		codes = append(codes, code)
+	}
+	sort.Strings(codes)
+	return codes
+}
+
+// CurrencyAmounts are amounts in whole major units keyed by currency code.
+// Config reads them as comma separated CODE:AMOUNT pairs, such as USD:1000,JPY:150000
+type CurrencyAmounts map[string]int64
+
+// UnmarshalText parses comma separated CODE:AMOUNT pairs of supported currencies
+func (amounts *CurrencyAmounts) UnmarshalText(text []byte) error {
+	parsed := CurrencyAmounts{}
+
+	for _, pair := range strings.Split(string(text), ",") {
+		pair = strings.TrimSpace(pair)
+		if pair == "" {
+			continue
+		}
+
+		code, value, ok := strings.Cut(pair, ":")
+		if !ok {
+			return fmt.Errorf("invalid currency amount %q, must be CODE:AMOUNT", pair)
+		}
+
+		code = strings.TrimSpace(code)
+		if _, ok := LookupCurrency(code); !ok {
+			return fmt.Errorf("unsupported currency %q", code)
+		}
+
+		amount, err := strconv.ParseInt(strings.TrimSpace(value), 10, 64)
+		if err != nil || amount <= 0 {
+			return fmt.Errorf("invalid amount %q for %s, must be a positive whole number", value, code)
+		}
+
+		parsed[code] = amount
 	}
-	return false
+
+	*amounts = parsed
+	return nil
 }
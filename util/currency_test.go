@@ -0,0 +1,23 @@
+package util
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestCurrencyAmountsUnmarshalText(t *testing.T) {
+	var amounts CurrencyAmounts
+	err := amounts.UnmarshalText([]byte("USD:1000, JPY:150000,KWD:300"))
+	require.NoError(t, err)
+	require.Equal(t, CurrencyAmounts{USD: 1000, JPY: 150000, KWD: 300}, amounts)
+
+	err = amounts.UnmarshalText([]byte(""))
+	require.NoError(t, err)
+	require.Empty(t, amounts)
+
+	for _, text := range []string{"USD", "XYZ:1000", "USD:", "USD:abc", "USD:0", "USD:-5"} {
+		err = amounts.UnmarshalText([]byte(text))
+		require.Error(t, err, text)
+	}
+}
@@ -0,0 +1,97 @@
+package util
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Money is an amount in the minor unit of its currency, e.g. cents for USD
+type Money struct {
+	Amount   int64
+	Currency Currency
+}
+
+// NewMoney creates an amount of minor units in the currency with the given code
+func NewMoney(amount int64, code string) (Money, error) {
+	currency, ok := LookupCurrency(code)
+	if !ok {
+		return Money{}, fmt.Errorf("currency %q is not supported", code)
+	}
+
+	return Money{Amount: amount, Currency: currency}, nil
+}
+
+// ParseMoney parses a decimal string in the major unit of the currency, such as "12.50" for USD.
+// It rejects values with more decimal places than the currency has
+func ParseMoney(value string, code string) (Money, error) {
+	currency, ok := LookupCurrency(code)
+	if !ok {
+		return Money{}, fmt.Errorf("currency %q is not supported", code)
+	}
+
+	digits := strings.TrimPrefix(value, "-")
+	whole, frac, hasPoint := strings.Cut(digits, ".")
+	if whole == "" || (hasPoint && frac == "") || !isDigits(whole) || !isDigits(frac) {
+		return Money{}, fmt.Errorf("invalid amount %q", value)
+	}
+	if len(frac) > currency.Exponent {
+		return Money{}, fmt.Errorf("amount %q has more than %d decimal places for %s", value, currency.Exponent, code)
+	}
+	frac += strings.Repeat("0", currency.Exponent-len(frac))
+
+	amount, err := strconv.ParseInt(whole+frac, 10, 64)
+	if err != nil {
+		return Money{}, fmt.Errorf("invalid amount %q", value)
+	}
+	if len(digits) < len(value) {
+		amount = -amount
+	}
+
+	return Money{Amount: amount, Currency: currency}, nil
+}
+
+// Decimal formats the amount in the major unit with all the currency's decimal places, such as "12.50"
+func (m Money) Decimal() string {
+	sign := ""
+	amount := strconv.FormatInt(m.Amount, 10)
+	if m.Amount < 0 {
+		sign, amount = "-", amount[1:]
+	}
+
+	if m.Currency.Exponent == 0 {
+		return sign + amount
+	}
+
+	if len(amount) <= m.Currency.Exponent {
+		amount = strings.Repeat("0", m.Currency.Exponent-len(amount)+1) + amount
+	}
+	point := len(amount) - m.Currency.Exponent
+
+	return sign + amount[:point] + "." + amount[point:]
+}
+
+// ExceedsMajorUnits reports whether the amount is more than major whole units of its currency,
+// such as more than 1000 USD or 1000 JPY whatever the currency's exponent
+func (m Money) ExceedsMajorUnits(major int64) bool {
+	scale := int64(1)
+	for i := 0; i < m.Currency.Exponent; i++ {
+		scale *= 10
+	}
+
+	whole := m.Amount / scale
+	return whole > major || (whole == major && m.Amount%scale > 0)
+}
+
+func (m Money) String() string {
+	return m.Decimal() + " " + m.Currency.Code
+}
+
+func isDigits(s string) bool {
+	for _, c := range s {
+		if c < '0' || c > '9' {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,117 @@
+package util
+
+import (
+	"math"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseMoney(t *testing.T) {
+	testCases := []struct {
+		value    string
+		currency string
+		want     int64
+		wantErr  bool
+	}{
+		{value: "12.50", currency: USD, want: 1250},
+		{value: "12.5", currency: USD, want: 1250},
+		{value: "12", currency: USD, want: 1200},
+		{value: "0.01", currency: EUR, want: 1},
+		{value: "-3.07", currency: CAD, want: -307},
+		{value: "1500", currency: JPY, want: 1500},
+		{value: "1.234", currency: KWD, want: 1234},
+		{value: "12.345", currency: USD, wantErr: true},
+		{value: "1500.0", currency: JPY, wantErr: true},
+		{value: "1.2345", currency: KWD, wantErr: true},
+		{value: "", currency: USD, wantErr: true},
+		{value: "12.", currency: USD, wantErr: true},
+		{value: ".5", currency: USD, wantErr: true},
+		{value: "+1", currency: USD, wantErr: true},
+		{value: "1,000", currency: USD, wantErr: true},
+		{value: "1e3", currency: USD, wantErr: true},
+		{value: "100000000000000000000", currency: USD, wantErr: true},
+		{value: "1", currency: "XYZ", wantErr: true},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.currency+" "+tc.value, func(t *testing.T) {
+			money, err := ParseMoney(tc.value, tc.currency)
+			if tc.wantErr {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, tc.want, money.Amount)
+			require.Equal(t, tc.currency, money.Currency.Code)
+		})
+	}
+}
+
+func TestMoneyDecimal(t *testing.T) {
+	testCases := []struct {
+		amount   int64
+		currency string
+		want     string
+	}{
+		{amount: 1250, currency: USD, want: "12.50"},
+		{amount: 5, currency: EUR, want: "0.05"},
+		{amount: 0, currency: CAD, want: "0.00"},
+		{amount: -307, currency: CAD, want: "-3.07"},
+		{amount: -7, currency: USD, want: "-0.07"},
+		{amount: 1500, currency: JPY, want: "1500"},
+		{amount: 1234, currency: KWD, want: "1.234"},
+		{amount: 12, currency: KWD, want: "0.012"},
+		{amount: math.MinInt64, currency: USD, want: "-92233720368547758.08"},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.want, func(t *testing.T) {
+			money, err := NewMoney(tc.amount, tc.currency)
+			require.NoError(t, err)
+			require.Equal(t, tc.want, money.Decimal())
+			require.Equal(t, tc.want+" "+tc.currency, money.String())
+
+			parsed, err := ParseMoney(money.Decimal(), tc.currency)
+			if tc.amount == math.MinInt64 {
+				// the magnitude of the smallest int64 does not fit in an int64
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, money, parsed)
+		})
+	}
+}
+
+func TestMoneyExceedsMajorUnits(t *testing.T) {
+	testCases := []struct {
+		amount   int64
+		currency string
+		want     bool
+	}{
+		{amount: 100000, currency: USD, want: false},
+		{amount: 100001, currency: USD, want: true},
+		{amount: 1000, currency: JPY, want: false},
+		{amount: 1001, currency: JPY, want: true},
+		{amount: 1000000, currency: KWD, want: false},
+		{amount: 1000001, currency: KWD, want: true},
+	}
+
+	for _, tc := range testCases {
+		money, err := NewMoney(tc.amount, tc.currency)
+		require.NoError(t, err)
+		require.Equal(t, tc.want, money.ExceedsMajorUnits(1000), money.String())
+	}
+}
+
+func TestLookupCurrency(t *testing.T) {
+	currency, ok := LookupCurrency(JPY)
+	require.True(t, ok)
+	require.Equal(t, 0, currency.Exponent)
+
+	_, ok = LookupCurrency("usd")
+	require.False(t, ok)
+
+	require.Equal(t, []string{CAD, EUR, GBP, JPY, KWD, USD}, SupportedCurrencies())
+}
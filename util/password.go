@@ -18,3 +18,15 @@ func HashPassword(password string) (string, error) {
 func CheckPassword(password, hashedPassword string) error {
 	return bcrypt.CompareHashAndPassword([]byte(hashedPassword), []byte(password))
 }
+
+// dummyHashedPassword is a bcrypt hash of a password no user has, at the cost HashPassword uses
+const dummyHashedPassword = "$2a$10$Zvub5fOnBdzGMl77zHPtP.ItO2ApMseyRymUxH.tEyve8qKCeRFbS"
+
+// CheckDummyPassword takes as long as CheckPassword against a real hash and always fails. It is used
+// when there is no user to check the password of, so that the time taken does not tell whether the user exists
+func CheckDummyPassword(password string) error {
+	if err := CheckPassword(password, dummyHashedPassword); err != nil {
+		return err
+	}
+	return bcrypt.ErrMismatchedHashAndPassword
+}
@@ -27,3 +27,12 @@ func TestPassword(t *testing.T) {
 	require.NotEmpty(t, hashedPassword2)
 	require.NotEqual(t, hashedPassword2, hashedPassword1)
 }
+
+func TestDummyPassword(t *testing.T) {
+	cost, err := bcrypt.Cost([]byte(dummyHashedPassword))
+	require.NoError(t, err)
+	require.Equal(t, bcrypt.DefaultCost, cost)
+
+	err = CheckDummyPassword(RandomString(6))
+	require.EqualError(t, err, bcrypt.ErrMismatchedHashAndPassword.Error())
+}
@@ -4,3 +4,14 @@ const (
 	DepositorRole = "depositor"
 	BankerRole    = "banker"
 )
+
+// TotpChallengeRole is given to the token a password login returns when the user has two-factor authentication on.
+// It is not a user role: the token grants nothing except finishing the login with a TOTP code
+const TotpChallengeRole = "totp_challenge"
+
+// SystemRole is the role of the bank's own user, which owns the system and fx accounts.
+// The user cannot log in and is hidden from the user and account RPCs
+const SystemRole = "system"
+
+// SystemUsername is the username of the bank's own user
+const SystemUsername = "simplebank"
@@ -0,0 +1,108 @@
+package util
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/robfig/cron/v3"
+)
+
+// scheduleParser accepts standard five field cron expressions and descriptors such as @daily or @every 168h
+var scheduleParser = cron.NewParser(cron.Minute | cron.Hour | cron.Dom | cron.Month | cron.Dow | cron.Descriptor)
+
+// ErrScheduleNeverRuns is returned for cron expressions that name dates which do not exist, such as 0 0 30 2 *
+var ErrScheduleNeverRuns = errors.New("schedule never runs")
+
+// NextRun returns the first time after the given time that the schedule fires. Schedules are evaluated in UTC.
+// It returns ErrScheduleNeverRuns if the schedule has no run left
+func NextRun(schedule string, after time.Time) (time.Time, error) {
+	parsed, err := scheduleParser.Parse(schedule)
+	if err != nil {
+		return time.Time{}, err
+	}
+
+	next := parsed.Next(after.UTC())
+	if next.IsZero() {
+		return time.Time{}, ErrScheduleNeverRuns
+	}
+	return next, nil
+}
+
+// RunsMoreOftenThan reports whether two runs of the schedule can ever be closer together than interval.
+// For cron expressions the runs of a day are compared with each other, and the last run of a day with the first
+// run of the next whenever the schedule runs on two days in a row.
+// It returns ErrScheduleNeverRuns for a schedule that never fires
+func RunsMoreOftenThan(schedule string, interval time.Duration) (bool, error) {
+	parsed, err := scheduleParser.Parse(schedule)
+	if err != nil {
+		return false, err
+	}
+
+	if parsed.Next(time.Now().UTC()).IsZero() {
+		return false, ErrScheduleNeverRuns
+	}
+
+	switch parsed := parsed.(type) {
+	case cron.ConstantDelaySchedule:
+		return parsed.Delay < interval, nil
+	case *cron.SpecSchedule:
+		return specRunsMoreOftenThan(parsed, interval), nil
+	default:
+		return false, fmt.Errorf("unsupported schedule %q", schedule)
+	}
+}
+
+func specRunsMoreOftenThan(spec *cron.SpecSchedule, interval time.Duration) bool {
+	// every day the schedule runs, it runs at the same times
+	var times []time.Duration
+	for hour := 0; hour < 24; hour++ {
+		if spec.Hour&(1<<uint(hour)) == 0 {
+			continue
+		}
+		for minute := 0; minute < 60; minute++ {
+			if spec.Minute&(1<<uint(minute)) != 0 {
+				times = append(times, time.Duration(hour)*time.Hour+time.Duration(minute)*time.Minute)
+			}
+		}
+	}
+
+	for i := 1; i < len(times); i++ {
+		if times[i]-times[i-1] < interval {
+			return true
+		}
+	}
+
+	overnight := 24*time.Hour - times[len(times)-1] + times[0]
+	if overnight >= interval {
+		return false
+	}
+	return runsOnConsecutiveDays(spec)
+}
+
+// scheduleCalendarCycle is long enough for every date to fall on every weekday, leap days included
+const scheduleCalendarCycle = 28
+
+// runsOnConsecutiveDays reports whether the schedule runs on two days in a row, going through
+// the days it runs on over a whole calendar cycle
+func runsOnConsecutiveDays(spec *cron.SpecSchedule) bool {
+	day := time.Date(2000, time.January, 1, 0, 0, 0, 0, time.UTC)
+	end := day.AddDate(scheduleCalendarCycle, 0, 0)
+
+	for {
+		// Next is strictly after the time it is given, so step back a second to include midnight
+		run := spec.Next(day.Add(-time.Second))
+		if run.IsZero() || !run.Before(end) {
+			return false
+		}
+
+		runDay := time.Date(run.Year(), run.Month(), run.Day(), 0, 0, 0, 0, time.UTC)
+		nextDay := runDay.AddDate(0, 0, 1)
+
+		nextRun := spec.Next(nextDay.Add(-time.Second))
+		if !nextRun.IsZero() && nextRun.Before(nextDay.AddDate(0, 0, 1)) {
+			return true
+		}
+		day = nextDay
+	}
+}
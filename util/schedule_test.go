@@ -0,0 +1,68 @@
+package util
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestNextRun(t *testing.T) {
+	after := time.Date(2024, time.January, 31, 10, 30, 0, 0, time.UTC)
+
+	next, err := NextRun("0 9 1 * *", after)
+	require.NoError(t, err)
+	require.Equal(t, time.Date(2024, time.February, 1, 9, 0, 0, 0, time.UTC), next)
+
+	next, err = NextRun("@every 24h", after)
+	require.NoError(t, err)
+	require.Equal(t, after.Add(24*time.Hour), next)
+
+	_, err = NextRun("not a schedule", after)
+	require.Error(t, err)
+
+	_, err = NextRun("0 0 30 2 *", after)
+	require.ErrorIs(t, err, ErrScheduleNeverRuns)
+}
+
+func TestRunsMoreOftenThan(t *testing.T) {
+	testCases := []struct {
+		schedule string
+		interval time.Duration
+		tooOften bool
+	}{
+		{schedule: "0 * * * *", interval: time.Hour, tooOften: false},
+		{schedule: "*/30 * * * *", interval: time.Hour, tooOften: true},
+		// the runs are half an hour apart however late in the day the first two are looked at
+		{schedule: "0,30 9 * * *", interval: time.Hour, tooOften: true},
+		{schedule: "0 9,17 * * *", interval: time.Hour, tooOften: false},
+		{schedule: "@every 1h", interval: time.Hour, tooOften: false},
+		{schedule: "@every 30m", interval: time.Hour, tooOften: true},
+		{schedule: "@hourly", interval: time.Hour, tooOften: false},
+		// 23:00 and 00:00 are an hour apart only when the schedule runs on two days in a row
+		{schedule: "0 0,23 * * *", interval: 2 * time.Hour, tooOften: true},
+		{schedule: "0 0,23 * * 1", interval: 2 * time.Hour, tooOften: false},
+		{schedule: "0 0,23 * * 1,2", interval: 2 * time.Hour, tooOften: true},
+		{schedule: "0 0,23 31 12 *", interval: 2 * time.Hour, tooOften: false},
+		{schedule: "0 0,23 1,31 1,12 *", interval: 2 * time.Hour, tooOften: true},
+		{schedule: "0 0,23 29 2 *", interval: 2 * time.Hour, tooOften: false},
+		{schedule: "0 0,23 1,29 2,3 *", interval: 2 * time.Hour, tooOften: true},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.schedule, func(t *testing.T) {
+			tooOften, err := RunsMoreOftenThan(tc.schedule, tc.interval)
+			require.NoError(t, err)
+			require.Equal(t, tc.tooOften, tooOften)
+		})
+	}
+
+	_, err := RunsMoreOftenThan("not a schedule", time.Hour)
+	require.Error(t, err)
+
+	// dates that do not exist never run, whichever path the check takes
+	for _, schedule := range []string{"0 0 30 2 *", "0 0 31 4 *", "0 0,23 30 2 *", "0 0,23 31 4,6 *"} {
+		_, err = RunsMoreOftenThan(schedule, 2*time.Hour)
+		require.ErrorIs(t, err, ErrScheduleNeverRuns, schedule)
+	}
+}
@@ -0,0 +1,29 @@
+package util
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+)
+
+// secretCodeSize is the number of random bytes in a secret code, it is twice as many characters once hex encoded
+const secretCodeSize = 32
+
+// RandomSecretCode generates the secret code of a link sent by email. Whoever holds the code can use the link,
+// so it comes from crypto/rand rather than the math/rand source of RandomString
+func RandomSecretCode() (string, error) {
+	code := make([]byte, secretCodeSize)
+	if _, err := rand.Read(code); err != nil {
+		return "", fmt.Errorf("cannot generate secret code: %w", err)
+	}
+
+	return hex.EncodeToString(code), nil
+}
+
+// HashSecretCode returns the hash that is stored in place of a secret code.
+// The codes are long and random, so unlike passwords a plain SHA-256 is enough and the stored hash can be looked up
+func HashSecretCode(code string) string {
+	hash := sha256.Sum256([]byte(code))
+	return hex.EncodeToString(hash[:])
+}
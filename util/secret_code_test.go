@@ -0,0 +1,22 @@
+package util
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestSecretCode(t *testing.T) {
+	code1, err := RandomSecretCode()
+	require.NoError(t, err)
+	require.Len(t, code1, 2*secretCodeSize)
+
+	code2, err := RandomSecretCode()
+	require.NoError(t, err)
+	require.NotEqual(t, code1, code2)
+
+	hashedCode := HashSecretCode(code1)
+	require.NotEqual(t, code1, hashedCode)
+	require.Equal(t, hashedCode, HashSecretCode(code1))
+	require.NotEqual(t, hashedCode, HashSecretCode(code2))
+}
@@ -1,16 +1,21 @@
 package val
 
 import (
+	"errors"
 	"fmt"
 	"net/mail"
 	"regexp"
+	"time"
 
+	"github.com/google/uuid"
 	"github.com/tonisco/simple-bank-go/util"
+	"google.golang.org/protobuf/types/known/timestamppb"
 )
 
 var (
 	isValidUsername = regexp.MustCompile(`^[a-z0-9_]+$`).MatchString
 	isValidFullName = regexp.MustCompile(`^[a-zA-Z\s]+$`).MatchString
+	isValidTotpCode = regexp.MustCompile(`^[0-9]{6}$`).MatchString
 )
 
 func ValidateString(value string, minLength, maxLength int) error {
@@ -82,3 +87,117 @@ func ValidateRole(roleValue string) error {
 	}
 	return fmt.Errorf("role does not exist")
 }
+
+func ValidateID(value int64) error {
+	if value <= 0 {
+		return fmt.Errorf("must be a positive integer")
+	}
+	return nil
+}
+
+func ValidateCurrency(value string) error {
+	if _, ok := util.LookupCurrency(value); !ok {
+		return fmt.Errorf("currency is not supported")
+	}
+	return nil
+}
+
+func ValidatePageID(value int32) error {
+	if value < 1 {
+		return fmt.Errorf("must be at least 1")
+	}
+	return nil
+}
+
+func ValidatePageSize(value int32) error {
+	if value < 5 || value > 10 {
+		return fmt.Errorf("must be between 5 and 10")
+	}
+	return nil
+}
+
+func ValidateAmount(value int64) error {
+	if value <= 0 {
+		return fmt.Errorf("must be greater than 0")
+	}
+	return nil
+}
+
+func ValidateLimit(value int64) error {
+	if value < 0 {
+		return fmt.Errorf("must not be negative")
+	}
+	return nil
+}
+
+// ValidateDecimalAmount checks a positive decimal amount such as "12.50" with no more decimal places than the currency has
+func ValidateDecimalAmount(value string, currency string) error {
+	money, err := util.ParseMoney(value, currency)
+	if err != nil {
+		return err
+	}
+	return ValidateAmount(money.Amount)
+}
+
+func ValidateIdempotencyKey(value string) error {
+	return ValidateString(value, 1, 255)
+}
+
+func ValidateToken(value string) error {
+	return ValidateString(value, 1, 2048)
+}
+
+func ValidateTotpCode(value string) error {
+	if !isValidTotpCode(value) {
+		return fmt.Errorf("must be 6 digits")
+	}
+	return nil
+}
+
+func ValidateRecoveryCode(value string) error {
+	return ValidateString(value, 10, 11)
+}
+
+func ValidateTimestamp(value *timestamppb.Timestamp) error {
+	if value == nil {
+		return fmt.Errorf("is required")
+	}
+	return value.CheckValid()
+}
+
+func ValidateTimeRange(from time.Time, to time.Time) error {
+	if !to.After(from) {
+		return fmt.Errorf("must be after from")
+	}
+	return nil
+}
+
+func ValidateUUID(value string) error {
+	if _, err := uuid.Parse(value); err != nil {
+		return fmt.Errorf("must be a valid UUID")
+	}
+	return nil
+}
+
+// minScheduleInterval is the shortest time allowed between two runs of a recurring transfer
+const minScheduleInterval = time.Hour
+
+// ValidateSchedule checks a cron expression or @every interval that fires at most once every hour
+func ValidateSchedule(value string) error {
+	if err := ValidateString(value, 1, 100); err != nil {
+		return err
+	}
+
+	tooOften, err := util.RunsMoreOftenThan(value, minScheduleInterval)
+	if errors.Is(err, util.ErrScheduleNeverRuns) {
+		return fmt.Errorf("must run at least once")
+	}
+	if err != nil {
+		return fmt.Errorf("must be a cron expression or an @every interval")
+	}
+
+	if tooOften {
+		return fmt.Errorf("must not run more than once every %s", minScheduleInterval)
+	}
+	return nil
+}
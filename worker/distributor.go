@@ -12,6 +12,31 @@ type TaskDistributor interface {
 		payload *PayloadSendVerifyEmail,
 		opts ...asynq.Option,
 	) error
+	DistributeTaskSendPasswordResetEmail(
+		ctx context.Context,
+		payload *PayloadSendPasswordResetEmail,
+		opts ...asynq.Option,
+	) error
+	DistributeTaskSendLockoutEmail(
+		ctx context.Context,
+		payload *PayloadSendLockoutEmail,
+		opts ...asynq.Option,
+	) error
+	DistributeTaskSendStatement(
+		ctx context.Context,
+		payload *PayloadSendStatement,
+		opts ...asynq.Option,
+	) error
+	DistributeTaskExecuteScheduledTransfer(
+		ctx context.Context,
+		payload *PayloadExecuteScheduledTransfer,
+		opts ...asynq.Option,
+	) error
+	DistributeTaskSendScheduledTransferFailed(
+		ctx context.Context,
+		payload *PayloadSendScheduledTransferFailed,
+		opts ...asynq.Option,
+	) error
 }
 
 type RedisTaskDistributor struct {
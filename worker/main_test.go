@@ -0,0 +1,24 @@
+package worker_test
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/hibiken/asynq"
+	"github.com/stretchr/testify/require"
+	db "github.com/tonisco/simple-bank-go/db/sqlc"
+	"github.com/tonisco/simple-bank-go/mail"
+	"github.com/tonisco/simple-bank-go/worker"
+)
+
+// newTestProcessor creates a processor that is never started, so its redis server is never dialled
+func newTestProcessor(t *testing.T, store db.Store, mailer mail.EmailSender, distributor worker.TaskDistributor) worker.TaskProcessor {
+	redisOpt := asynq.RedisClientOpt{Addr: "localhost:6379"}
+	return worker.NewRedisTaskProcessor(redisOpt, store, mailer, distributor)
+}
+
+func mustMarshal(t *testing.T, payload any) []byte {
+	data, err := json.Marshal(payload)
+	require.NoError(t, err)
+	return data
+}
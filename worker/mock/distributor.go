@@ -36,6 +36,101 @@ func (m *MockTaskDistributor) EXPECT() *MockTaskDistributorMockRecorder {
 	return m.recorder
 }
 
+// DistributeTaskExecuteScheduledTransfer mocks base method.
+func (m *MockTaskDistributor) DistributeTaskExecuteScheduledTransfer(arg0 context.Context, arg1 *worker.PayloadExecuteScheduledTransfer, arg2 ...asynq.Option) error {
+	m.ctrl.T.Helper()
+	varargs := []interface{}{arg0, arg1}
+	for _, a := range arg2 {
+		varargs = append(varargs, a)
+	}
+	ret := m.ctrl.Call(m, "DistributeTaskExecuteScheduledTransfer", varargs...)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DistributeTaskExecuteScheduledTransfer indicates an expected call of DistributeTaskExecuteScheduledTransfer.
+func (mr *MockTaskDistributorMockRecorder) DistributeTaskExecuteScheduledTransfer(arg0, arg1 interface{}, arg2 ...interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	varargs := append([]interface{}{arg0, arg1}, arg2...)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DistributeTaskExecuteScheduledTransfer", reflect.TypeOf((*MockTaskDistributor)(nil).DistributeTaskExecuteScheduledTransfer), varargs...)
+}
+
+// DistributeTaskSendLockoutEmail mocks base method.
+func (m *MockTaskDistributor) DistributeTaskSendLockoutEmail(arg0 context.Context, arg1 *worker.PayloadSendLockoutEmail, arg2 ...asynq.Option) error {
+	m.ctrl.T.Helper()
+	varargs := []interface{}{arg0, arg1}
+	for _, a := range arg2 {
+		varargs = append(varargs, a)
+	}
+	ret := m.ctrl.Call(m, "DistributeTaskSendLockoutEmail", varargs...)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DistributeTaskSendLockoutEmail indicates an expected call of DistributeTaskSendLockoutEmail.
+func (mr *MockTaskDistributorMockRecorder) DistributeTaskSendLockoutEmail(arg0, arg1 interface{}, arg2 ...interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	varargs := append([]interface{}{arg0, arg1}, arg2...)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DistributeTaskSendLockoutEmail", reflect.TypeOf((*MockTaskDistributor)(nil).DistributeTaskSendLockoutEmail), varargs...)
+}
+
+// DistributeTaskSendPasswordResetEmail mocks base method.
+func (m *MockTaskDistributor) DistributeTaskSendPasswordResetEmail(arg0 context.Context, arg1 *worker.PayloadSendPasswordResetEmail, arg2 ...asynq.Option) error {
+	m.ctrl.T.Helper()
+	varargs := []interface{}{arg0, arg1}
+	for _, a := range arg2 {
+		varargs = append(varargs, a)
+	}
+	ret := m.ctrl.Call(m, "DistributeTaskSendPasswordResetEmail", varargs...)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DistributeTaskSendPasswordResetEmail indicates an expected call of DistributeTaskSendPasswordResetEmail.
+func (mr *MockTaskDistributorMockRecorder) DistributeTaskSendPasswordResetEmail(arg0, arg1 interface{}, arg2 ...interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	varargs := append([]interface{}{arg0, arg1}, arg2...)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DistributeTaskSendPasswordResetEmail", reflect.TypeOf((*MockTaskDistributor)(nil).DistributeTaskSendPasswordResetEmail), varargs...)
+}
+
+// DistributeTaskSendScheduledTransferFailed mocks base method.
+func (m *MockTaskDistributor) DistributeTaskSendScheduledTransferFailed(arg0 context.Context, arg1 *worker.PayloadSendScheduledTransferFailed, arg2 ...asynq.Option) error {
+	m.ctrl.T.Helper()
+	varargs := []interface{}{arg0, arg1}
+	for _, a := range arg2 {
+		varargs = append(varargs, a)
+	}
+	ret := m.ctrl.Call(m, "DistributeTaskSendScheduledTransferFailed", varargs...)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DistributeTaskSendScheduledTransferFailed indicates an expected call of DistributeTaskSendScheduledTransferFailed.
+func (mr *MockTaskDistributorMockRecorder) DistributeTaskSendScheduledTransferFailed(arg0, arg1 interface{}, arg2 ...interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	varargs := append([]interface{}{arg0, arg1}, arg2...)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DistributeTaskSendScheduledTransferFailed", reflect.TypeOf((*MockTaskDistributor)(nil).DistributeTaskSendScheduledTransferFailed), varargs...)
+}
+
+// DistributeTaskSendStatement mocks base method.
+func (m *MockTaskDistributor) DistributeTaskSendStatement(arg0 context.Context, arg1 *worker.PayloadSendStatement, arg2 ...asynq.Option) error {
+	m.ctrl.T.Helper()
+	varargs := []interface{}{arg0, arg1}
+	for _, a := range arg2 {
+		varargs = append(varargs, a)
+	}
+	ret := m.ctrl.Call(m, "DistributeTaskSendStatement", varargs...)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DistributeTaskSendStatement indicates an expected call of DistributeTaskSendStatement.
+func (mr *MockTaskDistributorMockRecorder) DistributeTaskSendStatement(arg0, arg1 interface{}, arg2 ...interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	varargs := append([]interface{}{arg0, arg1}, arg2...)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DistributeTaskSendStatement", reflect.TypeOf((*MockTaskDistributor)(nil).DistributeTaskSendStatement), varargs...)
+}
+
 // DistributeTaskSendVerifyEmail mocks base method.
 func (m *MockTaskDistributor) DistributeTaskSendVerifyEmail(arg0 context.Context, arg1 *worker.PayloadSendVerifyEmail, arg2 ...asynq.Option) error {
 	m.ctrl.T.Helper()
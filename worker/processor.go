@@ -17,15 +17,25 @@ const (
 type TaskProcessor interface {
 	Start() error
 	ProcessTaskSendVerifyEmail(ctx context.Context, task *asynq.Task) error
+	ProcessTaskSendPasswordResetEmail(ctx context.Context, task *asynq.Task) error
+	ProcessTaskSendLockoutEmail(ctx context.Context, task *asynq.Task) error
+	ProcessTaskSendStatement(ctx context.Context, task *asynq.Task) error
+	ProcessTaskEnqueueMonthlyStatements(ctx context.Context, task *asynq.Task) error
+	ProcessTaskReconcileLedger(ctx context.Context, task *asynq.Task) error
+	ProcessTaskEnqueueDueScheduledTransfers(ctx context.Context, task *asynq.Task) error
+	ProcessTaskExecuteScheduledTransfer(ctx context.Context, task *asynq.Task) error
+	ProcessTaskSendScheduledTransferFailed(ctx context.Context, task *asynq.Task) error
+	ProcessTaskExpireHolds(ctx context.Context, task *asynq.Task) error
 }
 
 type RedisTaskProcessor struct {
-	server *asynq.Server
-	store  db.Store
-	mailer mail.EmailSender
+	server      *asynq.Server
+	store       db.Store
+	mailer      mail.EmailSender
+	distributor TaskDistributor
 }
 
-func NewRedisTaskProcessor(opts asynq.RedisClientOpt, store db.Store, mailer mail.EmailSender) TaskProcessor {
+func NewRedisTaskProcessor(opts asynq.RedisClientOpt, store db.Store, mailer mail.EmailSender, distributor TaskDistributor) TaskProcessor {
 	server := asynq.NewServer(opts, asynq.Config{
 		Queues: map[string]int{
 			QueueCritical: 10,
@@ -38,9 +48,10 @@ func NewRedisTaskProcessor(opts asynq.RedisClientOpt, store db.Store, mailer mai
 		Logger: NewLogger(),
 	})
 	return &RedisTaskProcessor{
-		server: server,
-		store:  store,
-		mailer: mailer,
+		server:      server,
+		store:       store,
+		mailer:      mailer,
+		distributor: distributor,
 	}
 }
 
@@ -48,6 +59,15 @@ func (processor *RedisTaskProcessor) Start() error {
 	mux := asynq.NewServeMux()
 
 	mux.HandleFunc(TaskSendVerifyEmail, processor.ProcessTaskSendVerifyEmail)
+	mux.HandleFunc(TaskSendPasswordResetEmail, processor.ProcessTaskSendPasswordResetEmail)
+	mux.HandleFunc(TaskSendLockoutEmail, processor.ProcessTaskSendLockoutEmail)
+	mux.HandleFunc(TaskSendStatement, processor.ProcessTaskSendStatement)
+	mux.HandleFunc(TaskEnqueueMonthlyStatements, processor.ProcessTaskEnqueueMonthlyStatements)
+	mux.HandleFunc(TaskReconcileLedger, processor.ProcessTaskReconcileLedger)
+	mux.HandleFunc(TaskEnqueueDueScheduledTransfers, processor.ProcessTaskEnqueueDueScheduledTransfers)
+	mux.HandleFunc(TaskExecuteScheduledTransfer, processor.ProcessTaskExecuteScheduledTransfer)
+	mux.HandleFunc(TaskSendScheduledTransferFailed, processor.ProcessTaskSendScheduledTransferFailed)
+	mux.HandleFunc(TaskExpireHolds, processor.ProcessTaskExpireHolds)
 
 	return processor.server.Start(mux)
 }
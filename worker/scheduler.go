@@ -0,0 +1,99 @@
+package worker
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/hibiken/asynq"
+	"github.com/rs/zerolog/log"
+	"github.com/tonisco/simple-bank-go/util"
+)
+
+// monthlyStatementsCronspec runs at midnight UTC on the first day of every month
+const monthlyStatementsCronspec = "0 0 1 * *"
+
+// reconcileLedgerCronspec runs at 02:00 UTC every day, when there is little traffic
+const reconcileLedgerCronspec = "0 2 * * *"
+
+// dueScheduledTransfersCronspec runs every minute, so a scheduled transfer is made within a minute of falling due
+const dueScheduledTransfersCronspec = "* * * * *"
+
+// expireHoldsCronspec runs every minute, so the funds of an expired hold are available again within a minute
+const expireHoldsCronspec = "* * * * *"
+
+type TaskScheduler interface {
+	Start() error
+}
+
+type RedisTaskScheduler struct {
+	scheduler *asynq.Scheduler
+	config    util.Config
+}
+
+func NewRedisTaskScheduler(redisOpt asynq.RedisClientOpt, config util.Config) TaskScheduler {
+	scheduler := asynq.NewScheduler(redisOpt, &asynq.SchedulerOpts{
+		Location: time.UTC,
+		Logger:   NewLogger(),
+		PostEnqueueFunc: func(info *asynq.TaskInfo, err error) {
+			if err != nil {
+				log.Error().Err(err).Msg("failed to enqueue scheduled task")
+			}
+		},
+	})
+
+	return &RedisTaskScheduler{
+		scheduler: scheduler,
+		config:    config,
+	}
+}
+
+func (scheduler *RedisTaskScheduler) Start() error {
+	_, err := scheduler.scheduler.Register(
+		monthlyStatementsCronspec,
+		asynq.NewTask(TaskEnqueueMonthlyStatements, nil),
+		asynq.Queue(QueueDefault),
+	)
+	if err != nil {
+		return fmt.Errorf("failed to register monthly statements task: %w", err)
+	}
+
+	reconcilePayload, err := json.Marshal(&PayloadReconcileLedger{
+		FreezeMismatched: scheduler.config.ReconcileFreezeAccounts,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to marshal task payload: %w", err)
+	}
+
+	_, err = scheduler.scheduler.Register(
+		reconcileLedgerCronspec,
+		asynq.NewTask(TaskReconcileLedger, reconcilePayload),
+		asynq.MaxRetry(1),
+		asynq.Queue(QueueDefault),
+	)
+	if err != nil {
+		return fmt.Errorf("failed to register reconcile ledger task: %w", err)
+	}
+
+	_, err = scheduler.scheduler.Register(
+		dueScheduledTransfersCronspec,
+		asynq.NewTask(TaskEnqueueDueScheduledTransfers, nil),
+		asynq.MaxRetry(0),
+		asynq.Queue(QueueCritical),
+	)
+	if err != nil {
+		return fmt.Errorf("failed to register due scheduled transfers task: %w", err)
+	}
+
+	_, err = scheduler.scheduler.Register(
+		expireHoldsCronspec,
+		asynq.NewTask(TaskExpireHolds, nil),
+		asynq.MaxRetry(0),
+		asynq.Queue(QueueCritical),
+	)
+	if err != nil {
+		return fmt.Errorf("failed to register expire holds task: %w", err)
+	}
+
+	return scheduler.scheduler.Start()
+}
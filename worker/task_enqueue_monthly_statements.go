@@ -0,0 +1,71 @@
+package worker
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/hibiken/asynq"
+	"github.com/rs/zerolog/log"
+	db "github.com/tonisco/simple-bank-go/db/sqlc"
+)
+
+// TaskEnqueueMonthlyStatements is run by the scheduler at the start of every month
+// and fans out one TaskSendStatement per customer account that is not closed, for the month that just ended
+const TaskEnqueueMonthlyStatements = "task:enqueue_monthly_statements"
+
+const statementAccountsPageSize = 100
+
+func (processor *RedisTaskProcessor) ProcessTaskEnqueueMonthlyStatements(ctx context.Context, task *asynq.Task) error {
+	from, to := previousMonth(time.Now())
+
+	var afterID int64
+	var enqueued int
+	for {
+		ids, err := processor.store.ListAccountIDs(ctx, db.ListAccountIDsParams{
+			AfterID: afterID,
+			Limit:   statementAccountsPageSize,
+		})
+		if err != nil {
+			return fmt.Errorf("failed to list accounts: %w", err)
+		}
+
+		for _, id := range ids {
+			payload := &PayloadSendStatement{
+				AccountID: id,
+				From:      from,
+				To:        to,
+			}
+
+			// the task ID stops a retry of this task from mailing the same statement twice
+			err := processor.distributor.DistributeTaskSendStatement(ctx, payload,
+				asynq.TaskID(fmt.Sprintf("statement:%d:%s", id, from.Format("2006-01"))),
+				asynq.MaxRetry(5),
+				asynq.Queue(QueueDefault),
+			)
+			if err != nil && !errors.Is(err, asynq.ErrTaskIDConflict) {
+				return err
+			}
+			enqueued++
+		}
+
+		if len(ids) < statementAccountsPageSize {
+			break
+		}
+		afterID = ids[len(ids)-1]
+	}
+
+	log.Info().Str("type", task.Type()).Time("from", from).Time("to", to).
+		Int("accounts", enqueued).Msg("processed task")
+
+	return nil
+}
+
+// previousMonth returns the start and end of the calendar month before now, in UTC
+func previousMonth(now time.Time) (from time.Time, to time.Time) {
+	now = now.UTC()
+	to = time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, time.UTC)
+	from = to.AddDate(0, -1, 0)
+	return from, to
+}
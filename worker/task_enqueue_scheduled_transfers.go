@@ -0,0 +1,62 @@
+package worker
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/hibiken/asynq"
+	"github.com/rs/zerolog/log"
+	db "github.com/tonisco/simple-bank-go/db/sqlc"
+)
+
+// TaskEnqueueDueScheduledTransfers is run by the scheduler every minute
+// and fans out one TaskExecuteScheduledTransfer per scheduled transfer that has fallen due
+const TaskEnqueueDueScheduledTransfers = "task:enqueue_due_scheduled_transfers"
+
+const scheduledTransfersPageSize = 100
+
+func (processor *RedisTaskProcessor) ProcessTaskEnqueueDueScheduledTransfers(ctx context.Context, task *asynq.Task) error {
+	now := time.Now()
+
+	var afterID int64
+	var enqueued int
+	for {
+		due, err := processor.store.ListDueScheduledTransfers(ctx, db.ListDueScheduledTransfersParams{
+			Now:     now,
+			AfterID: afterID,
+			Limit:   scheduledTransfersPageSize,
+		})
+		if err != nil {
+			return fmt.Errorf("failed to list due scheduled transfers: %w", err)
+		}
+
+		for _, scheduled := range due {
+			payload := &PayloadExecuteScheduledTransfer{
+				ScheduledTransferID: scheduled.ID,
+				RunAt:               scheduled.NextRunAt,
+			}
+
+			// the task ID stops a transfer that is still due on the next tick from being enqueued twice
+			err := processor.distributor.DistributeTaskExecuteScheduledTransfer(ctx, payload,
+				asynq.TaskID(fmt.Sprintf("scheduled_transfer:%d:%d", scheduled.ID, scheduled.NextRunAt.UnixMicro())),
+				asynq.MaxRetry(5),
+				asynq.Queue(QueueCritical),
+			)
+			if err != nil && !errors.Is(err, asynq.ErrTaskIDConflict) {
+				return err
+			}
+			enqueued++
+		}
+
+		if len(due) < scheduledTransfersPageSize {
+			break
+		}
+		afterID = due[len(due)-1].ID
+	}
+
+	log.Info().Str("type", task.Type()).Int("scheduled_transfers", enqueued).Msg("processed task")
+
+	return nil
+}
@@ -0,0 +1,83 @@
+package worker
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/hibiken/asynq"
+	"github.com/rs/zerolog/log"
+	db "github.com/tonisco/simple-bank-go/db/sqlc"
+)
+
+const TaskExecuteScheduledTransfer = "task:execute_scheduled_transfer"
+
+type PayloadExecuteScheduledTransfer struct {
+	ScheduledTransferID int64     `json:"scheduled_transfer_id"`
+	RunAt               time.Time `json:"run_at"`
+}
+
+func (distributor *RedisTaskDistributor) DistributeTaskExecuteScheduledTransfer(
+	ctx context.Context,
+	payload *PayloadExecuteScheduledTransfer,
+	opts ...asynq.Option,
+) error {
+	jsonPayload, err := json.Marshal(payload)
+	if err != nil {
+		return fmt.Errorf("failed to marshal task payload: %w", err)
+	}
+
+	task := asynq.NewTask(TaskExecuteScheduledTransfer, jsonPayload, opts...)
+	info, err := distributor.client.EnqueueContext(ctx, task)
+
+	if err != nil {
+		return fmt.Errorf("failed to enqueue task: %w", err)
+	}
+
+	log.Info().Str("type", task.Type()).Bytes("payload", task.Payload()).
+		Str("queue", info.Queue).Int("max_retry", info.MaxRetry).Msg("enqueued task")
+
+	return nil
+}
+
+func (processor *RedisTaskProcessor) ProcessTaskExecuteScheduledTransfer(ctx context.Context, task *asynq.Task) error {
+	var payload PayloadExecuteScheduledTransfer
+
+	if err := json.Unmarshal(task.Payload(), &payload); err != nil {
+		return fmt.Errorf("failed to unmarshal payload: %w", asynq.SkipRetry)
+	}
+
+	result, err := processor.store.ExecuteScheduledTransferTx(ctx, db.ExecuteScheduledTransferTxParams{
+		ID:    payload.ScheduledTransferID,
+		RunAt: payload.RunAt,
+	})
+	if err != nil {
+		if errors.Is(err, db.ErrRecordNotFound) {
+			return fmt.Errorf("scheduled transfer does not exist: %w", asynq.SkipRetry)
+		}
+		if errors.Is(err, db.ErrScheduledTransferNotDue) {
+			// the run was already made by an earlier attempt, or the owner paused the transfer since it was enqueued
+			log.Info().Str("type", task.Type()).Bytes("payload", task.Payload()).Msg("scheduled transfer is no longer due")
+			return nil
+		}
+		return fmt.Errorf("failed to execute scheduled transfer: %w", err)
+	}
+
+	if result.Run.Status == db.ScheduledTransferRunFailed {
+		err = processor.distributor.DistributeTaskSendScheduledTransferFailed(ctx,
+			&PayloadSendScheduledTransferFailed{RunID: result.Run.ID},
+			asynq.MaxRetry(10),
+			asynq.Queue(QueueDefault),
+		)
+		if err != nil {
+			return fmt.Errorf("failed to distribute task to send scheduled transfer failure email: %w", err)
+		}
+	}
+
+	log.Info().Str("type", task.Type()).Bytes("payload", task.Payload()).
+		Str("status", result.Run.Status).Str("error", result.Run.Error).Msg("processed task")
+
+	return nil
+}
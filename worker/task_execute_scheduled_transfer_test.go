@@ -0,0 +1,174 @@
+package worker_test
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+	"time"
+
+	"github.com/hibiken/asynq"
+	"github.com/stretchr/testify/require"
+	mockdb "github.com/tonisco/simple-bank-go/db/mock"
+	db "github.com/tonisco/simple-bank-go/db/sqlc"
+	"github.com/tonisco/simple-bank-go/util"
+	"github.com/tonisco/simple-bank-go/worker"
+	mockwk "github.com/tonisco/simple-bank-go/worker/mock"
+	"go.uber.org/mock/gomock"
+)
+
+func TestProcessTaskExecuteScheduledTransfer(t *testing.T) {
+	payload := worker.PayloadExecuteScheduledTransfer{
+		ScheduledTransferID: util.RandomInt(1, 1000),
+		RunAt:               time.Now().UTC().Truncate(time.Second),
+	}
+	arg := db.ExecuteScheduledTransferTxParams{
+		ID:    payload.ScheduledTransferID,
+		RunAt: payload.RunAt,
+	}
+
+	succeeded := db.ExecuteScheduledTransferTxResult{
+		Run: db.ScheduledTransferRun{
+			ID:                  util.RandomInt(1, 1000),
+			ScheduledTransferID: payload.ScheduledTransferID,
+			RunAt:               payload.RunAt,
+			Status:              db.ScheduledTransferRunSucceeded,
+		},
+	}
+
+	failed := succeeded
+	failed.Run.Status = db.ScheduledTransferRunFailed
+	failed.Run.Error = "insufficient funds"
+
+	testCases := []struct {
+		name        string
+		payload     []byte
+		buildStubs  func(store *mockdb.MockStore, distributor *mockwk.MockTaskDistributor)
+		checkResult func(t *testing.T, err error)
+	}{
+		{
+			name:    "Succeeded",
+			payload: mustMarshal(t, payload),
+			buildStubs: func(store *mockdb.MockStore, distributor *mockwk.MockTaskDistributor) {
+				store.EXPECT().
+					ExecuteScheduledTransferTx(gomock.Any(), gomock.Eq(arg)).
+					Times(1).
+					Return(succeeded, nil)
+				distributor.EXPECT().
+					DistributeTaskSendScheduledTransferFailed(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkResult: func(t *testing.T, err error) {
+				require.NoError(t, err)
+			},
+		},
+		{
+			name:    "FailedRunSendsEmail",
+			payload: mustMarshal(t, payload),
+			buildStubs: func(store *mockdb.MockStore, distributor *mockwk.MockTaskDistributor) {
+				store.EXPECT().
+					ExecuteScheduledTransferTx(gomock.Any(), gomock.Eq(arg)).
+					Times(1).
+					Return(failed, nil)
+				taskPayload := &worker.PayloadSendScheduledTransferFailed{RunID: failed.Run.ID}
+				distributor.EXPECT().
+					DistributeTaskSendScheduledTransferFailed(gomock.Any(), gomock.Eq(taskPayload), gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(nil)
+			},
+			checkResult: func(t *testing.T, err error) {
+				require.NoError(t, err)
+			},
+		},
+		{
+			name:    "DistributeError",
+			payload: mustMarshal(t, payload),
+			buildStubs: func(store *mockdb.MockStore, distributor *mockwk.MockTaskDistributor) {
+				store.EXPECT().
+					ExecuteScheduledTransferTx(gomock.Any(), gomock.Eq(arg)).
+					Times(1).
+					Return(failed, nil)
+				distributor.EXPECT().
+					DistributeTaskSendScheduledTransferFailed(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(sql.ErrConnDone)
+			},
+			checkResult: func(t *testing.T, err error) {
+				require.Error(t, err)
+				require.NotErrorIs(t, err, asynq.SkipRetry)
+			},
+		},
+		{
+			name:    "NotDue",
+			payload: mustMarshal(t, payload),
+			buildStubs: func(store *mockdb.MockStore, distributor *mockwk.MockTaskDistributor) {
+				store.EXPECT().
+					ExecuteScheduledTransferTx(gomock.Any(), gomock.Eq(arg)).
+					Times(1).
+					Return(db.ExecuteScheduledTransferTxResult{}, db.ErrScheduledTransferNotDue)
+				distributor.EXPECT().
+					DistributeTaskSendScheduledTransferFailed(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkResult: func(t *testing.T, err error) {
+				require.NoError(t, err)
+			},
+		},
+		{
+			name:    "NotFound",
+			payload: mustMarshal(t, payload),
+			buildStubs: func(store *mockdb.MockStore, distributor *mockwk.MockTaskDistributor) {
+				store.EXPECT().
+					ExecuteScheduledTransferTx(gomock.Any(), gomock.Eq(arg)).
+					Times(1).
+					Return(db.ExecuteScheduledTransferTxResult{}, db.ErrRecordNotFound)
+			},
+			checkResult: func(t *testing.T, err error) {
+				require.ErrorIs(t, err, asynq.SkipRetry)
+			},
+		},
+		{
+			name:    "InternalError",
+			payload: mustMarshal(t, payload),
+			buildStubs: func(store *mockdb.MockStore, distributor *mockwk.MockTaskDistributor) {
+				store.EXPECT().
+					ExecuteScheduledTransferTx(gomock.Any(), gomock.Eq(arg)).
+					Times(1).
+					Return(db.ExecuteScheduledTransferTxResult{}, sql.ErrConnDone)
+			},
+			checkResult: func(t *testing.T, err error) {
+				require.Error(t, err)
+				require.NotErrorIs(t, err, asynq.SkipRetry)
+			},
+		},
+		{
+			name:    "InvalidPayload",
+			payload: []byte("{"),
+			buildStubs: func(store *mockdb.MockStore, distributor *mockwk.MockTaskDistributor) {
+				store.EXPECT().
+					ExecuteScheduledTransferTx(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkResult: func(t *testing.T, err error) {
+				require.ErrorIs(t, err, asynq.SkipRetry)
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+			store := mockdb.NewMockStore(ctrl)
+			distributor := mockwk.NewMockTaskDistributor(ctrl)
+
+			tc.buildStubs(store, distributor)
+			processor := newTestProcessor(t, store, nil, distributor)
+
+			task := asynq.NewTask(worker.TaskExecuteScheduledTransfer, tc.payload)
+			err := processor.ProcessTaskExecuteScheduledTransfer(context.Background(), task)
+			tc.checkResult(t, err)
+		})
+	}
+}
@@ -0,0 +1,41 @@
+package worker
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/hibiken/asynq"
+	"github.com/rs/zerolog/log"
+	db "github.com/tonisco/simple-bank-go/db/sqlc"
+)
+
+// TaskExpireHolds is run by the scheduler every minute and gives the funds of expired holds back to their accounts
+const TaskExpireHolds = "task:expire_holds"
+
+const expireHoldsBatchSize = 100
+
+func (processor *RedisTaskProcessor) ProcessTaskExpireHolds(ctx context.Context, task *asynq.Task) error {
+	now := time.Now()
+
+	var expired int
+	for {
+		holds, err := processor.store.ExpireHoldsTx(ctx, db.ExpireHoldsTxParams{
+			Now:   now,
+			Limit: expireHoldsBatchSize,
+		})
+		if err != nil {
+			return fmt.Errorf("failed to expire holds: %w", err)
+		}
+
+		expired += len(holds)
+
+		if len(holds) < expireHoldsBatchSize {
+			break
+		}
+	}
+
+	log.Info().Str("type", task.Type()).Int("expired_holds", expired).Msg("processed task")
+
+	return nil
+}
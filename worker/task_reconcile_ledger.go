@@ -0,0 +1,52 @@
+package worker
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/hibiken/asynq"
+	"github.com/rs/zerolog/log"
+	db "github.com/tonisco/simple-bank-go/db/sqlc"
+)
+
+// TaskReconcileLedger is run by the scheduler every night
+// and checks that each account balance equals the sum of its entries
+const TaskReconcileLedger = "task:reconcile_ledger"
+
+type PayloadReconcileLedger struct {
+	FreezeMismatched bool `json:"freeze_mismatched"`
+}
+
+func (processor *RedisTaskProcessor) ProcessTaskReconcileLedger(ctx context.Context, task *asynq.Task) error {
+	var payload PayloadReconcileLedger
+
+	if err := json.Unmarshal(task.Payload(), &payload); err != nil {
+		return fmt.Errorf("failed to unmarshal payload: %w", asynq.SkipRetry)
+	}
+
+	_, err := ReconcileLedger(ctx, processor.store, payload.FreezeMismatched)
+	return err
+}
+
+// ReconcileLedger reconciles every account and logs each drift with its account ID and delta.
+// It is shared by the scheduled task and the reconcile command
+func ReconcileLedger(ctx context.Context, store db.Store, freezeMismatched bool) (db.ReconcileAccountsResult, error) {
+	result, err := store.ReconcileAccounts(ctx, db.ReconcileAccountsParams{
+		FreezeMismatched: freezeMismatched,
+	})
+	if err != nil {
+		return result, fmt.Errorf("failed to reconcile accounts: %w", err)
+	}
+
+	for _, drift := range result.Drifts {
+		log.Warn().Int64("account_id", drift.AccountID).Int64("balance", drift.Balance).
+			Int64("entries_balance", drift.EntriesBalance).Int64("delta", drift.Delta).
+			Bool("frozen", drift.Frozen).Msg("account balance does not match its entries")
+	}
+
+	log.Info().Int("accounts", result.AccountsChecked).Int("drifts", len(result.Drifts)).
+		Msg("reconciled ledger")
+
+	return result, nil
+}
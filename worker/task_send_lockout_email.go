@@ -0,0 +1,77 @@
+package worker
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/hibiken/asynq"
+	"github.com/rs/zerolog/log"
+	db "github.com/tonisco/simple-bank-go/db/sqlc"
+)
+
+const TaskSendLockoutEmail = "task:send_lockout_email"
+
+type PayloadSendLockoutEmail struct {
+	Username    string    `json:"username"`
+	LockedUntil time.Time `json:"locked_until"`
+}
+
+func (distributor *RedisTaskDistributor) DistributeTaskSendLockoutEmail(
+	ctx context.Context,
+	payload *PayloadSendLockoutEmail,
+	opts ...asynq.Option,
+) error {
+	jsonPayload, err := json.Marshal(payload)
+	if err != nil {
+		return fmt.Errorf("failed to marshal task payload: %w", err)
+	}
+
+	task := asynq.NewTask(TaskSendLockoutEmail, jsonPayload, opts...)
+	info, err := distributor.client.EnqueueContext(ctx, task)
+
+	if err != nil {
+		return fmt.Errorf("failed to enqueue task: %w", err)
+	}
+
+	log.Info().Str("type", task.Type()).Bytes("payload", task.Payload()).
+		Str("queue", info.Queue).Int("max_retry", info.MaxRetry).Msg("enqueued task")
+
+	return nil
+}
+
+func (processor *RedisTaskProcessor) ProcessTaskSendLockoutEmail(ctx context.Context, task *asynq.Task) error {
+	var payload PayloadSendLockoutEmail
+
+	if err := json.Unmarshal(task.Payload(), &payload); err != nil {
+		return fmt.Errorf("failed to unmarshal payload: %w", asynq.SkipRetry)
+	}
+
+	user, err := processor.store.GetUser(ctx, payload.Username)
+
+	if err != nil {
+		if errors.Is(err, db.ErrRecordNotFound) {
+			return fmt.Errorf("user does not exist: %w", asynq.SkipRetry)
+		}
+		return fmt.Errorf("failed to get user: %w", err)
+	}
+
+	subject := "Your Simple Bank account has been locked"
+	content := fmt.Sprintf(`Hello %s,<br/>
+	There were too many failed attempts to log in to your account, so logins are blocked until %s.<br/>
+	If this was not you, someone may be guessing your password. We recommend resetting it once the lock expires.<br/>
+	`, user.FullName, payload.LockedUntil.UTC().Format("2006-01-02 15:04 MST"))
+	to := []string{user.Email}
+
+	err = processor.mailer.SendEmail(subject, content, to, nil, nil, nil)
+	if err != nil {
+		return fmt.Errorf("failed to send lockout email: %w", err)
+	}
+
+	log.Info().Str("type", task.Type()).Bytes("payload", task.Payload()).
+		Str("email", user.Email).Msg("processed task")
+
+	return nil
+}
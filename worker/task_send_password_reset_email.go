@@ -0,0 +1,95 @@
+package worker
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/hibiken/asynq"
+	"github.com/rs/zerolog/log"
+	db "github.com/tonisco/simple-bank-go/db/sqlc"
+	"github.com/tonisco/simple-bank-go/util"
+)
+
+const TaskSendPasswordResetEmail = "task:send_password_reset_email"
+
+type PayloadSendPasswordResetEmail struct {
+	Username string `json:"username"`
+}
+
+func (distributor *RedisTaskDistributor) DistributeTaskSendPasswordResetEmail(
+	ctx context.Context,
+	payload *PayloadSendPasswordResetEmail,
+	opts ...asynq.Option,
+) error {
+	jsonPayload, err := json.Marshal(payload)
+	if err != nil {
+		return fmt.Errorf("failed to marshal task payload: %w", err)
+	}
+
+	task := asynq.NewTask(TaskSendPasswordResetEmail, jsonPayload, opts...)
+	info, err := distributor.client.EnqueueContext(ctx, task)
+
+	if err != nil {
+		return fmt.Errorf("failed to enqueue task: %w", err)
+	}
+
+	log.Info().Str("type", task.Type()).Bytes("payload", task.Payload()).
+		Str("queue", info.Queue).Int("max_retry", info.MaxRetry).Msg("enqueued task")
+
+	return nil
+}
+
+func (processor *RedisTaskProcessor) ProcessTaskSendPasswordResetEmail(ctx context.Context, task *asynq.Task) error {
+	var payload PayloadSendPasswordResetEmail
+
+	if err := json.Unmarshal(task.Payload(), &payload); err != nil {
+		return fmt.Errorf("failed to unmarshal payload: %w", asynq.SkipRetry)
+	}
+
+	user, err := processor.store.GetUser(ctx, payload.Username)
+
+	if err != nil {
+		if errors.Is(err, db.ErrRecordNotFound) {
+			return fmt.Errorf("user does not exist: %w", asynq.SkipRetry)
+		}
+		return fmt.Errorf("failed to get user: %w", err)
+	}
+
+	secretCode, err := util.RandomSecretCode()
+	if err != nil {
+		return fmt.Errorf("failed to generate secret code: %w", err)
+	}
+
+	// only the email has the code itself, the database keeps its hash
+	passwordReset, err := processor.store.CreatePasswordReset(ctx, db.CreatePasswordResetParams{
+		Username:   user.Username,
+		Email:      user.Email,
+		SecretCode: util.HashSecretCode(secretCode),
+	})
+	if err != nil {
+		return fmt.Errorf("failed to create password reset: %w", err)
+	}
+
+	subject := "Reset your Simple Bank password"
+	// TODO: replace this URL with an environment variable that points to a front-end page
+	resetUrl := fmt.Sprintf("http://localhost:8080/reset_password?reset_id=%d&secret_code=%s",
+		passwordReset.ID, secretCode)
+	content := fmt.Sprintf(`Hello %s,<br/>
+	We received a request to reset your password.<br/>
+	Please <a href="%s">click here</a> to choose a new one. The link expires in 15 minutes.<br/>
+	If you did not ask for this, you can ignore this email.<br/>
+	`, user.FullName, resetUrl)
+	to := []string{user.Email}
+
+	err = processor.mailer.SendEmail(subject, content, to, nil, nil, nil)
+	if err != nil {
+		return fmt.Errorf("failed to send password reset email: %w", err)
+	}
+
+	log.Info().Str("type", task.Type()).Bytes("payload", task.Payload()).
+		Str("email", user.Email).Msg("processed task")
+
+	return nil
+}
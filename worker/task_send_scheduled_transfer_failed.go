@@ -0,0 +1,98 @@
+package worker
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/hibiken/asynq"
+	"github.com/rs/zerolog/log"
+	db "github.com/tonisco/simple-bank-go/db/sqlc"
+	"github.com/tonisco/simple-bank-go/util"
+)
+
+const TaskSendScheduledTransferFailed = "task:send_scheduled_transfer_failed"
+
+type PayloadSendScheduledTransferFailed struct {
+	RunID int64 `json:"run_id"`
+}
+
+func (distributor *RedisTaskDistributor) DistributeTaskSendScheduledTransferFailed(
+	ctx context.Context,
+	payload *PayloadSendScheduledTransferFailed,
+	opts ...asynq.Option,
+) error {
+	jsonPayload, err := json.Marshal(payload)
+	if err != nil {
+		return fmt.Errorf("failed to marshal task payload: %w", err)
+	}
+
+	task := asynq.NewTask(TaskSendScheduledTransferFailed, jsonPayload, opts...)
+	info, err := distributor.client.EnqueueContext(ctx, task)
+
+	if err != nil {
+		return fmt.Errorf("failed to enqueue task: %w", err)
+	}
+
+	log.Info().Str("type", task.Type()).Bytes("payload", task.Payload()).
+		Str("queue", info.Queue).Int("max_retry", info.MaxRetry).Msg("enqueued task")
+
+	return nil
+}
+
+func (processor *RedisTaskProcessor) ProcessTaskSendScheduledTransferFailed(ctx context.Context, task *asynq.Task) error {
+	var payload PayloadSendScheduledTransferFailed
+
+	if err := json.Unmarshal(task.Payload(), &payload); err != nil {
+		return fmt.Errorf("failed to unmarshal payload: %w", asynq.SkipRetry)
+	}
+
+	run, err := processor.store.GetScheduledTransferRun(ctx, payload.RunID)
+	if err != nil {
+		if errors.Is(err, db.ErrRecordNotFound) {
+			return fmt.Errorf("scheduled transfer run does not exist: %w", asynq.SkipRetry)
+		}
+		return fmt.Errorf("failed to get scheduled transfer run: %w", err)
+	}
+
+	scheduled, err := processor.store.GetScheduledTransfer(ctx, run.ScheduledTransferID)
+	if err != nil {
+		if errors.Is(err, db.ErrRecordNotFound) {
+			return fmt.Errorf("scheduled transfer does not exist: %w", asynq.SkipRetry)
+		}
+		return fmt.Errorf("failed to get scheduled transfer: %w", err)
+	}
+
+	account, err := processor.store.GetAccount(ctx, scheduled.FromAccountID)
+	if err != nil {
+		return fmt.Errorf("failed to get account: %w", err)
+	}
+
+	user, err := processor.store.GetUser(ctx, scheduled.Owner)
+	if err != nil {
+		return fmt.Errorf("failed to get user: %w", err)
+	}
+
+	amount, err := util.NewMoney(scheduled.Amount, account.Currency)
+	if err != nil {
+		return fmt.Errorf("failed to format amount: %w", asynq.SkipRetry)
+	}
+
+	subject := "Your scheduled transfer could not be made"
+	content := fmt.Sprintf(`Hello %s,<br/>
+	Your scheduled transfer #%d of %s from account #%d to account #%d due on %s could not be made: %s.<br/>
+	`, user.FullName, scheduled.ID, amount, scheduled.FromAccountID, scheduled.ToAccountID,
+		run.RunAt.UTC().Format("2 January 2006 15:04 MST"), run.Error)
+	to := []string{user.Email}
+
+	err = processor.mailer.SendEmail(subject, content, to, nil, nil, nil)
+	if err != nil {
+		return fmt.Errorf("failed to send scheduled transfer failure email: %w", err)
+	}
+
+	log.Info().Str("type", task.Type()).Bytes("payload", task.Payload()).
+		Str("email", user.Email).Msg("processed task")
+
+	return nil
+}
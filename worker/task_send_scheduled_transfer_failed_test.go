@@ -0,0 +1,165 @@
+package worker_test
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+	"time"
+
+	"github.com/hibiken/asynq"
+	"github.com/stretchr/testify/require"
+	mockdb "github.com/tonisco/simple-bank-go/db/mock"
+	db "github.com/tonisco/simple-bank-go/db/sqlc"
+	mockmail "github.com/tonisco/simple-bank-go/mail/mock"
+	"github.com/tonisco/simple-bank-go/util"
+	"github.com/tonisco/simple-bank-go/worker"
+	"go.uber.org/mock/gomock"
+)
+
+func TestProcessTaskSendScheduledTransferFailed(t *testing.T) {
+	user := db.User{
+		Username: util.RandomOwner(),
+		FullName: util.RandomOwner(),
+		Email:    util.RandomEmail(),
+		Role:     util.DepositorRole,
+	}
+	account := db.Account{
+		ID:       util.RandomInt(1, 1000),
+		Owner:    user.Username,
+		Currency: util.USD,
+	}
+	scheduled := db.ScheduledTransfer{
+		ID:            util.RandomInt(1, 1000),
+		Owner:         user.Username,
+		FromAccountID: account.ID,
+		ToAccountID:   util.RandomInt(1, 1000),
+		Amount:        util.RandomInt(1, 100),
+		Schedule:      "0 9 1 * *",
+		Status:        db.ScheduledTransferStatusActive,
+	}
+	run := db.ScheduledTransferRun{
+		ID:                  util.RandomInt(1, 1000),
+		ScheduledTransferID: scheduled.ID,
+		RunAt:               time.Now().UTC().Truncate(time.Second),
+		Status:              db.ScheduledTransferRunFailed,
+		Error:               "insufficient funds",
+	}
+
+	payload := worker.PayloadSendScheduledTransferFailed{RunID: run.ID}
+
+	testCases := []struct {
+		name        string
+		payload     []byte
+		buildStubs  func(store *mockdb.MockStore, mailer *mockmail.MockEmailSender)
+		checkResult func(t *testing.T, err error)
+	}{
+		{
+			name:    "OK",
+			payload: mustMarshal(t, payload),
+			buildStubs: func(store *mockdb.MockStore, mailer *mockmail.MockEmailSender) {
+				store.EXPECT().GetScheduledTransferRun(gomock.Any(), gomock.Eq(run.ID)).Times(1).Return(run, nil)
+				store.EXPECT().GetScheduledTransfer(gomock.Any(), gomock.Eq(scheduled.ID)).Times(1).Return(scheduled, nil)
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account.ID)).Times(1).Return(account, nil)
+				store.EXPECT().GetUser(gomock.Any(), gomock.Eq(user.Username)).Times(1).Return(user, nil)
+
+				mailer.EXPECT().
+					SendEmail(gomock.Any(), gomock.Any(), gomock.Eq([]string{user.Email}), gomock.Nil(), gomock.Nil(), gomock.Nil()).
+					Times(1).
+					DoAndReturn(func(subject, content string, to, cc, bcc, attachFiles []string) error {
+						require.Contains(t, content, user.FullName)
+						require.Contains(t, content, run.Error)
+						return nil
+					})
+			},
+			checkResult: func(t *testing.T, err error) {
+				require.NoError(t, err)
+			},
+		},
+		{
+			name:    "RunNotFound",
+			payload: mustMarshal(t, payload),
+			buildStubs: func(store *mockdb.MockStore, mailer *mockmail.MockEmailSender) {
+				store.EXPECT().GetScheduledTransferRun(gomock.Any(), gomock.Eq(run.ID)).Times(1).Return(db.ScheduledTransferRun{}, db.ErrRecordNotFound)
+				store.EXPECT().GetScheduledTransfer(gomock.Any(), gomock.Any()).Times(0)
+				mailer.EXPECT().SendEmail(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResult: func(t *testing.T, err error) {
+				require.ErrorIs(t, err, asynq.SkipRetry)
+			},
+		},
+		{
+			name:    "ScheduledTransferNotFound",
+			payload: mustMarshal(t, payload),
+			buildStubs: func(store *mockdb.MockStore, mailer *mockmail.MockEmailSender) {
+				store.EXPECT().GetScheduledTransferRun(gomock.Any(), gomock.Eq(run.ID)).Times(1).Return(run, nil)
+				store.EXPECT().GetScheduledTransfer(gomock.Any(), gomock.Eq(scheduled.ID)).Times(1).Return(db.ScheduledTransfer{}, db.ErrRecordNotFound)
+				mailer.EXPECT().SendEmail(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResult: func(t *testing.T, err error) {
+				require.ErrorIs(t, err, asynq.SkipRetry)
+			},
+		},
+		{
+			name:    "GetUserError",
+			payload: mustMarshal(t, payload),
+			buildStubs: func(store *mockdb.MockStore, mailer *mockmail.MockEmailSender) {
+				store.EXPECT().GetScheduledTransferRun(gomock.Any(), gomock.Eq(run.ID)).Times(1).Return(run, nil)
+				store.EXPECT().GetScheduledTransfer(gomock.Any(), gomock.Eq(scheduled.ID)).Times(1).Return(scheduled, nil)
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account.ID)).Times(1).Return(account, nil)
+				store.EXPECT().GetUser(gomock.Any(), gomock.Eq(user.Username)).Times(1).Return(db.User{}, sql.ErrConnDone)
+				mailer.EXPECT().SendEmail(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResult: func(t *testing.T, err error) {
+				require.Error(t, err)
+				require.NotErrorIs(t, err, asynq.SkipRetry)
+			},
+		},
+		{
+			name:    "SendEmailError",
+			payload: mustMarshal(t, payload),
+			buildStubs: func(store *mockdb.MockStore, mailer *mockmail.MockEmailSender) {
+				store.EXPECT().GetScheduledTransferRun(gomock.Any(), gomock.Eq(run.ID)).Times(1).Return(run, nil)
+				store.EXPECT().GetScheduledTransfer(gomock.Any(), gomock.Eq(scheduled.ID)).Times(1).Return(scheduled, nil)
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account.ID)).Times(1).Return(account, nil)
+				store.EXPECT().GetUser(gomock.Any(), gomock.Eq(user.Username)).Times(1).Return(user, nil)
+				mailer.EXPECT().
+					SendEmail(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(sql.ErrConnDone)
+			},
+			checkResult: func(t *testing.T, err error) {
+				require.Error(t, err)
+				require.NotErrorIs(t, err, asynq.SkipRetry)
+			},
+		},
+		{
+			name:    "InvalidPayload",
+			payload: []byte("{"),
+			buildStubs: func(store *mockdb.MockStore, mailer *mockmail.MockEmailSender) {
+				store.EXPECT().GetScheduledTransferRun(gomock.Any(), gomock.Any()).Times(0)
+				mailer.EXPECT().SendEmail(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResult: func(t *testing.T, err error) {
+				require.ErrorIs(t, err, asynq.SkipRetry)
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+			store := mockdb.NewMockStore(ctrl)
+			mailer := mockmail.NewMockEmailSender(ctrl)
+
+			tc.buildStubs(store, mailer)
+			processor := newTestProcessor(t, store, mailer, nil)
+
+			task := asynq.NewTask(worker.TaskSendScheduledTransferFailed, tc.payload)
+			err := processor.ProcessTaskSendScheduledTransferFailed(context.Background(), task)
+			tc.checkResult(t, err)
+		})
+	}
+}
@@ -0,0 +1,128 @@
+package worker
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/hibiken/asynq"
+	"github.com/rs/zerolog/log"
+	db "github.com/tonisco/simple-bank-go/db/sqlc"
+	"github.com/tonisco/simple-bank-go/statement"
+)
+
+const TaskSendStatement = "task:send_statement"
+
+type PayloadSendStatement struct {
+	AccountID int64     `json:"account_id"`
+	From      time.Time `json:"from"`
+	To        time.Time `json:"to"`
+}
+
+func (distributor *RedisTaskDistributor) DistributeTaskSendStatement(
+	ctx context.Context,
+	payload *PayloadSendStatement,
+	opts ...asynq.Option,
+) error {
+	jsonPayload, err := json.Marshal(payload)
+	if err != nil {
+		return fmt.Errorf("failed to marshal task payload: %w", err)
+	}
+
+	task := asynq.NewTask(TaskSendStatement, jsonPayload, opts...)
+	info, err := distributor.client.EnqueueContext(ctx, task)
+
+	if err != nil {
+		return fmt.Errorf("failed to enqueue task: %w", err)
+	}
+
+	log.Info().Str("type", task.Type()).Bytes("payload", task.Payload()).
+		Str("queue", info.Queue).Int("max_retry", info.MaxRetry).Msg("enqueued task")
+
+	return nil
+}
+
+func (processor *RedisTaskProcessor) ProcessTaskSendStatement(ctx context.Context, task *asynq.Task) error {
+	var payload PayloadSendStatement
+
+	if err := json.Unmarshal(task.Payload(), &payload); err != nil {
+		return fmt.Errorf("failed to unmarshal payload: %w", asynq.SkipRetry)
+	}
+
+	account, err := processor.store.GetAccount(ctx, payload.AccountID)
+	if err != nil {
+		if errors.Is(err, db.ErrRecordNotFound) {
+			return fmt.Errorf("account does not exist: %w", asynq.SkipRetry)
+		}
+		return fmt.Errorf("failed to get account: %w", err)
+	}
+
+	user, err := processor.store.GetUser(ctx, account.Owner)
+	if err != nil {
+		return fmt.Errorf("failed to get user: %w", err)
+	}
+
+	period, err := processor.store.GetAccountStatement(ctx, db.AccountStatementParams{
+		AccountID: account.ID,
+		From:      payload.From,
+		To:        payload.To,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to get account statement: %w", err)
+	}
+
+	stmt := statement.Statement{
+		Account:     account,
+		Period:      period,
+		GeneratedAt: time.Now(),
+	}
+
+	exporter := &statement.CSVExporter{}
+
+	// the mailer attaches files from disk, so the statement is written to a temporary directory first
+	dir, err := os.MkdirTemp("", "statement")
+	if err != nil {
+		return fmt.Errorf("failed to create temporary directory: %w", err)
+	}
+	defer os.RemoveAll(dir)
+
+	attachment := filepath.Join(dir, statement.FileName(exporter, stmt))
+	if err := writeStatementFile(attachment, exporter, stmt); err != nil {
+		return fmt.Errorf("failed to write statement: %w", err)
+	}
+
+	month := payload.From.UTC().Format("January 2006")
+	subject := fmt.Sprintf("Your Simple Bank statement for %s", month)
+	content := fmt.Sprintf(`Hello %s,<br/>
+	Please find attached the statement of your %s account #%d for %s.<br/>
+	`, user.FullName, account.Currency, account.ID, month)
+	to := []string{user.Email}
+
+	err = processor.mailer.SendEmail(subject, content, to, nil, nil, []string{attachment})
+	if err != nil {
+		return fmt.Errorf("failed to send statement email: %w", err)
+	}
+
+	log.Info().Str("type", task.Type()).Bytes("payload", task.Payload()).
+		Str("email", user.Email).Msg("processed task")
+
+	return nil
+}
+
+func writeStatementFile(name string, exporter statement.Exporter, stmt statement.Statement) error {
+	file, err := os.Create(name)
+	if err != nil {
+		return err
+	}
+
+	if err := exporter.Export(file, stmt); err != nil {
+		file.Close()
+		return err
+	}
+
+	return file.Close()
+}